@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+)
+
+// dump_folders walks the folder tree and writes every raw GetFolders/GetSubFolders
+// response to the dump directory, one file per request, for offline analysis or
+// as test fixtures.
+// Usage: go run cmd/dump_folders.go [-dump-dir DIR]
+func main() {
+	dumpDir := flag.String("dump-dir", "exports/folders", "directory the raw folder responses are written to")
+	flag.Parse()
+
+	if *dumpDir == "" {
+		fmt.Fprintln(os.Stderr, "-dump-dir must not be empty")
+		os.Exit(2)
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	cfg, err := sfmce.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := sfmce.NewSalesforceWithLogger(cfg, logger)
+	if err := client.SetDumpDir(*dumpDir); err != nil {
+		logger.Error("Failed to set dump dir", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to set dump dir: %v\n", err)
+		os.Exit(1)
+	}
+
+	count, err := walkFolders(client, logger)
+	if err != nil {
+		logger.Error("Failed to walk folders", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to walk folders: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger.Info("Folder responses dumped", zap.String("dump_dir", *dumpDir), zap.Int("folder_count", count))
+	fmt.Printf("Dumped folder responses for %d folders to %s\n", count, *dumpDir)
+}
+
+// walkFolders lists every folder reachable from GetFolders, fetching the children
+// of each folder once, and returns the number of folders visited
+func walkFolders(client sfmce.SalesforceClient, logger *zap.Logger) (int, error) {
+	resp, err := client.GetFolders()
+	if err != nil {
+		return 0, fmt.Errorf("GetFolders: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var queue []string
+	for _, f := range resp.Entry {
+		if !seen[f.ID] {
+			seen[f.ID] = true
+			queue = append(queue, f.ID)
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		sub, err := client.GetSubFolders(id)
+		if err != nil {
+			logger.Warn("GetSubFolders failed", zap.String("folder_id", id), zap.Error(err))
+			continue
+		}
+		for _, f := range sub.Entry {
+			if !seen[f.ID] {
+				seen[f.ID] = true
+				queue = append(queue, f.ID)
+			}
+		}
+	}
+
+	return len(seen), nil
+}
@@ -2,21 +2,62 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"sort"
+	"slices"
 
+	"github.com/natserract/sf/dataretention/services"
 	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
 	"go.uber.org/zap"
 )
 
 const (
-	pageSize     = 96
 	topCount     = 20
-	defaultFname = "export.json"
+	defaultFname = "export"
+)
+
+// Export formats accepted by -format
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
 )
 
 func main() {
+	objectTypesFlag := flag.String("object-types", "", "comma-separated PartnerAPIObjectTypeNames to export (default: all)")
+	format := flag.String("format", formatJSON, "export format: json or csv")
+	top := flag.Int("top", topCount, "number of data extensions to export")
+	sortBy := flag.String("sort-by", services.SortRowCount, "field to rank by: rowCount, fieldCount, modifiedDate or name")
+	order := flag.String("order", services.OrderDesc, "sort order: asc or desc")
+	pageSize := flag.Int("page-size", sfmce.DefaultDataExtensionPageSize, "number of data extensions requested per page")
+	flag.Parse()
+
+	if *top <= 0 {
+		fmt.Fprintf(os.Stderr, "Invalid -top %d: must be positive\n", *top)
+		os.Exit(2)
+	}
+	if *pageSize <= 0 {
+		fmt.Fprintf(os.Stderr, "Invalid -page-size %d: must be positive\n", *pageSize)
+		os.Exit(2)
+	}
+	compare, err := services.DataExtensionOrder(*sortBy, *order)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid sort: %v\n", err)
+		os.Exit(2)
+	}
+
+	if *format != formatJSON && *format != formatCSV {
+		fmt.Fprintf(os.Stderr, "Invalid -format %q: must be %s or %s\n", *format, formatJSON, formatCSV)
+		os.Exit(2)
+	}
+
+	objectTypes, err := sfmce.ParseObjectTypes(*objectTypesFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid -object-types: %v\n", err)
+		os.Exit(2)
+	}
+
 	logger, err := zap.NewProduction()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
@@ -43,7 +84,7 @@ func main() {
 	logger.Info("Phase 1 done", zap.Int("folder_count", len(folderIDs)))
 
 	// Phase 2 – all data extensions
-	allDE, err := fetchAllDataExtensions(client, folderIDs, logger)
+	allDE, err := services.FetchAllDataExtensions(client, folderIDs, *pageSize, objectTypes, logger)
 	if err != nil {
 		logger.Error("Phase 2 failed", zap.Error(err))
 		fmt.Fprintf(os.Stderr, "Phase 2 (data extensions) failed: %v\n", err)
@@ -51,14 +92,9 @@ func main() {
 	}
 	logger.Info("Phase 2 done", zap.Int("data_extension_count", len(allDE)))
 
-	// Phase 3 – sort by RowCount desc, take top 20
-	sort.Slice(allDE, func(i, j int) bool {
-		return allDE[i].RowCount > allDE[j].RowCount
-	})
-	top := allDE
-	if len(top) > topCount {
-		top = top[:topCount]
-	}
+	// Phase 3 – sort, take the top N
+	slices.SortStableFunc(allDE, compare)
+	selected := allDE[:min(*top, len(allDE))]
 
 	// Phase 4 – export
 	if err := os.MkdirAll("exports", 0755); err != nil {
@@ -68,27 +104,45 @@ func main() {
 	}
 	fname := defaultFname
 	if cfg.AccountID != "" {
-		fname = cfg.AccountID + ".json"
+		fname = cfg.AccountID
 	}
-	path := "exports/" + fname
-	payload, err := json.MarshalIndent(top, "", "  ")
+	path := "exports/" + fname + "." + *format
+	f, err := os.Create(path)
 	if err != nil {
-		logger.Error("Failed to marshal JSON", zap.Error(err))
-		fmt.Fprintf(os.Stderr, "Failed to marshal JSON: %v\n", err)
+		logger.Error("Failed to create export file", zap.String("path", path), zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", path, err)
 		os.Exit(1)
 	}
-	if err := os.WriteFile(path, payload, 0644); err != nil {
+	if *format == formatCSV {
+		err = services.WriteDataExtensionsCSV(f, selected)
+	} else {
+		err = writeJSON(f, selected)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
 		logger.Error("Failed to write export file", zap.String("path", path), zap.Error(err))
 		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", path, err)
 		os.Exit(1)
 	}
-	logger.Info("Export written", zap.String("path", path), zap.Int("count", len(top)))
-	fmt.Printf("Exported top %d data extensions to %s\n", len(top), path)
+	logger.Info("Export written", zap.String("path", path), zap.Int("count", len(selected)))
+	fmt.Printf("Exported top %d data extensions by %s to %s\n", len(selected), *sortBy, path)
+}
+
+// writeJSON writes the data extensions as an indented JSON array
+func writeJSON(w io.Writer, dataExtensions []sfmce.DataExtension) error {
+	payload, err := json.MarshalIndent(dataExtensions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	_, err = w.Write(payload)
+	return err
 }
 
 // collectAllFolderIDs returns a unique slice of folder IDs by traversing
 // GetFolders() and recursively GetSubFolders until no new IDs are found.
-func collectAllFolderIDs(client salesforce.SalesforceClient, logger *zap.Logger) ([]string, error) {
+func collectAllFolderIDs(client sfmce.SalesforceClient, logger *zap.Logger) ([]string, error) {
 	seen := make(map[string]bool)
 	var queue []string
 
@@ -96,12 +150,26 @@ func collectAllFolderIDs(client salesforce.SalesforceClient, logger *zap.Logger)
 	if err != nil {
 		return nil, fmt.Errorf("GetFolders: %w", err)
 	}
-	for _, f := range resp.Entry {
+	// Seed the queue parents-first from the known hierarchy, then add folders
+	// whose parent wasn't listed so nothing from GetFolders is dropped
+	adjacency := sfmce.BuildAdjacency(resp.Entry)
+	enqueue := func(f sfmce.Folder) {
 		if !seen[f.ID] {
 			seen[f.ID] = true
 			queue = append(queue, f.ID)
 		}
 	}
+	for _, root := range adjacency[sfmce.RootParentID] {
+		enqueue(root)
+	}
+	for i := 0; i < len(queue); i++ {
+		for _, child := range adjacency[queue[i]] {
+			enqueue(child)
+		}
+	}
+	for _, f := range resp.Entry {
+		enqueue(f)
+	}
 
 	for len(queue) > 0 {
 		id := queue[0]
@@ -125,31 +193,3 @@ func collectAllFolderIDs(client salesforce.SalesforceClient, logger *zap.Logger)
 	}
 	return ids, nil
 }
-
-// fetchAllDataExtensions calls GetDataExtensions for each folder ID with
-// pagination (loop until len(resp.Items) < pageSize) and returns one slice.
-func fetchAllDataExtensions(client salesforce.SalesforceClient, folderIDs []string, logger *zap.Logger) ([]salesforce.DataExtension, error) {
-	var all []salesforce.DataExtension
-	for _, folderID := range folderIDs {
-		page := 1
-		for {
-			resp, err := client.GetDataExtensions(folderID, page, pageSize)
-			if err != nil {
-				return nil, fmt.Errorf("GetDataExtensions folder=%s page=%d: %w", folderID, page, err)
-			}
-			if len(resp.Items) == 0 {
-				break
-			}
-			for _, de := range resp.Items {
-				if de.CategoryFullPathForRecycleBin == nil || *de.CategoryFullPathForRecycleBin == "" {
-					all = append(all, de)
-				}
-			}
-			if len(resp.Items) < pageSize {
-				break
-			}
-			page++
-		}
-	}
-	return all, nil
-}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/natserract/sf/dataretention/services"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+)
+
+// list_empty_folders prints the folders that directly contain no data extensions.
+// It only reports candidates for cleanup; deleting them is a separate, confirmed step.
+// Usage: go run cmd/list_empty_folders.go
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	cfg, err := sfmce.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := sfmce.NewSalesforceWithLogger(cfg, logger)
+
+	// Listing only reads from the API, so no database connection is needed
+	folderSvc := services.NewFolderService(nil, logger)
+
+	emptyFolders, err := folderSvc.ListEmptyFolders(context.Background(), client)
+	if err != nil {
+		logger.Error("Failed to list empty folders", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to list empty folders: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(emptyFolders) == 0 {
+		fmt.Println("No empty folders found")
+		return
+	}
+
+	fmt.Printf("Found %d empty folder(s):\n", len(emptyFolders))
+	for _, folder := range emptyFolders {
+		fmt.Printf("  %s\t%s\t(parent: %s)\n", folder.ID, folder.Name, folder.ParentID)
+	}
+}
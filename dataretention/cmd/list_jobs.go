@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/services"
+	"go.uber.org/zap"
+)
+
+// list_jobs prints the most recent sync jobs (one per folder synced) with their run,
+// status, item counts and duration, newest first.
+// Usage: go run cmd/list_jobs.go [-limit N]
+func main() {
+	limit := flag.Int("limit", 20, "number of jobs to list")
+	flag.Parse()
+
+	if *limit < 1 {
+		fmt.Fprintf(os.Stderr, "Invalid -limit %d: must be at least 1\n", *limit)
+		os.Exit(2)
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	// Listing only reads from the database, so no API config or client is needed
+	dbCfg := postgres.NewConfig()
+	db, err := postgres.New(dbCfg, logger)
+	if err != nil {
+		logger.Error("Failed to connect to database", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	folderSvc := services.NewFolderService(db, logger)
+	dataExtSvc := services.NewDataExtensionService(db, logger)
+	syncSvc := services.NewSyncService(nil, dataExtSvc, folderSvc, db, logger)
+
+	jobs, err := syncSvc.ListSyncJobs(context.Background(), *limit)
+	if err != nil {
+		logger.Error("Failed to list sync jobs", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to list sync jobs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(jobs) == 0 {
+		fmt.Println("No sync jobs recorded")
+		return
+	}
+
+	fmt.Printf("%-20s %-36s %-10s %-30s %-22s %s\n", "STARTED", "RUN ID", "STATUS", "FOLDER", "SUCCEEDED/FAILED/TOTAL", "DURATION")
+	for _, job := range jobs {
+		var meta struct {
+			RunID      string `json:"run_id"`
+			FolderName string `json:"folder_name"`
+		}
+		_ = json.Unmarshal(job.Metadata, &meta)
+		if meta.FolderName == "" {
+			meta.FolderName = "(unknown)"
+		}
+
+		started := "-"
+		if job.StartedAt.Valid {
+			started = job.StartedAt.Time.Local().Format("2006-01-02 15:04:05")
+		}
+		duration := "-"
+		if job.DurationMs.Valid {
+			duration = (time.Duration(job.DurationMs.Int32) * time.Millisecond).String()
+		} else if job.Status == "running" && job.StartedAt.Valid {
+			duration = time.Since(job.StartedAt.Time).Round(time.Second).String() + " (running)"
+		}
+		items := fmt.Sprintf("%d/%d/%d", job.SucceededItems, job.FailedItems, job.TotalItems)
+
+		fmt.Printf("%-20s %-36s %-10s %-30s %-22s %s\n", started, meta.RunID, job.Status, meta.FolderName, items, duration)
+		if job.ErrorMessage.Valid {
+			fmt.Printf("%20s error: %s\n", "", job.ErrorMessage.String)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"go.uber.org/zap"
+)
+
+// migrate applies the migrations embedded in the binary that the database hasn't
+// applied yet. Re-running it on an up-to-date database changes nothing.
+// Usage: go run cmd/migrate.go
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	dbCfg := postgres.NewConfig()
+	db, err := postgres.New(dbCfg, logger)
+	if err != nil {
+		logger.Error("Failed to connect to database", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := db.Migrate(context.Background()); err != nil {
+		logger.Error("Failed to migrate database", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to migrate database: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Database migrations up to date")
+}
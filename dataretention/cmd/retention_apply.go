@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/services"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+)
+
+// retention_apply applies a retention plan produced by retention_plan. The plan is
+// computed again and only applied if -confirm-token matches it, so nothing changes
+// unless the operator reviewed exactly these changes.
+// Usage: go run cmd/retention_apply.go -confirm-token TOKEN [-policy FILE] [folderID ...]
+// Each folder is planned with its subfolders; with no folder IDs, every top-level
+// folder returned by the API is planned.
+func main() {
+	confirmToken := flag.String("confirm-token", "", "token printed by retention_plan for the reviewed plan")
+	policyPath := flag.String("policy", "", "JSON file with the retention policy (default: the sync's default policy)")
+	flag.Parse()
+
+	if *confirmToken == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s -confirm-token TOKEN [-policy FILE] [folderID ...]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Run retention_plan first to review the changes and get the token\n")
+		os.Exit(2)
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	cfg, err := sfmce.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	policy := services.DefaultRetentionPolicy()
+	if *policyPath != "" {
+		policy, err = services.LoadRetentionPolicy(*policyPath)
+		if err != nil {
+			logger.Error("Failed to load retention policy", zap.Error(err))
+			fmt.Fprintf(os.Stderr, "Failed to load retention policy: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	client := sfmce.NewSalesforceWithLogger(cfg, logger)
+
+	folderIDs := flag.Args()
+	if len(folderIDs) == 0 {
+		folders, err := client.GetFolders()
+		if err != nil {
+			logger.Error("Failed to get folders", zap.Error(err))
+			fmt.Fprintf(os.Stderr, "Failed to get folders: %v\n", err)
+			os.Exit(1)
+		}
+		for _, folder := range folders.Entry {
+			folderIDs = append(folderIDs, folder.ID)
+		}
+	}
+
+	// Applied changes are recorded like the sync's, in data_retention_properties and
+	// retention_dead_letters
+	db, err := postgres.New(postgres.NewConfig(), logger)
+	if err != nil {
+		logger.Error("Failed to connect to database", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := db.CheckSchema(context.Background()); err != nil {
+		logger.Error("Database schema check failed", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	dataExtSvc := services.NewDataExtensionService(db, logger)
+
+	plan, err := dataExtSvc.ApplyRetentionPlan(context.Background(), client, policy, services.RetentionScope{
+		FolderIDs: folderIDs,
+	}, *confirmToken)
+	if errors.Is(err, services.ErrPlanTokenMismatch) {
+		fmt.Fprintf(os.Stderr, "Confirm token %s does not match the current plan (token %s); nothing was applied.\n", *confirmToken, plan.Token)
+		fmt.Fprintf(os.Stderr, "The data extensions or policy changed since the plan was reviewed; run retention_plan again.\n")
+		os.Exit(1)
+	}
+	if err != nil {
+		logger.Error("Failed to apply retention plan", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to apply retention plan: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Applied %d retention change(s) (%d enable deletion at end of retention), plan token %s\n",
+		len(plan.Changes), len(plan.Deletions()), plan.Token)
+}
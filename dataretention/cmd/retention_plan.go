@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/natserract/sf/dataretention/services"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+)
+
+// retention_plan prints the retention changes a sync would make, without applying them,
+// along with the confirm token retention_apply needs to apply exactly this plan.
+// Usage: go run cmd/retention_plan.go [-policy FILE] [folderID ...]
+// Each folder is planned with its subfolders; with no folder IDs, every top-level
+// folder returned by the API is planned.
+func main() {
+	policyPath := flag.String("policy", "", "JSON file with the retention policy (default: the sync's default policy)")
+	flag.Parse()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	cfg, err := sfmce.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	policy := services.DefaultRetentionPolicy()
+	if *policyPath != "" {
+		policy, err = services.LoadRetentionPolicy(*policyPath)
+		if err != nil {
+			logger.Error("Failed to load retention policy", zap.Error(err))
+			fmt.Fprintf(os.Stderr, "Failed to load retention policy: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	client := sfmce.NewSalesforceWithLogger(cfg, logger)
+
+	folderIDs := flag.Args()
+	if len(folderIDs) == 0 {
+		folders, err := client.GetFolders()
+		if err != nil {
+			logger.Error("Failed to get folders", zap.Error(err))
+			fmt.Fprintf(os.Stderr, "Failed to get folders: %v\n", err)
+			os.Exit(1)
+		}
+		for _, folder := range folders.Entry {
+			folderIDs = append(folderIDs, folder.ID)
+		}
+	}
+
+	// Planning only reads from the API, so no database connection is needed
+	dataExtSvc := services.NewDataExtensionService(nil, logger)
+
+	plan, err := dataExtSvc.PlanRetention(context.Background(), client, policy, services.RetentionScope{
+		FolderIDs: folderIDs,
+	})
+	if err != nil {
+		logger.Error("Failed to plan retention changes", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to plan retention changes: %v\n", err)
+		os.Exit(1)
+	}
+
+	payload, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		logger.Error("Failed to marshal JSON", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to marshal JSON: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(payload))
+	fmt.Fprintf(os.Stderr, "%d data extension(s) would change\n", len(plan.Changes))
+
+	if deletions := plan.Deletions(); len(deletions) > 0 {
+		fmt.Fprintf(os.Stderr, "WARNING: %d data extension(s) would be deleted at the end of their retention period:\n", len(deletions))
+		for _, change := range deletions {
+			fmt.Fprintf(os.Stderr, "  %s (%s)\n", change.DataExtensionName, change.DataExtensionID)
+		}
+	}
+	if len(plan.Changes) > 0 {
+		fmt.Fprintf(os.Stderr, "To apply this plan, run retention_apply with the same policy and folders and -confirm-token %s\n", plan.Token)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/services"
+	"go.uber.org/zap"
+)
+
+// retention_status_report prints how many stored data extensions of each folder are
+// pending, succeeded, failed or skipped in their last retention update.
+// Usage: go run cmd/retention_status_report.go
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	// The report only reads from the database, so no API config is needed
+	dbCfg := postgres.NewConfig()
+	db, err := postgres.New(dbCfg, logger)
+	if err != nil {
+		logger.Error("Failed to connect to database", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	dataExtSvc := services.NewDataExtensionService(db, logger)
+
+	report, err := dataExtSvc.GetRetentionStatusReport(context.Background())
+	if err != nil {
+		logger.Error("Failed to get retention status report", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to get retention status report: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(report) == 0 {
+		fmt.Println("No retention updates recorded")
+		return
+	}
+
+	fmt.Printf("%-40s %-20s %-10s %s\n", "FOLDER", "FOLDER ID", "STATUS", "COUNT")
+	for _, row := range report {
+		name := row.FolderName
+		if name == "" {
+			name = "(unknown)"
+		}
+		fmt.Printf("%-40s %-20s %-10s %d\n", name, row.FolderID, row.Status, row.Count)
+	}
+}
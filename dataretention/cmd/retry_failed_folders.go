@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/services"
+	"github.com/natserract/sf/pkg/logging"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+)
+
+// retry_failed_folders re-syncs only the folders whose jobs failed in a prior run.
+// Usage: go run cmd/retry_failed_folders.go [-quiet] <RUN_ID>
+func main() {
+	quiet := flag.Bool("quiet", false, "only log warnings and errors; the summary is still printed")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-quiet] <RUN_ID>\n", os.Args[0])
+		os.Exit(2)
+	}
+	runID, err := uuid.Parse(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid run ID %q: %v\n", flag.Arg(0), err)
+		os.Exit(2)
+	}
+
+	logger, err := logging.New(*quiet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	cfg, err := sfmce.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	syncCfg, err := services.LoadSyncConfig()
+	if err != nil {
+		logger.Error("Failed to load sync config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load sync config: %v\n", err)
+		os.Exit(1)
+	}
+
+	dbCfg := postgres.NewConfig()
+	db, err := postgres.New(dbCfg, logger)
+	if err != nil {
+		logger.Error("Failed to connect to database", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := db.CheckSchema(context.Background()); err != nil {
+		logger.Error("Database schema check failed", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := sfmce.NewSalesforceWithLogger(cfg, logger)
+	folderSvc := services.NewFolderService(db, logger)
+	dataExtSvc := services.NewDataExtensionService(db, logger)
+	syncSvc := services.NewSyncServiceWithConfig(client, dataExtSvc, folderSvc, db, syncCfg, logger)
+
+	metrics, err := syncSvc.RetryFailedFolders(context.Background(), runID)
+	if err != nil {
+		logger.Error("Failed to retry failed folders", zap.String("run_id", runID.String()), zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Retry of run %s completed as run %s:\n", runID, metrics.RunID)
+	fmt.Printf("  Folders: %d succeeded, %d failed\n", metrics.FoldersSucceeded, metrics.FoldersFailed)
+	fmt.Printf("  Data Extensions: %d succeeded, %d failed\n", metrics.DataExtensionsSucceeded, metrics.DataExtensionsFailed)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/services"
+	httpclient "github.com/natserract/sf/pkg/http"
+	"github.com/natserract/sf/pkg/logging"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// minRequestTimeout is the smallest per-request timeout handed out as the run
+// budget nears its deadline
+const minRequestTimeout = 5 * time.Second
+
+// sync_accounts syncs several accounts (business units) concurrently, sharing one
+// rate limiter between them, and prints a per-account and overall report.
+// Completed accounts are recorded in a checkpoint file; with -resume, a restarted
+// run skips them.
+// Usage: go run cmd/sync_accounts.go [-resume] [-checkpoint FILE] [-quiet] <ACCOUNTS_FILE>
+func main() {
+	resume := flag.Bool("resume", false, "skip accounts the checkpoint lists as completed")
+	checkpointPath := flag.String("checkpoint", "", "checkpoint file (default: <ACCOUNTS_FILE>.checkpoint.json)")
+	quiet := flag.Bool("quiet", false, "only log warnings and errors; the report is still printed")
+	flag.Parse()
+
+	path := os.Getenv("MCE_ACCOUNTS_FILE")
+	if flag.NArg() > 0 {
+		path = flag.Arg(0)
+	}
+	if path == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-resume] [-checkpoint FILE] [-quiet] <ACCOUNTS_FILE> (or set MCE_ACCOUNTS_FILE)\n", os.Args[0])
+		os.Exit(2)
+	}
+	if *checkpointPath == "" {
+		*checkpointPath = path + ".checkpoint.json"
+	}
+
+	logger, err := logging.New(*quiet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	accountsCfg, err := sfmce.LoadAccountsFromFile(path)
+	if err != nil {
+		logger.Error("Failed to load accounts", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load accounts: %v\n", err)
+		os.Exit(1)
+	}
+
+	syncCfg, err := services.LoadSyncConfig()
+	if err != nil {
+		logger.Error("Failed to load sync config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load sync config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The accounts share one database, so a single account's sync can't tell which
+	// rows are gone upstream
+	if syncCfg.SoftDeleteMissing {
+		logger.Warn("Soft-deleting missing rows is not supported for multi-account syncs, disabling it")
+		syncCfg.SoftDeleteMissing = false
+	}
+
+	// A fresh run starts a new checkpoint; a resumed one continues the previous one
+	var checkpoint *services.AccountCheckpoint
+	if *resume {
+		checkpoint, err = services.LoadAccountCheckpoint(*checkpointPath)
+	} else {
+		checkpoint = services.NewAccountCheckpoint(*checkpointPath)
+		err = checkpoint.Save()
+	}
+	if err != nil {
+		logger.Error("Failed to prepare checkpoint", zap.String("path", *checkpointPath), zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to prepare checkpoint: %v\n", err)
+		os.Exit(1)
+	}
+
+	dbCfg := postgres.NewConfig()
+	db, err := postgres.New(dbCfg, logger)
+	if err != nil {
+		logger.Error("Failed to connect to database", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := db.CheckSchema(context.Background()); err != nil {
+		logger.Error("Database schema check failed", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// All accounts hit the same tenant limits, so they share one limiter
+	var limiter *rate.Limiter
+	if accountsCfg.RateLimit > 0 {
+		limiter = httpclient.NewRateLimiter(accountsCfg.RateLimit, accountsCfg.RateBurst)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Request timeouts shrink as the budget runs out and no request starts after the
+	// deadline. The run's context ends once the last request started before it has
+	// had its minRequestTimeout.
+	var budget *httpclient.RunBudget
+	if syncCfg.RunBudget > 0 {
+		budget = httpclient.NewRunBudget(syncCfg.RunBudget, minRequestTimeout)
+		ctx, cancel = context.WithDeadline(ctx, budget.Deadline().Add(minRequestTimeout))
+		defer cancel()
+	}
+
+	newSyncer := func(cfg *sfmce.Config) *services.SyncService {
+		accountLogger := logger.With(zap.String("account_id", cfg.AccountID))
+		client := sfmce.NewSalesforceWithLogger(cfg, accountLogger)
+		if limiter != nil {
+			client.HTTPClient().SetRateLimiter(limiter)
+		}
+		if budget != nil {
+			client.HTTPClient().SetRunBudget(budget)
+		}
+		client.StartTokenRefresher(ctx)
+
+		folderSvc := services.NewFolderService(db, accountLogger)
+		dataExtSvc := services.NewDataExtensionService(db, accountLogger)
+		syncSvc := services.NewSyncServiceWithConfig(client, dataExtSvc, folderSvc, db, syncCfg, accountLogger)
+		// Accounts sync concurrently into the same database, so each takes its own lock
+		syncSvc.SetLockName(services.SyncLockName(cfg.AccountID))
+		return syncSvc
+	}
+
+	report := services.SyncAccountsWithCheckpoint(ctx, accountsCfg.Accounts, syncCfg.AccountConcurrency, checkpoint, newSyncer, logger)
+
+	fmt.Printf("Multi-account sync finished in %s\n", report.Duration.Round(time.Second))
+	for _, account := range report.Accounts {
+		if account.Skipped {
+			fmt.Printf("  Account %s: skipped, already completed\n", account.AccountID)
+			continue
+		}
+		if account.Err != nil {
+			fmt.Printf("  Account %s: FAILED after %s: %v\n", account.AccountID, account.Duration.Round(time.Second), account.Err)
+		}
+		if account.Metrics == nil {
+			continue
+		}
+		m := account.Metrics
+		fmt.Printf("  Account %s (run %s, %s):\n", account.AccountID, m.RunID, account.Duration.Round(time.Second))
+		fmt.Printf("    Folders: %d succeeded, %d failed\n", m.FoldersSucceeded, m.FoldersFailed)
+		fmt.Printf("    Subfolders: %d succeeded, %d failed\n", m.SubfoldersSucceeded, m.SubfoldersFailed)
+		fmt.Printf("    Data Extensions: %d succeeded, %d failed\n", m.DataExtensionsSucceeded, m.DataExtensionsFailed)
+	}
+
+	totals := report.Totals()
+	fmt.Printf("Overall (%d accounts, %d failed, %d skipped):\n", len(report.Accounts), report.FailedAccounts(), report.SkippedAccounts())
+	fmt.Printf("  Folders: %d succeeded, %d failed\n", totals.FoldersSucceeded, totals.FoldersFailed)
+	fmt.Printf("  Subfolders: %d succeeded, %d failed\n", totals.SubfoldersSucceeded, totals.SubfoldersFailed)
+	fmt.Printf("  Data Extensions: %d succeeded, %d failed\n", totals.DataExtensionsSucceeded, totals.DataExtensionsFailed)
+	fmt.Printf("  Total: %d succeeded, %d failed\n", totals.TotalSucceeded(), totals.TotalFailed())
+
+	if report.FailedAccounts() > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/services"
+	"github.com/natserract/sf/pkg/logging"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+)
+
+// sync_folder syncs a single folder and its subtree as a new run.
+// Usage: go run cmd/sync_folder.go [-quiet] <FOLDER_ID>
+func main() {
+	quiet := flag.Bool("quiet", false, "only log warnings and errors; the summary is still printed")
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [-quiet] <FOLDER_ID>\n", os.Args[0])
+		os.Exit(2)
+	}
+	folderID := flag.Arg(0)
+
+	logger, err := logging.New(*quiet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	cfg, err := sfmce.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	syncCfg, err := services.LoadSyncConfig()
+	if err != nil {
+		logger.Error("Failed to load sync config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load sync config: %v\n", err)
+		os.Exit(1)
+	}
+
+	dbCfg := postgres.NewConfig()
+	db, err := postgres.New(dbCfg, logger)
+	if err != nil {
+		logger.Error("Failed to connect to database", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := db.CheckSchema(context.Background()); err != nil {
+		logger.Error("Database schema check failed", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := sfmce.NewSalesforceWithLogger(cfg, logger)
+	folderSvc := services.NewFolderService(db, logger)
+	dataExtSvc := services.NewDataExtensionService(db, logger)
+	syncSvc := services.NewSyncServiceWithConfig(client, dataExtSvc, folderSvc, db, syncCfg, logger)
+
+	metrics, err := syncSvc.SyncFolderTree(context.Background(), folderID)
+	if err != nil {
+		logger.Error("Failed to sync folder", zap.String("folder_id", folderID), zap.Error(err))
+		if errors.Is(err, services.ErrFolderNotFound) {
+			fmt.Fprintf(os.Stderr, "Error: folder %s does not exist\n", folderID)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	fmt.Printf("Sync of folder %s completed as run %s:\n", folderID, metrics.RunID)
+	fmt.Printf("  Folders: %d succeeded, %d failed\n", metrics.FoldersSucceeded, metrics.FoldersFailed)
+	fmt.Printf("  Subfolders: %d succeeded, %d failed\n", metrics.SubfoldersSucceeded, metrics.SubfoldersFailed)
+	fmt.Printf("  Data Extensions: %d succeeded, %d failed\n", metrics.DataExtensionsSucceeded, metrics.DataExtensionsFailed)
+}
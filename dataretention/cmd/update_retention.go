@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 
@@ -11,11 +13,22 @@ import (
 	"go.uber.org/zap"
 )
 
+// update_retention sets the retention of one data extension, by default to the sync's policy.
+// Usage: go run cmd/update_retention.go [-period-length N] [-period-unit UNIT] [-row-based=false] [-delete-at-end] [-reset-on-import] [DATA_EXTENSION_ID]
 func main() {
+	retentionArgs := services.RegisterRetentionFlags(flag.CommandLine)
+	flag.Parse()
+
+	retention, err := retentionArgs.Properties()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid retention settings: %v\n", err)
+		os.Exit(2)
+	}
+
 	// Get data extension ID from command line or use default
 	dataExtensionID := "57ddcfc3-83f2-ea11-a2f5-48df370ed95c"
-	if len(os.Args) > 1 {
-		dataExtensionID = os.Args[1]
+	if flag.NArg() > 0 {
+		dataExtensionID = flag.Arg(0)
 	}
 
 	// Initialize logger
@@ -51,11 +64,27 @@ func main() {
 	// Create data extension service
 	dataExtSvc := services.NewDataExtensionService(db, logger)
 
+	// Look up the data extension first, so a wrong ID fails clearly and the
+	// current settings are shown before they are replaced
+	de, err := client.GetDataExtension(dataExtensionID)
+	if err != nil {
+		logger.Error("Failed to get data extension",
+			zap.String("data_extension_id", dataExtensionID),
+			zap.Error(err))
+		if errors.Is(err, sfmce.ErrDataExtensionNotFound) {
+			fmt.Fprintf(os.Stderr, "Error: data extension %s does not exist\n", dataExtensionID)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: Failed to get data extension: %v\n", err)
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("Current retention of %s (%s): %s\n", de.Name, dataExtensionID, de.DataRetentionProperties)
+
 	// Update data retention for the specified ID
 	ctx := context.Background()
-	fmt.Printf("Updating data retention for data extension: %s\n", dataExtensionID)
+	fmt.Printf("Updating data retention for data extension %s to: %s\n", dataExtensionID, retention)
 
-	err = dataExtSvc.UpdateDataRetentionViaAPI(ctx, client, dataExtensionID)
+	updated, err := dataExtSvc.UpdateDataRetentionPolicyViaAPI(ctx, client, *de, retention)
 	if err != nil {
 		logger.Error("Failed to update data retention",
 			zap.String("data_extension_id", dataExtensionID),
@@ -64,6 +93,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if !updated {
+		fmt.Printf("Data retention already up to date for data extension: %s\n", dataExtensionID)
+		return
+	}
+
 	fmt.Printf("Successfully updated data retention for data extension: %s\n", dataExtensionID)
 	logger.Info("Successfully updated data retention",
 		zap.String("data_extension_id", dataExtensionID))
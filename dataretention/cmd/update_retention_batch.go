@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/services"
+	"github.com/natserract/sf/pkg/logging"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+)
+
+// update_retention_batch applies a retention policy to many data extensions, reading
+// their IDs one per line from a file, or from stdin when the file is "-" or omitted.
+// It exits non-zero if any update failed.
+// Usage: go run cmd/update_retention_batch.go [-policy FILE] [-concurrency N] [-quiet] [IDS_FILE]
+func main() {
+	policyPath := flag.String("policy", "", "JSON file with the retention policy (default: the sync's default policy)")
+	concurrency := flag.Int("concurrency", 4, "data extensions updated at once")
+	quiet := flag.Bool("quiet", false, "only log warnings and errors; the summary is still printed")
+	flag.Parse()
+
+	if *concurrency < 1 {
+		fmt.Fprintf(os.Stderr, "Invalid -concurrency %d: must be at least 1\n", *concurrency)
+		os.Exit(2)
+	}
+
+	input := io.Reader(os.Stdin)
+	if path := flag.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		input = f
+	}
+	ids, err := services.ReadIDs(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read data extension IDs: %v\n", err)
+		os.Exit(1)
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(os.Stderr, "No data extension IDs given")
+		os.Exit(2)
+	}
+
+	logger, err := logging.New(*quiet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	cfg, err := sfmce.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	policy := services.DefaultRetentionPolicy()
+	if *policyPath != "" {
+		policy, err = services.LoadRetentionPolicy(*policyPath)
+		if err != nil {
+			logger.Error("Failed to load retention policy", zap.Error(err))
+			fmt.Fprintf(os.Stderr, "Failed to load retention policy: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	dbCfg := postgres.NewConfig()
+	db, err := postgres.New(dbCfg, logger)
+	if err != nil {
+		logger.Error("Failed to connect to database", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := db.CheckSchema(context.Background()); err != nil {
+		logger.Error("Database schema check failed", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := sfmce.NewSalesforceWithLogger(cfg, logger)
+	dataExtSvc := services.NewDataExtensionService(db, logger)
+
+	fmt.Printf("Updating data retention of %d data extensions to: %s\n", len(ids), policy)
+	results := dataExtSvc.UpdateDataRetentionBatch(context.Background(), client, ids, policy, *concurrency)
+
+	updated, unchanged, failed := 0, 0, 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Printf("  FAILED     %s: %v\n", result.DataExtensionID, result.Err)
+		case result.Updated:
+			updated++
+			fmt.Printf("  updated    %s\n", result.DataExtensionID)
+		default:
+			unchanged++
+			fmt.Printf("  unchanged  %s\n", result.DataExtensionID)
+		}
+	}
+	fmt.Printf("%d updated, %d already up to date, %d failed\n", updated, unchanged, failed)
+
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
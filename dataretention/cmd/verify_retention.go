@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/natserract/sf/dataretention/services"
+	"github.com/natserract/sf/pkg/logging"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+)
+
+// verify_retention checks that Marketing Cloud applied a retention policy, reading
+// data extension IDs one per line from a file, or from stdin when the file is "-" or
+// omitted. It exits non-zero if any data extension doesn't match or couldn't be fetched.
+// Usage: go run cmd/verify_retention.go [-policy FILE] [-quiet] [IDS_FILE]
+func main() {
+	policyPath := flag.String("policy", "", "JSON file with the expected retention policy (default: the sync's default policy)")
+	quiet := flag.Bool("quiet", false, "only log warnings and errors; the summary is still printed")
+	flag.Parse()
+
+	input := io.Reader(os.Stdin)
+	if path := flag.Arg(0); path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		input = f
+	}
+	ids, err := services.ReadIDs(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read data extension IDs: %v\n", err)
+		os.Exit(1)
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(os.Stderr, "No data extension IDs given")
+		os.Exit(2)
+	}
+
+	logger, err := logging.New(*quiet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	cfg, err := sfmce.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	expected := services.DefaultRetentionPolicy()
+	if *policyPath != "" {
+		expected, err = services.LoadRetentionPolicy(*policyPath)
+		if err != nil {
+			logger.Error("Failed to load retention policy", zap.Error(err))
+			fmt.Fprintf(os.Stderr, "Failed to load retention policy: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	client := sfmce.NewSalesforceWithLogger(cfg, logger)
+	// Verification only reads from Marketing Cloud, so no database is needed
+	dataExtSvc := services.NewDataExtensionService(nil, logger)
+
+	fmt.Printf("Verifying data retention of %d data extensions against: %s\n", len(ids), expected)
+	results := dataExtSvc.VerifyRetention(context.Background(), client, ids, expected)
+
+	matched, mismatched, failed := 0, 0, 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Printf("  FAILED     %s: %v\n", result.DataExtensionID, result.Err)
+		case len(result.Mismatches) > 0:
+			mismatched++
+			fmt.Printf("  MISMATCH   %s (%s): %s differ, actual %s\n", result.DataExtensionID, result.DataExtensionName,
+				strings.Join(result.Mismatches, ", "), result.Actual)
+		default:
+			matched++
+			fmt.Printf("  ok         %s\n", result.DataExtensionID)
+		}
+	}
+	fmt.Printf("%d match, %d mismatched, %d failed\n", matched, mismatched, failed)
+
+	if mismatched > 0 || failed > 0 {
+		os.Exit(1)
+	}
+}
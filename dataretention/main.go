@@ -2,32 +2,64 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/natserract/sf/dataretention/schema/postgres"
 	"github.com/natserract/sf/dataretention/services"
+	httpclient "github.com/natserract/sf/pkg/http"
+	"github.com/natserract/sf/pkg/logging"
 	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
 	"go.uber.org/zap"
 )
 
+// minRequestTimeout is the smallest per-request timeout handed out as the run
+// budget nears its deadline
+const minRequestTimeout = 5 * time.Second
+
+// requestSource identifies the sync in the X-Request-Source header of its API calls
+const requestSource = "dataretention-sync"
+
 func main() {
+	reportCSV := flag.String("report-csv", "", "write a CSV of per-folder sync outcomes to this file")
+	resultJSON := flag.String("json", "", "write the sync result as JSON to this file, or to stdout when \"-\" (the text summary then goes to stderr)")
+	quiet := flag.Bool("quiet", false, "only log warnings and errors; the summary is still printed")
+	flag.Parse()
+
 	// Initialize logger
-	logger, err := zap.NewProduction()
+	logger, err := logging.New(*quiet)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
 		os.Exit(1)
 	}
 	defer logger.Sync()
 
-	// Load configuration
-	cfg, err := sfmce.LoadConfig()
+	// Load configuration, from a file when MCE_CONFIG_FILE points at one
+	var cfg *sfmce.Config
+	if path := os.Getenv("MCE_CONFIG_FILE"); path != "" {
+		cfg, err = sfmce.LoadFromFile(path)
+	} else {
+		cfg, err = sfmce.LoadConfig()
+	}
 	if err != nil {
 		logger.Error("Failed to load config", zap.Error(err))
 		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
 		os.Exit(1)
 	}
 
+	// Load sync configuration (concurrency limits, run budget)
+	syncCfg, err := services.LoadSyncConfig()
+	if err != nil {
+		logger.Error("Failed to load sync config", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Failed to load sync config: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Initialize database connection (optional - graceful degradation if DB not available)
 	var db *postgres.DB
 	dbCfg := postgres.NewConfig()
@@ -40,12 +72,61 @@ func main() {
 		os.Exit(1) // Exit if DB is not available since we need it for this operation
 	}
 	defer db.Close()
+
+	if err := db.CheckSchema(context.Background()); err != nil {
+		logger.Error("Database schema check failed", zap.Error(err))
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// Keep stdout clean for the JSON result when it is written there
+	out := io.Writer(os.Stdout)
+	if *resultJSON == "-" {
+		out = os.Stderr
+	}
+
 	logger.Info("Database connection established")
-	fmt.Println("Database connection established")
+	fmt.Fprintln(out, "Database connection established")
 
 	// Create Salesforce client
 	client := sfmce.NewSalesforceWithLogger(cfg, logger)
 
+	// Tag every API call so it can be traced back to this process and run
+	correlationID := uuid.NewString()
+	client.HTTPClient().SetDefaultHeaders(map[string]string{
+		"X-Request-Source": requestSource,
+		"X-Correlation-ID": correlationID,
+	})
+	logger.Info("Tagging API requests", zap.String("request_source", requestSource), zap.String("correlation_id", correlationID))
+
+	// Optionally budget the whole run; request timeouts shrink as the deadline nears
+	// and no request starts after it. The run's context ends once the last request
+	// started before the deadline has had its minRequestTimeout.
+	ctx := context.Background()
+	if syncCfg.RunBudget > 0 {
+		budget := httpclient.NewRunBudget(syncCfg.RunBudget, minRequestTimeout)
+		client.HTTPClient().SetRunBudget(budget)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, budget.Deadline().Add(minRequestTimeout))
+		defer cancel()
+		logger.Info("Run budget enabled", zap.Duration("budget", syncCfg.RunBudget), zap.Time("deadline", budget.Deadline()))
+	}
+
+	// Periodically log pool utilization when asked to, to spot connection starvation
+	if v := os.Getenv("DB_STATS_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			logger.Error("Invalid DB_STATS_INTERVAL", zap.String("value", v), zap.Error(err))
+			fmt.Fprintf(os.Stderr, "DB_STATS_INTERVAL must be a positive duration, got %q\n", v)
+			os.Exit(1)
+		}
+		db.StartStatsLogger(ctx, interval)
+	}
+
+	// Keep the access token warm so no request stalls on re-authentication
+	refreshCtx, stopRefresher := context.WithCancel(ctx)
+	defer stopRefresher()
+	client.StartTokenRefresher(refreshCtx)
+
 	// Create folder service
 	folderSvc := services.NewFolderService(db, logger)
 
@@ -53,10 +134,10 @@ func main() {
 	dataExtSvc := services.NewDataExtensionService(db, logger)
 
 	// Create sync service
-	syncSvc := services.NewSyncService(client, dataExtSvc, folderSvc, db, logger)
+	syncSvc := services.NewSyncServiceWithConfig(client, dataExtSvc, folderSvc, db, syncCfg, logger)
+	syncSvc.SetLockName(services.SyncLockName(cfg.AccountID))
 
 	// Fetch and process folders, subfolders, and data extensions
-	ctx := context.Background()
 	metrics, err := syncSvc.SyncAll(ctx)
 	if err != nil {
 		logger.Error("Failed to sync data", zap.Error(err))
@@ -66,6 +147,7 @@ func main() {
 
 	// Log and print final metrics
 	logger.Info("Successfully completed fetching and storing folders, subfolders, and data extensions",
+		zap.String("run_id", metrics.RunID.String()),
 		zap.Int("folders_succeeded", metrics.FoldersSucceeded),
 		zap.Int("folders_failed", metrics.FoldersFailed),
 		zap.Int("subfolders_succeeded", metrics.SubfoldersSucceeded),
@@ -75,10 +157,57 @@ func main() {
 		zap.Int("total_succeeded", metrics.TotalSucceeded()),
 		zap.Int("total_failed", metrics.TotalFailed()))
 
-	fmt.Println("Successfully completed fetching and storing folders, subfolders, and data extensions")
-	fmt.Printf("Sync Metrics:\n")
-	fmt.Printf("  Folders: %d succeeded, %d failed\n", metrics.FoldersSucceeded, metrics.FoldersFailed)
-	fmt.Printf("  Subfolders: %d succeeded, %d failed\n", metrics.SubfoldersSucceeded, metrics.SubfoldersFailed)
-	fmt.Printf("  Data Extensions: %d succeeded, %d failed\n", metrics.DataExtensionsSucceeded, metrics.DataExtensionsFailed)
-	fmt.Printf("  Total: %d succeeded, %d failed\n", metrics.TotalSucceeded(), metrics.TotalFailed())
+	fmt.Fprintln(out, "Successfully completed fetching and storing folders, subfolders, and data extensions")
+	fmt.Fprintf(out, "Sync Metrics (run %s):\n", metrics.RunID)
+	fmt.Fprintf(out, "  Folders: %d succeeded, %d failed\n", metrics.FoldersSucceeded, metrics.FoldersFailed)
+	fmt.Fprintf(out, "  Subfolders: %d succeeded, %d failed\n", metrics.SubfoldersSucceeded, metrics.SubfoldersFailed)
+	fmt.Fprintf(out, "  Data Extensions: %d succeeded, %d failed\n", metrics.DataExtensionsSucceeded, metrics.DataExtensionsFailed)
+	fmt.Fprintf(out, "  Total: %d succeeded, %d failed\n", metrics.TotalSucceeded(), metrics.TotalFailed())
+
+	if *reportCSV != "" {
+		if err := writeReport(*reportCSV, metrics); err != nil {
+			logger.Error("Failed to write per-folder report", zap.String("path", *reportCSV), zap.Error(err))
+			fmt.Fprintf(os.Stderr, "Failed to write per-folder report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(out, "Per-folder report written to %s\n", *reportCSV)
+	}
+
+	if *resultJSON != "" {
+		if err := writeResult(*resultJSON, metrics.Result()); err != nil {
+			logger.Error("Failed to write sync result", zap.String("path", *resultJSON), zap.Error(err))
+			fmt.Fprintf(os.Stderr, "Failed to write sync result: %v\n", err)
+			os.Exit(1)
+		}
+		if *resultJSON != "-" {
+			fmt.Fprintf(out, "Sync result written to %s\n", *resultJSON)
+		}
+	}
+}
+
+// writeResult writes the sync result as indented JSON to path, or to stdout when path is "-"
+func writeResult(path string, result *services.SyncResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sync result: %w", err)
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// writeReport writes the per-folder CSV report of the run to path
+func writeReport(path string, metrics *services.SyncMetrics) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := services.WritePerFolderReport(f, metrics); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
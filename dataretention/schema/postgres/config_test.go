@@ -0,0 +1,129 @@
+package postgres_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"go.uber.org/zap"
+)
+
+func TestDSNIncludesTLSParams(t *testing.T) {
+	t.Setenv("DB_SSLMODE", "verify-full")
+	t.Setenv("DB_SSLROOTCERT", "/etc/ssl/db/root.crt")
+	t.Setenv("DB_SSLCERT", "/etc/ssl/db/client.crt")
+	t.Setenv("DB_SSLKEY", "/etc/ssl/db/client.key")
+
+	cfg := postgres.NewConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	dsn := cfg.DSN()
+	for _, param := range []string{
+		"sslmode=verify-full",
+		"sslrootcert=/etc/ssl/db/root.crt",
+		"sslcert=/etc/ssl/db/client.crt",
+		"sslkey=/etc/ssl/db/client.key",
+	} {
+		if !strings.Contains(dsn, param) {
+			t.Errorf("DSN() = %q, want it to include %s", dsn, param)
+		}
+	}
+}
+
+func TestDSNOmitsUnsetTLSParams(t *testing.T) {
+	t.Setenv("DB_SSLMODE", "")
+	t.Setenv("DB_SSLROOTCERT", "")
+	t.Setenv("DB_SSLCERT", "")
+	t.Setenv("DB_SSLKEY", "")
+
+	dsn := postgres.NewConfig().DSN()
+	if !strings.Contains(dsn, "sslmode=disable") {
+		t.Errorf("DSN() = %q, want sslmode=disable by default", dsn)
+	}
+	for _, param := range []string{"sslrootcert", "sslcert", "sslkey"} {
+		if strings.Contains(dsn, param) {
+			t.Errorf("DSN() = %q, want no %s when unset", dsn, param)
+		}
+	}
+}
+
+func TestConfigValidateTLS(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     postgres.Config
+		wantErr string
+	}{
+		{name: "verify-full with root cert", cfg: postgres.Config{SSLMode: "verify-full", SSLRootCert: "root.crt"}},
+		{name: "require without certs", cfg: postgres.Config{SSLMode: "require"}},
+		{name: "client cert and key", cfg: postgres.Config{SSLMode: "require", SSLCert: "client.crt", SSLKey: "client.key"}},
+		{name: "verify-full without root cert", cfg: postgres.Config{SSLMode: "verify-full"}, wantErr: "requires DB_SSLROOTCERT"},
+		{name: "cert without key", cfg: postgres.Config{SSLMode: "require", SSLCert: "client.crt"}, wantErr: "set together"},
+		{name: "key without cert", cfg: postgres.Config{SSLMode: "require", SSLKey: "client.key"}, wantErr: "set together"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidTLSConfig(t *testing.T) {
+	cfg := &postgres.Config{Host: "localhost", Port: postgres.DefaultPort, SSLMode: "verify-full"}
+	if _, err := postgres.New(cfg, zap.NewNop()); err == nil || !strings.Contains(err.Error(), "DB_SSLROOTCERT") {
+		t.Errorf("New() error = %v, want the config rejected before connecting", err)
+	}
+}
+
+func TestNewConfigPort(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    int
+		wantErr bool
+	}{
+		{env: "", want: postgres.DefaultPort},
+		{env: "6543", want: 6543},
+		{env: "1", want: 1},
+		{env: "65535", want: 65535},
+		{env: "0", want: postgres.DefaultPort, wantErr: true},
+		{env: "65536", want: postgres.DefaultPort, wantErr: true},
+		{env: "postgres", want: postgres.DefaultPort, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run("DB_PORT="+tt.env, func(t *testing.T) {
+			t.Setenv("DB_PORT", tt.env)
+			t.Setenv("DB_SSLMODE", "")
+			t.Setenv("DB_SSLROOTCERT", "")
+			t.Setenv("DB_SSLCERT", "")
+			t.Setenv("DB_SSLKEY", "")
+
+			cfg := postgres.NewConfig()
+			if cfg.Port != tt.want {
+				t.Errorf("Port = %d, want %d", cfg.Port, tt.want)
+			}
+			err := cfg.Validate()
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "DB_PORT") {
+					t.Errorf("Validate() error = %v, want DB_PORT rejected", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+			if want := fmt.Sprintf("port=%d ", tt.want); !strings.Contains(cfg.DSN(), want) {
+				t.Errorf("DSN() = %q, want %q", cfg.DSN(), want)
+			}
+		})
+	}
+}
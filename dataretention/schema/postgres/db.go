@@ -4,6 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -13,8 +17,11 @@ import (
 
 // DB wraps the pgx connection pool and provides methods for database operations
 type DB struct {
-	pool   *pgxpool.Pool
-	logger *zap.Logger
+	// mu guards pool, which WithRetry replaces when the database was lost
+	mu         sync.RWMutex
+	pool       *pgxpool.Pool
+	poolConfig *pgxpool.Config
+	logger     *zap.Logger
 }
 
 // Config holds database configuration
@@ -25,12 +32,20 @@ type Config struct {
 	Password        string
 	Database        string
 	SSLMode         string
+	SSLRootCert     string
+	SSLCert         string
+	SSLKey          string
 	MaxConns        int32
 	MinConns        int32
 	MaxConnLifetime time.Duration
 	MaxConnIdleTime time.Duration
+	// envErr is a malformed environment variable found by NewConfig, reported by Validate
+	envErr error
 }
 
+// DefaultPort is the port used when DB_PORT isn't set
+const DefaultPort = 5432
+
 // NewConfig creates a new database config from environment variables
 func NewConfig() *Config {
 	sslMode := os.Getenv("DB_SSLMODE")
@@ -43,28 +58,75 @@ func NewConfig() *Config {
 	maxConnLifetime := 5 * time.Minute
 	maxConnIdleTime := 30 * time.Minute
 
+	var envErr error
+	port := DefaultPort
+	if v := os.Getenv("DB_PORT"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 || parsed > 65535 {
+			envErr = fmt.Errorf("DB_PORT must be a port number between 1 and 65535, got %q", v)
+		} else {
+			port = parsed
+		}
+	}
+
 	return &Config{
 		Host:            getEnv("DB_HOST", "localhost"),
-		Port:            5432,
+		Port:            port,
 		User:            getEnv("DB_USER", "postgres"),
 		Password:        getEnv("DB_PASSWORD", ""),
 		Database:        getEnv("DB_NAME", "sforce"),
 		SSLMode:         sslMode,
+		SSLRootCert:     getEnv("DB_SSLROOTCERT", ""),
+		SSLCert:         getEnv("DB_SSLCERT", ""),
+		SSLKey:          getEnv("DB_SSLKEY", ""),
 		MaxConns:        maxConns,
 		MinConns:        minConns,
 		MaxConnLifetime: maxConnLifetime,
 		MaxConnIdleTime: maxConnIdleTime,
+		envErr:          envErr,
 	}
 }
 
-// New creates a new database connection pool using pgx
-func New(cfg *Config, logger *zap.Logger) (*DB, error) {
+// Validate checks the settings read from the environment and that the TLS settings
+// are consistent
+func (c *Config) Validate() error {
+	if c.envErr != nil {
+		return c.envErr
+	}
+	if c.SSLMode == "verify-full" && c.SSLRootCert == "" {
+		return fmt.Errorf("DB_SSLMODE=verify-full requires DB_SSLROOTCERT")
+	}
+	if (c.SSLCert == "") != (c.SSLKey == "") {
+		return fmt.Errorf("DB_SSLCERT and DB_SSLKEY must be set together")
+	}
+	return nil
+}
+
+// DSN returns the connection string for the config
+func (c *Config) DSN() string {
 	dsn := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database, cfg.SSLMode,
+		c.Host, c.Port, c.User, c.Password, c.Database, c.SSLMode,
 	)
+	if c.SSLRootCert != "" {
+		dsn += " sslrootcert=" + c.SSLRootCert
+	}
+	if c.SSLCert != "" {
+		dsn += " sslcert=" + c.SSLCert
+	}
+	if c.SSLKey != "" {
+		dsn += " sslkey=" + c.SSLKey
+	}
+	return dsn
+}
 
-	config, err := pgxpool.ParseConfig(dsn)
+// New creates a new database connection pool using pgx
+func New(cfg *Config, logger *zap.Logger) (*DB, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	config, err := pgxpool.ParseConfig(cfg.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse connection string: %w", err)
 	}
@@ -94,38 +156,151 @@ func New(cfg *Config, logger *zap.Logger) (*DB, error) {
 		zap.Int32("max_conns", cfg.MaxConns))
 
 	return &DB{
-		pool:   pool,
-		logger: logger,
+		pool:       pool,
+		poolConfig: config,
+		logger:     logger,
 	}, nil
 }
 
 // Close closes the database connection pool
 func (db *DB) Close() {
-	if db.pool != nil {
-		db.pool.Close()
+	if pool := db.Pool(); pool != nil {
+		pool.Close()
 	}
 }
 
-// Pool returns the underlying connection pool
+// Pool returns the underlying connection pool. The pool may be replaced after a lost
+// connection (see WithRetry), so fetch it for each operation rather than keeping it.
 func (db *DB) Pool() *pgxpool.Pool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 	return db.pool
 }
 
 // Ping checks if the database connection is alive
 func (db *DB) Ping(ctx context.Context) error {
-	return db.pool.Ping(ctx)
+	return db.Pool().Ping(ctx)
+}
+
+// PoolStats is a snapshot of the connection pool's utilization
+type PoolStats struct {
+	// AcquiredConns is the number of connections currently in use
+	AcquiredConns int32
+	// IdleConns is the number of connections open but not in use
+	IdleConns int32
+	// TotalConns is the number of open connections, acquired, idle or being established
+	TotalConns int32
+	// MaxConns is the most connections the pool will open
+	MaxConns int32
+	// AcquireCount is the number of connections acquired since the pool was created
+	AcquireCount int64
+	// EmptyAcquireCount is how many of those acquires had to wait because no idle
+	// connection was available
+	EmptyAcquireCount int64
+	// AcquireDuration is the total time spent waiting for connections
+	AcquireDuration time.Duration
+}
+
+// Stats returns a snapshot of the connection pool's utilization
+func (db *DB) Stats() PoolStats {
+	stat := db.Pool().Stat()
+	return PoolStats{
+		AcquiredConns:     stat.AcquiredConns(),
+		IdleConns:         stat.IdleConns(),
+		TotalConns:        stat.TotalConns(),
+		MaxConns:          stat.MaxConns(),
+		AcquireCount:      stat.AcquireCount(),
+		EmptyAcquireCount: stat.EmptyAcquireCount(),
+		AcquireDuration:   stat.AcquireDuration(),
+	}
+}
+
+// StartStatsLogger logs the pool stats every interval until ctx is cancelled. A pool
+// whose acquired connections sit at MaxConns with a growing empty-acquire count is
+// the bottleneck of the run.
+func (db *DB) StartStatsLogger(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			stats := db.Stats()
+			db.logger.Info("Database pool stats",
+				zap.Int32("acquired_conns", stats.AcquiredConns),
+				zap.Int32("idle_conns", stats.IdleConns),
+				zap.Int32("total_conns", stats.TotalConns),
+				zap.Int32("max_conns", stats.MaxConns),
+				zap.Int64("acquire_count", stats.AcquireCount),
+				zap.Int64("empty_acquire_count", stats.EmptyAcquireCount),
+				zap.Duration("acquire_duration", stats.AcquireDuration))
+		}
+	}()
+}
+
+// RequiredTables lists the tables the sync reads and writes; CheckSchema verifies they exist
+var RequiredTables = []string{"folders", "data_extensions", "data_retention_properties", "retention_dead_letters"}
+
+// OptionalTables lists tables the sync uses when they exist but can run without;
+// CheckSchema only warns when they are missing. Without sync_jobs the sync runs
+// untracked.
+var OptionalTables = []string{"sync_jobs"}
+
+// CheckSchema verifies that every table in RequiredTables exists in the current
+// schema, so a database that hasn't been migrated fails before any work starts
+// instead of midway through a run. Missing OptionalTables are logged as a warning.
+func (db *DB) CheckSchema(ctx context.Context) error {
+	tables := slices.Concat(RequiredTables, OptionalTables)
+	rows, err := db.Pool().Query(ctx,
+		`SELECT table_name FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_name = ANY($1::text[])`,
+		tables)
+	if err != nil {
+		return fmt.Errorf("failed to check database schema: %w", err)
+	}
+	existing, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to check database schema: %w", err)
+	}
+
+	var missing, missingOptional []string
+	for _, table := range RequiredTables {
+		if !slices.Contains(existing, table) {
+			missing = append(missing, table)
+		}
+	}
+	for _, table := range OptionalTables {
+		if !slices.Contains(existing, table) {
+			missingOptional = append(missingOptional, table)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("database schema is missing tables %s; run the migrations (make migrate-up) first",
+			strings.Join(missing, ", "))
+	}
+	if len(missingOptional) > 0 {
+		db.logger.Warn("Database schema is missing optional tables; run the migrations (make migrate-up) to enable them",
+			zap.Strings("tables", missingOptional))
+	}
+
+	db.logger.Debug("Database schema check passed", zap.Strings("tables", tables))
+	return nil
 }
 
 // BeginTx starts a new transaction
 func (db *DB) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
-	return db.pool.BeginTx(ctx, txOptions)
+	return db.Pool().BeginTx(ctx, txOptions)
 }
 
 // InitSchema initializes the database schema by executing the schema SQL
 func (db *DB) InitSchema(ctx context.Context, schemaSQL string) error {
 	db.logger.Info("Initializing database schema")
 
-	_, err := db.pool.Exec(ctx, schemaSQL)
+	_, err := db.Pool().Exec(ctx, schemaSQL)
 	if err != nil {
 		return fmt.Errorf("failed to initialize schema: %w", err)
 	}
@@ -151,4 +326,3 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
-
@@ -0,0 +1,106 @@
+package postgres_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestCheckSchema(t *testing.T) {
+	db := postgrestest.New(t)
+	if err := db.CheckSchema(context.Background()); err != nil {
+		t.Fatalf("CheckSchema() error = %v on a migrated database", err)
+	}
+}
+
+func TestCheckSchemaUnmigrated(t *testing.T) {
+	db := postgrestest.NewEmpty(t)
+	err := db.CheckSchema(context.Background())
+	if err == nil {
+		t.Fatal("CheckSchema() error = nil on an unmigrated database")
+	}
+	for _, table := range postgres.RequiredTables {
+		if !strings.Contains(err.Error(), table) {
+			t.Errorf("CheckSchema() error = %v, want it to name %s", err, table)
+		}
+	}
+}
+
+func TestCheckSchemaMissingTable(t *testing.T) {
+	db := postgrestest.New(t)
+	ctx := context.Background()
+	if _, err := db.Pool().Exec(ctx, "DROP TABLE retention_dead_letters"); err != nil {
+		t.Fatalf("failed to drop table: %v", err)
+	}
+
+	err := db.CheckSchema(ctx)
+	if err == nil || !strings.Contains(err.Error(), "retention_dead_letters") || strings.Contains(err.Error(), "folders") {
+		t.Fatalf("CheckSchema() error = %v, want only retention_dead_letters missing", err)
+	}
+}
+
+func TestStatsReflectPoolSize(t *testing.T) {
+	cfg := postgrestest.Config(t)
+	cfg.MaxConns = 3
+	db, err := postgres.New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	var conns []*pgxpool.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := db.Pool().Acquire(ctx)
+		if err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+		conns = append(conns, conn)
+	}
+
+	stats := db.Stats()
+	if stats.MaxConns != 3 || stats.AcquiredConns != 2 || stats.TotalConns < 2 {
+		t.Errorf("Stats() with 2 of 3 connections acquired = %+v", stats)
+	}
+
+	for _, conn := range conns {
+		conn.Release()
+	}
+	stats = db.Stats()
+	if stats.AcquiredConns != 0 || stats.IdleConns != stats.TotalConns || stats.AcquireCount < 2 {
+		t.Errorf("Stats() after releasing = %+v, want every connection idle", stats)
+	}
+}
+
+func TestCheckSchemaMissingOptionalTable(t *testing.T) {
+	migrated := postgrestest.New(t)
+	ctx := context.Background()
+	if _, err := migrated.Pool().Exec(ctx, "DROP TABLE sync_jobs"); err != nil {
+		t.Fatalf("failed to drop table: %v", err)
+	}
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	db, err := postgres.New(postgrestest.Config(t), zap.New(core))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer db.Close()
+
+	if err := db.CheckSchema(ctx); err != nil {
+		t.Fatalf("CheckSchema() error = %v, want only a warning for sync_jobs", err)
+	}
+	warnings := logs.FilterMessageSnippet("missing optional tables").All()
+	if len(warnings) != 1 {
+		t.Fatalf("got %d warnings about optional tables, want 1", len(warnings))
+	}
+	if tables, _ := warnings[0].ContextMap()["tables"].([]interface{}); len(tables) != 1 || tables[0] != "sync_jobs" {
+		t.Errorf("warned about tables %v, want sync_jobs", warnings[0].ContextMap()["tables"])
+	}
+}
@@ -21,7 +21,7 @@ INSERT INTO data_extensions (
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25
 )
-RETURNING id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at
+RETURNING id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at, deleted_at, category_full_path
 `
 
 type CreateDataExtensionParams struct {
@@ -35,20 +35,20 @@ type CreateDataExtensionParams struct {
 	SendableSubscriberField    pgtype.Text        `json:"sendable_subscriber_field"`
 	IsTestable                 bool               `json:"is_testable"`
 	CategoryID                 string             `json:"category_id"`
-	OwnerID                    int32              `json:"owner_id"`
+	OwnerID                    int64              `json:"owner_id"`
 	IsObjectDeletable          bool               `json:"is_object_deletable"`
 	IsFieldAdditionAllowed     bool               `json:"is_field_addition_allowed"`
 	IsFieldModificationAllowed bool               `json:"is_field_modification_allowed"`
 	CreatedDate                pgtype.Timestamptz `json:"created_date"`
-	CreatedByID                int32              `json:"created_by_id"`
+	CreatedByID                int64              `json:"created_by_id"`
 	CreatedByName              pgtype.Text        `json:"created_by_name"`
 	ModifiedDate               pgtype.Timestamptz `json:"modified_date"`
-	ModifiedByID               pgtype.Int4        `json:"modified_by_id"`
+	ModifiedByID               pgtype.Int8        `json:"modified_by_id"`
 	ModifiedByName             pgtype.Text        `json:"modified_by_name"`
 	OwnerName                  pgtype.Text        `json:"owner_name"`
-	PartnerApiObjectTypeID     pgtype.Int4        `json:"partner_api_object_type_id"`
+	PartnerApiObjectTypeID     pgtype.Int8        `json:"partner_api_object_type_id"`
 	PartnerApiObjectTypeName   pgtype.Text        `json:"partner_api_object_type_name"`
-	RowCount                   int32              `json:"row_count"`
+	RowCount                   int64              `json:"row_count"`
 	FieldCount                 int32              `json:"field_count"`
 }
 
@@ -109,6 +109,8 @@ func (q *Queries) CreateDataExtension(ctx context.Context, db DBTX, arg CreateDa
 		&i.FieldCount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.CategoryFullPath,
 	)
 	return &i, err
 }
@@ -124,7 +126,7 @@ func (q *Queries) DeleteDataExtension(ctx context.Context, db DBTX, id string) e
 }
 
 const getDataExtensionByID = `-- name: GetDataExtensionByID :one
-SELECT id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at FROM data_extensions
+SELECT id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at, deleted_at, category_full_path FROM data_extensions
 WHERE id = $1
 `
 
@@ -159,12 +161,14 @@ func (q *Queries) GetDataExtensionByID(ctx context.Context, db DBTX, id string)
 		&i.FieldCount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.CategoryFullPath,
 	)
 	return &i, err
 }
 
 const getDataExtensionByKey = `-- name: GetDataExtensionByKey :one
-SELECT id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at FROM data_extensions
+SELECT id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at, deleted_at, category_full_path FROM data_extensions
 WHERE key = $1
 `
 
@@ -199,12 +203,26 @@ func (q *Queries) GetDataExtensionByKey(ctx context.Context, db DBTX, key string
 		&i.FieldCount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.CategoryFullPath,
 	)
 	return &i, err
 }
 
+const getDataExtensionModifiedDate = `-- name: GetDataExtensionModifiedDate :one
+SELECT modified_date FROM data_extensions
+WHERE id = $1
+`
+
+func (q *Queries) GetDataExtensionModifiedDate(ctx context.Context, db DBTX, id string) (pgtype.Timestamptz, error) {
+	row := db.QueryRow(ctx, getDataExtensionModifiedDate, id)
+	var modified_date pgtype.Timestamptz
+	err := row.Scan(&modified_date)
+	return modified_date, err
+}
+
 const getDataExtensionsByCategoryID = `-- name: GetDataExtensionsByCategoryID :many
-SELECT id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at FROM data_extensions
+SELECT id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at, deleted_at, category_full_path FROM data_extensions
 WHERE category_id = $1
 ORDER BY modified_date DESC
 `
@@ -246,6 +264,8 @@ func (q *Queries) GetDataExtensionsByCategoryID(ctx context.Context, db DBTX, ca
 			&i.FieldCount,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.CategoryFullPath,
 		); err != nil {
 			return nil, err
 		}
@@ -258,7 +278,7 @@ func (q *Queries) GetDataExtensionsByCategoryID(ctx context.Context, db DBTX, ca
 }
 
 const getDataExtensionsByCategoryIDPaginated = `-- name: GetDataExtensionsByCategoryIDPaginated :many
-SELECT id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at FROM data_extensions
+SELECT id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at, deleted_at, category_full_path FROM data_extensions
 WHERE category_id = $1
 ORDER BY modified_date DESC
 LIMIT $2 OFFSET $3
@@ -307,6 +327,64 @@ func (q *Queries) GetDataExtensionsByCategoryIDPaginated(ctx context.Context, db
 			&i.FieldCount,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.CategoryFullPath,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDataExtensionsAboveRowCount = `-- name: ListDataExtensionsAboveRowCount :many
+SELECT id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at, deleted_at, category_full_path FROM data_extensions
+WHERE row_count > $1 AND deleted_at IS NULL
+ORDER BY row_count DESC, id
+`
+
+func (q *Queries) ListDataExtensionsAboveRowCount(ctx context.Context, db DBTX, rowCount int64) ([]*DataExtensions, error) {
+	rows, err := db.Query(ctx, listDataExtensionsAboveRowCount, rowCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*DataExtensions
+	for rows.Next() {
+		var i DataExtensions
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Key,
+			&i.Description,
+			&i.IsActive,
+			&i.IsSendable,
+			&i.SendableCustomObjectField,
+			&i.SendableSubscriberField,
+			&i.IsTestable,
+			&i.CategoryID,
+			&i.OwnerID,
+			&i.IsObjectDeletable,
+			&i.IsFieldAdditionAllowed,
+			&i.IsFieldModificationAllowed,
+			&i.CreatedDate,
+			&i.CreatedByID,
+			&i.CreatedByName,
+			&i.ModifiedDate,
+			&i.ModifiedByID,
+			&i.ModifiedByName,
+			&i.OwnerName,
+			&i.PartnerApiObjectTypeID,
+			&i.PartnerApiObjectTypeName,
+			&i.RowCount,
+			&i.FieldCount,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.CategoryFullPath,
 		); err != nil {
 			return nil, err
 		}
@@ -318,11 +396,57 @@ func (q *Queries) GetDataExtensionsByCategoryIDPaginated(ctx context.Context, db
 	return items, nil
 }
 
+const restoreDataExtensionsSeen = `-- name: RestoreDataExtensionsSeen :execrows
+UPDATE data_extensions
+SET deleted_at = NULL
+WHERE deleted_at IS NOT NULL
+  AND id = ANY($1::text[])
+`
+
+func (q *Queries) RestoreDataExtensionsSeen(ctx context.Context, db DBTX, seenIds []string) (int64, error) {
+	result, err := db.Exec(ctx, restoreDataExtensionsSeen, seenIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setDataExtensionCategoryFullPath = `-- name: SetDataExtensionCategoryFullPath :exec
+UPDATE data_extensions
+SET category_full_path = $2
+WHERE id = $1
+`
+
+type SetDataExtensionCategoryFullPathParams struct {
+	ID               string      `json:"id"`
+	CategoryFullPath pgtype.Text `json:"category_full_path"`
+}
+
+func (q *Queries) SetDataExtensionCategoryFullPath(ctx context.Context, db DBTX, arg SetDataExtensionCategoryFullPathParams) error {
+	_, err := db.Exec(ctx, setDataExtensionCategoryFullPath, arg.ID, arg.CategoryFullPath)
+	return err
+}
+
+const softDeleteDataExtensionsNotSeen = `-- name: SoftDeleteDataExtensionsNotSeen :execrows
+UPDATE data_extensions
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE deleted_at IS NULL
+  AND NOT (id = ANY($1::text[]))
+`
+
+func (q *Queries) SoftDeleteDataExtensionsNotSeen(ctx context.Context, db DBTX, seenIds []string) (int64, error) {
+	result, err := db.Exec(ctx, softDeleteDataExtensionsNotSeen, seenIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateDataExtension = `-- name: UpdateDataExtension :one
 UPDATE data_extensions
 SET name = $2, description = $3, is_active = $4, modified_date = $5, modified_by_id = $6, modified_by_name = $7, row_count = $8, field_count = $9
 WHERE id = $1
-RETURNING id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at
+RETURNING id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at, deleted_at, category_full_path
 `
 
 type UpdateDataExtensionParams struct {
@@ -331,9 +455,9 @@ type UpdateDataExtensionParams struct {
 	Description    pgtype.Text        `json:"description"`
 	IsActive       bool               `json:"is_active"`
 	ModifiedDate   pgtype.Timestamptz `json:"modified_date"`
-	ModifiedByID   pgtype.Int4        `json:"modified_by_id"`
+	ModifiedByID   pgtype.Int8        `json:"modified_by_id"`
 	ModifiedByName pgtype.Text        `json:"modified_by_name"`
-	RowCount       int32              `json:"row_count"`
+	RowCount       int64              `json:"row_count"`
 	FieldCount     int32              `json:"field_count"`
 }
 
@@ -378,6 +502,121 @@ func (q *Queries) UpdateDataExtension(ctx context.Context, db DBTX, arg UpdateDa
 		&i.FieldCount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.CategoryFullPath,
+	)
+	return &i, err
+}
+
+const upsertDataExtension = `-- name: UpsertDataExtension :one
+INSERT INTO data_extensions (
+    id, name, key, description, is_active, is_sendable, sendable_custom_object_field,
+    sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable,
+    is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id,
+    created_by_name, modified_date, modified_by_id, modified_by_name, owner_name,
+    partner_api_object_type_id, partner_api_object_type_name, row_count, field_count
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25
+)
+ON CONFLICT (id) DO UPDATE
+SET name = EXCLUDED.name,
+    description = EXCLUDED.description,
+    is_active = EXCLUDED.is_active,
+    modified_date = EXCLUDED.modified_date,
+    modified_by_id = EXCLUDED.modified_by_id,
+    modified_by_name = EXCLUDED.modified_by_name,
+    row_count = EXCLUDED.row_count,
+    field_count = EXCLUDED.field_count
+RETURNING id, name, key, description, is_active, is_sendable, sendable_custom_object_field, sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable, is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id, created_by_name, modified_date, modified_by_id, modified_by_name, owner_name, partner_api_object_type_id, partner_api_object_type_name, row_count, field_count, created_at, updated_at, deleted_at, category_full_path
+`
+
+type UpsertDataExtensionParams struct {
+	ID                         string             `json:"id"`
+	Name                       string             `json:"name"`
+	Key                        string             `json:"key"`
+	Description                pgtype.Text        `json:"description"`
+	IsActive                   bool               `json:"is_active"`
+	IsSendable                 bool               `json:"is_sendable"`
+	SendableCustomObjectField  pgtype.Text        `json:"sendable_custom_object_field"`
+	SendableSubscriberField    pgtype.Text        `json:"sendable_subscriber_field"`
+	IsTestable                 bool               `json:"is_testable"`
+	CategoryID                 string             `json:"category_id"`
+	OwnerID                    int64              `json:"owner_id"`
+	IsObjectDeletable          bool               `json:"is_object_deletable"`
+	IsFieldAdditionAllowed     bool               `json:"is_field_addition_allowed"`
+	IsFieldModificationAllowed bool               `json:"is_field_modification_allowed"`
+	CreatedDate                pgtype.Timestamptz `json:"created_date"`
+	CreatedByID                int64              `json:"created_by_id"`
+	CreatedByName              pgtype.Text        `json:"created_by_name"`
+	ModifiedDate               pgtype.Timestamptz `json:"modified_date"`
+	ModifiedByID               pgtype.Int8        `json:"modified_by_id"`
+	ModifiedByName             pgtype.Text        `json:"modified_by_name"`
+	OwnerName                  pgtype.Text        `json:"owner_name"`
+	PartnerApiObjectTypeID     pgtype.Int8        `json:"partner_api_object_type_id"`
+	PartnerApiObjectTypeName   pgtype.Text        `json:"partner_api_object_type_name"`
+	RowCount                   int64              `json:"row_count"`
+	FieldCount                 int32              `json:"field_count"`
+}
+
+func (q *Queries) UpsertDataExtension(ctx context.Context, db DBTX, arg UpsertDataExtensionParams) (*DataExtensions, error) {
+	row := db.QueryRow(ctx, upsertDataExtension,
+		arg.ID,
+		arg.Name,
+		arg.Key,
+		arg.Description,
+		arg.IsActive,
+		arg.IsSendable,
+		arg.SendableCustomObjectField,
+		arg.SendableSubscriberField,
+		arg.IsTestable,
+		arg.CategoryID,
+		arg.OwnerID,
+		arg.IsObjectDeletable,
+		arg.IsFieldAdditionAllowed,
+		arg.IsFieldModificationAllowed,
+		arg.CreatedDate,
+		arg.CreatedByID,
+		arg.CreatedByName,
+		arg.ModifiedDate,
+		arg.ModifiedByID,
+		arg.ModifiedByName,
+		arg.OwnerName,
+		arg.PartnerApiObjectTypeID,
+		arg.PartnerApiObjectTypeName,
+		arg.RowCount,
+		arg.FieldCount,
+	)
+	var i DataExtensions
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Key,
+		&i.Description,
+		&i.IsActive,
+		&i.IsSendable,
+		&i.SendableCustomObjectField,
+		&i.SendableSubscriberField,
+		&i.IsTestable,
+		&i.CategoryID,
+		&i.OwnerID,
+		&i.IsObjectDeletable,
+		&i.IsFieldAdditionAllowed,
+		&i.IsFieldModificationAllowed,
+		&i.CreatedDate,
+		&i.CreatedByID,
+		&i.CreatedByName,
+		&i.ModifiedDate,
+		&i.ModifiedByID,
+		&i.ModifiedByName,
+		&i.OwnerName,
+		&i.PartnerApiObjectTypeID,
+		&i.PartnerApiObjectTypeName,
+		&i.RowCount,
+		&i.FieldCount,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.CategoryFullPath,
 	)
 	return &i, err
 }
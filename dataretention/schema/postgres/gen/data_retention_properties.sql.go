@@ -150,6 +150,51 @@ func (q *Queries) GetDataRetentionPropertiesByDataExtensionID(ctx context.Contex
 	return &i, err
 }
 
+const getRetentionStatusReport = `-- name: GetRetentionStatusReport :many
+SELECT de.category_id AS folder_id,
+       f.name AS folder_name,
+       COALESCE(drp.last_api_update_status, 'pending')::VARCHAR AS status,
+       COUNT(*) AS data_extension_count
+FROM data_retention_properties drp
+INNER JOIN data_extensions de ON drp.data_extension_id = de.id
+LEFT JOIN folders f ON de.category_id = f.id
+WHERE de.deleted_at IS NULL
+GROUP BY de.category_id, f.name, status
+ORDER BY f.name ASC NULLS LAST, de.category_id ASC, status ASC
+`
+
+type GetRetentionStatusReportRow struct {
+	FolderID           string      `json:"folder_id"`
+	FolderName         pgtype.Text `json:"folder_name"`
+	Status             string      `json:"status"`
+	DataExtensionCount int64       `json:"data_extension_count"`
+}
+
+func (q *Queries) GetRetentionStatusReport(ctx context.Context, db DBTX) ([]*GetRetentionStatusReportRow, error) {
+	rows, err := db.Query(ctx, getRetentionStatusReport)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*GetRetentionStatusReportRow
+	for rows.Next() {
+		var i GetRetentionStatusReportRow
+		if err := rows.Scan(
+			&i.FolderID,
+			&i.FolderName,
+			&i.Status,
+			&i.DataExtensionCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const resetDataRetentionAPIUpdateStatus = `-- name: ResetDataRetentionAPIUpdateStatus :one
 UPDATE data_retention_properties
 SET last_api_update_status = 'pending',
@@ -187,20 +232,20 @@ SET last_api_update_at = CURRENT_TIMESTAMP,
     last_api_update_error = $2,
     api_update_retry_count = CASE 
         WHEN $1::VARCHAR = 'failed' THEN api_update_retry_count + 1
-        WHEN $1::VARCHAR = 'succeeded' THEN 0
+        WHEN $1::VARCHAR IN ('succeeded', 'skipped') THEN 0
         ELSE api_update_retry_count
     END,
-    -- Update retention properties when status is 'succeeded'
+    -- Update retention properties when status is 'succeeded' or 'skipped'
     data_retention_period_length = CASE 
-        WHEN $1::VARCHAR = 'succeeded' THEN $3
+        WHEN $1::VARCHAR IN ('succeeded', 'skipped') THEN $3
         ELSE data_retention_period_length
     END,
     data_retention_period_unit_of_measure = CASE 
-        WHEN $1::VARCHAR = 'succeeded' THEN $4
+        WHEN $1::VARCHAR IN ('succeeded', 'skipped') THEN $4
         ELSE data_retention_period_unit_of_measure
     END,
     is_row_based_retention = CASE 
-        WHEN $1::VARCHAR = 'succeeded' THEN $5
+        WHEN $1::VARCHAR IN ('succeeded', 'skipped') THEN $5
         ELSE is_row_based_retention
     END,
     updated_at = CURRENT_TIMESTAMP
@@ -14,14 +14,14 @@ import (
 const createFolder = `-- name: CreateFolder :one
 INSERT INTO folders (id, type, last_updated, created_by, parent_id, name, description, icon_type)
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at
+RETURNING id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at, deleted_at
 `
 
 type CreateFolderParams struct {
 	ID          string             `json:"id"`
 	Type        string             `json:"type"`
 	LastUpdated pgtype.Timestamptz `json:"last_updated"`
-	CreatedBy   int32              `json:"created_by"`
+	CreatedBy   int64              `json:"created_by"`
 	ParentID    pgtype.Text        `json:"parent_id"`
 	Name        string             `json:"name"`
 	Description pgtype.Text        `json:"description"`
@@ -51,6 +51,7 @@ func (q *Queries) CreateFolder(ctx context.Context, db DBTX, arg CreateFolderPar
 		&i.IconType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
@@ -66,7 +67,7 @@ func (q *Queries) DeleteFolder(ctx context.Context, db DBTX, id string) error {
 }
 
 const getFolderByID = `-- name: GetFolderByID :one
-SELECT id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at FROM folders
+SELECT id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at, deleted_at FROM folders
 WHERE id = $1
 `
 
@@ -84,12 +85,25 @@ func (q *Queries) GetFolderByID(ctx context.Context, db DBTX, id string) (*Folde
 		&i.IconType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
 
+const getFolderLastUpdated = `-- name: GetFolderLastUpdated :one
+SELECT last_updated FROM folders
+WHERE id = $1
+`
+
+func (q *Queries) GetFolderLastUpdated(ctx context.Context, db DBTX, id string) (pgtype.Timestamptz, error) {
+	row := db.QueryRow(ctx, getFolderLastUpdated, id)
+	var last_updated pgtype.Timestamptz
+	err := row.Scan(&last_updated)
+	return last_updated, err
+}
+
 const getFoldersByParentID = `-- name: GetFoldersByParentID :many
-SELECT id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at FROM folders
+SELECT id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at, deleted_at FROM folders
 WHERE parent_id = $1
 ORDER BY name ASC
 `
@@ -114,6 +128,7 @@ func (q *Queries) GetFoldersByParentID(ctx context.Context, db DBTX, parentID pg
 			&i.IconType,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -126,7 +141,7 @@ func (q *Queries) GetFoldersByParentID(ctx context.Context, db DBTX, parentID pg
 }
 
 const getFoldersByType = `-- name: GetFoldersByType :many
-SELECT id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at FROM folders
+SELECT id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at, deleted_at FROM folders
 WHERE type = $1
 ORDER BY name ASC
 `
@@ -151,6 +166,7 @@ func (q *Queries) GetFoldersByType(ctx context.Context, db DBTX, type_ string) (
 			&i.IconType,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -163,7 +179,7 @@ func (q *Queries) GetFoldersByType(ctx context.Context, db DBTX, type_ string) (
 }
 
 const listAllFolders = `-- name: ListAllFolders :many
-SELECT id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at FROM folders
+SELECT id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at, deleted_at FROM folders
 ORDER BY name ASC
 `
 
@@ -187,6 +203,7 @@ func (q *Queries) ListAllFolders(ctx context.Context, db DBTX) ([]*Folders, erro
 			&i.IconType,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -198,11 +215,41 @@ func (q *Queries) ListAllFolders(ctx context.Context, db DBTX) ([]*Folders, erro
 	return items, nil
 }
 
+const restoreFoldersSeen = `-- name: RestoreFoldersSeen :execrows
+UPDATE folders
+SET deleted_at = NULL
+WHERE deleted_at IS NOT NULL
+  AND id = ANY($1::text[])
+`
+
+func (q *Queries) RestoreFoldersSeen(ctx context.Context, db DBTX, seenIds []string) (int64, error) {
+	result, err := db.Exec(ctx, restoreFoldersSeen, seenIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const softDeleteFoldersNotSeen = `-- name: SoftDeleteFoldersNotSeen :execrows
+UPDATE folders
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE deleted_at IS NULL
+  AND NOT (id = ANY($1::text[]))
+`
+
+func (q *Queries) SoftDeleteFoldersNotSeen(ctx context.Context, db DBTX, seenIds []string) (int64, error) {
+	result, err := db.Exec(ctx, softDeleteFoldersNotSeen, seenIds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateFolder = `-- name: UpdateFolder :one
 UPDATE folders
 SET type = $2, last_updated = $3, name = $4, description = $5, icon_type = $6
 WHERE id = $1
-RETURNING id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at
+RETURNING id, type, last_updated, created_by, parent_id, name, description, icon_type, created_at, updated_at, deleted_at
 `
 
 type UpdateFolderParams struct {
@@ -235,6 +282,7 @@ func (q *Queries) UpdateFolder(ctx context.Context, db DBTX, arg UpdateFolderPar
 		&i.IconType,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return &i, err
 }
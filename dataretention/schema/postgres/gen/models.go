@@ -9,6 +9,19 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type DataExtensionFields struct {
+	DataExtensionID string             `json:"data_extension_id"`
+	Name            string             `json:"name"`
+	FieldType       string             `json:"field_type"`
+	Length          pgtype.Int4        `json:"length"`
+	Scale           pgtype.Int4        `json:"scale"`
+	Ordinal         int32              `json:"ordinal"`
+	IsPrimaryKey    bool               `json:"is_primary_key"`
+	IsNullable      bool               `json:"is_nullable"`
+	DefaultValue    pgtype.Text        `json:"default_value"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+}
+
 type DataExtensions struct {
 	ID                         string             `json:"id"`
 	Name                       string             `json:"name"`
@@ -20,23 +33,25 @@ type DataExtensions struct {
 	SendableSubscriberField    pgtype.Text        `json:"sendable_subscriber_field"`
 	IsTestable                 bool               `json:"is_testable"`
 	CategoryID                 string             `json:"category_id"`
-	OwnerID                    int32              `json:"owner_id"`
+	OwnerID                    int64              `json:"owner_id"`
 	IsObjectDeletable          bool               `json:"is_object_deletable"`
 	IsFieldAdditionAllowed     bool               `json:"is_field_addition_allowed"`
 	IsFieldModificationAllowed bool               `json:"is_field_modification_allowed"`
 	CreatedDate                pgtype.Timestamptz `json:"created_date"`
-	CreatedByID                int32              `json:"created_by_id"`
+	CreatedByID                int64              `json:"created_by_id"`
 	CreatedByName              pgtype.Text        `json:"created_by_name"`
 	ModifiedDate               pgtype.Timestamptz `json:"modified_date"`
-	ModifiedByID               pgtype.Int4        `json:"modified_by_id"`
+	ModifiedByID               pgtype.Int8        `json:"modified_by_id"`
 	ModifiedByName             pgtype.Text        `json:"modified_by_name"`
 	OwnerName                  pgtype.Text        `json:"owner_name"`
-	PartnerApiObjectTypeID     pgtype.Int4        `json:"partner_api_object_type_id"`
+	PartnerApiObjectTypeID     pgtype.Int8        `json:"partner_api_object_type_id"`
 	PartnerApiObjectTypeName   pgtype.Text        `json:"partner_api_object_type_name"`
-	RowCount                   int32              `json:"row_count"`
+	RowCount                   int64              `json:"row_count"`
 	FieldCount                 int32              `json:"field_count"`
 	CreatedAt                  pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt                  pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt                  pgtype.Timestamptz `json:"deleted_at"`
+	CategoryFullPath           pgtype.Text        `json:"category_full_path"`
 }
 
 type DataRetentionProperties struct {
@@ -58,13 +73,14 @@ type Folders struct {
 	ID          string             `json:"id"`
 	Type        string             `json:"type"`
 	LastUpdated pgtype.Timestamptz `json:"last_updated"`
-	CreatedBy   int32              `json:"created_by"`
+	CreatedBy   int64              `json:"created_by"`
 	ParentID    pgtype.Text        `json:"parent_id"`
 	Name        string             `json:"name"`
 	Description pgtype.Text        `json:"description"`
 	IconType    pgtype.Text        `json:"icon_type"`
 	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	DeletedAt   pgtype.Timestamptz `json:"deleted_at"`
 }
 
 type MessageHistory struct {
@@ -92,6 +108,15 @@ type MessageQueue struct {
 	NextRetryAt  pgtype.Timestamptz `json:"next_retry_at"`
 }
 
+type RetentionDeadLetters struct {
+	DataExtensionID string             `json:"data_extension_id"`
+	StatusCode      pgtype.Int4        `json:"status_code"`
+	LastError       string             `json:"last_error"`
+	AttemptCount    int32              `json:"attempt_count"`
+	FirstFailedAt   pgtype.Timestamptz `json:"first_failed_at"`
+	LastFailedAt    pgtype.Timestamptz `json:"last_failed_at"`
+}
+
 type SyncJobs struct {
 	ID                  uuid.UUID          `json:"id"`
 	JobType             string             `json:"job_type"`
@@ -15,25 +15,31 @@ type Querier interface {
 	CancelSyncJob(ctx context.Context, db DBTX, arg CancelSyncJobParams) error
 	CompleteSyncJob(ctx context.Context, db DBTX, arg CompleteSyncJobParams) error
 	CreateDataExtension(ctx context.Context, db DBTX, arg CreateDataExtensionParams) (*DataExtensions, error)
+	CreateDataExtensionField(ctx context.Context, db DBTX, arg CreateDataExtensionFieldParams) error
 	CreateDataRetentionProperties(ctx context.Context, db DBTX, arg CreateDataRetentionPropertiesParams) (*DataRetentionProperties, error)
 	CreateFolder(ctx context.Context, db DBTX, arg CreateFolderParams) (*Folders, error)
 	CreateMessageHistory(ctx context.Context, db DBTX, arg CreateMessageHistoryParams) (*MessageHistory, error)
 	CreateSyncJob(ctx context.Context, db DBTX, arg CreateSyncJobParams) (*SyncJobs, error)
 	DeleteDataExtension(ctx context.Context, db DBTX, id string) error
+	DeleteDataExtensionFields(ctx context.Context, db DBTX, dataExtensionID string) error
 	DeleteDataRetentionProperties(ctx context.Context, db DBTX, dataExtensionID string) error
 	DeleteFolder(ctx context.Context, db DBTX, id string) error
+	DeleteRetentionDeadLetter(ctx context.Context, db DBTX, dataExtensionID string) error
 	DequeueMessages(ctx context.Context, db DBTX, arg DequeueMessagesParams) ([]*MessageQueue, error)
 	EnqueueMessage(ctx context.Context, db DBTX, arg EnqueueMessageParams) (*MessageQueue, error)
 	FailMessageWithRetry(ctx context.Context, db DBTX, arg FailMessageWithRetryParams) error
+	FailStaleSyncJobs(ctx context.Context, db DBTX, arg FailStaleSyncJobsParams) (int64, error)
 	FailSyncJob(ctx context.Context, db DBTX, arg FailSyncJobParams) error
 	GetDataExtensionByID(ctx context.Context, db DBTX, id string) (*DataExtensions, error)
 	GetDataExtensionByKey(ctx context.Context, db DBTX, key string) (*DataExtensions, error)
+	GetDataExtensionModifiedDate(ctx context.Context, db DBTX, id string) (pgtype.Timestamptz, error)
 	GetDataExtensionsByCategoryID(ctx context.Context, db DBTX, categoryID string) ([]*DataExtensions, error)
 	GetDataExtensionsByCategoryIDPaginated(ctx context.Context, db DBTX, arg GetDataExtensionsByCategoryIDPaginatedParams) ([]*DataExtensions, error)
 	GetDataExtensionsNeedingRetentionUpdate(ctx context.Context, db DBTX, limit int32) ([]*GetDataExtensionsNeedingRetentionUpdateRow, error)
 	GetDataRetentionPropertiesByDataExtensionID(ctx context.Context, db DBTX, dataExtensionID string) (*DataRetentionProperties, error)
 	GetDeadLetterMessages(ctx context.Context, db DBTX, arg GetDeadLetterMessagesParams) ([]*MessageQueue, error)
 	GetFolderByID(ctx context.Context, db DBTX, id string) (*Folders, error)
+	GetFolderLastUpdated(ctx context.Context, db DBTX, id string) (pgtype.Timestamptz, error)
 	GetFoldersByParentID(ctx context.Context, db DBTX, parentID pgtype.Text) ([]*Folders, error)
 	GetFoldersByType(ctx context.Context, db DBTX, type_ string) ([]*Folders, error)
 	GetMessageByID(ctx context.Context, db DBTX, id uuid.UUID) (*MessageQueue, error)
@@ -42,13 +48,23 @@ type Querier interface {
 	GetMessageState(ctx context.Context, db DBTX, id uuid.UUID) (*GetMessageStateRow, error)
 	GetPendingMessagesForRetry(ctx context.Context, db DBTX, arg GetPendingMessagesForRetryParams) ([]*MessageQueue, error)
 	GetRecentSyncJobs(ctx context.Context, db DBTX, createdAt pgtype.Timestamptz) ([]*SyncJobs, error)
+	GetRetentionStatusReport(ctx context.Context, db DBTX) ([]*GetRetentionStatusReportRow, error)
 	GetSyncJobByID(ctx context.Context, db DBTX, id uuid.UUID) (*SyncJobs, error)
 	GetSyncJobMetrics(ctx context.Context, db DBTX, createdAt pgtype.Timestamptz) (*GetSyncJobMetricsRow, error)
+	GetSyncJobsByRunID(ctx context.Context, db DBTX, runID string) ([]*SyncJobs, error)
 	GetSyncJobsByStatus(ctx context.Context, db DBTX, arg GetSyncJobsByStatusParams) ([]*SyncJobs, error)
 	GetSyncJobsByType(ctx context.Context, db DBTX, arg GetSyncJobsByTypeParams) ([]*SyncJobs, error)
 	ListAllFolders(ctx context.Context, db DBTX) ([]*Folders, error)
 	ListAllSyncJobs(ctx context.Context, db DBTX, limit int32) ([]*SyncJobs, error)
+	ListDataExtensionFields(ctx context.Context, db DBTX, dataExtensionID string) ([]*DataExtensionFields, error)
+	ListDataExtensionsAboveRowCount(ctx context.Context, db DBTX, rowCount int64) ([]*DataExtensions, error)
+	ListRetentionDeadLetters(ctx context.Context, db DBTX) ([]*RetentionDeadLetters, error)
 	ResetDataRetentionAPIUpdateStatus(ctx context.Context, db DBTX, dataExtensionID string) (*DataRetentionProperties, error)
+	RestoreDataExtensionsSeen(ctx context.Context, db DBTX, seenIds []string) (int64, error)
+	RestoreFoldersSeen(ctx context.Context, db DBTX, seenIds []string) (int64, error)
+	SetDataExtensionCategoryFullPath(ctx context.Context, db DBTX, arg SetDataExtensionCategoryFullPathParams) error
+	SoftDeleteDataExtensionsNotSeen(ctx context.Context, db DBTX, seenIds []string) (int64, error)
+	SoftDeleteFoldersNotSeen(ctx context.Context, db DBTX, seenIds []string) (int64, error)
 	UpdateDataExtension(ctx context.Context, db DBTX, arg UpdateDataExtensionParams) (*DataExtensions, error)
 	UpdateDataRetentionAPIUpdateStatus(ctx context.Context, db DBTX, arg UpdateDataRetentionAPIUpdateStatusParams) (*DataRetentionProperties, error)
 	UpdateDataRetentionProperties(ctx context.Context, db DBTX, arg UpdateDataRetentionPropertiesParams) (*DataRetentionProperties, error)
@@ -57,6 +73,8 @@ type Querier interface {
 	UpdateMessageStatusWithError(ctx context.Context, db DBTX, arg UpdateMessageStatusWithErrorParams) error
 	UpdateSyncJobProgress(ctx context.Context, db DBTX, arg UpdateSyncJobProgressParams) error
 	UpdateSyncJobStatus(ctx context.Context, db DBTX, arg UpdateSyncJobStatusParams) error
+	UpsertDataExtension(ctx context.Context, db DBTX, arg UpsertDataExtensionParams) (*DataExtensions, error)
+	UpsertRetentionDeadLetter(ctx context.Context, db DBTX, arg UpsertRetentionDeadLetterParams) (*RetentionDeadLetters, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -100,6 +100,29 @@ func (q *Queries) CreateSyncJob(ctx context.Context, db DBTX, arg CreateSyncJobP
 	return &i, err
 }
 
+const failStaleSyncJobs = `-- name: FailStaleSyncJobs :execrows
+UPDATE sync_jobs
+SET status = 'failed',
+    completed_at = CURRENT_TIMESTAMP,
+    error_message = $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE status = 'running'
+  AND started_at < $2
+`
+
+type FailStaleSyncJobsParams struct {
+	ErrorMessage pgtype.Text        `json:"error_message"`
+	StartedAt    pgtype.Timestamptz `json:"started_at"`
+}
+
+func (q *Queries) FailStaleSyncJobs(ctx context.Context, db DBTX, arg FailStaleSyncJobsParams) (int64, error) {
+	result, err := db.Exec(ctx, failStaleSyncJobs, arg.ErrorMessage, arg.StartedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const failSyncJob = `-- name: FailSyncJob :exec
 UPDATE sync_jobs
 SET status = $1,
@@ -241,6 +264,50 @@ func (q *Queries) GetSyncJobMetrics(ctx context.Context, db DBTX, createdAt pgty
 	return &i, err
 }
 
+const getSyncJobsByRunID = `-- name: GetSyncJobsByRunID :many
+SELECT id, job_type, status, started_at, completed_at, total_items, processed_items, succeeded_items, failed_items, error_rate, success_rate, duration_ms, avg_processing_time_ms, metadata, error_message, created_at, updated_at FROM sync_jobs
+WHERE metadata->>'run_id' = $1::text
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetSyncJobsByRunID(ctx context.Context, db DBTX, runID string) ([]*SyncJobs, error) {
+	rows, err := db.Query(ctx, getSyncJobsByRunID, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []*SyncJobs
+	for rows.Next() {
+		var i SyncJobs
+		if err := rows.Scan(
+			&i.ID,
+			&i.JobType,
+			&i.Status,
+			&i.StartedAt,
+			&i.CompletedAt,
+			&i.TotalItems,
+			&i.ProcessedItems,
+			&i.SucceededItems,
+			&i.FailedItems,
+			&i.ErrorRate,
+			&i.SuccessRate,
+			&i.DurationMs,
+			&i.AvgProcessingTimeMs,
+			&i.Metadata,
+			&i.ErrorMessage,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getSyncJobsByStatus = `-- name: GetSyncJobsByStatus :many
 SELECT id, job_type, status, started_at, completed_at, total_items, processed_items, succeeded_items, failed_items, error_rate, success_rate, duration_ms, avg_processing_time_ms, metadata, error_message, created_at, updated_at FROM sync_jobs
 WHERE status = $1
@@ -0,0 +1,54 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AdvisoryLock is a session-level Postgres advisory lock. It is held on a connection
+// of its own, which goes back to the pool on Release.
+type AdvisoryLock struct {
+	conn *pgxpool.Conn
+	key  int64
+}
+
+// AdvisoryLockKey derives the advisory lock key of a lock name
+func AdvisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// TryAdvisoryLock takes the advisory lock for key without waiting. It returns a nil
+// lock and no error when another session holds it.
+func (db *DB) TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+	conn, err := db.Pool().Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire connection for advisory lock: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Release()
+		return nil, nil
+	}
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Release unlocks the lock. Should the unlock fail, the connection is closed instead,
+// which releases the lock with the session.
+func (l *AdvisoryLock) Release(ctx context.Context) error {
+	defer l.conn.Release()
+	if _, err := l.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", l.key); err != nil {
+		l.conn.Conn().Close(ctx)
+		return fmt.Errorf("failed to release advisory lock: %w", err)
+	}
+	return nil
+}
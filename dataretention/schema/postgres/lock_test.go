@@ -0,0 +1,64 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+	"go.uber.org/zap"
+)
+
+func TestAdvisoryLockKey(t *testing.T) {
+	if postgres.AdvisoryLockKey("a") != postgres.AdvisoryLockKey("a") {
+		t.Error("the same name gave different keys")
+	}
+	if postgres.AdvisoryLockKey("a") == postgres.AdvisoryLockKey("b") {
+		t.Error("different names gave the same key")
+	}
+}
+
+func TestTryAdvisoryLockAcrossConnections(t *testing.T) {
+	first := postgrestest.New(t)
+	// A second pool on the same database stands in for another process
+	second, err := postgres.New(postgrestest.Config(t), zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to open second pool: %v", err)
+	}
+	defer second.Close()
+
+	ctx := context.Background()
+	key := postgres.AdvisoryLockKey("lock-test")
+
+	lock, err := first.TryAdvisoryLock(ctx, key)
+	if err != nil || lock == nil {
+		t.Fatalf("first TryAdvisoryLock = %v, %v; want a lock", lock, err)
+	}
+
+	held, err := second.TryAdvisoryLock(ctx, key)
+	if err != nil {
+		t.Fatalf("second TryAdvisoryLock: %v", err)
+	}
+	if held != nil {
+		t.Fatal("second session took a lock the first one holds")
+	}
+
+	other, err := second.TryAdvisoryLock(ctx, postgres.AdvisoryLockKey("other-lock"))
+	if err != nil || other == nil {
+		t.Fatalf("TryAdvisoryLock on another key = %v, %v; want a lock", other, err)
+	}
+	if err := other.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	again, err := second.TryAdvisoryLock(ctx, key)
+	if err != nil || again == nil {
+		t.Fatalf("TryAdvisoryLock after release = %v, %v; want a lock", again, err)
+	}
+	if err := again.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+}
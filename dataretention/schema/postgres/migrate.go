@@ -0,0 +1,143 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// migrationsFS holds the SQL migrations, so they ship inside the binary instead of
+// being read from a path at runtime
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationLockID is the advisory lock key that serializes concurrent Migrate calls
+const migrationLockID = 7365617201
+
+// Migration is one numbered SQL migration file
+type Migration struct {
+	Version int64
+	Name    string
+	SQL     string
+}
+
+// Migrations returns the embedded migrations ordered by version. Files are named
+// NNN_description.sql (or with a timestamp prefix, as make migrate-create does); the
+// leading number is the version.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationsFS, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	var migrations []Migration
+	seen := make(map[int64]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s has no NNN_ version prefix", name)
+		}
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version prefix: %w", name, err)
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, name, version)
+		}
+		seen[version] = name
+
+		sql, err := fs.ReadFile(migrationsFS, path.Join("migrations", name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(sql)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrate applies the embedded migrations that haven't been applied yet, in version
+// order, each in its own transaction. Applied versions are recorded in the
+// schema_migrations table, so re-running Migrate is a no-op once the schema is up
+// to date, and concurrent runs apply each migration only once.
+func (db *DB) Migrate(ctx context.Context) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Pool().Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied := 0
+	for _, migration := range migrations {
+		ran, err := db.applyMigration(ctx, migration)
+		if err != nil {
+			return err
+		}
+		if ran {
+			applied++
+		}
+	}
+
+	db.logger.Info("Database migrations up to date",
+		zap.Int("applied", applied),
+		zap.Int("total", len(migrations)))
+	return nil
+}
+
+// applyMigration runs a single migration in a transaction unless it is already
+// recorded, and reports whether it ran
+func (db *DB) applyMigration(ctx context.Context, migration Migration) (bool, error) {
+	tx, err := db.Pool().Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction for migration %s: %w", migration.Name, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Held until commit, so a concurrent Migrate waits and then sees the version as applied
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockID); err != nil {
+		return false, fmt.Errorf("failed to lock migrations: %w", err)
+	}
+
+	var exists bool
+	err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", migration.Version).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check migration %s: %w", migration.Name, err)
+	}
+	if exists {
+		return false, nil
+	}
+
+	db.logger.Info("Applying migration", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+
+	// The simple protocol lets a migration file hold several statements
+	if _, err := tx.Exec(ctx, migration.SQL, pgx.QueryExecModeSimpleProtocol); err != nil {
+		return false, fmt.Errorf("failed to apply migration %s: %w", migration.Name, err)
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name); err != nil {
+		return false, fmt.Errorf("failed to record migration %s: %w", migration.Name, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit migration %s: %w", migration.Name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,81 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+)
+
+func TestMigrationsOrdered(t *testing.T) {
+	migrations, err := postgres.Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Migrations() found no embedded migrations")
+	}
+	for i, migration := range migrations {
+		if migration.SQL == "" {
+			t.Errorf("migration %s is empty", migration.Name)
+		}
+		if i > 0 && migration.Version <= migrations[i-1].Version {
+			t.Errorf("migration %s follows %s, want ascending versions", migration.Name, migrations[i-1].Name)
+		}
+	}
+}
+
+func TestMigrateTwice(t *testing.T) {
+	db := postgrestest.NewEmpty(t)
+	ctx := context.Background()
+	migrations, err := postgres.Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+
+	appliedAt := func() map[int64]time.Time {
+		t.Helper()
+		rows, err := db.Pool().Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+		if err != nil {
+			t.Fatalf("failed to read schema_migrations: %v", err)
+		}
+		defer rows.Close()
+		applied := make(map[int64]time.Time)
+		for rows.Next() {
+			var version int64
+			var at time.Time
+			if err := rows.Scan(&version, &at); err != nil {
+				t.Fatalf("failed to read schema_migrations: %v", err)
+			}
+			applied[version] = at
+		}
+		return applied
+	}
+
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("first Migrate() error = %v", err)
+	}
+	first := appliedAt()
+	if len(first) != len(migrations) {
+		t.Fatalf("recorded %d migrations, want %d", len(first), len(migrations))
+	}
+
+	// Non-idempotent statements such as ADD CONSTRAINT would fail if re-applied
+	if err := db.Migrate(ctx); err != nil {
+		t.Fatalf("second Migrate() error = %v", err)
+	}
+	second := appliedAt()
+	if len(second) != len(first) {
+		t.Errorf("recorded %d migrations after re-running, want %d", len(second), len(first))
+	}
+	for version, at := range first {
+		if !second[version].Equal(at) {
+			t.Errorf("migration %d was re-applied at %s", version, second[version])
+		}
+	}
+	if err := db.CheckSchema(ctx); err != nil {
+		t.Errorf("CheckSchema() error = %v after migrating", err)
+	}
+}
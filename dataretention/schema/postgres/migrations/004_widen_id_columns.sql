@@ -0,0 +1,13 @@
+-- Migration: 004_widen_id_columns.sql
+-- Description: Widen user/object ID and row count columns to BIGINT so large Marketing Cloud IDs don't overflow
+-- Created: 2025-01-XX
+
+ALTER TABLE data_extensions
+ALTER COLUMN owner_id TYPE BIGINT,
+ALTER COLUMN created_by_id TYPE BIGINT,
+ALTER COLUMN modified_by_id TYPE BIGINT,
+ALTER COLUMN partner_api_object_type_id TYPE BIGINT,
+ALTER COLUMN row_count TYPE BIGINT;
+
+ALTER TABLE folders
+ALTER COLUMN created_by TYPE BIGINT;
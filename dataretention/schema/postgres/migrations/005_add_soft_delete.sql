@@ -0,0 +1,12 @@
+-- Migration: 005_add_soft_delete.sql
+-- Description: Track folders and data extensions that no longer exist in Marketing Cloud instead of keeping them as live rows
+-- Created: 2025-01-XX
+
+ALTER TABLE folders
+ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE data_extensions
+ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_folders_deleted_at ON folders(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_data_extensions_deleted_at ON data_extensions(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Migration: 006_add_category_full_path.sql
+-- Description: Store the human-readable folder path of each data extension for reporting
+-- Created: 2025-01-XX
+
+ALTER TABLE data_extensions
+ADD COLUMN IF NOT EXISTS category_full_path TEXT;
@@ -0,0 +1,9 @@
+-- Migration: 007_add_skipped_retention_status.sql
+-- Description: Allow recording retention updates that were skipped because the settings already matched
+-- Created: 2025-01-XX
+
+ALTER TABLE data_retention_properties
+DROP CONSTRAINT IF EXISTS chk_api_update_status;
+
+ALTER TABLE data_retention_properties
+ADD CONSTRAINT chk_api_update_status CHECK (last_api_update_status IN ('pending', 'succeeded', 'failed', 'skipped'));
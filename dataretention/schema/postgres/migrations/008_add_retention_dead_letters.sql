@@ -0,0 +1,16 @@
+-- Migration: 008_add_retention_dead_letters.sql
+-- Description: Record data extensions whose retention update failed permanently so they can be handled manually
+-- Created: 2025-01-XX
+
+CREATE TABLE IF NOT EXISTS retention_dead_letters (
+    data_extension_id VARCHAR(255) PRIMARY KEY,
+    status_code INTEGER,
+    last_error TEXT NOT NULL,
+    attempt_count INTEGER NOT NULL DEFAULT 1,
+    first_failed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_failed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_dead_letter_data_extension FOREIGN KEY (data_extension_id) REFERENCES data_extensions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_retention_dead_letters_last_failed_at
+ON retention_dead_letters(last_failed_at DESC);
@@ -0,0 +1,18 @@
+-- Migration: 009_add_data_extension_fields.sql
+-- Description: Store the field definitions of data extensions for auditing
+-- Created: 2025-01-XX
+
+CREATE TABLE IF NOT EXISTS data_extension_fields (
+    data_extension_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    field_type VARCHAR(50) NOT NULL,
+    length INTEGER,
+    scale INTEGER,
+    ordinal INTEGER NOT NULL DEFAULT 0,
+    is_primary_key BOOLEAN NOT NULL DEFAULT false,
+    is_nullable BOOLEAN NOT NULL DEFAULT true,
+    default_value TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (data_extension_id, name),
+    CONSTRAINT fk_field_data_extension FOREIGN KEY (data_extension_id) REFERENCES data_extensions(id) ON DELETE CASCADE
+);
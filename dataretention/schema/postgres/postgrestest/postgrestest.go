@@ -0,0 +1,72 @@
+// Package postgrestest provides a freshly migrated database to tests that need
+// Postgres. They are skipped unless TEST_DB_NAME names a database the tests may
+// wipe; the other connection settings come from the DB_* variables, as for the sync.
+package postgrestest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"go.uber.org/zap"
+)
+
+// lockKey is the advisory lock a test holds on the database while it runs, so tests
+// of different packages don't reset the schema under each other
+var lockKey = postgres.AdvisoryLockKey("postgrestest")
+
+// Config returns the connection settings of the test database, skipping the test
+// when TEST_DB_NAME isn't set
+func Config(t testing.TB) *postgres.Config {
+	t.Helper()
+	name := os.Getenv("TEST_DB_NAME")
+	if name == "" {
+		t.Skip("TEST_DB_NAME not set, skipping database test")
+	}
+	cfg := postgres.NewConfig()
+	cfg.Database = name
+	cfg.MinConns = 0
+	return cfg
+}
+
+// New returns a connection to the test database with every migration applied to an
+// empty schema. The database is the test's alone until it ends.
+func New(t testing.TB) *postgres.DB {
+	t.Helper()
+	db := NewEmpty(t)
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+// NewEmpty is New without the migrations, for tests of the migrations themselves
+func NewEmpty(t testing.TB) *postgres.DB {
+	t.Helper()
+	cfg := Config(t)
+	ctx := context.Background()
+
+	// The lock lives on a connection of its own, outside the pool the test uses
+	lockConn, err := pgx.Connect(ctx, cfg.DSN())
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { lockConn.Close(context.Background()) })
+	if _, err := lockConn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		t.Fatalf("failed to lock test database: %v", err)
+	}
+	for _, sql := range []string{"DROP SCHEMA IF EXISTS public CASCADE", "CREATE SCHEMA public"} {
+		if _, err := lockConn.Exec(ctx, sql); err != nil {
+			t.Fatalf("failed to reset test database: %v", err)
+		}
+	}
+
+	db, err := postgres.New(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
@@ -0,0 +1,15 @@
+-- name: CreateDataExtensionField :exec
+INSERT INTO data_extension_fields (
+    data_extension_id, name, field_type, length, scale, ordinal, is_primary_key, is_nullable, default_value
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+);
+
+-- name: DeleteDataExtensionFields :exec
+DELETE FROM data_extension_fields
+WHERE data_extension_id = $1;
+
+-- name: ListDataExtensionFields :many
+SELECT * FROM data_extension_fields
+WHERE data_extension_id = $1
+ORDER BY ordinal;
@@ -18,6 +18,10 @@ WHERE id = $1;
 SELECT * FROM data_extensions
 WHERE key = $1;
 
+-- name: GetDataExtensionModifiedDate :one
+SELECT modified_date FROM data_extensions
+WHERE id = $1;
+
 -- name: GetDataExtensionsByCategoryID :many
 SELECT * FROM data_extensions
 WHERE category_id = $1
@@ -29,6 +33,32 @@ WHERE category_id = $1
 ORDER BY modified_date DESC
 LIMIT $2 OFFSET $3;
 
+-- name: ListDataExtensionsAboveRowCount :many
+SELECT * FROM data_extensions
+WHERE row_count > $1 AND deleted_at IS NULL
+ORDER BY row_count DESC, id;
+
+-- name: UpsertDataExtension :one
+INSERT INTO data_extensions (
+    id, name, key, description, is_active, is_sendable, sendable_custom_object_field,
+    sendable_subscriber_field, is_testable, category_id, owner_id, is_object_deletable,
+    is_field_addition_allowed, is_field_modification_allowed, created_date, created_by_id,
+    created_by_name, modified_date, modified_by_id, modified_by_name, owner_name,
+    partner_api_object_type_id, partner_api_object_type_name, row_count, field_count
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25
+)
+ON CONFLICT (id) DO UPDATE
+SET name = EXCLUDED.name,
+    description = EXCLUDED.description,
+    is_active = EXCLUDED.is_active,
+    modified_date = EXCLUDED.modified_date,
+    modified_by_id = EXCLUDED.modified_by_id,
+    modified_by_name = EXCLUDED.modified_by_name,
+    row_count = EXCLUDED.row_count,
+    field_count = EXCLUDED.field_count
+RETURNING *;
+
 -- name: UpdateDataExtension :one
 UPDATE data_extensions
 SET name = $2, description = $3, is_active = $4, modified_date = $5, modified_by_id = $6, modified_by_name = $7, row_count = $8, field_count = $9
@@ -39,3 +69,20 @@ RETURNING *;
 DELETE FROM data_extensions
 WHERE id = $1;
 
+
+-- name: SoftDeleteDataExtensionsNotSeen :execrows
+UPDATE data_extensions
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE deleted_at IS NULL
+  AND NOT (id = ANY(sqlc.arg(seen_ids)::text[]));
+
+-- name: RestoreDataExtensionsSeen :execrows
+UPDATE data_extensions
+SET deleted_at = NULL
+WHERE deleted_at IS NOT NULL
+  AND id = ANY(sqlc.arg(seen_ids)::text[]);
+
+-- name: SetDataExtensionCategoryFullPath :exec
+UPDATE data_extensions
+SET category_full_path = $2
+WHERE id = $1;
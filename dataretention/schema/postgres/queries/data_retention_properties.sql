@@ -30,20 +30,20 @@ SET last_api_update_at = CURRENT_TIMESTAMP,
     last_api_update_error = sqlc.arg('last_api_update_error'),
     api_update_retry_count = CASE 
         WHEN sqlc.arg('last_api_update_status')::VARCHAR = 'failed' THEN api_update_retry_count + 1
-        WHEN sqlc.arg('last_api_update_status')::VARCHAR = 'succeeded' THEN 0
+        WHEN sqlc.arg('last_api_update_status')::VARCHAR IN ('succeeded', 'skipped') THEN 0
         ELSE api_update_retry_count
     END,
-    -- Update retention properties when status is 'succeeded'
+    -- Update retention properties when status is 'succeeded' or 'skipped'
     data_retention_period_length = CASE 
-        WHEN sqlc.arg('last_api_update_status')::VARCHAR = 'succeeded' THEN sqlc.arg('data_retention_period_length')
+        WHEN sqlc.arg('last_api_update_status')::VARCHAR IN ('succeeded', 'skipped') THEN sqlc.arg('data_retention_period_length')
         ELSE data_retention_period_length
     END,
     data_retention_period_unit_of_measure = CASE 
-        WHEN sqlc.arg('last_api_update_status')::VARCHAR = 'succeeded' THEN sqlc.arg('data_retention_period_unit_of_measure')
+        WHEN sqlc.arg('last_api_update_status')::VARCHAR IN ('succeeded', 'skipped') THEN sqlc.arg('data_retention_period_unit_of_measure')
         ELSE data_retention_period_unit_of_measure
     END,
     is_row_based_retention = CASE 
-        WHEN sqlc.arg('last_api_update_status')::VARCHAR = 'succeeded' THEN sqlc.arg('is_row_based_retention')
+        WHEN sqlc.arg('last_api_update_status')::VARCHAR IN ('succeeded', 'skipped') THEN sqlc.arg('is_row_based_retention')
         ELSE is_row_based_retention
     END,
     updated_at = CURRENT_TIMESTAMP
@@ -59,6 +59,18 @@ WHERE drp.last_api_update_status IN ('pending', 'failed')
 ORDER BY drp.last_api_update_at ASC NULLS FIRST, drp.api_update_retry_count ASC
 LIMIT $1;
 
+-- name: GetRetentionStatusReport :many
+SELECT de.category_id AS folder_id,
+       f.name AS folder_name,
+       COALESCE(drp.last_api_update_status, 'pending')::VARCHAR AS status,
+       COUNT(*) AS data_extension_count
+FROM data_retention_properties drp
+INNER JOIN data_extensions de ON drp.data_extension_id = de.id
+LEFT JOIN folders f ON de.category_id = f.id
+WHERE de.deleted_at IS NULL
+GROUP BY de.category_id, f.name, status
+ORDER BY f.name ASC NULLS LAST, de.category_id ASC, status ASC;
+
 -- name: ResetDataRetentionAPIUpdateStatus :one
 UPDATE data_retention_properties
 SET last_api_update_status = 'pending',
@@ -7,6 +7,10 @@ RETURNING *;
 SELECT * FROM folders
 WHERE id = $1;
 
+-- name: GetFolderLastUpdated :one
+SELECT last_updated FROM folders
+WHERE id = $1;
+
 -- name: GetFoldersByParentID :many
 SELECT * FROM folders
 WHERE parent_id = $1
@@ -31,3 +35,15 @@ WHERE id = $1;
 SELECT * FROM folders
 ORDER BY name ASC;
 
+
+-- name: SoftDeleteFoldersNotSeen :execrows
+UPDATE folders
+SET deleted_at = CURRENT_TIMESTAMP
+WHERE deleted_at IS NULL
+  AND NOT (id = ANY(sqlc.arg(seen_ids)::text[]));
+
+-- name: RestoreFoldersSeen :execrows
+UPDATE folders
+SET deleted_at = NULL
+WHERE deleted_at IS NOT NULL
+  AND id = ANY(sqlc.arg(seen_ids)::text[]);
@@ -0,0 +1,20 @@
+-- name: UpsertRetentionDeadLetter :one
+INSERT INTO retention_dead_letters (
+    data_extension_id, status_code, last_error
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (data_extension_id) DO UPDATE
+SET status_code = EXCLUDED.status_code,
+    last_error = EXCLUDED.last_error,
+    attempt_count = retention_dead_letters.attempt_count + 1,
+    last_failed_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: DeleteRetentionDeadLetter :exec
+DELETE FROM retention_dead_letters
+WHERE data_extension_id = $1;
+
+-- name: ListRetentionDeadLetters :many
+SELECT * FROM retention_dead_letters
+ORDER BY last_failed_at DESC;
@@ -45,6 +45,15 @@ SET status = $1,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $3;
 
+-- name: FailStaleSyncJobs :execrows
+UPDATE sync_jobs
+SET status = 'failed',
+    completed_at = CURRENT_TIMESTAMP,
+    error_message = $1,
+    updated_at = CURRENT_TIMESTAMP
+WHERE status = 'running'
+  AND started_at < $2;
+
 -- name: CancelSyncJob :exec
 UPDATE sync_jobs
 SET status = $1,
@@ -64,6 +73,11 @@ WHERE job_type = $1
 ORDER BY created_at DESC
 LIMIT $2;
 
+-- name: GetSyncJobsByRunID :many
+SELECT * FROM sync_jobs
+WHERE metadata->>'run_id' = sqlc.arg(run_id)::text
+ORDER BY created_at ASC;
+
 -- name: GetRecentSyncJobs :many
 SELECT * FROM sync_jobs
 WHERE created_at >= $1
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/puddle/v2"
+	"go.uber.org/zap"
+)
+
+const (
+	// reconnectMaxTries bounds the attempts WithRetry makes, the first one included
+	reconnectMaxTries = 5
+	// reconnectMaxElapsed bounds the time WithRetry spends waiting for the database
+	reconnectMaxElapsed = time.Minute
+)
+
+// IsConnectionError reports whether err means the connection to the database was lost
+// or couldn't be established, as opposed to the query itself failing
+func IsConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// Class 08 is connection exceptions; 57P01-57P03 are the server shutting
+		// down or not accepting connections yet
+		return strings.HasPrefix(pgErr.Code, "08") ||
+			pgErr.Code == "57P01" || pgErr.Code == "57P02" || pgErr.Code == "57P03"
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		errors.Is(err, puddle.ErrClosedPool) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		pgconn.SafeToRetry(err)
+}
+
+// IsUndefinedTable reports whether err is Postgres rejecting a query because a table
+// it uses doesn't exist, e.g. on a schema that predates a migration
+func IsUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42P01"
+}
+
+// WithRetry runs fn, and when it fails with a connection error (see IsConnectionError)
+// re-establishes the pool if the database can't be reached through it any more, then
+// runs fn again with backoff, up to 5 attempts within a minute. Any other error is
+// returned at once. fn must get the pool from Pool() on each run and be safe to
+// repeat, e.g. a single statement or a whole transaction.
+func (db *DB) WithRetry(ctx context.Context, fn func(ctx context.Context) error) error {
+	attempt := 0
+	_, err := backoff.Retry(ctx, func() (struct{}, error) {
+		attempt++
+		err := fn(ctx)
+		if err == nil {
+			return struct{}{}, nil
+		}
+		if !IsConnectionError(err) {
+			return struct{}{}, backoff.Permanent(err)
+		}
+
+		db.logger.Warn("Lost database connection, reconnecting",
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+		if reconnectErr := db.reconnect(ctx); reconnectErr != nil {
+			db.logger.Warn("Failed to re-establish database pool", zap.Error(reconnectErr))
+		}
+		return struct{}{}, err
+	},
+		backoff.WithBackOff(backoff.NewExponentialBackOff()),
+		backoff.WithMaxTries(reconnectMaxTries),
+		backoff.WithMaxElapsedTime(reconnectMaxElapsed))
+	return err
+}
+
+// reconnect replaces the pool with a new one unless the current pool can reach the
+// database again. The old pool is closed once its acquired connections are released.
+func (db *DB) reconnect(ctx context.Context) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// A pool drops broken connections by itself, so it may already be healthy
+	if db.pool.Ping(pingCtx) == nil {
+		return nil
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, db.poolConfig.Copy())
+	if err != nil {
+		return fmt.Errorf("failed to create connection pool: %w", err)
+	}
+	if err := pool.Ping(pingCtx); err != nil {
+		pool.Close()
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	old := db.pool
+	db.pool = pool
+	go old.Close()
+	db.logger.Info("Database connection pool re-established")
+	return nil
+}
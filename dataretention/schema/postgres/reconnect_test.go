@@ -0,0 +1,114 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, true},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, true},
+		{"cannot connect now", fmt.Errorf("query: %w", &pgconn.PgError{Code: "57P03"}), true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"undefined table", &pgconn.PgError{Code: "42P01"}, false},
+		{"network error", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, true},
+		{"unexpected EOF", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{"canceled", context.Canceled, false},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+		{"no rows", pgx.ErrNoRows, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := postgres.IsConnectionError(tt.err); got != tt.want {
+				t.Errorf("IsConnectionError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	db := postgrestest.New(t)
+	ctx := context.Background()
+
+	t.Run("retries connection errors", func(t *testing.T) {
+		attempts := 0
+		err := db.WithRetry(ctx, func(ctx context.Context) error {
+			attempts++
+			if attempts < 3 {
+				return &pgconn.PgError{Code: "08006"}
+			}
+			_, err := db.Pool().Exec(ctx, "SELECT 1")
+			return err
+		})
+		if err != nil || attempts != 3 {
+			t.Errorf("WithRetry() = %v after %d attempts, want success on the third", err, attempts)
+		}
+	})
+
+	t.Run("returns other errors at once", func(t *testing.T) {
+		attempts := 0
+		err := db.WithRetry(ctx, func(ctx context.Context) error {
+			attempts++
+			_, err := db.Pool().Exec(ctx, "SELECT * FROM no_such_table")
+			return err
+		})
+		if !postgres.IsUndefinedTable(err) || attempts != 1 {
+			t.Errorf("WithRetry() = %v after %d attempts, want the undefined table after one", err, attempts)
+		}
+	})
+
+	t.Run("gives up after the attempt limit", func(t *testing.T) {
+		attempts := 0
+		err := db.WithRetry(ctx, func(ctx context.Context) error {
+			attempts++
+			return &pgconn.PgError{Code: "08006"}
+		})
+		if !postgres.IsConnectionError(err) || attempts != 5 {
+			t.Errorf("WithRetry() = %v after %d attempts, want the connection error after 5", err, attempts)
+		}
+	})
+}
+
+func TestWithRetryRecoversTerminatedConnection(t *testing.T) {
+	db := postgrestest.New(t)
+	ctx := context.Background()
+
+	var pid uint32
+	if err := db.Pool().QueryRow(ctx, "SELECT pg_backend_pid()").Scan(&pid); err != nil {
+		t.Fatalf("failed to get backend pid: %v", err)
+	}
+
+	// Kill the pool's connection from outside, as a database restart would
+	admin, err := pgx.Connect(ctx, postgrestest.Config(t).DSN())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer admin.Close(ctx)
+	if _, err := admin.Exec(ctx, "SELECT pg_terminate_backend($1)", pid); err != nil {
+		t.Fatalf("failed to terminate backend: %v", err)
+	}
+
+	var one int
+	err = db.WithRetry(ctx, func(ctx context.Context) error {
+		return db.Pool().QueryRow(ctx, "SELECT 1").Scan(&one)
+	})
+	if err != nil || one != 1 {
+		t.Errorf("WithRetry() = %v, want the query to succeed on a fresh connection", err)
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/sourcegraph/conc/pool"
+	"go.uber.org/zap"
+)
+
+// AccountSyncer builds the sync service used for a single account
+type AccountSyncer func(cfg *sfmce.Config) *SyncService
+
+// AccountResult is the outcome of syncing a single account
+type AccountResult struct {
+	AccountID string
+	Metrics   *SyncMetrics
+	Duration  time.Duration
+	Err       error
+	// Skipped is set when the account had already completed in a resumed run
+	Skipped bool
+}
+
+// AccountsReport summarizes a multi-account sync, per account and overall
+type AccountsReport struct {
+	Accounts []AccountResult
+	Duration time.Duration
+}
+
+// Totals returns the metrics of all accounts added together
+func (r *AccountsReport) Totals() *SyncMetrics {
+	totals := &SyncMetrics{}
+	for _, account := range r.Accounts {
+		if account.Metrics != nil {
+			totals.Merge(account.Metrics)
+		}
+	}
+	return totals
+}
+
+// SkippedAccounts returns the number of accounts skipped because they had already completed
+func (r *AccountsReport) SkippedAccounts() int {
+	skipped := 0
+	for _, account := range r.Accounts {
+		if account.Skipped {
+			skipped++
+		}
+	}
+	return skipped
+}
+
+// FailedAccounts returns the number of accounts whose sync returned an error
+func (r *AccountsReport) FailedAccounts() int {
+	failed := 0
+	for _, account := range r.Accounts {
+		if account.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// SyncAccounts runs a full sync for each account, at most concurrency at a time.
+// Every account gets its own run ID; a failing account doesn't stop the others.
+// Results are reported in the same order as accounts.
+func SyncAccounts(ctx context.Context, accounts []*sfmce.Config, concurrency int, newSyncer AccountSyncer, logger *zap.Logger) *AccountsReport {
+	return SyncAccountsWithCheckpoint(ctx, accounts, concurrency, nil, newSyncer, logger)
+}
+
+// SyncAccountsWithCheckpoint works like SyncAccounts, but skips the accounts the
+// checkpoint lists as completed and records every account that completes without
+// error, a failed folder or data extension, or an incomplete crawl. A nil checkpoint
+// syncs every account.
+func SyncAccountsWithCheckpoint(ctx context.Context, accounts []*sfmce.Config, concurrency int, checkpoint *AccountCheckpoint, newSyncer AccountSyncer, logger *zap.Logger) *AccountsReport {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	startTime := time.Now()
+	report := &AccountsReport{Accounts: make([]AccountResult, len(accounts))}
+
+	logger.Info("Starting multi-account sync",
+		zap.Int("accounts", len(accounts)),
+		zap.Int("concurrency", concurrency))
+
+	accountPool := pool.New().WithMaxGoroutines(concurrency)
+	for idx, account := range accounts {
+		i := idx           // capture index
+		account := account // capture loop variable
+		accountPool.Go(func() {
+			if checkpoint != nil {
+				if entry, ok := checkpoint.Completed(account.AccountID); ok {
+					logger.Info("Account already completed, skipping",
+						zap.String("account_id", account.AccountID),
+						zap.String("run_id", entry.RunID.String()))
+					report.Accounts[i] = AccountResult{AccountID: account.AccountID, Skipped: true}
+					return
+				}
+			}
+
+			accountStart := time.Now()
+			metrics, err := newSyncer(account).SyncAll(ctx)
+			report.Accounts[i] = AccountResult{
+				AccountID: account.AccountID,
+				Metrics:   metrics,
+				Duration:  time.Since(accountStart),
+				Err:       err,
+			}
+			if err != nil {
+				logger.Error("Account sync failed",
+					zap.String("account_id", account.AccountID),
+					zap.Error(err))
+				return
+			}
+			if checkpoint == nil {
+				return
+			}
+			// An account with an incomplete crawl or failures is synced again on resume
+			if !metrics.CrawlComplete() || metrics.TotalFailed() > 0 {
+				logger.Warn("Account sync finished with failures, not checkpointing it",
+					zap.String("account_id", account.AccountID),
+					zap.Bool("crawl_complete", metrics.CrawlComplete()),
+					zap.Int("failed", metrics.TotalFailed()))
+				return
+			}
+			if err := checkpoint.MarkCompleted(account.AccountID, metrics.RunID); err != nil {
+				logger.Warn("Failed to checkpoint completed account",
+					zap.String("account_id", account.AccountID),
+					zap.Error(err))
+			}
+		})
+	}
+	accountPool.Wait()
+
+	report.Duration = time.Since(startTime)
+	totals := report.Totals()
+	logger.Info("Completed multi-account sync",
+		zap.Duration("duration", report.Duration),
+		zap.Int("accounts", len(accounts)),
+		zap.Int("accounts_failed", report.FailedAccounts()),
+		zap.Int("accounts_skipped", report.SkippedAccounts()),
+		zap.Int("total_succeeded", totals.TotalSucceeded()),
+		zap.Int("total_failed", totals.TotalFailed()))
+
+	return report
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// countingLimiter is a rate limiter that counts the calls waiting on it
+type countingLimiter struct {
+	*rate.Limiter
+	waits atomic.Int32
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.waits.Add(1)
+	return l.Limiter.Wait(ctx)
+}
+
+// limitedClient is a fake client whose listing calls wait on a rate limiter, the
+// way the HTTP client of a real one does
+type limitedClient struct {
+	*fake.Client
+	limiter *countingLimiter
+	calls   atomic.Int32
+}
+
+func (c *limitedClient) wait(ctx context.Context) error {
+	c.calls.Add(1)
+	return c.limiter.Wait(ctx)
+}
+
+func (c *limitedClient) GetSubFoldersCtx(ctx context.Context, folderID string) (*sfmce.FoldersResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.Client.GetSubFoldersCtx(ctx, folderID)
+}
+
+func (c *limitedClient) GetDataExtensionsCtx(ctx context.Context, folderID string, page, pageSize int) (*sfmce.DataExtensionsResponse, error) {
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+	return c.Client.GetDataExtensionsCtx(ctx, folderID, page, pageSize)
+}
+
+// accountClient returns a fake account with a folder holding two data extensions
+func accountClient(folderID string) *fake.Client {
+	client := fake.NewClient()
+	client.AddFolder(testFolder(folderID, ""))
+	client.AddDataExtension(testDataExtension("de-"+folderID+"-a", folderID), testDataExtension("de-"+folderID+"-b", folderID))
+	return client
+}
+
+func TestSyncAccountsSharesLimiter(t *testing.T) {
+	db := postgrestest.New(t)
+	limiter := &countingLimiter{Limiter: rate.NewLimiter(rate.Inf, 1)}
+	clients := map[string]*limitedClient{
+		"100": {Client: accountClient("1"), limiter: limiter},
+		"200": {Client: accountClient("2"), limiter: limiter},
+	}
+
+	newSyncer := func(cfg *sfmce.Config) *SyncService {
+		logger := zap.NewNop()
+		svc := NewSyncServiceWithConfig(clients[cfg.AccountID], NewDataExtensionService(db, logger), NewFolderService(db, logger), db, DefaultSyncConfig(), logger)
+		svc.SetLockName(SyncLockName(cfg.AccountID))
+		return svc
+	}
+
+	accounts := []*sfmce.Config{{AccountID: "100"}, {AccountID: "200"}}
+	report := SyncAccounts(context.Background(), accounts, 2, newSyncer, zap.NewNop())
+
+	if len(report.Accounts) != 2 || report.FailedAccounts() != 0 || report.SkippedAccounts() != 0 {
+		t.Fatalf("report = %+v, want two successful accounts", report.Accounts)
+	}
+	for i, account := range report.Accounts {
+		if account.AccountID != accounts[i].AccountID {
+			t.Errorf("account %d is %s, want the input order", i, account.AccountID)
+		}
+		if account.Metrics == nil || account.Metrics.DataExtensionsSucceeded != 2 {
+			t.Errorf("account %s metrics = %+v, want 2 data extensions", account.AccountID, account.Metrics)
+		}
+		if clients[account.AccountID].calls.Load() == 0 {
+			t.Errorf("account %s made no calls through the shared limiter", account.AccountID)
+		}
+	}
+	if got, want := limiter.waits.Load(), clients["100"].calls.Load()+clients["200"].calls.Load(); got != want {
+		t.Errorf("shared limiter saw %d calls, want both accounts' %d", got, want)
+	}
+	if totals := report.Totals(); totals.DataExtensionsSucceeded != 4 || totals.TotalFailed() != 0 {
+		t.Errorf("totals = %d succeeded, %d failed, want 4 and 0", totals.DataExtensionsSucceeded, totals.TotalFailed())
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM data_extensions"); n != 4 {
+		t.Errorf("stored %d data extensions, want both accounts' 4", n)
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountCheckpoint records which accounts of a multi-account run have fully
+// completed, in a JSON file, so that a restarted run can skip them. Accounts that
+// didn't complete are not recorded and are synced again from scratch.
+type AccountCheckpoint struct {
+	path      string
+	completed map[string]CompletedAccount
+	mu        sync.Mutex
+}
+
+// CompletedAccount is the checkpoint entry of an account whose sync completed
+type CompletedAccount struct {
+	RunID       uuid.UUID `json:"runId"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// checkpointFile is the on-disk layout of an AccountCheckpoint
+type checkpointFile struct {
+	Completed map[string]CompletedAccount `json:"completed"`
+}
+
+// NewAccountCheckpoint returns an empty checkpoint that is stored at path,
+// replacing whatever a previous run recorded there
+func NewAccountCheckpoint(path string) *AccountCheckpoint {
+	return &AccountCheckpoint{
+		path:      path,
+		completed: make(map[string]CompletedAccount),
+	}
+}
+
+// LoadAccountCheckpoint reads the checkpoint stored at path to resume a run. A
+// missing file is an empty checkpoint.
+func LoadAccountCheckpoint(path string) (*AccountCheckpoint, error) {
+	cp := NewAccountCheckpoint(path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cp, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var file checkpointFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	for accountID, entry := range file.Completed {
+		cp.completed[accountID] = entry
+	}
+	return cp, nil
+}
+
+// Completed returns the checkpoint entry of the account, if it completed
+func (c *AccountCheckpoint) Completed(accountID string) (CompletedAccount, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.completed[accountID]
+	return entry, ok
+}
+
+// MarkCompleted records that the account completed in the given run and writes
+// the checkpoint to disk
+func (c *AccountCheckpoint) MarkCompleted(accountID string, runID uuid.UUID) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.completed[accountID] = CompletedAccount{RunID: runID, CompletedAt: time.Now().UTC()}
+	return c.save()
+}
+
+// Save writes the checkpoint to disk
+func (c *AccountCheckpoint) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.save()
+}
+
+// save writes the checkpoint to a temporary file and renames it into place, so a
+// crash mid-write never leaves a truncated checkpoint behind
+func (c *AccountCheckpoint) save() error {
+	data, err := json.MarshalIndent(checkpointFile{Completed: c.completed}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+	"go.uber.org/zap"
+)
+
+func TestAccountCheckpointRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	runID := uuid.New()
+
+	cp := NewAccountCheckpoint(path)
+	if err := cp.MarkCompleted("100", runID); err != nil {
+		t.Fatalf("MarkCompleted() error = %v", err)
+	}
+
+	loaded, err := LoadAccountCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadAccountCheckpoint() error = %v", err)
+	}
+	entry, ok := loaded.Completed("100")
+	if !ok || entry.RunID != runID {
+		t.Errorf("Completed(100) = %+v, %v, want run %s", entry, ok, runID)
+	}
+	if _, ok := loaded.Completed("200"); ok {
+		t.Error("Completed(200) = true for an account that never completed")
+	}
+}
+
+func TestLoadAccountCheckpointMissingFile(t *testing.T) {
+	cp, err := LoadAccountCheckpoint(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("LoadAccountCheckpoint() error = %v, want an empty checkpoint", err)
+	}
+	if _, ok := cp.Completed("100"); ok {
+		t.Error("empty checkpoint lists an account as completed")
+	}
+}
+
+func TestLoadAccountCheckpointCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := os.WriteFile(path, []byte(`{"completed":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadAccountCheckpoint(path); err == nil {
+		t.Error("LoadAccountCheckpoint() error = nil for a truncated file")
+	}
+}
+
+func TestSyncAccountsSkipsCheckpointedAccounts(t *testing.T) {
+	cp := NewAccountCheckpoint(filepath.Join(t.TempDir(), "checkpoint.json"))
+	for _, accountID := range []string{"100", "200"} {
+		if err := cp.MarkCompleted(accountID, uuid.New()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newSyncer := func(cfg *sfmce.Config) *SyncService {
+		t.Errorf("built a syncer for checkpointed account %s", cfg.AccountID)
+		return nil
+	}
+	accounts := []*sfmce.Config{{AccountID: "100"}, {AccountID: "200"}}
+	report := SyncAccountsWithCheckpoint(context.Background(), accounts, 2, cp, newSyncer, zap.NewNop())
+
+	if got := report.SkippedAccounts(); got != 2 {
+		t.Errorf("SkippedAccounts() = %d, want 2", got)
+	}
+}
+
+func TestSyncAccountsResumeSkipsCompleted(t *testing.T) {
+	db := postgrestest.New(t)
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	failing := accountClient("2")
+	failing.FailOn("GetFolders", errors.New("account unavailable"))
+	clients := map[string]*fake.Client{"100": accountClient("1"), "200": failing}
+	synced := make(map[string]int)
+
+	newSyncer := func(cfg *sfmce.Config) *SyncService {
+		synced[cfg.AccountID]++
+		logger := zap.NewNop()
+		svc := NewSyncServiceWithConfig(clients[cfg.AccountID], NewDataExtensionService(db, logger), NewFolderService(db, logger), db, DefaultSyncConfig(), logger)
+		svc.SetLockName(SyncLockName(cfg.AccountID))
+		return svc
+	}
+	accounts := []*sfmce.Config{{AccountID: "100"}, {AccountID: "200"}}
+
+	// Serial runs keep the syncer counts race-free
+	first := SyncAccountsWithCheckpoint(context.Background(), accounts, 1, NewAccountCheckpoint(path), newSyncer, zap.NewNop())
+	if first.FailedAccounts() != 1 || first.Accounts[1].Err == nil {
+		t.Fatalf("first run = %+v, want account 200 to fail", first.Accounts)
+	}
+
+	// The restarted run reads the checkpoint the first one left on disk
+	clients["200"] = accountClient("2")
+	cp, err := LoadAccountCheckpoint(path)
+	if err != nil {
+		t.Fatalf("LoadAccountCheckpoint() error = %v", err)
+	}
+	second := SyncAccountsWithCheckpoint(context.Background(), accounts, 1, cp, newSyncer, zap.NewNop())
+
+	if !second.Accounts[0].Skipped || second.Accounts[1].Skipped {
+		t.Errorf("second run = %+v, want only account 100 skipped", second.Accounts)
+	}
+	if second.FailedAccounts() != 0 {
+		t.Errorf("second run failed %d accounts, want 0", second.FailedAccounts())
+	}
+	if synced["100"] != 1 || synced["200"] != 2 {
+		t.Errorf("synced %v, want account 100 once and account 200 twice", synced)
+	}
+	if _, ok := cp.Completed("200"); !ok {
+		t.Error("account 200 not checkpointed after completing")
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM data_extensions"); n != 4 {
+		t.Errorf("stored %d data extensions, want both accounts' 4", n)
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/joho/godotenv"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+)
+
+// PhaseMode selects how SyncFolders walks the folder tree
+type PhaseMode string
+
+const (
+	// PhaseModeBatched saves all listed folders first and then processes every
+	// folder concurrently
+	PhaseModeBatched PhaseMode = "batched"
+	// PhaseModeInterleaved syncs one top-level folder's subtree at a time (folders,
+	// data extensions and retention), finishing it before starting the next
+	PhaseModeInterleaved PhaseMode = "interleaved"
+)
+
+// SyncConfig holds tunables for SyncService
+type SyncConfig struct {
+	// FolderConcurrency bounds how many folders are saved/processed at once
+	FolderConcurrency int
+	// SubfolderConcurrency bounds how many subfolders of a single folder are processed at once
+	SubfolderConcurrency int
+	// DataExtensionConcurrency bounds how many data extensions of a folder are saved/updated at once
+	DataExtensionConcurrency int
+	// RunBudget is the total time allowed for a sync (zero means unbounded)
+	RunBudget time.Duration
+	// StaleJobAge is how long a sync job may stay running before SyncAll marks it as
+	// failed at startup (zero disables reaping)
+	StaleJobAge time.Duration
+	// AccountConcurrency bounds how many accounts are synced at once in a multi-account sync
+	AccountConcurrency int
+	// DataExtensionPageSize is how many data extensions are requested per page
+	DataExtensionPageSize int
+	// Incremental skips folders and data extensions whose stored timestamps show
+	// they haven't changed since the last sync
+	Incremental bool
+	// SaveOnly stores folders and data extensions without updating their retention
+	// in Marketing Cloud, to refresh the database alone
+	SaveOnly bool
+	// BufferFolderLogs holds each folder's log lines until the folder finishes,
+	// so they are written contiguously instead of interleaved with other folders
+	BufferFolderLogs bool
+	// SoftDeleteMissing marks stored folders and data extensions that a complete
+	// full sync didn't see as deleted. It assumes the database mirrors a single account.
+	SoftDeleteMissing bool
+	// PhaseMode selects batched or interleaved traversal of the folder tree
+	PhaseMode PhaseMode
+	// FolderFilter limits the sync to part of the folder tree
+	FolderFilter FolderFilter
+	// AdaptiveConcurrency bounds the API calls of all worker pools together and
+	// lowers the bound while the API returns server errors, raising it again on success
+	AdaptiveConcurrency bool
+	// AdaptiveMaxInFlight is the most API calls allowed at once, and where the bound starts
+	AdaptiveMaxInFlight int
+	// AdaptiveMinInFlight is the floor the bound never drops below
+	AdaptiveMinInFlight int
+	// AdaptiveDecreaseFactor multiplies the bound on each overload failure (between 0 and 1)
+	AdaptiveDecreaseFactor float64
+}
+
+// DefaultSyncConfig returns the default sync configuration
+func DefaultSyncConfig() *SyncConfig {
+	return &SyncConfig{
+		FolderConcurrency:        10,
+		SubfolderConcurrency:     5,
+		DataExtensionConcurrency: 10,
+		AccountConcurrency:       2,
+		DataExtensionPageSize:    sfmce.DefaultDataExtensionPageSize,
+		StaleJobAge:              6 * time.Hour,
+		PhaseMode:                PhaseModeBatched,
+		AdaptiveMaxInFlight:      20,
+		AdaptiveMinInFlight:      1,
+		AdaptiveDecreaseFactor:   0.5,
+	}
+}
+
+// LoadSyncConfig builds a sync configuration from environment variables,
+// falling back to the defaults for anything that isn't set
+func LoadSyncConfig() (*SyncConfig, error) {
+	// Try to load .env file, but don't fail if it doesn't exist
+	_ = godotenv.Load()
+
+	cfg := DefaultSyncConfig()
+
+	var err error
+	if cfg.FolderConcurrency, err = getEnvInt("SYNC_FOLDER_CONCURRENCY", cfg.FolderConcurrency); err != nil {
+		return nil, err
+	}
+	if cfg.SubfolderConcurrency, err = getEnvInt("SYNC_SUBFOLDER_CONCURRENCY", cfg.SubfolderConcurrency); err != nil {
+		return nil, err
+	}
+	if cfg.DataExtensionConcurrency, err = getEnvInt("SYNC_DATA_EXTENSION_CONCURRENCY", cfg.DataExtensionConcurrency); err != nil {
+		return nil, err
+	}
+	if cfg.AccountConcurrency, err = getEnvInt("SYNC_ACCOUNT_CONCURRENCY", cfg.AccountConcurrency); err != nil {
+		return nil, err
+	}
+	if cfg.DataExtensionPageSize, err = getEnvInt("SYNC_DATA_EXTENSION_PAGE_SIZE", cfg.DataExtensionPageSize); err != nil {
+		return nil, err
+	}
+	if v := os.Getenv("SYNC_RUN_BUDGET"); v != "" {
+		if cfg.RunBudget, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("SYNC_RUN_BUDGET must be a duration: %w", err)
+		}
+	}
+
+	if v := os.Getenv("SYNC_STALE_JOB_AGE"); v != "" {
+		if cfg.StaleJobAge, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("SYNC_STALE_JOB_AGE must be a duration: %w", err)
+		}
+	}
+
+	if v := os.Getenv("SYNC_INCREMENTAL"); v != "" {
+		if cfg.Incremental, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("SYNC_INCREMENTAL must be a boolean: %w", err)
+		}
+	}
+	if v := os.Getenv("SYNC_SAVE_ONLY"); v != "" {
+		if cfg.SaveOnly, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("SYNC_SAVE_ONLY must be a boolean: %w", err)
+		}
+	}
+	if v := os.Getenv("SYNC_BUFFER_FOLDER_LOGS"); v != "" {
+		if cfg.BufferFolderLogs, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("SYNC_BUFFER_FOLDER_LOGS must be a boolean: %w", err)
+		}
+	}
+	if v := os.Getenv("SYNC_SOFT_DELETE_MISSING"); v != "" {
+		if cfg.SoftDeleteMissing, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("SYNC_SOFT_DELETE_MISSING must be a boolean: %w", err)
+		}
+	}
+
+	if cfg.FolderFilter, err = loadFolderFilter(); err != nil {
+		return nil, err
+	}
+
+	if v := os.Getenv("SYNC_PHASE_MODE"); v != "" {
+		cfg.PhaseMode = PhaseMode(v)
+	}
+
+	if v := os.Getenv("SYNC_ADAPTIVE_CONCURRENCY"); v != "" {
+		if cfg.AdaptiveConcurrency, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("SYNC_ADAPTIVE_CONCURRENCY must be a boolean: %w", err)
+		}
+	}
+	if cfg.AdaptiveMaxInFlight, err = getEnvInt("SYNC_ADAPTIVE_MAX_IN_FLIGHT", cfg.AdaptiveMaxInFlight); err != nil {
+		return nil, err
+	}
+	if cfg.AdaptiveMinInFlight, err = getEnvInt("SYNC_ADAPTIVE_MIN_IN_FLIGHT", cfg.AdaptiveMinInFlight); err != nil {
+		return nil, err
+	}
+	if v := os.Getenv("SYNC_ADAPTIVE_DECREASE_FACTOR"); v != "" {
+		if cfg.AdaptiveDecreaseFactor, err = strconv.ParseFloat(v, 64); err != nil {
+			return nil, fmt.Errorf("SYNC_ADAPTIVE_DECREASE_FACTOR must be a number: %w", err)
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that the concurrency, paging, duration and phase settings are in range
+func (c *SyncConfig) Validate() error {
+	if c.FolderConcurrency < 1 {
+		return fmt.Errorf("folder concurrency must be at least 1")
+	}
+	if c.SubfolderConcurrency < 1 {
+		return fmt.Errorf("subfolder concurrency must be at least 1")
+	}
+	if c.DataExtensionConcurrency < 1 {
+		return fmt.Errorf("data extension concurrency must be at least 1")
+	}
+	if c.AccountConcurrency < 1 {
+		return fmt.Errorf("account concurrency must be at least 1")
+	}
+	if c.DataExtensionPageSize < 1 {
+		return fmt.Errorf("data extension page size must be at least 1")
+	}
+	if c.RunBudget < 0 {
+		return fmt.Errorf("run budget must not be negative")
+	}
+	if c.StaleJobAge < 0 {
+		return fmt.Errorf("stale job age must not be negative")
+	}
+	switch c.PhaseMode {
+	case PhaseModeBatched, PhaseModeInterleaved:
+	default:
+		return fmt.Errorf("phase mode must be %q or %q, got %q", PhaseModeBatched, PhaseModeInterleaved, c.PhaseMode)
+	}
+	if c.AdaptiveConcurrency {
+		if c.AdaptiveMinInFlight < 1 {
+			return fmt.Errorf("adaptive min in flight must be at least 1")
+		}
+		if c.AdaptiveMaxInFlight < c.AdaptiveMinInFlight {
+			return fmt.Errorf("adaptive max in flight must be at least the min (%d)", c.AdaptiveMinInFlight)
+		}
+		if c.AdaptiveDecreaseFactor <= 0 || c.AdaptiveDecreaseFactor >= 1 {
+			return fmt.Errorf("adaptive decrease factor must be between 0 and 1")
+		}
+	}
+	return nil
+}
+
+// getEnvInt gets an integer environment variable or returns a default value
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return n, nil
+}
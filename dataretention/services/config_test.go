@@ -0,0 +1,86 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadSyncConfigConcurrency(t *testing.T) {
+	t.Setenv("SYNC_FOLDER_CONCURRENCY", "1")
+	t.Setenv("SYNC_SUBFOLDER_CONCURRENCY", "2")
+	t.Setenv("SYNC_DATA_EXTENSION_CONCURRENCY", "3")
+
+	cfg, err := LoadSyncConfig()
+	if err != nil {
+		t.Fatalf("LoadSyncConfig() error = %v", err)
+	}
+	if cfg.FolderConcurrency != 1 || cfg.SubfolderConcurrency != 2 || cfg.DataExtensionConcurrency != 3 {
+		t.Errorf("concurrency = %d/%d/%d, want 1/2/3",
+			cfg.FolderConcurrency, cfg.SubfolderConcurrency, cfg.DataExtensionConcurrency)
+	}
+}
+
+func TestLoadSyncConfigDefaults(t *testing.T) {
+	cfg, err := LoadSyncConfig()
+	if err != nil {
+		t.Fatalf("LoadSyncConfig() error = %v", err)
+	}
+	defaults := DefaultSyncConfig()
+	if cfg.FolderConcurrency != defaults.FolderConcurrency || cfg.SubfolderConcurrency != defaults.SubfolderConcurrency {
+		t.Errorf("concurrency = %d/%d, want the defaults %d/%d",
+			cfg.FolderConcurrency, cfg.SubfolderConcurrency, defaults.FolderConcurrency, defaults.SubfolderConcurrency)
+	}
+}
+
+func TestLoadSyncConfigInvalidConcurrency(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr string
+	}{
+		{"0", "folder concurrency must be at least 1"},
+		{"-2", "folder concurrency must be at least 1"},
+		{"many", "SYNC_FOLDER_CONCURRENCY must be an integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("SYNC_FOLDER_CONCURRENCY", tt.value)
+			_, err := LoadSyncConfig()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadSyncConfig() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadSyncConfigSaveOnly(t *testing.T) {
+	t.Setenv("SYNC_SAVE_ONLY", "true")
+	cfg, err := LoadSyncConfig()
+	if err != nil {
+		t.Fatalf("LoadSyncConfig() error = %v", err)
+	}
+	if !cfg.SaveOnly {
+		t.Error("SaveOnly = false, want true")
+	}
+
+	t.Setenv("SYNC_SAVE_ONLY", "sometimes")
+	if _, err := LoadSyncConfig(); err == nil || !strings.Contains(err.Error(), "SYNC_SAVE_ONLY must be a boolean") {
+		t.Errorf("LoadSyncConfig() error = %v, want the invalid boolean", err)
+	}
+}
+
+func TestLoadSyncConfigPageSize(t *testing.T) {
+	t.Setenv("SYNC_DATA_EXTENSION_PAGE_SIZE", "250")
+	cfg, err := LoadSyncConfig()
+	if err != nil {
+		t.Fatalf("LoadSyncConfig() error = %v", err)
+	}
+	if cfg.DataExtensionPageSize != 250 {
+		t.Errorf("DataExtensionPageSize = %d, want 250", cfg.DataExtensionPageSize)
+	}
+
+	t.Setenv("SYNC_DATA_EXTENSION_PAGE_SIZE", "0")
+	if _, err := LoadSyncConfig(); err == nil || !strings.Contains(err.Error(), "page size must be at least 1") {
+		t.Errorf("LoadSyncConfig() error = %v, want the invalid page size", err)
+	}
+}
@@ -4,34 +4,107 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"net/http"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/natserract/sf/dataretention/schema/postgres"
 	"github.com/natserract/sf/dataretention/schema/postgres/gen"
+	httpclient "github.com/natserract/sf/pkg/http"
+	"github.com/natserract/sf/pkg/paging"
 	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
 	"go.uber.org/zap"
 )
 
 // DataExtensionService handles data extension persistence operations
 type DataExtensionService struct {
-	queries *gen.Queries
-	db      *postgres.DB
-	logger  *zap.Logger
+	queries     *gen.Queries
+	db          *postgres.DB
+	logger      *zap.Logger
+	incremental bool
+	pageSize    int
 }
 
 // NewDataExtensionService creates a new data extension service
 func NewDataExtensionService(db *postgres.DB, logger *zap.Logger) *DataExtensionService {
 	return &DataExtensionService{
-		queries: gen.New(),
-		db:      db,
-		logger:  logger,
+		queries:  gen.New(),
+		db:       db,
+		logger:   logger,
+		pageSize: sfmce.DefaultDataExtensionPageSize,
 	}
 }
 
+// SetPageSize sets how many data extensions GetDataExtensions requests per page.
+// Larger pages take fewer round trips. A size of zero or less restores
+// sfmce.DefaultDataExtensionPageSize.
+func (d *DataExtensionService) SetPageSize(size int) {
+	if size <= 0 {
+		size = sfmce.DefaultDataExtensionPageSize
+	}
+	d.pageSize = size
+}
+
+// SetIncremental makes SaveDataExtension skip data extensions whose stored
+// modified date is already up to date
+func (d *DataExtensionService) SetIncremental(enabled bool) {
+	d.incremental = enabled
+}
+
+// IsUnchanged reports whether the stored copy of the data extension is at least as
+// recent as the API's modified date. Data extensions that aren't stored yet, or
+// carry no modified date, count as changed.
+func (d *DataExtensionService) IsUnchanged(ctx context.Context, de sfmce.DataExtension) (bool, error) {
+	return d.isUnchanged(ctx, d.db.Pool(), de)
+}
+
+func (d *DataExtensionService) isUnchanged(ctx context.Context, db gen.DBTX, de sfmce.DataExtension) (bool, error) {
+	if de.ModifiedDate.Time.IsZero() {
+		return false, nil
+	}
+
+	stored, err := d.queries.GetDataExtensionModifiedDate(ctx, db, de.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get stored modified date for data extension %s: %w", de.ID, err)
+	}
+
+	return stored.Valid && !de.ModifiedDate.Time.After(stored.Time), nil
+}
+
 // SaveDataExtension saves or updates a data extension in the database
+// In incremental mode, data extensions that haven't changed since they were stored are skipped
+// A lost database connection is re-established and the save retried (see postgres.DB.WithRetry)
 func (d *DataExtensionService) SaveDataExtension(ctx context.Context, de sfmce.DataExtension) error {
+	return d.db.WithRetry(ctx, func(ctx context.Context) error {
+		return d.saveDataExtension(ctx, d.db.Pool(), de)
+	})
+}
+
+// saveDataExtension saves a data extension and its retention properties through db,
+// which is either the pool or a transaction
+func (d *DataExtensionService) saveDataExtension(ctx context.Context, db gen.DBTX, de sfmce.DataExtension) error {
+	if err := validateDataExtension(de); err != nil {
+		return err
+	}
+
+	if d.incremental {
+		unchanged, err := d.isUnchanged(ctx, db, de)
+		if err != nil {
+			return err
+		}
+		if unchanged {
+			d.logger.Debug("Skipping unchanged data extension", zap.String("data_extension_id", de.ID))
+			return nil
+		}
+	}
+
 	createdDate := pgtype.Timestamptz{Time: de.CreatedDate.Time, Valid: !de.CreatedDate.Time.IsZero()}
 	modifiedDate := pgtype.Timestamptz{Time: de.ModifiedDate.Time, Valid: !de.ModifiedDate.Time.IsZero()}
 
@@ -39,13 +112,13 @@ func (d *DataExtensionService) SaveDataExtension(ctx context.Context, de sfmce.D
 	sendableCustomObjectField := pgtype.Text{String: de.SendableCustomObjectField, Valid: de.SendableCustomObjectField != ""}
 	sendableSubscriberField := pgtype.Text{String: de.SendableSubscriberField, Valid: de.SendableSubscriberField != ""}
 	createdByName := pgtype.Text{String: de.CreatedByName, Valid: de.CreatedByName != ""}
-	modifiedByID := pgtype.Int4{Int32: int32(de.ModifiedByID), Valid: de.ModifiedByID != 0}
+	modifiedByID := pgtype.Int8{Int64: int64(de.ModifiedByID), Valid: de.ModifiedByID != 0}
 	modifiedByName := pgtype.Text{String: de.ModifiedByName, Valid: de.ModifiedByName != ""}
 	ownerName := pgtype.Text{String: de.OwnerName, Valid: de.OwnerName != ""}
-	partnerAPIObjectTypeID := pgtype.Int4{Int32: int32(de.PartnerAPIObjectTypeID), Valid: de.PartnerAPIObjectTypeID != 0}
+	partnerAPIObjectTypeID := pgtype.Int8{Int64: int64(de.PartnerAPIObjectTypeID), Valid: de.PartnerAPIObjectTypeID != 0}
 	partnerAPIObjectTypeName := pgtype.Text{String: de.PartnerAPIObjectTypeName, Valid: de.PartnerAPIObjectTypeName != ""}
 
-	params := gen.CreateDataExtensionParams{
+	params := gen.UpsertDataExtensionParams{
 		ID:                         de.ID,
 		Name:                       de.Name,
 		Key:                        de.Key,
@@ -55,13 +128,13 @@ func (d *DataExtensionService) SaveDataExtension(ctx context.Context, de sfmce.D
 		SendableCustomObjectField:  sendableCustomObjectField,
 		SendableSubscriberField:    sendableSubscriberField,
 		IsTestable:                 de.IsTestable,
-		CategoryID:                 fmt.Sprintf("%d", de.CategoryID),
-		OwnerID:                    int32(de.OwnerID),
+		CategoryID:                 de.FolderID(),
+		OwnerID:                    int64(de.OwnerID),
 		IsObjectDeletable:          de.IsObjectDeletable,
 		IsFieldAdditionAllowed:     de.IsFieldAdditionAllowed,
 		IsFieldModificationAllowed: de.IsFieldModificationAllowed,
 		CreatedDate:                createdDate,
-		CreatedByID:                int32(de.CreatedByID),
+		CreatedByID:                int64(de.CreatedByID),
 		CreatedByName:              createdByName,
 		ModifiedDate:               modifiedDate,
 		ModifiedByID:               modifiedByID,
@@ -69,48 +142,45 @@ func (d *DataExtensionService) SaveDataExtension(ctx context.Context, de sfmce.D
 		OwnerName:                  ownerName,
 		PartnerApiObjectTypeID:     partnerAPIObjectTypeID,
 		PartnerApiObjectTypeName:   partnerAPIObjectTypeName,
-		RowCount:                   int32(de.RowCount),
+		RowCount:                   int64(de.RowCount),
 		FieldCount:                 int32(de.FieldCount),
 	}
 
-	_, err := d.queries.CreateDataExtension(ctx, d.db.Pool(), params)
-	if err != nil {
-		// Check if it's a unique constraint violation (record already exists)
-		if isUniqueConstraintViolation(err) {
-			// Try update if insert fails due to existing record
-			updateParams := gen.UpdateDataExtensionParams{
-				ID:             de.ID,
-				Name:           de.Name,
-				Description:    description,
-				IsActive:       de.IsActive,
-				ModifiedDate:   modifiedDate,
-				ModifiedByID:   modifiedByID,
-				ModifiedByName: modifiedByName,
-				RowCount:       int32(de.RowCount),
-				FieldCount:     int32(de.FieldCount),
-			}
-			_, updateErr := d.queries.UpdateDataExtension(ctx, d.db.Pool(), updateParams)
-			if updateErr != nil {
-				d.logger.Error("Failed to update data extension",
-					zap.String("data_extension_id", de.ID),
-					zap.Error(updateErr))
-				return fmt.Errorf("failed to update data extension %s: %w", de.ID, updateErr)
-			}
-			d.logger.Debug("Updated existing data extension", zap.String("data_extension_id", de.ID))
-		} else {
-			// Log the actual error for debugging
-			d.logger.Error("Failed to create data extension",
-				zap.String("data_extension_id", de.ID),
-				zap.Error(err))
-			return fmt.Errorf("failed to create data extension %s: %w", de.ID, err)
-		}
-	} else {
-		d.logger.Debug("Created data extension", zap.String("data_extension_id", de.ID))
+	// A single upsert, so saving is idempotent and needs no insert-then-update fallback
+	if _, err := d.queries.UpsertDataExtension(ctx, db, params); err != nil {
+		d.logger.Error("Failed to save data extension",
+			zap.String("data_extension_id", de.ID),
+			zap.Error(err))
+		return fmt.Errorf("failed to save data extension %s: %w", de.ID, err)
 	}
+	d.logger.Debug("Saved data extension", zap.String("data_extension_id", de.ID))
 
-	// Save data retention properties if present
-	if de.DataRetentionProperties != nil {
-		retentionParams := gen.CreateDataRetentionPropertiesParams{
+	if de.DataRetentionProperties == nil {
+		return nil
+	}
+	return d.saveRetentionProperties(ctx, db, de)
+}
+
+// saveRetentionProperties saves the retention properties of a data extension through
+// db. Outside a transaction a failure is only logged, as before; inside one the
+// error is returned, since the statement has already aborted the transaction.
+func (d *DataExtensionService) saveRetentionProperties(ctx context.Context, db gen.DBTX, de sfmce.DataExtension) error {
+	retentionParams := gen.CreateDataRetentionPropertiesParams{
+		DataExtensionID:                  de.ID,
+		DataRetentionPeriodLength:        int32(de.DataRetentionProperties.DataRetentionPeriodLength),
+		DataRetentionPeriodUnitOfMeasure: int32(de.DataRetentionProperties.DataRetentionPeriodUnitOfMeasure),
+		IsDeleteAtEndOfRetentionPeriod:   de.DataRetentionProperties.IsDeleteAtEndOfRetentionPeriod,
+		IsRowBasedRetention:              de.DataRetentionProperties.IsRowBasedRetention,
+		IsResetRetentionPeriodOnImport:   de.DataRetentionProperties.IsResetRetentionPeriodOnImport,
+	}
+
+	err := withSavepoint(ctx, db, func(db gen.DBTX) error {
+		_, err := d.queries.CreateDataRetentionProperties(ctx, db, retentionParams)
+		return err
+	})
+	if err != nil {
+		// Try update if insert fails
+		updateRetentionParams := gen.UpdateDataRetentionPropertiesParams{
 			DataExtensionID:                  de.ID,
 			DataRetentionPeriodLength:        int32(de.DataRetentionProperties.DataRetentionPeriodLength),
 			DataRetentionPeriodUnitOfMeasure: int32(de.DataRetentionProperties.DataRetentionPeriodUnitOfMeasure),
@@ -118,32 +188,31 @@ func (d *DataExtensionService) SaveDataExtension(ctx context.Context, de sfmce.D
 			IsRowBasedRetention:              de.DataRetentionProperties.IsRowBasedRetention,
 			IsResetRetentionPeriodOnImport:   de.DataRetentionProperties.IsResetRetentionPeriodOnImport,
 		}
-
-		_, err = d.queries.CreateDataRetentionProperties(ctx, d.db.Pool(), retentionParams)
+		_, err = d.queries.UpdateDataRetentionProperties(ctx, db, updateRetentionParams)
 		if err != nil {
-			// Try update if insert fails
-			updateRetentionParams := gen.UpdateDataRetentionPropertiesParams{
-				DataExtensionID:                  de.ID,
-				DataRetentionPeriodLength:        int32(de.DataRetentionProperties.DataRetentionPeriodLength),
-				DataRetentionPeriodUnitOfMeasure: int32(de.DataRetentionProperties.DataRetentionPeriodUnitOfMeasure),
-				IsDeleteAtEndOfRetentionPeriod:   de.DataRetentionProperties.IsDeleteAtEndOfRetentionPeriod,
-				IsRowBasedRetention:              de.DataRetentionProperties.IsRowBasedRetention,
-				IsResetRetentionPeriodOnImport:   de.DataRetentionProperties.IsResetRetentionPeriodOnImport,
-			}
-			_, err = d.queries.UpdateDataRetentionProperties(ctx, d.db.Pool(), updateRetentionParams)
-			if err != nil {
-				d.logger.Warn("Failed to save retention properties",
-					zap.String("data_extension_id", de.ID),
-					zap.Error(err))
+			if _, inTx := db.(pgx.Tx); inTx {
+				return fmt.Errorf("failed to save retention properties for data extension %s: %w", de.ID, err)
 			}
+			d.logger.Warn("Failed to save retention properties",
+				zap.String("data_extension_id", de.ID),
+				zap.Error(err))
 		}
 	}
 
 	return nil
 }
 
-// SaveDataExtensionsBatch saves multiple data extensions in a transaction
+// SaveDataExtensionsBatch saves multiple data extensions in a single transaction.
+// If any of them fails, the whole batch is rolled back. A lost database connection is
+// re-established and the whole transaction retried.
 func (d *DataExtensionService) SaveDataExtensionsBatch(ctx context.Context, dataExtensions []sfmce.DataExtension) error {
+	return d.db.WithRetry(ctx, func(ctx context.Context) error {
+		return d.saveDataExtensionsBatch(ctx, dataExtensions)
+	})
+}
+
+// saveDataExtensionsBatch implements SaveDataExtensionsBatch for a single attempt
+func (d *DataExtensionService) saveDataExtensionsBatch(ctx context.Context, dataExtensions []sfmce.DataExtension) error {
 	tx, err := d.db.Pool().Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -151,7 +220,7 @@ func (d *DataExtensionService) SaveDataExtensionsBatch(ctx context.Context, data
 	defer tx.Rollback(ctx)
 
 	for _, de := range dataExtensions {
-		if err := d.SaveDataExtension(ctx, de); err != nil {
+		if err := d.saveDataExtension(ctx, tx, de); err != nil {
 			return fmt.Errorf("failed to save data extension in batch: %w", err)
 		}
 	}
@@ -164,52 +233,105 @@ func (d *DataExtensionService) SaveDataExtensionsBatch(ctx context.Context, data
 	return nil
 }
 
+// SaveDataExtensionFields replaces the stored field definitions of a data extension
+// with fields, as returned by GetDataExtensionFields. The data extension itself must
+// already be stored.
+func (d *DataExtensionService) SaveDataExtensionFields(ctx context.Context, dataExtensionID string, fields []sfmce.DataExtensionField) error {
+	tx, err := d.db.Pool().Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := d.queries.DeleteDataExtensionFields(ctx, tx, dataExtensionID); err != nil {
+		return fmt.Errorf("failed to clear fields of data extension %s: %w", dataExtensionID, err)
+	}
+	for _, field := range fields {
+		err := d.queries.CreateDataExtensionField(ctx, tx, gen.CreateDataExtensionFieldParams{
+			DataExtensionID: dataExtensionID,
+			Name:            field.Name,
+			FieldType:       field.Type,
+			Length:          pgtype.Int4{Int32: int32(field.Length), Valid: field.Length != 0},
+			Scale:           pgtype.Int4{Int32: int32(field.Scale), Valid: field.Scale != 0},
+			Ordinal:         int32(field.Ordinal),
+			IsPrimaryKey:    field.IsPrimaryKey,
+			IsNullable:      field.IsNullable,
+			DefaultValue:    pgtype.Text{String: field.DefaultValue, Valid: field.DefaultValue != ""},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save field %s of data extension %s: %w", field.Name, dataExtensionID, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	d.logger.Debug("Saved data extension fields",
+		zap.String("data_extension_id", dataExtensionID),
+		zap.Int("field_count", len(fields)))
+	return nil
+}
+
+// withSavepoint runs fn inside a savepoint when db is a transaction, so that an
+// expected failure, like the unique violation the create-or-update fallback relies
+// on, doesn't abort the whole transaction. Outside a transaction fn runs as is.
+func withSavepoint(ctx context.Context, db gen.DBTX, fn func(db gen.DBTX) error) error {
+	tx, ok := db.(pgx.Tx)
+	if !ok {
+		return fn(db)
+	}
+
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+	if err := fn(savepoint); err != nil {
+		_ = savepoint.Rollback(ctx)
+		return err
+	}
+	return savepoint.Commit(ctx)
+}
+
 // GetDataExtensions fetches all data extensions for a folder with pagination
 // Handles pagination internally and returns all matching data extensions as a single slice
 func (d *DataExtensionService) GetDataExtensions(ctx context.Context, client sfmce.SalesforceClient, folderID string) ([]sfmce.DataExtension, error) {
-	page := 1
-	pageSize := 96
-
-	d.logger.Info("Fetching data extensions",
-		zap.String("folder_id", folderID))
+	return d.GetDataExtensionsModifiedSince(ctx, client, folderID, time.Time{})
+}
 
-	var allDataExtensions []sfmce.DataExtension
+// GetDataExtensionsModifiedSince fetches the data extensions of a folder modified at or
+// after since. Pages come newest first, so paging stops at the first older data
+// extension. A zero since fetches all of them.
+func (d *DataExtensionService) GetDataExtensionsModifiedSince(ctx context.Context, client sfmce.SalesforceClient, folderID string, since time.Time) ([]sfmce.DataExtension, error) {
+	d.logger.Debug("Fetching data extensions",
+		zap.String("folder_id", folderID),
+		zap.Time("modified_since", since))
 
-	for {
-		// Fetch data extensions for current page
-		resp, err := client.GetDataExtensions(folderID, page, pageSize)
+	fetch := func(page int) ([]sfmce.DataExtension, error) {
+		resp, err := client.GetDataExtensionsCtx(ctx, folderID, page, d.pageSize)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch data extensions for folder %s (page %d): %w", folderID, page, err)
 		}
-
-		if len(resp.Items) == 0 {
-			// No more items, break the loop
-			break
-		}
-
-		d.logger.Info("Fetched data extensions page",
+		d.logger.Debug("Fetched data extensions page",
 			zap.String("folder_id", folderID),
 			zap.Int("page", page),
 			zap.Int("items_in_page", len(resp.Items)))
+		return resp.Items, nil
+	}
 
-		// Add all items to the result
-		allDataExtensions = append(allDataExtensions, resp.Items...)
-
-		// Check if there are more pages
-		// If we get fewer items than pageSize, we've reached the last page
-		if len(resp.Items) < pageSize {
-			d.logger.Info("Reached end of data extensions",
-				zap.String("folder_id", folderID),
-				zap.Int("items_in_page", len(resp.Items)),
-				zap.Int("page_size", pageSize))
-			break
+	var olderThanCutoff func(sfmce.DataExtension) bool
+	if !since.IsZero() {
+		olderThanCutoff = func(de sfmce.DataExtension) bool {
+			return !de.ModifiedDate.Time.IsZero() && de.ModifiedDate.Time.Before(since)
 		}
+	}
 
-		// Move to next page
-		page++
+	allDataExtensions, err := paging.PageUntil(d.pageSize, fetch, olderThanCutoff)
+	if err != nil {
+		return nil, err
 	}
 
-	d.logger.Info("Completed fetching data extensions for folder",
+	d.logger.Debug("Completed fetching data extensions for folder",
 		zap.String("folder_id", folderID),
 		zap.Int("total_items", len(allDataExtensions)))
 
@@ -217,19 +339,44 @@ func (d *DataExtensionService) GetDataExtensions(ctx context.Context, client sfm
 }
 
 // UpdateDataRetentionViaAPI updates data retention properties via Salesforce API
-// Uses the standard payload: 3 months retention, row-based, no reset on import, no delete at end
-func (d *DataExtensionService) UpdateDataRetentionViaAPI(ctx context.Context, client sfmce.SalesforceClient, dataExtensionID string) error {
-	// Create the retention properties payload as specified
-	retention := &sfmce.DataRetentionProperties{
-		DataRetentionPeriodLength:        1,
-		DataRetentionPeriodUnitOfMeasure: 5, // 5 = months
-		IsDeleteAtEndOfRetentionPeriod:   false,
-		IsRowBasedRetention:              true,
-		IsResetRetentionPeriodOnImport:   false,
+// Uses the standard payload from DefaultRetentionPolicy. When the data extension's
+// current retention already matches it, no API call is made and the update is
+// recorded as skipped; updated reports whether the API was called.
+func (d *DataExtensionService) UpdateDataRetentionViaAPI(ctx context.Context, client sfmce.SalesforceClient, de sfmce.DataExtension) (updated bool, err error) {
+	return d.UpdateDataRetentionPolicyViaAPI(ctx, client, de, DefaultRetentionPolicy())
+}
+
+// UpdateDataRetentionPolicyViaAPI is UpdateDataRetentionViaAPI with the given
+// retention settings instead of the default policy. When the data extension only
+// accepts the period length and unit, that much is recorded and the returned error
+// wraps sfmce.ErrRetentionFlagsDropped.
+func (d *DataExtensionService) UpdateDataRetentionPolicyViaAPI(ctx context.Context, client sfmce.SalesforceClient, de sfmce.DataExtension, retention *sfmce.DataRetentionProperties) (updated bool, err error) {
+	if err := retention.Validate(); err != nil {
+		return false, fmt.Errorf("invalid retention settings for %s: %w", de.ID, err)
+	}
+	dataExtensionID := de.ID
+
+	if de.DataRetentionProperties.Equal(retention) {
+		_, err := d.queries.UpdateDataRetentionAPIUpdateStatus(ctx, d.db.Pool(), gen.UpdateDataRetentionAPIUpdateStatusParams{
+			DataExtensionID:                  dataExtensionID,
+			LastApiUpdateStatus:              "skipped",
+			LastApiUpdateError:               pgtype.Text{Valid: false},
+			DataRetentionPeriodLength:        int32(retention.DataRetentionPeriodLength),
+			DataRetentionPeriodUnitOfMeasure: int32(retention.DataRetentionPeriodUnitOfMeasure),
+			IsRowBasedRetention:              retention.IsRowBasedRetention,
+		})
+		if err != nil {
+			d.logger.Warn("Failed to update retention status to skipped",
+				zap.String("data_extension_id", dataExtensionID),
+				zap.Error(err))
+		}
+		d.logger.Debug("Data retention already matches, skipping API update",
+			zap.String("data_extension_id", dataExtensionID))
+		return false, nil
 	}
 
 	// First, mark as pending in the database
-	_, err := d.queries.UpdateDataRetentionAPIUpdateStatus(ctx, d.db.Pool(), gen.UpdateDataRetentionAPIUpdateStatusParams{
+	_, err = d.queries.UpdateDataRetentionAPIUpdateStatus(ctx, d.db.Pool(), gen.UpdateDataRetentionAPIUpdateStatusParams{
 		DataExtensionID:                  dataExtensionID,
 		LastApiUpdateStatus:              "pending",
 		LastApiUpdateError:               pgtype.Text{Valid: false},
@@ -245,7 +392,11 @@ func (d *DataExtensionService) UpdateDataRetentionViaAPI(ctx context.Context, cl
 	}
 
 	// Call the Salesforce API to update retention
-	err = client.UpdateDataRetention(dataExtensionID, retention)
+	err = client.UpdateDataRetentionCtx(ctx, dataExtensionID, retention)
+	if errors.Is(err, sfmce.ErrRetentionFlagsDropped) {
+		d.recordFlagsDropped(ctx, de, retention, err)
+		return true, fmt.Errorf("failed to fully update data retention via API for %s: %w", dataExtensionID, err)
+	}
 	if err != nil {
 		// Update database with failed status
 		errorMsg := err.Error()
@@ -265,7 +416,16 @@ func (d *DataExtensionService) UpdateDataRetentionViaAPI(ctx context.Context, cl
 				zap.String("data_extension_id", dataExtensionID),
 				zap.Error(updateErr))
 		}
-		return fmt.Errorf("failed to update data retention via API for %s: %w", dataExtensionID, err)
+		if statusCode, permanent := permanentRetentionFailure(err); permanent {
+			d.recordDeadLetter(ctx, dataExtensionID, statusCode, errorMsg)
+		}
+		return false, fmt.Errorf("failed to update data retention via API for %s: %w", dataExtensionID, err)
+	}
+
+	if err := d.queries.DeleteRetentionDeadLetter(ctx, d.db.Pool(), dataExtensionID); err != nil {
+		d.logger.Warn("Failed to clear retention dead letter",
+			zap.String("data_extension_id", dataExtensionID),
+			zap.Error(err))
 	}
 
 	// Update database with succeeded status and retention properties
@@ -284,9 +444,153 @@ func (d *DataExtensionService) UpdateDataRetentionViaAPI(ctx context.Context, cl
 		// Don't return error since API call succeeded
 	}
 
-	d.logger.Info("Successfully updated data retention via API",
+	d.logger.Debug("Successfully updated data retention via API",
 		zap.String("data_extension_id", dataExtensionID))
 
+	return true, nil
+}
+
+// recordFlagsDropped records a retention update that was applied without its flags.
+// Only the period length and unit were sent, so the stored row keeps the data
+// extension's previous row-based setting, and the error notes what was left out.
+func (d *DataExtensionService) recordFlagsDropped(ctx context.Context, de sfmce.DataExtension, retention *sfmce.DataRetentionProperties, err error) {
+	var rowBased bool
+	if de.DataRetentionProperties != nil {
+		rowBased = de.DataRetentionProperties.IsRowBasedRetention
+	}
+	d.logger.Warn("Data retention applied without its flags",
+		zap.String("data_extension_id", de.ID),
+		zap.String("requested", retention.String()),
+		zap.Error(err))
+
+	if err := d.queries.DeleteRetentionDeadLetter(ctx, d.db.Pool(), de.ID); err != nil {
+		d.logger.Warn("Failed to clear retention dead letter",
+			zap.String("data_extension_id", de.ID),
+			zap.Error(err))
+	}
+	_, updateErr := d.queries.UpdateDataRetentionAPIUpdateStatus(ctx, d.db.Pool(), gen.UpdateDataRetentionAPIUpdateStatusParams{
+		DataExtensionID:                  de.ID,
+		LastApiUpdateStatus:              "succeeded",
+		LastApiUpdateError:               pgtype.Text{String: err.Error(), Valid: true},
+		DataRetentionPeriodLength:        int32(retention.DataRetentionPeriodLength),
+		DataRetentionPeriodUnitOfMeasure: int32(retention.DataRetentionPeriodUnitOfMeasure),
+		IsRowBasedRetention:              rowBased,
+	})
+	if updateErr != nil {
+		d.logger.Error("Failed to update retention status to succeeded",
+			zap.String("data_extension_id", de.ID),
+			zap.Error(updateErr))
+	}
+}
+
+// permanentRetentionFailure reports whether a failed retention update won't succeed
+// on a later run without someone looking at it: the API rejected the request with a
+// client error. Rate limiting, timeouts, auth failures and server errors are
+// transient.
+func permanentRetentionFailure(err error) (statusCode pgtype.Int4, permanent bool) {
+	statusErr, ok := httpclient.AsStatusError(err)
+	if !ok {
+		return pgtype.Int4{}, false
+	}
+	switch code := statusErr.StatusCode; {
+	case code == http.StatusUnauthorized, code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+		return pgtype.Int4{}, false
+	case code >= 400 && code < 500:
+		return pgtype.Int4{Int32: int32(code), Valid: true}, true
+	}
+	return pgtype.Int4{}, false
+}
+
+// recordDeadLetter upserts the data extension into retention_dead_letters, counting
+// the attempt. The entry is cleared by the next successful update.
+func (d *DataExtensionService) recordDeadLetter(ctx context.Context, dataExtensionID string, statusCode pgtype.Int4, errorMsg string) {
+	deadLetter, err := d.queries.UpsertRetentionDeadLetter(ctx, d.db.Pool(), gen.UpsertRetentionDeadLetterParams{
+		DataExtensionID: dataExtensionID,
+		StatusCode:      statusCode,
+		LastError:       errorMsg,
+	})
+	if err != nil {
+		d.logger.Error("Failed to record retention dead letter",
+			zap.String("data_extension_id", dataExtensionID),
+			zap.Error(err))
+		return
+	}
+
+	d.logger.Warn("Retention update failed permanently, recorded dead letter",
+		zap.String("data_extension_id", dataExtensionID),
+		zap.Int32("status_code", statusCode.Int32),
+		zap.Int32("attempt_count", deadLetter.AttemptCount))
+}
+
+// SetCategoryFullPath stores the human-readable folder path of a data extension
+func (d *DataExtensionService) SetCategoryFullPath(ctx context.Context, dataExtensionID, path string) error {
+	err := d.queries.SetDataExtensionCategoryFullPath(ctx, d.db.Pool(), gen.SetDataExtensionCategoryFullPathParams{
+		ID:               dataExtensionID,
+		CategoryFullPath: pgtype.Text{String: path, Valid: path != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set category full path for data extension %s: %w", dataExtensionID, err)
+	}
+	return nil
+}
+
+// GetFolderPath returns the human-readable path of the stored folder a data extension
+// lives in, e.g. "Data Extensions / Campaigns / 2024". It is FolderService.GetFolderPath,
+// offered here for reports that only have the data extension service at hand.
+func (d *DataExtensionService) GetFolderPath(ctx context.Context, folderID string) (string, error) {
+	return NewFolderService(d.db, d.logger).GetFolderPath(ctx, folderID)
+}
+
+// ReconcileDeleted marks the stored data extensions missing from seenIDs as deleted
+// and clears the mark on stored data extensions that are in it again. seenIDs must
+// be the complete set of data extensions that exist upstream.
+func (d *DataExtensionService) ReconcileDeleted(ctx context.Context, seenIDs []string) (deleted, restored int64, err error) {
+	restored, err = d.queries.RestoreDataExtensionsSeen(ctx, d.db.Pool(), seenIDs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to restore seen data extensions: %w", err)
+	}
+	deleted, err = d.queries.SoftDeleteDataExtensionsNotSeen(ctx, d.db.Pool(), seenIDs)
+	if err != nil {
+		return 0, restored, fmt.Errorf("failed to soft-delete missing data extensions: %w", err)
+	}
+	return deleted, restored, nil
+}
+
+// ListLargeDataExtensions returns the stored data extensions with more than threshold
+// rows, largest first. It reads the mirror only, so the counts are as of the last sync;
+// soft-deleted rows are left out.
+func (d *DataExtensionService) ListLargeDataExtensions(ctx context.Context, threshold int) ([]*gen.DataExtensions, error) {
+	dataExtensions, err := d.queries.ListDataExtensionsAboveRowCount(ctx, d.db.Pool(), int64(threshold))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data extensions above %d rows: %w", threshold, err)
+	}
+	return dataExtensions, nil
+}
+
+// validateDataExtension checks that the identifiers and counts of a data extension
+// fit the columns they are stored in before it is written to the database
+func validateDataExtension(de sfmce.DataExtension) error {
+	if de.ID == "" {
+		return fmt.Errorf("data extension %q has no id", de.Name)
+	}
+	if de.CategoryID <= 0 {
+		return fmt.Errorf("data extension %s has invalid category id %d", de.ID, de.CategoryID)
+	}
+	ids := map[string]int{
+		"owner id":                   de.OwnerID,
+		"created by id":              de.CreatedByID,
+		"modified by id":             de.ModifiedByID,
+		"partner api object type id": de.PartnerAPIObjectTypeID,
+		"row count":                  de.RowCount,
+	}
+	for name, v := range ids {
+		if v < 0 {
+			return fmt.Errorf("data extension %s has negative %s %d", de.ID, name, v)
+		}
+	}
+	if de.FieldCount < 0 || de.FieldCount > math.MaxInt32 {
+		return fmt.Errorf("data extension %s has out of range field count %d", de.ID, de.FieldCount)
+	}
 	return nil
 }
 
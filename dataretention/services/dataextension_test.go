@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+	httpclient "github.com/natserract/sf/pkg/http"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+	"go.uber.org/zap"
+)
+
+// saveTestFolders stores folders so data extensions can reference them
+func saveTestFolders(t *testing.T, db *postgres.DB, folders ...sfmce.Folder) {
+	t.Helper()
+	folderSvc := NewFolderService(db, zap.NewNop())
+	for _, folder := range folders {
+		if err := folderSvc.SaveFolder(context.Background(), folder); err != nil {
+			t.Fatalf("SaveFolder(%s) error = %v", folder.ID, err)
+		}
+	}
+}
+
+func TestDataExtensionIsUnchanged(t *testing.T) {
+	db := postgrestest.New(t)
+	saveTestFolders(t, db, testFolder("1", ""))
+	dataExtSvc := NewDataExtensionService(db, zap.NewNop())
+	ctx := context.Background()
+	if err := dataExtSvc.SaveDataExtension(ctx, testDataExtension("de-1", "1")); err != nil {
+		t.Fatalf("SaveDataExtension() error = %v", err)
+	}
+
+	modifiedAt := func(modified time.Time) sfmce.DataExtension {
+		de := testDataExtension("de-1", "1")
+		de.ModifiedDate.Time = modified
+		return de
+	}
+
+	tests := []struct {
+		name string
+		de   sfmce.DataExtension
+		want bool
+	}{
+		{"same modified date", testDataExtension("de-1", "1"), true},
+		{"older modified date", modifiedAt(testTime.Add(-time.Hour)), true},
+		{"modified since", modifiedAt(testTime.Add(time.Hour)), false},
+		{"no modified date", modifiedAt(time.Time{}), false},
+		{"not stored", testDataExtension("de-2", "1"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := dataExtSvc.IsUnchanged(ctx, tt.de)
+			if err != nil {
+				t.Fatalf("IsUnchanged() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsUnchanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveDataExtensionLargeIDs(t *testing.T) {
+	db := postgrestest.New(t)
+	folder := testFolder("3000000000", "")
+	folder.CreatedBy = 4_000_000_001
+	saveTestFolders(t, db, folder)
+
+	de := testDataExtension("de-large", "3000000000")
+	de.OwnerID = 5_000_000_002
+	de.CreatedByID = 6_000_000_003
+	de.ModifiedByID = 7_000_000_004
+	de.PartnerAPIObjectTypeID = 8_000_000_005
+	de.RowCount = 9_000_000_006
+	if err := NewDataExtensionService(db, zap.NewNop()).SaveDataExtension(context.Background(), de); err != nil {
+		t.Fatalf("SaveDataExtension() error = %v", err)
+	}
+
+	var ownerID, createdByID, modifiedByID, objectTypeID, rowCount, folderCreatedBy int64
+	err := db.Pool().QueryRow(context.Background(), `
+		SELECT de.owner_id, de.created_by_id, de.modified_by_id, de.partner_api_object_type_id, de.row_count, f.created_by
+		FROM data_extensions de
+		JOIN folders f ON f.id = de.category_id
+		WHERE de.id = $1`, de.ID).Scan(&ownerID, &createdByID, &modifiedByID, &objectTypeID, &rowCount, &folderCreatedBy)
+	if err != nil {
+		t.Fatalf("failed to read back data extension joined to its folder: %v", err)
+	}
+
+	got := []int64{ownerID, createdByID, modifiedByID, objectTypeID, rowCount, folderCreatedBy}
+	want := []int64{5_000_000_002, 6_000_000_003, 7_000_000_004, 8_000_000_005, 9_000_000_006, 4_000_000_001}
+	if !slices.Equal(got, want) {
+		t.Errorf("stored IDs = %v, want %v", got, want)
+	}
+}
+
+func TestUpdateDataRetentionSkipsMatchingSettings(t *testing.T) {
+	db := postgrestest.New(t)
+	saveTestFolders(t, db, testFolder("1", ""))
+	dataExtSvc := NewDataExtensionService(db, zap.NewNop())
+	ctx := context.Background()
+
+	differing := *DefaultRetentionPolicy()
+	differing.DataRetentionPeriodLength = 6
+	matching := withRetention(testDataExtension("de-match", "1"), DefaultRetentionPolicy())
+	outdated := withRetention(testDataExtension("de-differ", "1"), &differing)
+
+	client := fake.NewClient()
+	client.AddDataExtension(matching, outdated)
+	for _, de := range []sfmce.DataExtension{matching, outdated} {
+		if err := dataExtSvc.SaveDataExtension(ctx, de); err != nil {
+			t.Fatalf("SaveDataExtension(%s) error = %v", de.ID, err)
+		}
+	}
+
+	tests := []struct {
+		de          sfmce.DataExtension
+		wantUpdated bool
+		wantStatus  string
+	}{
+		{matching, false, "skipped"},
+		{outdated, true, "succeeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.de.ID, func(t *testing.T) {
+			updated, err := dataExtSvc.UpdateDataRetentionViaAPI(ctx, client, tt.de)
+			if err != nil {
+				t.Fatalf("UpdateDataRetentionViaAPI() error = %v", err)
+			}
+			if updated != tt.wantUpdated {
+				t.Errorf("UpdateDataRetentionViaAPI() updated = %v, want %v", updated, tt.wantUpdated)
+			}
+
+			var status string
+			var length int32
+			err = db.Pool().QueryRow(ctx, "SELECT last_api_update_status, data_retention_period_length FROM data_retention_properties WHERE data_extension_id = $1", tt.de.ID).Scan(&status, &length)
+			if err != nil {
+				t.Fatalf("failed to read retention status: %v", err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("last_api_update_status = %q, want %q", status, tt.wantStatus)
+			}
+			if want := int32(DefaultRetentionPolicy().DataRetentionPeriodLength); length != want {
+				t.Errorf("stored retention period = %d, want the policy's %d", length, want)
+			}
+		})
+	}
+
+	updates := client.RetentionUpdates()
+	if len(updates) != 1 || updates[0].DataExtensionID != "de-differ" {
+		t.Errorf("retention updates = %+v, want only de-differ", updates)
+	}
+}
+
+func TestSaveDataExtensionsBatchRollsBack(t *testing.T) {
+	db := postgrestest.New(t)
+	saveTestFolders(t, db, testFolder("1", ""))
+	dataExtSvc := NewDataExtensionService(db, zap.NewNop())
+
+	// The name column holds at most 500 characters, so the third save fails
+	tooLong := testDataExtension("de-3", "1")
+	tooLong.Name = strings.Repeat("x", 501)
+	batch := []sfmce.DataExtension{
+		withRetention(testDataExtension("de-1", "1"), DefaultRetentionPolicy()),
+		withRetention(testDataExtension("de-2", "1"), DefaultRetentionPolicy()),
+		tooLong,
+		testDataExtension("de-4", "1"),
+	}
+
+	if err := dataExtSvc.SaveDataExtensionsBatch(context.Background(), batch); err == nil {
+		t.Fatal("SaveDataExtensionsBatch() error = nil, want the third save to fail")
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM data_extensions"); n != 0 {
+		t.Errorf("%d data extensions committed, want the batch rolled back", n)
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM data_retention_properties"); n != 0 {
+		t.Errorf("%d retention rows committed, want the batch rolled back", n)
+	}
+
+	if err := dataExtSvc.SaveDataExtensionsBatch(context.Background(), batch[:2]); err != nil {
+		t.Fatalf("SaveDataExtensionsBatch() error = %v", err)
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM data_retention_properties"); n != 2 {
+		t.Errorf("%d retention rows committed, want both of the batch", n)
+	}
+}
+
+func TestUpdateDataRetentionDeadLetters(t *testing.T) {
+	db := postgrestest.New(t)
+	saveTestFolders(t, db, testFolder("1", ""))
+	dataExtSvc := NewDataExtensionService(db, zap.NewNop())
+	ctx := context.Background()
+
+	client := fake.NewClient()
+	for _, id := range []string{"de-rejected", "de-unavailable"} {
+		de := testDataExtension(id, "1")
+		client.AddDataExtension(de)
+		if err := dataExtSvc.SaveDataExtension(ctx, withRetention(de, &sfmce.DataRetentionProperties{})); err != nil {
+			t.Fatalf("SaveDataExtension(%s) error = %v", id, err)
+		}
+	}
+
+	type deadLetter struct {
+		statusCode pgtype.Int4
+		lastError  string
+		attempts   int32
+	}
+	readDeadLetter := func(id string) (deadLetter, bool) {
+		t.Helper()
+		var dl deadLetter
+		err := db.Pool().QueryRow(ctx, "SELECT status_code, last_error, attempt_count FROM retention_dead_letters WHERE data_extension_id = $1", id).
+			Scan(&dl.statusCode, &dl.lastError, &dl.attempts)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return dl, false
+		}
+		if err != nil {
+			t.Fatalf("failed to read dead letter of %s: %v", id, err)
+		}
+		return dl, true
+	}
+	update := func(id string, failure error) {
+		t.Helper()
+		client.FailOn("UpdateDataRetention", failure)
+		_, err := dataExtSvc.UpdateDataRetentionViaAPI(ctx, client, testDataExtension(id, "1"))
+		if (err != nil) != (failure != nil) {
+			t.Fatalf("UpdateDataRetentionViaAPI(%s) error = %v, want failure %v", id, err, failure)
+		}
+	}
+
+	rejected := &httpclient.StatusError{StatusCode: http.StatusBadRequest, Body: []byte(`{"message":"Retention not allowed on this data extension"}`)}
+	update("de-rejected", rejected)
+	update("de-rejected", rejected)
+	dl, ok := readDeadLetter("de-rejected")
+	if !ok {
+		t.Fatal("permanently rejected update has no dead letter")
+	}
+	if !dl.statusCode.Valid || dl.statusCode.Int32 != http.StatusBadRequest {
+		t.Errorf("status_code = %+v, want 400", dl.statusCode)
+	}
+	if !strings.Contains(dl.lastError, "Retention not allowed") {
+		t.Errorf("last_error = %q, want the API's message", dl.lastError)
+	}
+	if dl.attempts != 2 {
+		t.Errorf("attempt_count = %d, want 2", dl.attempts)
+	}
+
+	// Server errors and rate limiting may pass on a later run
+	update("de-unavailable", &httpclient.StatusError{StatusCode: http.StatusServiceUnavailable})
+	update("de-unavailable", &httpclient.StatusError{StatusCode: http.StatusTooManyRequests})
+	if _, ok := readDeadLetter("de-unavailable"); ok {
+		t.Error("transient failures were recorded as a dead letter")
+	}
+
+	// A later success clears the dead letter
+	update("de-rejected", nil)
+	if _, ok := readDeadLetter("de-rejected"); ok {
+		t.Error("dead letter kept after the update succeeded")
+	}
+}
+
+func TestSaveDataExtensionUpserts(t *testing.T) {
+	db := postgrestest.New(t)
+	saveTestFolders(t, db, testFolder("1", ""), testFolder("2", ""))
+	dataExtSvc := NewDataExtensionService(db, zap.NewNop())
+	ctx := context.Background()
+
+	de := testDataExtension("de-1", "1")
+	de.RowCount = 10
+	if err := dataExtSvc.SaveDataExtension(ctx, de); err != nil {
+		t.Fatalf("SaveDataExtension() insert error = %v", err)
+	}
+
+	updated := testDataExtension("de-1", "2")
+	updated.Name = "Renamed"
+	updated.RowCount = 20
+	if err := dataExtSvc.SaveDataExtension(ctx, updated); err != nil {
+		t.Fatalf("SaveDataExtension() update error = %v", err)
+	}
+
+	if n := countRows(t, db, "SELECT COUNT(*) FROM data_extensions"); n != 1 {
+		t.Fatalf("%d data extensions stored, want the update to replace the insert", n)
+	}
+	var (
+		name       string
+		categoryID string
+		rowCount   int64
+	)
+	err := db.Pool().QueryRow(ctx, "SELECT name, category_id, row_count FROM data_extensions WHERE id = $1", "de-1").
+		Scan(&name, &categoryID, &rowCount)
+	if err != nil {
+		t.Fatalf("failed to read data extension: %v", err)
+	}
+	if name != "Renamed" || categoryID != "2" || rowCount != 20 {
+		t.Errorf("stored (%q, %q, %d), want (%q, %q, 20)", name, categoryID, rowCount, "Renamed", "2")
+	}
+}
+
+func TestListLargeDataExtensions(t *testing.T) {
+	db := postgrestest.New(t)
+	saveTestFolders(t, db, testFolder("1", ""))
+	dataExtSvc := NewDataExtensionService(db, zap.NewNop())
+	ctx := context.Background()
+
+	for id, rows := range map[string]int{"de-small": 50, "de-edge": 100, "de-big": 5000, "de-mid": 300, "de-deleted": 9000} {
+		de := testDataExtension(id, "1")
+		de.RowCount = rows
+		if err := dataExtSvc.SaveDataExtension(ctx, de); err != nil {
+			t.Fatalf("SaveDataExtension(%s) error = %v", id, err)
+		}
+	}
+	if _, err := db.Pool().Exec(ctx, "UPDATE data_extensions SET deleted_at = NOW() WHERE id = 'de-deleted'"); err != nil {
+		t.Fatalf("failed to soft-delete: %v", err)
+	}
+
+	large, err := dataExtSvc.ListLargeDataExtensions(ctx, 100)
+	if err != nil {
+		t.Fatalf("ListLargeDataExtensions() error = %v", err)
+	}
+	// The threshold itself is excluded, as are soft-deleted rows
+	var got []string
+	for _, de := range large {
+		got = append(got, de.ID)
+	}
+	if want := []string{"de-big", "de-mid"}; !slices.Equal(got, want) {
+		t.Errorf("ListLargeDataExtensions(100) = %v, want %v", got, want)
+	}
+}
+
+func TestGetFolderPath(t *testing.T) {
+	db := postgrestest.New(t)
+	saveTestFolders(t, db, testFolder("1", ""), testFolder("2", "1"), testFolder("3", "2"), testFolder("4", "1"))
+	dataExtSvc := NewDataExtensionService(db, zap.NewNop())
+	ctx := context.Background()
+
+	tests := []struct {
+		folderID string
+		want     string
+	}{
+		{"1", "Folder 1"},
+		{"3", "Folder 1 / Folder 2 / Folder 3"},
+		{"4", "Folder 1 / Folder 4"},
+	}
+	for _, tt := range tests {
+		got, err := dataExtSvc.GetFolderPath(ctx, tt.folderID)
+		if err != nil {
+			t.Fatalf("GetFolderPath(%s) error = %v", tt.folderID, err)
+		}
+		if got != tt.want {
+			t.Errorf("GetFolderPath(%s) = %q, want %q", tt.folderID, got, tt.want)
+		}
+	}
+
+	if _, err := dataExtSvc.GetFolderPath(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "not stored") {
+		t.Errorf("GetFolderPath(missing) error = %v, want the folder not stored", err)
+	}
+
+	// Point the top-level folder at its grandchild to close a cycle
+	if _, err := db.Pool().Exec(ctx, "UPDATE folders SET parent_id = '3' WHERE id = '1'"); err != nil {
+		t.Fatalf("failed to create a cycle: %v", err)
+	}
+	if _, err := dataExtSvc.GetFolderPath(ctx, "3"); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("GetFolderPath(3) error = %v, want the cycle detected", err)
+	}
+}
+
+// pageSizeClient is a fake client that records the page and page size of each data extension listing
+type pageSizeClient struct {
+	*fake.Client
+	mu    sync.Mutex
+	calls [][2]int
+}
+
+func (c *pageSizeClient) GetDataExtensionsCtx(ctx context.Context, folderID string, page, pageSize int) (*sfmce.DataExtensionsResponse, error) {
+	c.mu.Lock()
+	c.calls = append(c.calls, [2]int{page, pageSize})
+	c.mu.Unlock()
+	return c.Client.GetDataExtensionsCtx(ctx, folderID, page, pageSize)
+}
+
+func TestGetDataExtensionsPageSize(t *testing.T) {
+	def := sfmce.DefaultDataExtensionPageSize
+	tests := []struct {
+		name      string
+		total     int
+		pageSize  int
+		wantCalls [][2]int
+	}{
+		{"partial last page", 25, 10, [][2]int{{1, 10}, {2, 10}, {3, 10}}},
+		{"exact pages", 20, 10, [][2]int{{1, 10}, {2, 10}, {3, 10}}},
+		{"single page", 5, 500, [][2]int{{1, 500}}},
+		{"empty folder", 0, 10, [][2]int{{1, 10}}},
+		{"default", 5, 0, [][2]int{{1, def}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &pageSizeClient{Client: fake.NewClient()}
+			for i := range tt.total {
+				client.AddDataExtension(testDataExtension(strconv.Itoa(i), "1"))
+			}
+			svc := NewDataExtensionService(nil, zap.NewNop())
+			svc.SetPageSize(tt.pageSize)
+
+			got, err := svc.GetDataExtensions(context.Background(), client, "1")
+			if err != nil {
+				t.Fatalf("GetDataExtensions() error = %v", err)
+			}
+			if len(got) != tt.total {
+				t.Errorf("got %d data extensions, want %d", len(got), tt.total)
+			}
+			if !reflect.DeepEqual(client.calls, tt.wantCalls) {
+				t.Errorf("requested (page, size) %v, want %v", client.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSyncConfigPageSizeReachesClient(t *testing.T) {
+	client := &pageSizeClient{Client: fake.NewClient()}
+	cfg := DefaultSyncConfig()
+	cfg.DataExtensionPageSize = 250
+	dataExtSvc := NewDataExtensionService(nil, zap.NewNop())
+	NewSyncServiceWithConfig(client, dataExtSvc, NewFolderService(nil, zap.NewNop()), nil, cfg, zap.NewNop())
+
+	if _, err := dataExtSvc.GetDataExtensions(context.Background(), client, "1"); err != nil {
+		t.Fatalf("GetDataExtensions() error = %v", err)
+	}
+	if want := [][2]int{{1, 250}}; !reflect.DeepEqual(client.calls, want) {
+		t.Errorf("requested (page, size) %v, want %v", client.calls, want)
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/natserract/sf/pkg/paging"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+)
+
+// WriteDataExtensionsCSV writes data extensions as CSV, one row each with its id,
+// name, key, row count, modified date (RFC 3339) and retention period
+func WriteDataExtensionsCSV(w io.Writer, dataExtensions []sfmce.DataExtension) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "name", "key", "rowCount", "modifiedDate", "retentionPeriod"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, de := range dataExtensions {
+		modified := ""
+		if !de.ModifiedDate.IsZero() {
+			modified = de.ModifiedDate.Format(time.RFC3339)
+		}
+		record := []string{
+			de.ID,
+			de.Name,
+			de.Key,
+			strconv.Itoa(de.RowCount),
+			modified,
+			retentionPeriod(de.DataRetentionProperties),
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV row for data extension %s: %w", de.ID, err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// retentionPeriod describes a retention period, e.g. "3 months". It is empty when
+// the data extension has no retention settings or no period.
+func retentionPeriod(p *sfmce.DataRetentionProperties) string {
+	if p == nil || p.DataRetentionPeriodLength == 0 {
+		return ""
+	}
+	unit, ok := sfmce.RetentionUnitNames[p.DataRetentionPeriodUnitOfMeasure]
+	if !ok {
+		unit = fmt.Sprintf("(unit %d)", p.DataRetentionPeriodUnitOfMeasure)
+	}
+	return fmt.Sprintf("%d %s", p.DataRetentionPeriodLength, unit)
+}
+
+// FetchAllDataExtensions calls GetDataExtensions for each folder ID with
+// pagination, pageSize items per page, and returns one slice.
+// When objectTypes is non-nil, only data extensions of those types are kept.
+// Shared data extensions are listed under several folders; each ID is kept once,
+// as its most recently modified occurrence.
+func FetchAllDataExtensions(client sfmce.SalesforceClient, folderIDs []string, pageSize int, objectTypes map[string]bool, logger *zap.Logger) ([]sfmce.DataExtension, error) {
+	var all []sfmce.DataExtension
+	index := make(map[string]int) // position in all, by data extension ID
+	duplicates := 0
+	for _, folderID := range folderIDs {
+		items, err := paging.PageUntil(pageSize, func(page int) ([]sfmce.DataExtension, error) {
+			resp, err := client.GetDataExtensions(folderID, page, pageSize)
+			if err != nil {
+				return nil, fmt.Errorf("GetDataExtensions folder=%s page=%d: %w", folderID, page, err)
+			}
+			return resp.Items, nil
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, de := range items {
+			if sfmce.IsInRecycleBin(de) {
+				continue
+			}
+			if !de.HasObjectType(objectTypes) {
+				continue
+			}
+			if i, ok := index[de.ID]; ok {
+				duplicates++
+				if de.ModifiedDate.After(all[i].ModifiedDate.Time) {
+					all[i] = de
+				}
+				continue
+			}
+			index[de.ID] = len(all)
+			all = append(all, de)
+		}
+	}
+	if duplicates > 0 {
+		logger.Info("Dropped data extensions listed under more than one folder", zap.Int("duplicates", duplicates))
+	}
+	return all, nil
+}
+
+// Sort fields accepted by DataExtensionOrder
+const (
+	SortRowCount     = "rowCount"
+	SortFieldCount   = "fieldCount"
+	SortModifiedDate = "modifiedDate"
+	SortName         = "name"
+)
+
+// Sort orders accepted by DataExtensionOrder
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// DataExtensionOrder returns the comparison that ranks data extensions by the
+// given sort field and order. Names compare case-insensitively.
+func DataExtensionOrder(sortBy, order string) (func(a, b sfmce.DataExtension) int, error) {
+	var compare func(a, b sfmce.DataExtension) int
+	switch sortBy {
+	case SortRowCount:
+		compare = func(a, b sfmce.DataExtension) int { return cmp.Compare(a.RowCount, b.RowCount) }
+	case SortFieldCount:
+		compare = func(a, b sfmce.DataExtension) int { return cmp.Compare(a.FieldCount, b.FieldCount) }
+	case SortModifiedDate:
+		compare = func(a, b sfmce.DataExtension) int { return a.ModifiedDate.Compare(b.ModifiedDate.Time) }
+	case SortName:
+		compare = func(a, b sfmce.DataExtension) int {
+			return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		}
+	default:
+		return nil, fmt.Errorf("unknown sort field %q", sortBy)
+	}
+
+	switch order {
+	case OrderAsc:
+		return compare, nil
+	case OrderDesc:
+		return func(a, b sfmce.DataExtension) int { return compare(b, a) }, nil
+	default:
+		return nil, fmt.Errorf("unknown sort order %q", order)
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+	"go.uber.org/zap"
+)
+
+func TestWriteDataExtensionsCSV(t *testing.T) {
+	withPeriod := testDataExtension("de-1", "1")
+	withPeriod.Name = "Orders, 2024"
+	withPeriod.RowCount = 1200
+	withPeriod.DataRetentionProperties = &sfmce.DataRetentionProperties{
+		DataRetentionPeriodLength:        3,
+		DataRetentionPeriodUnitOfMeasure: sfmce.RetentionUnitMonths,
+	}
+	unknownUnit := testDataExtension("de-2", "1")
+	unknownUnit.DataRetentionProperties = &sfmce.DataRetentionProperties{
+		DataRetentionPeriodLength:        7,
+		DataRetentionPeriodUnitOfMeasure: 9,
+	}
+	noRetention := testDataExtension("de-3", "1")
+	noRetention.ModifiedDate = sfmce.APITime{}
+
+	var buf strings.Builder
+	if err := WriteDataExtensionsCSV(&buf, []sfmce.DataExtension{withPeriod, unknownUnit, noRetention}); err != nil {
+		t.Fatalf("WriteDataExtensionsCSV() error = %v", err)
+	}
+
+	// A name with a comma is quoted; a missing date or period leaves the column empty
+	want := "id,name,key,rowCount,modifiedDate,retentionPeriod\n" +
+		"de-1,\"Orders, 2024\",key-de-1,1200,2025-01-15T12:00:00Z,3 months\n" +
+		"de-2,DE de-2,key-de-2,0,2025-01-15T12:00:00Z,7 (unit 9)\n" +
+		"de-3,DE de-3,key-de-3,0,,\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteDataExtensionsCSV() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteDataExtensionsCSVEmpty(t *testing.T) {
+	var buf strings.Builder
+	if err := WriteDataExtensionsCSV(&buf, nil); err != nil {
+		t.Fatalf("WriteDataExtensionsCSV() error = %v", err)
+	}
+	if want := "id,name,key,rowCount,modifiedDate,retentionPeriod\n"; buf.String() != want {
+		t.Errorf("WriteDataExtensionsCSV() = %q, want only the header", buf.String())
+	}
+}
+
+func TestDataExtensionOrder(t *testing.T) {
+	dataExtensions := func() []sfmce.DataExtension {
+		a := testDataExtension("a", "1")
+		a.Name, a.RowCount, a.FieldCount = "beta", 10, 3
+		a.ModifiedDate = sfmce.APITime{Time: testTime.Add(2 * time.Hour)}
+		b := testDataExtension("b", "1")
+		b.Name, b.RowCount, b.FieldCount = "Alpha", 30, 1
+		b.ModifiedDate = sfmce.APITime{Time: testTime}
+		c := testDataExtension("c", "1")
+		c.Name, c.RowCount, c.FieldCount = "gamma", 20, 2
+		c.ModifiedDate = sfmce.APITime{Time: testTime.Add(time.Hour)}
+		return []sfmce.DataExtension{a, b, c}
+	}
+
+	tests := []struct {
+		sortBy string
+		order  string
+		want   []string
+	}{
+		{SortRowCount, OrderAsc, []string{"a", "c", "b"}},
+		{SortRowCount, OrderDesc, []string{"b", "c", "a"}},
+		{SortFieldCount, OrderAsc, []string{"b", "c", "a"}},
+		{SortFieldCount, OrderDesc, []string{"a", "c", "b"}},
+		{SortModifiedDate, OrderAsc, []string{"b", "c", "a"}},
+		{SortModifiedDate, OrderDesc, []string{"a", "c", "b"}},
+		{SortName, OrderAsc, []string{"b", "a", "c"}},
+		{SortName, OrderDesc, []string{"c", "a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sortBy+" "+tt.order, func(t *testing.T) {
+			compare, err := DataExtensionOrder(tt.sortBy, tt.order)
+			if err != nil {
+				t.Fatalf("DataExtensionOrder() error = %v", err)
+			}
+			sorted := dataExtensions()
+			slices.SortStableFunc(sorted, compare)
+			var got []string
+			for _, de := range sorted {
+				got = append(got, de.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sorted %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataExtensionOrderInvalid(t *testing.T) {
+	for _, tt := range []struct{ sortBy, order string }{
+		{"size", OrderAsc},
+		{SortName, "up"},
+	} {
+		if _, err := DataExtensionOrder(tt.sortBy, tt.order); err == nil {
+			t.Errorf("DataExtensionOrder(%q, %q) error = nil, want an error", tt.sortBy, tt.order)
+		}
+	}
+}
+
+// overlapClient is a fake client whose folder listings are given per folder, so the
+// same data extension can be listed under several folders
+type overlapClient struct {
+	*fake.Client
+	listings map[string][]sfmce.DataExtension
+}
+
+func (c *overlapClient) GetDataExtensions(folderID string, page, pageSize int) (*sfmce.DataExtensionsResponse, error) {
+	items := c.listings[folderID]
+	start := min((page-1)*pageSize, len(items))
+	end := min(start+pageSize, len(items))
+	return &sfmce.DataExtensionsResponse{Count: len(items), Page: page, PageSize: pageSize, Items: items[start:end]}, nil
+}
+
+func TestFetchAllDataExtensionsDeduplicates(t *testing.T) {
+	shared := testDataExtension("de-shared", "1")
+	sharedNewer := shared
+	sharedNewer.ModifiedDate = sfmce.APITime{Time: testTime.Add(time.Hour)}
+	sharedNewer.RowCount = 99
+	recycled := "/Data Extensions/Old"
+	inBin := testDataExtension("de-bin", "2")
+	inBin.CategoryFullPathForRecycleBin = &recycled
+
+	client := &overlapClient{
+		Client: fake.NewClient(),
+		listings: map[string][]sfmce.DataExtension{
+			"1": {testDataExtension("de-1", "1"), shared, testDataExtension("de-2", "1")},
+			"2": {sharedNewer, testDataExtension("de-3", "2"), inBin},
+			"3": {shared, testDataExtension("de-1", "1")},
+		},
+	}
+
+	// A page size of 2 makes the listings span pages
+	all, err := FetchAllDataExtensions(client, []string{"1", "2", "3"}, 2, nil, zap.NewNop())
+	if err != nil {
+		t.Fatalf("FetchAllDataExtensions() error = %v", err)
+	}
+
+	var ids []string
+	for _, de := range all {
+		ids = append(ids, de.ID)
+		if de.ID == "de-shared" && de.RowCount != 99 {
+			t.Errorf("de-shared kept with %d rows, want the most recently modified listing", de.RowCount)
+		}
+	}
+	if want := []string{"de-1", "de-shared", "de-2", "de-3"}; !slices.Equal(ids, want) {
+		t.Errorf("FetchAllDataExtensions() IDs = %v, want each once in first-seen order %v", ids, want)
+	}
+}
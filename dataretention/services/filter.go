@@ -0,0 +1,115 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+)
+
+// FolderFilter limits a sync to part of the folder tree. A folder that is filtered
+// out is skipped together with its subtree, before any of its data extensions are
+// fetched. The zero value lets every folder through.
+type FolderFilter struct {
+	// Include, when set, keeps only folders whose name, or an ancestor's name, matches it
+	Include *regexp.Regexp
+	// Exclude skips folders whose name matches it, along with their subtrees
+	Exclude *regexp.Regexp
+	// Types, when set, keeps only folders of these types (e.g. "dataextension")
+	Types []string
+	// RootIDs, when set, keeps only these folders and their subtrees
+	RootIDs []string
+}
+
+// IsZero reports whether the filter lets every folder through
+func (f FolderFilter) IsZero() bool {
+	return f.Include == nil && f.Exclude == nil && len(f.Types) == 0 && len(f.RootIDs) == 0
+}
+
+// loadFolderFilter reads the folder filter from SYNC_INCLUDE_FOLDERS and
+// SYNC_EXCLUDE_FOLDERS (name regexps), SYNC_FOLDER_TYPES and SYNC_ROOT_FOLDER_IDS
+// (comma-separated lists)
+func loadFolderFilter() (FolderFilter, error) {
+	var filter FolderFilter
+	var err error
+	if v := os.Getenv("SYNC_INCLUDE_FOLDERS"); v != "" {
+		if filter.Include, err = regexp.Compile(v); err != nil {
+			return FolderFilter{}, fmt.Errorf("SYNC_INCLUDE_FOLDERS must be a regular expression: %w", err)
+		}
+	}
+	if v := os.Getenv("SYNC_EXCLUDE_FOLDERS"); v != "" {
+		if filter.Exclude, err = regexp.Compile(v); err != nil {
+			return FolderFilter{}, fmt.Errorf("SYNC_EXCLUDE_FOLDERS must be a regular expression: %w", err)
+		}
+	}
+	filter.Types = splitList(os.Getenv("SYNC_FOLDER_TYPES"))
+	filter.RootIDs = splitList(os.Getenv("SYNC_ROOT_FOLDER_IDS"))
+	return filter, nil
+}
+
+// splitList splits a comma-separated list, dropping blank entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// admitsListed reports whether a listed folder passes the filter. Its ancestors are
+// looked up in folderMap, the folders of the same listing by ID.
+func (f FolderFilter) admitsListed(folder sfmce.Folder, folderMap map[string]sfmce.Folder) bool {
+	if !f.admitsChild(folder) {
+		return false
+	}
+
+	included := f.Include == nil || f.Include.MatchString(folder.Name)
+	rooted := len(f.RootIDs) == 0 || slices.Contains(f.RootIDs, folder.ID)
+	seen := map[string]bool{folder.ID: true}
+	for parentID := sfmce.NormalizeParentID(folder.ParentID); parentID != sfmce.RootParentID && !seen[parentID]; {
+		seen[parentID] = true
+		parent, ok := folderMap[parentID]
+		if !ok {
+			// The ancestor wasn't listed, so its name is unknown; only its ID can match
+			rooted = rooted || slices.Contains(f.RootIDs, parentID)
+			break
+		}
+		if f.Exclude != nil && f.Exclude.MatchString(parent.Name) {
+			return false
+		}
+		included = included || f.Include.MatchString(parent.Name)
+		rooted = rooted || slices.Contains(f.RootIDs, parent.ID)
+		parentID = sfmce.NormalizeParentID(parent.ParentID)
+	}
+	return included && rooted
+}
+
+// admitsChild reports whether a subfolder of an admitted folder passes the filter;
+// its ancestors already satisfy Include and RootIDs
+func (f FolderFilter) admitsChild(folder sfmce.Folder) bool {
+	if f.Exclude != nil && f.Exclude.MatchString(folder.Name) {
+		return false
+	}
+	return len(f.Types) == 0 || slices.ContainsFunc(f.Types, func(t string) bool {
+		return strings.EqualFold(t, folder.Type)
+	})
+}
+
+// filterListed returns the listed folders that pass the filter
+func (f FolderFilter) filterListed(folders []sfmce.Folder, folderMap map[string]sfmce.Folder) []sfmce.Folder {
+	if f.IsZero() {
+		return folders
+	}
+	var admitted []sfmce.Folder
+	for _, folder := range folders {
+		if f.admitsListed(folder, folderMap) {
+			admitted = append(admitted, folder)
+		}
+	}
+	return admitted
+}
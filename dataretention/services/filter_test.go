@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+)
+
+// mixedFolders returns a folder tree with a retention subtree holding an archive,
+// another top-level folder and a shared folder:
+//
+//	1 Retention
+//	  2 Campaigns
+//	  3 Archive
+//	    4 Deep
+//	5 Other
+//	6 Shared (shared_data)
+func mixedFolders() []sfmce.Folder {
+	named := func(id, parentID, name string) sfmce.Folder {
+		folder := testFolder(id, parentID)
+		folder.Name = name
+		return folder
+	}
+	shared := named("6", "", "Shared")
+	shared.Type = "shared_data"
+	return []sfmce.Folder{
+		named("1", "", "Retention"),
+		named("2", "1", "Campaigns"),
+		named("3", "1", "Archive"),
+		named("4", "3", "Deep"),
+		named("5", "", "Other"),
+		shared,
+	}
+}
+
+// folderIDs returns the sorted IDs of folders
+func folderIDs(folders []sfmce.Folder) []string {
+	ids := make([]string, len(folders))
+	for i, folder := range folders {
+		ids[i] = folder.ID
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestFolderFilterListed(t *testing.T) {
+	folders := mixedFolders()
+	folderMap := make(map[string]sfmce.Folder)
+	for _, folder := range folders {
+		folderMap[folder.ID] = folder
+	}
+
+	tests := []struct {
+		name   string
+		filter FolderFilter
+		want   []string
+	}{
+		{"zero", FolderFilter{}, []string{"1", "2", "3", "4", "5", "6"}},
+		{"include with descendants", FolderFilter{Include: regexp.MustCompile("^Retention")}, []string{"1", "2", "3", "4"}},
+		{"exclude with descendants", FolderFilter{Exclude: regexp.MustCompile("(?i)archive")}, []string{"1", "2", "5", "6"}},
+		{"types", FolderFilter{Types: []string{"DataExtension"}}, []string{"1", "2", "3", "4", "5"}},
+		{"root IDs", FolderFilter{RootIDs: []string{"3", "6"}}, []string{"3", "4", "6"}},
+		{"combined", FolderFilter{Include: regexp.MustCompile("^Retention"), Exclude: regexp.MustCompile("Archive")}, []string{"1", "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := folderIDs(tt.filter.filterListed(folders, folderMap)); !slices.Equal(got, tt.want) {
+				t.Errorf("filterListed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFolderFilterUnlistedRoot(t *testing.T) {
+	// The root folder itself wasn't listed, so only its ID is known
+	child := testFolder("7", "99")
+	filter := FolderFilter{RootIDs: []string{"99"}}
+	if !filter.admitsListed(child, map[string]sfmce.Folder{}) {
+		t.Error("folder under an unlisted root was filtered out")
+	}
+	filter.RootIDs = []string{"98"}
+	if filter.admitsListed(child, map[string]sfmce.Folder{}) {
+		t.Error("folder under another root was let through")
+	}
+}
+
+func TestLoadFolderFilter(t *testing.T) {
+	t.Setenv("SYNC_INCLUDE_FOLDERS", "^Retention")
+	t.Setenv("SYNC_EXCLUDE_FOLDERS", "(?i)archive")
+	t.Setenv("SYNC_FOLDER_TYPES", "dataextension, shared_data,")
+	t.Setenv("SYNC_ROOT_FOLDER_IDS", "1234")
+
+	filter, err := loadFolderFilter()
+	if err != nil {
+		t.Fatalf("loadFolderFilter() error = %v", err)
+	}
+	if filter.Include.String() != "^Retention" || filter.Exclude.String() != "(?i)archive" {
+		t.Errorf("regexps = %v, %v", filter.Include, filter.Exclude)
+	}
+	if want := []string{"dataextension", "shared_data"}; !slices.Equal(filter.Types, want) {
+		t.Errorf("Types = %v, want %v", filter.Types, want)
+	}
+	if want := []string{"1234"}; !slices.Equal(filter.RootIDs, want) {
+		t.Errorf("RootIDs = %v, want %v", filter.RootIDs, want)
+	}
+
+	t.Setenv("SYNC_EXCLUDE_FOLDERS", "(")
+	if _, err := loadFolderFilter(); err == nil || !strings.Contains(err.Error(), "SYNC_EXCLUDE_FOLDERS") {
+		t.Errorf("loadFolderFilter() error = %v, want the invalid regexp", err)
+	}
+}
+
+func TestSyncAllFolderFilter(t *testing.T) {
+	client := &listingClient{Client: fake.NewClient()}
+	folders := mixedFolders()
+	client.AddFolder(folders...)
+	for _, folder := range folders {
+		client.AddDataExtension(testDataExtension("de-"+folder.ID, folder.ID))
+	}
+	cfg := DefaultSyncConfig()
+	cfg.FolderFilter = FolderFilter{Include: regexp.MustCompile("^Retention"), Exclude: regexp.MustCompile("(?i)archive")}
+	svc, db := newTestSync(t, client, cfg)
+
+	if _, err := svc.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll() error = %v", err)
+	}
+
+	listed := slices.Compact(slices.Sorted(slices.Values(client.listed)))
+	if want := []string{"1", "2"}; !slices.Equal(listed, want) {
+		t.Errorf("listed data extensions of folders %v, want %v", listed, want)
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM data_extensions"); n != 2 {
+		t.Errorf("saved %d data extensions, want 2", n)
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"flag"
+
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+)
+
+// RetentionFlags holds the retention settings given on the command line
+type RetentionFlags struct {
+	PeriodLength  int
+	PeriodUnit    string
+	RowBased      bool
+	DeleteAtEnd   bool
+	ResetOnImport bool
+}
+
+// RegisterRetentionFlags defines the retention flags on fs, defaulting to the sync's
+// default policy
+func RegisterRetentionFlags(fs *flag.FlagSet) *RetentionFlags {
+	defaults := DefaultRetentionPolicy()
+	f := &RetentionFlags{}
+	fs.IntVar(&f.PeriodLength, "period-length", defaults.DataRetentionPeriodLength, "retention period length")
+	fs.StringVar(&f.PeriodUnit, "period-unit", sfmce.RetentionUnitNames[defaults.DataRetentionPeriodUnitOfMeasure], "retention period unit: days, weeks, months or years")
+	fs.BoolVar(&f.RowBased, "row-based", defaults.IsRowBasedRetention, "delete individual records as they age out")
+	fs.BoolVar(&f.DeleteAtEnd, "delete-at-end", defaults.IsDeleteAtEndOfRetentionPeriod, "delete the data extension at the end of the period")
+	fs.BoolVar(&f.ResetOnImport, "reset-on-import", defaults.IsResetRetentionPeriodOnImport, "reset the retention period on import")
+	return f
+}
+
+// Properties builds the retention settings from the flags and validates them
+func (f *RetentionFlags) Properties() (*sfmce.DataRetentionProperties, error) {
+	unit, err := sfmce.ParseRetentionUnit(f.PeriodUnit)
+	if err != nil {
+		return nil, err
+	}
+	retention := &sfmce.DataRetentionProperties{
+		DataRetentionPeriodLength:        f.PeriodLength,
+		DataRetentionPeriodUnitOfMeasure: unit,
+		IsRowBasedRetention:              f.RowBased,
+		IsDeleteAtEndOfRetentionPeriod:   f.DeleteAtEnd,
+		IsResetRetentionPeriodOnImport:   f.ResetOnImport,
+	}
+	if err := retention.Validate(); err != nil {
+		return nil, err
+	}
+	return retention, nil
+}
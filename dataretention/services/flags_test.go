@@ -0,0 +1,92 @@
+package services
+
+import (
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+)
+
+func TestRetentionFlagsProperties(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    *sfmce.DataRetentionProperties
+		wantErr string
+	}{
+		{
+			name: "defaults to the default policy",
+			want: DefaultRetentionPolicy(),
+		},
+		{
+			name: "row-based in days",
+			args: []string{"-period-length", "30", "-period-unit", "days"},
+			want: &sfmce.DataRetentionProperties{
+				DataRetentionPeriodLength:        30,
+				DataRetentionPeriodUnitOfMeasure: sfmce.RetentionUnitDays,
+				IsRowBasedRetention:              true,
+			},
+		},
+		{
+			name: "whole data extension in weeks",
+			args: []string{"-period-length", "2", "-period-unit", "weeks", "-row-based=false", "-delete-at-end", "-reset-on-import"},
+			want: &sfmce.DataRetentionProperties{
+				DataRetentionPeriodLength:        2,
+				DataRetentionPeriodUnitOfMeasure: sfmce.RetentionUnitWeeks,
+				IsDeleteAtEndOfRetentionPeriod:   true,
+				IsResetRetentionPeriodOnImport:   true,
+			},
+		},
+		{
+			name: "singular unit in any case",
+			args: []string{"-period-length", "1", "-period-unit", "Year"},
+			want: &sfmce.DataRetentionProperties{
+				DataRetentionPeriodLength:        1,
+				DataRetentionPeriodUnitOfMeasure: sfmce.RetentionUnitYears,
+				IsRowBasedRetention:              true,
+			},
+		},
+		{
+			name:    "unknown unit",
+			args:    []string{"-period-unit", "fortnights"},
+			wantErr: "unknown retention unit",
+		},
+		{
+			name:    "unit without a period",
+			args:    []string{"-period-length", "0"},
+			wantErr: "without a period length",
+		},
+		{
+			name:    "row-based deleting at the end",
+			args:    []string{"-delete-at-end"},
+			wantErr: "cannot delete the data extension",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("update_retention", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			f := RegisterRetentionFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			got, err := f.Properties()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Properties() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Properties() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Properties() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/natserract/sf/dataretention/schema/postgres"
@@ -16,9 +18,10 @@ import (
 
 // FolderService handles folder persistence operations
 type FolderService struct {
-	queries *gen.Queries
-	db      *postgres.DB
-	logger  *zap.Logger
+	queries     *gen.Queries
+	db          *postgres.DB
+	logger      *zap.Logger
+	incremental bool
 }
 
 // NewFolderService creates a new folder service
@@ -30,11 +33,59 @@ func NewFolderService(db *postgres.DB, logger *zap.Logger) *FolderService {
 	}
 }
 
+// SetIncremental makes SaveFolder skip folders whose stored last-updated time is already up to date
+func (f *FolderService) SetIncremental(enabled bool) {
+	f.incremental = enabled
+}
+
+// IsUnchanged reports whether the stored copy of the folder is at least as recent
+// as the API's last-updated time. Folders that aren't stored yet, or carry no
+// last-updated time, count as changed.
+func (f *FolderService) IsUnchanged(ctx context.Context, folder sfmce.Folder) (bool, error) {
+	return f.isUnchanged(ctx, f.db.Pool(), folder)
+}
+
+func (f *FolderService) isUnchanged(ctx context.Context, db gen.DBTX, folder sfmce.Folder) (bool, error) {
+	if folder.LastUpdated.IsZero() {
+		return false, nil
+	}
+
+	stored, err := f.queries.GetFolderLastUpdated(ctx, db, folder.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get stored last updated time for folder %s: %w", folder.ID, err)
+	}
+
+	return stored.Valid && !folder.LastUpdated.After(stored.Time), nil
+}
+
 // SaveFolder saves or updates a folder in the database
+// In incremental mode, folders that haven't changed since they were stored are skipped
+// A lost database connection is re-established and the save retried (see postgres.DB.WithRetry)
 func (f *FolderService) SaveFolder(ctx context.Context, folder sfmce.Folder) error {
+	return f.db.WithRetry(ctx, func(ctx context.Context) error {
+		return f.saveFolder(ctx, f.db.Pool(), folder)
+	})
+}
+
+// saveFolder saves a folder through db, which is either the pool or a transaction
+func (f *FolderService) saveFolder(ctx context.Context, db gen.DBTX, folder sfmce.Folder) error {
+	if f.incremental {
+		unchanged, err := f.isUnchanged(ctx, db, folder)
+		if err != nil {
+			return err
+		}
+		if unchanged {
+			f.logger.Debug("Skipping unchanged folder", zap.String("folder_id", folder.ID))
+			return nil
+		}
+	}
+
 	lastUpdated := pgtype.Timestamptz{Time: folder.LastUpdated, Valid: !folder.LastUpdated.IsZero()}
 	// Treat "0" as empty/invalid parentId (it's a sentinel value meaning "no parent")
-	parentIDValid := folder.ParentID != "" && folder.ParentID != "0"
+	parentIDValid := !folder.IsRoot()
 	parentID := pgtype.Text{String: folder.ParentID, Valid: parentIDValid}
 	description := pgtype.Text{String: folder.Description, Valid: folder.Description != ""}
 	iconType := pgtype.Text{String: folder.IconType, Valid: folder.IconType != ""}
@@ -43,14 +94,17 @@ func (f *FolderService) SaveFolder(ctx context.Context, folder sfmce.Folder) err
 		ID:          folder.ID,
 		Type:        folder.Type,
 		LastUpdated: lastUpdated,
-		CreatedBy:   int32(folder.CreatedBy),
+		CreatedBy:   int64(folder.CreatedBy),
 		ParentID:    parentID,
 		Name:        folder.Name,
 		Description: description,
 		IconType:    iconType,
 	}
 
-	_, err := f.queries.CreateFolder(ctx, f.db.Pool(), params)
+	err := withSavepoint(ctx, db, func(db gen.DBTX) error {
+		_, err := f.queries.CreateFolder(ctx, db, params)
+		return err
+	})
 	if err != nil {
 		// Check if it's a unique constraint violation (record already exists)
 		if isUniqueConstraintViolation(err) {
@@ -63,7 +117,7 @@ func (f *FolderService) SaveFolder(ctx context.Context, folder sfmce.Folder) err
 				Description: description,
 				IconType:    iconType,
 			}
-			_, updateErr := f.queries.UpdateFolder(ctx, f.db.Pool(), updateParams)
+			_, updateErr := f.queries.UpdateFolder(ctx, db, updateParams)
 			if updateErr != nil {
 				f.logger.Error("Failed to update folder",
 					zap.String("folder_id", folder.ID),
@@ -85,8 +139,17 @@ func (f *FolderService) SaveFolder(ctx context.Context, folder sfmce.Folder) err
 	return nil
 }
 
-// SaveFoldersBatch saves multiple folders in a transaction
+// SaveFoldersBatch saves multiple folders in a single transaction.
+// If any of them fails, none are committed. Parents must come before their children.
+// A lost database connection is re-established and the whole transaction retried.
 func (f *FolderService) SaveFoldersBatch(ctx context.Context, folders []sfmce.Folder) error {
+	return f.db.WithRetry(ctx, func(ctx context.Context) error {
+		return f.saveFoldersBatch(ctx, folders)
+	})
+}
+
+// saveFoldersBatch implements SaveFoldersBatch for a single attempt
+func (f *FolderService) saveFoldersBatch(ctx context.Context, folders []sfmce.Folder) error {
 	tx, err := f.db.Pool().Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -94,7 +157,7 @@ func (f *FolderService) SaveFoldersBatch(ctx context.Context, folders []sfmce.Fo
 	defer tx.Rollback(ctx)
 
 	for _, folder := range folders {
-		if err := f.SaveFolder(ctx, folder); err != nil {
+		if err := f.saveFolder(ctx, tx, folder); err != nil {
 			return fmt.Errorf("failed to save folder in batch: %w", err)
 		}
 	}
@@ -122,7 +185,7 @@ func (f *FolderService) SaveFoldersInOrder(ctx context.Context, folders []sfmce.
 			}
 
 			// Check if parent exists (either in saved map or in folderMap)
-			if folder.ParentID != "" && folder.ParentID != "0" {
+			if !folder.IsRoot() {
 				// Check if parent is in our folder list and not yet saved
 				if _, parentInList := folderMap[folder.ParentID]; parentInList && !saved[folder.ParentID] {
 					allSaved = false
@@ -206,3 +269,101 @@ func isForeignKeyViolation(err error) bool {
 	return strings.Contains(errStr, "foreign key") ||
 		strings.Contains(errStr, "violates foreign key constraint")
 }
+
+// ListEmptyFolders walks the folder tree and returns the folders that directly
+// contain no data extensions. Subfolders are not taken into account, so a folder
+// holding only non-empty subfolders is still reported. Nothing is deleted.
+func (f *FolderService) ListEmptyFolders(ctx context.Context, client sfmce.SalesforceClient) ([]sfmce.Folder, error) {
+	foldersResp, err := client.GetFoldersCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch folders: %w", err)
+	}
+
+	var emptyFolders []sfmce.Folder
+	visited := make(map[string]bool)
+	queue := append([]sfmce.Folder(nil), foldersResp.Entry...)
+
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		folder := queue[0]
+		queue = queue[1:]
+		if visited[folder.ID] {
+			continue
+		}
+		visited[folder.ID] = true
+
+		// A single-item page is enough to know whether the folder holds anything
+		deResp, err := client.GetDataExtensionsCtx(ctx, folder.ID, 1, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch data extensions for folder %s: %w", folder.ID, err)
+		}
+		if deResp.Count == 0 && len(deResp.Items) == 0 {
+			emptyFolders = append(emptyFolders, folder)
+		}
+
+		subfoldersResp, err := client.GetSubFoldersCtx(ctx, folder.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch subfolders for folder %s: %w", folder.ID, err)
+		}
+		queue = append(queue, subfoldersResp.Entry...)
+	}
+
+	f.logger.Info("Listed empty folders",
+		zap.Int("folders_checked", len(visited)),
+		zap.Int("empty_folders", len(emptyFolders)))
+
+	return emptyFolders, nil
+}
+
+// ReconcileDeleted marks the stored folders missing from seenIDs as deleted and
+// clears the mark on stored folders that are in it again. seenIDs must be the
+// complete set of folders that exist upstream.
+func (f *FolderService) ReconcileDeleted(ctx context.Context, seenIDs []string) (deleted, restored int64, err error) {
+	restored, err = f.queries.RestoreFoldersSeen(ctx, f.db.Pool(), seenIDs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to restore seen folders: %w", err)
+	}
+	deleted, err = f.queries.SoftDeleteFoldersNotSeen(ctx, f.db.Pool(), seenIDs)
+	if err != nil {
+		return 0, restored, fmt.Errorf("failed to soft-delete missing folders: %w", err)
+	}
+	return deleted, restored, nil
+}
+
+// FolderPathSeparator joins folder names in the paths built by GetFolderPath
+const FolderPathSeparator = " / "
+
+// GetFolderPath returns the human-readable path of a stored folder, from its top-level
+// ancestor down to the folder itself (e.g. "Data Extensions / Campaigns / 2024").
+// The chain is walked through the stored folder rows, so ancestors must be synced first.
+func (f *FolderService) GetFolderPath(ctx context.Context, folderID string) (string, error) {
+	var names []string
+	visited := make(map[string]bool)
+
+	for id := folderID; id != sfmce.RootParentID; {
+		if visited[id] {
+			return "", fmt.Errorf("folder cycle detected at %s while resolving path of folder %s", id, folderID)
+		}
+		visited[id] = true
+
+		folder, err := f.queries.GetFolderByID(ctx, f.db.Pool(), id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return "", fmt.Errorf("folder %s in path of folder %s is not stored", id, folderID)
+			}
+			return "", fmt.Errorf("failed to get folder %s: %w", id, err)
+		}
+		names = append(names, folder.Name)
+
+		id = sfmce.RootParentID
+		if folder.ParentID.Valid {
+			id = sfmce.NormalizeParentID(folder.ParentID.String)
+		}
+	}
+
+	slices.Reverse(names)
+	return strings.Join(names, FolderPathSeparator), nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+	"go.uber.org/zap"
+)
+
+func TestListEmptyFolders(t *testing.T) {
+	client := fake.NewClient()
+	// 1 ─┬─ 2 ── 3
+	//    └─ 4
+	client.AddFolder(testFolder("1", "0"), testFolder("2", "1"), testFolder("3", "2"), testFolder("4", "1"))
+	client.AddDataExtension(testDataExtension("de-1", "1"), testDataExtension("de-3", "3"))
+
+	empty, err := NewFolderService(nil, zap.NewNop()).ListEmptyFolders(context.Background(), client)
+	if err != nil {
+		t.Fatalf("ListEmptyFolders() error = %v", err)
+	}
+
+	var ids []string
+	for _, folder := range empty {
+		ids = append(ids, folder.ID)
+	}
+	slices.Sort(ids)
+	// Folder 2 only holds a non-empty subfolder, which doesn't count
+	if want := []string{"2", "4"}; !slices.Equal(ids, want) {
+		t.Errorf("ListEmptyFolders() = %v, want %v", ids, want)
+	}
+	if updates := client.RetentionUpdates(); len(updates) != 0 {
+		t.Errorf("ListEmptyFolders() made %d retention updates, want none", len(updates))
+	}
+}
+
+func TestListEmptyFoldersError(t *testing.T) {
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", "0"))
+	client.FailOn("GetDataExtensions", errors.New("listing failed"))
+
+	if _, err := NewFolderService(nil, zap.NewNop()).ListEmptyFolders(context.Background(), client); err == nil {
+		t.Fatal("ListEmptyFolders() error = nil, want the listing failure")
+	}
+}
+
+func TestFolderIsUnchanged(t *testing.T) {
+	db := postgrestest.New(t)
+	saveTestFolders(t, db, testFolder("1", ""))
+	folderSvc := NewFolderService(db, zap.NewNop())
+
+	updated := testFolder("1", "")
+	updated.LastUpdated = testTime.Add(time.Minute)
+
+	tests := []struct {
+		name   string
+		folder sfmce.Folder
+		want   bool
+	}{
+		{"same last-updated time", testFolder("1", ""), true},
+		{"updated since", updated, false},
+		{"not stored", testFolder("2", ""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := folderSvc.IsUnchanged(context.Background(), tt.folder)
+			if err != nil {
+				t.Fatalf("IsUnchanged() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsUnchanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveFoldersBatchRollsBack(t *testing.T) {
+	db := postgrestest.New(t)
+	folderSvc := NewFolderService(db, zap.NewNop())
+
+	// The name column holds at most 500 characters, so the third save fails
+	tooLong := testFolder("3", "1")
+	tooLong.Name = strings.Repeat("x", 501)
+	batch := []sfmce.Folder{testFolder("1", ""), testFolder("2", "1"), tooLong, testFolder("4", "1")}
+
+	if err := folderSvc.SaveFoldersBatch(context.Background(), batch); err == nil {
+		t.Fatal("SaveFoldersBatch() error = nil, want the third save to fail")
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM folders"); n != 0 {
+		t.Errorf("%d folders committed, want the batch rolled back", n)
+	}
+
+	if err := folderSvc.SaveFoldersBatch(context.Background(), batch[:2]); err != nil {
+		t.Fatalf("SaveFoldersBatch() error = %v", err)
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM folders"); n != 2 {
+		t.Errorf("%d folders committed, want both of the batch", n)
+	}
+}
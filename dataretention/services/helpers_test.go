@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+)
+
+// testTime is the last-updated and modified time of the test folders and data extensions
+var testTime = time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+
+// newTestSync returns a sync service over client backed by a fresh test database.
+// A nil cfg uses the default configuration.
+func newTestSync(t *testing.T, client sfmce.SalesforceClient, cfg *SyncConfig) (*SyncService, *postgres.DB) {
+	t.Helper()
+	db := postgrestest.New(t)
+	if cfg == nil {
+		cfg = DefaultSyncConfig()
+	}
+	logger := zap.NewNop()
+	svc := NewSyncServiceWithConfig(client, NewDataExtensionService(db, logger), NewFolderService(db, logger), db, cfg, logger)
+	return svc, db
+}
+
+// testFolder returns a data extension folder; an empty parentID makes it top-level
+func testFolder(id, parentID string) sfmce.Folder {
+	return sfmce.Folder{
+		ID:          id,
+		Type:        "dataextension",
+		LastUpdated: testTime,
+		ParentID:    parentID,
+		Name:        "Folder " + id,
+	}
+}
+
+// testDataExtension returns a data extension in the folder with the given ID
+func testDataExtension(id, folderID string) sfmce.DataExtension {
+	categoryID, err := strconv.Atoi(folderID)
+	if err != nil {
+		panic("test folder IDs must be numeric: " + folderID)
+	}
+	return sfmce.DataExtension{
+		ID:           id,
+		Name:         "DE " + id,
+		Key:          "key-" + id,
+		CategoryID:   categoryID,
+		CreatedDate:  sfmce.APITime{Time: testTime},
+		ModifiedDate: sfmce.APITime{Time: testTime},
+	}
+}
+
+// countRows runs a COUNT(*) query and returns the count
+func countRows(t *testing.T, db *postgres.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := db.Pool().QueryRow(context.Background(), query, args...).Scan(&n); err != nil {
+		t.Fatalf("failed to count rows with %q: %v", query, err)
+	}
+	return n
+}
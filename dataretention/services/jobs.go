@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/schema/postgres/gen"
+	"go.uber.org/zap"
+)
+
+// jobTracking records whether the sync_jobs table turned out to be missing in the
+// current run. It is shared by the copies withLogger makes of a SyncService.
+type jobTracking struct {
+	missing atomic.Bool
+}
+
+// reset re-enables job tracking at the start of a run, in case the schema was migrated
+func (t *jobTracking) reset() {
+	t.missing.Store(false)
+}
+
+// jobsEnabled reports whether sync jobs are recorded in this run
+func (s *SyncService) jobsEnabled() bool {
+	return !s.jobs.missing.Load()
+}
+
+// disableJobsIfMissing turns job tracking off for the rest of the run when err says
+// the sync_jobs table doesn't exist, warning once, and reports whether it did. The
+// sync goes on without jobs, so the schema only has to be migrated for retries and
+// the job history.
+func (s *SyncService) disableJobsIfMissing(err error) bool {
+	if !postgres.IsUndefinedTable(err) {
+		return false
+	}
+	if s.jobs.missing.CompareAndSwap(false, true) {
+		s.logger.Warn("The sync_jobs table is missing, so sync jobs aren't recorded in this run; run the migrations to track them",
+			zap.Error(err))
+	}
+	return true
+}
+
+// ListSyncJobs returns the most recent sync jobs, newest first, at most limit of them
+func (s *SyncService) ListSyncJobs(ctx context.Context, limit int) ([]*gen.SyncJobs, error) {
+	jobs, err := s.queries.ListAllSyncJobs(ctx, s.db.Pool(), int32(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sync jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// ReapStaleJobs marks the sync jobs still running after olderThan as failed. Such jobs
+// were left behind by a process that crashed or was killed, and would otherwise stay
+// running forever. It returns the number of jobs reaped.
+func (s *SyncService) ReapStaleJobs(ctx context.Context, olderThan time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-olderThan)
+	reaped, err := s.queries.FailStaleSyncJobs(ctx, s.db.Pool(), gen.FailStaleSyncJobsParams{
+		ErrorMessage: pgtype.Text{
+			String: fmt.Sprintf("abandoned: still running after %s, the sync process likely exited before finishing it", olderThan),
+			Valid:  true,
+		},
+		StartedAt: pgtype.Timestamptz{Time: cutoff, Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to reap stale sync jobs: %w", err)
+	}
+	if reaped > 0 {
+		s.logger.Warn("Marked stale running sync jobs as failed",
+			zap.Int64("jobs", reaped),
+			zap.Duration("older_than", olderThan))
+	}
+	return reaped, nil
+}
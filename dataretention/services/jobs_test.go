@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// seedJobAt stores a sync job with the given status, created and started at the given time
+func seedJobAt(t *testing.T, db *postgres.DB, status string, at time.Time) string {
+	t.Helper()
+	var id string
+	err := db.Pool().QueryRow(context.Background(),
+		`INSERT INTO sync_jobs (job_type, status, started_at, created_at)
+		VALUES ('data_retention_update', $1, $2, $2) RETURNING id::text`, status, at).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to seed sync job: %v", err)
+	}
+	return id
+}
+
+func TestListSyncJobs(t *testing.T) {
+	svc, db := newTestSync(t, fake.NewClient(), nil)
+	now := time.Now()
+	oldest := seedJobAt(t, db, "completed", now.Add(-3*time.Hour))
+	middle := seedJobAt(t, db, "failed", now.Add(-2*time.Hour))
+	newest := seedJobAt(t, db, "running", now.Add(-time.Hour))
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{name: "all newest first", limit: 10, want: []string{newest, middle, oldest}},
+		{name: "limited", limit: 2, want: []string{newest, middle}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := svc.ListSyncJobs(context.Background(), tt.limit)
+			if err != nil {
+				t.Fatalf("ListSyncJobs: %v", err)
+			}
+			if len(jobs) != len(tt.want) {
+				t.Fatalf("got %d jobs, want %d", len(jobs), len(tt.want))
+			}
+			for i, job := range jobs {
+				if got := job.ID.String(); got != tt.want[i] {
+					t.Errorf("job %d: got %s, want %s", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReapStaleJobs(t *testing.T) {
+	svc, db := newTestSync(t, fake.NewClient(), nil)
+	now := time.Now()
+	stale := seedJobAt(t, db, "running", now.Add(-3*time.Hour))
+	fresh := seedJobAt(t, db, "running", now.Add(-10*time.Minute))
+	finished := seedJobAt(t, db, "completed", now.Add(-5*time.Hour))
+
+	reaped, err := svc.ReapStaleJobs(context.Background(), time.Hour)
+	if err != nil {
+		t.Fatalf("ReapStaleJobs: %v", err)
+	}
+	if reaped != 1 {
+		t.Errorf("reaped %d jobs, want 1", reaped)
+	}
+
+	want := map[string]string{stale: "failed", fresh: "running", finished: "completed"}
+	for id, status := range want {
+		var got string
+		var errorMessage *string
+		err := db.Pool().QueryRow(context.Background(),
+			"SELECT status, error_message FROM sync_jobs WHERE id = $1", id).Scan(&got, &errorMessage)
+		if err != nil {
+			t.Fatalf("failed to read job %s: %v", id, err)
+		}
+		if got != status {
+			t.Errorf("job %s: got status %q, want %q", id, got, status)
+		}
+		if id == stale && errorMessage == nil {
+			t.Errorf("reaped job %s has no error message", id)
+		}
+	}
+}
+
+func TestSyncAllWithoutSyncJobsTable(t *testing.T) {
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""), testFolder("2", "1"), testFolder("3", ""))
+	client.AddDataExtension(testDataExtension("de-1", "1"), testDataExtension("de-2", "2"), testDataExtension("de-3", "3"))
+
+	// A schema that predates the sync_jobs migration
+	db := postgrestest.New(t)
+	ctx := context.Background()
+	if _, err := db.Pool().Exec(ctx, "DROP TABLE sync_jobs"); err != nil {
+		t.Fatalf("failed to drop sync_jobs: %v", err)
+	}
+
+	core, logs := observer.New(zapcore.WarnLevel)
+	logger := zap.New(core)
+	svc := NewSyncServiceWithConfig(client, NewDataExtensionService(db, logger), NewFolderService(db, logger), db, DefaultSyncConfig(), logger)
+
+	metrics, err := svc.SyncAll(ctx)
+	if err != nil {
+		t.Fatalf("SyncAll() error = %v", err)
+	}
+	if metrics.DataExtensionsSucceeded != 3 || metrics.DataExtensionsFailed != 0 {
+		t.Errorf("data extensions = %d succeeded, %d failed, want 3 and 0",
+			metrics.DataExtensionsSucceeded, metrics.DataExtensionsFailed)
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM data_extensions"); n != 3 {
+		t.Errorf("saved %d data extensions, want 3", n)
+	}
+
+	if n := logs.FilterMessageSnippet("sync_jobs table is missing").Len(); n != 1 {
+		t.Errorf("warned about the missing table %d times, want once", n)
+	}
+	// Nothing else is logged for the failing job queries
+	undefined := 0
+	for _, entry := range logs.All() {
+		if postgres.IsUndefinedTable(errorField(entry)) {
+			undefined++
+		}
+	}
+	if undefined != 1 {
+		t.Errorf("logged %d entries with the missing table error, want only the warning", undefined)
+	}
+}
+
+// errorField returns the error logged with entry, if any
+func errorField(entry observer.LoggedEntry) error {
+	for _, field := range entry.Context {
+		if err, ok := field.Interface.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// reconcileDeleted soft-deletes the stored folders and data extensions the run
+// didn't see, and restores the ones that reappeared. It does nothing unless the
+// run crawled everything unfiltered, since a partial crawl would mark live rows as deleted.
+func (s *SyncService) reconcileDeleted(ctx context.Context, metrics *SyncMetrics) {
+	if !metrics.CrawlComplete() {
+		s.logger.Warn("Sync was incomplete, skipping soft-delete of missing rows",
+			zap.String("run_id", metrics.RunID.String()))
+		return
+	}
+
+	if !s.config.FolderFilter.IsZero() {
+		s.logger.Warn("Sync was limited by a folder filter, skipping soft-delete of missing rows",
+			zap.String("run_id", metrics.RunID.String()))
+		return
+	}
+
+	folderIDs, dataExtensionIDs := metrics.seenIDs()
+	if len(folderIDs) == 0 {
+		// An empty listing is far more likely an API problem than an empty account
+		s.logger.Warn("No folders seen, skipping soft-delete of missing rows",
+			zap.String("run_id", metrics.RunID.String()))
+		return
+	}
+
+	deleted, restored, err := s.folderSvc.ReconcileDeleted(ctx, folderIDs)
+	if err != nil {
+		s.logger.Error("Failed to reconcile deleted folders", zap.Error(err))
+		return
+	}
+	s.logger.Info("Reconciled deleted folders",
+		zap.Int("seen", len(folderIDs)),
+		zap.Int64("soft_deleted", deleted),
+		zap.Int64("restored", restored))
+
+	deleted, restored, err = s.dataExtSvc.ReconcileDeleted(ctx, dataExtensionIDs)
+	if err != nil {
+		s.logger.Error("Failed to reconcile deleted data extensions", zap.Error(err))
+		return
+	}
+	s.logger.Info("Reconciled deleted data extensions",
+		zap.Int("seen", len(dataExtensionIDs)),
+		zap.Int64("soft_deleted", deleted),
+		zap.Int64("restored", restored))
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+	"go.uber.org/zap"
+)
+
+// softDeleted returns the IDs of the rows of table marked as deleted
+func softDeleted(t *testing.T, db *postgres.DB, table string) []string {
+	t.Helper()
+	rows, err := db.Pool().Query(context.Background(), "SELECT id FROM "+table+" WHERE deleted_at IS NOT NULL ORDER BY id")
+	if err != nil {
+		t.Fatalf("failed to query deleted %s: %v", table, err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("failed to scan deleted %s: %v", table, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// reconcileClient returns a fake account holding folders 1 and 2 with a data
+// extension each, or only folder 1 when withFolder2 is false
+func reconcileClient(withFolder2 bool) *fake.Client {
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""))
+	client.AddDataExtension(testDataExtension("de-1", "1"))
+	if withFolder2 {
+		client.AddFolder(testFolder("2", ""))
+		client.AddDataExtension(testDataExtension("de-2", "2"))
+	}
+	return client
+}
+
+func TestFolderReconcileDeleted(t *testing.T) {
+	_, db := newTestSync(t, fake.NewClient(), nil)
+	saveTestFolders(t, db, testFolder("1", ""), testFolder("2", ""), testFolder("3", ""))
+	folderSvc := NewFolderService(db, zap.NewNop())
+	ctx := context.Background()
+
+	deleted, restored, err := folderSvc.ReconcileDeleted(ctx, []string{"1", "2"})
+	if err != nil {
+		t.Fatalf("ReconcileDeleted() error = %v", err)
+	}
+	if deleted != 1 || restored != 0 {
+		t.Errorf("ReconcileDeleted() = %d deleted, %d restored, want 1 and 0", deleted, restored)
+	}
+	if ids := softDeleted(t, db, "folders"); len(ids) != 1 || ids[0] != "3" {
+		t.Errorf("deleted folders = %v, want only the unseen 3", ids)
+	}
+
+	// A folder that shows up again is restored
+	deleted, restored, err = folderSvc.ReconcileDeleted(ctx, []string{"1", "2", "3"})
+	if err != nil {
+		t.Fatalf("second ReconcileDeleted() error = %v", err)
+	}
+	if deleted != 0 || restored != 1 {
+		t.Errorf("second ReconcileDeleted() = %d deleted, %d restored, want 0 and 1", deleted, restored)
+	}
+	if ids := softDeleted(t, db, "folders"); len(ids) != 0 {
+		t.Errorf("deleted folders = %v, want none", ids)
+	}
+}
+
+func TestSyncAllSoftDeletesMissing(t *testing.T) {
+	cfg := DefaultSyncConfig()
+	cfg.SoftDeleteMissing = true
+	first, db := newTestSync(t, reconcileClient(true), cfg)
+	if _, err := first.SyncAll(context.Background()); err != nil {
+		t.Fatalf("first SyncAll() error = %v", err)
+	}
+
+	logger := zap.NewNop()
+	resync := func(client *fake.Client) {
+		t.Helper()
+		svc := NewSyncServiceWithConfig(client, NewDataExtensionService(db, logger), NewFolderService(db, logger), db, cfg, logger)
+		if _, err := svc.SyncAll(context.Background()); err != nil {
+			t.Fatalf("SyncAll() error = %v", err)
+		}
+	}
+
+	// A crawl that couldn't list everything leaves the rows it didn't see alone
+	partial := reconcileClient(false)
+	partial.FailOn("GetSubFolders", errors.New("listing failed"))
+	resync(partial)
+	if folders, des := softDeleted(t, db, "folders"), softDeleted(t, db, "data_extensions"); len(folders)+len(des) != 0 {
+		t.Fatalf("incomplete sync deleted folders %v and data extensions %v, want none", folders, des)
+	}
+
+	// A complete one marks them deleted
+	resync(reconcileClient(false))
+	if ids := softDeleted(t, db, "folders"); len(ids) != 1 || ids[0] != "2" {
+		t.Errorf("deleted folders = %v, want the missing 2", ids)
+	}
+	if ids := softDeleted(t, db, "data_extensions"); len(ids) != 1 || ids[0] != "de-2" {
+		t.Errorf("deleted data extensions = %v, want the missing de-2", ids)
+	}
+
+	// And restores them once they reappear
+	resync(reconcileClient(true))
+	if folders, des := softDeleted(t, db, "folders"), softDeleted(t, db, "data_extensions"); len(folders)+len(des) != 0 {
+		t.Errorf("deleted folders %v and data extensions %v after they reappeared, want none", folders, des)
+	}
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// WritePerFolderReport writes the per-folder outcomes of a sync run as CSV: one row
+// per folder with its data extension and retention update counts, the number of data
+// extensions skipped for each reason in SkipReasons, the duration in milliseconds and
+// the fetch error, if any.
+func WritePerFolderReport(w io.Writer, metrics *SyncMetrics) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{
+		"folder_id",
+		"folder_name",
+		"data_extensions_succeeded",
+		"data_extensions_failed",
+		"retention_succeeded",
+		"retention_failed",
+	}
+	for _, reason := range SkipReasons {
+		header = append(header, "skipped_"+reason)
+	}
+	header = append(header, "duration_ms", "error")
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("failed to write report header: %w", err)
+	}
+
+	for _, folder := range metrics.PerFolder() {
+		record := []string{
+			folder.FolderID,
+			folder.FolderName,
+			strconv.Itoa(folder.DataExtensionsSucceeded),
+			strconv.Itoa(folder.DataExtensionsFailed),
+			strconv.Itoa(folder.RetentionSucceeded),
+			strconv.Itoa(folder.RetentionFailed),
+		}
+		for _, reason := range SkipReasons {
+			record = append(record, strconv.Itoa(folder.Skipped[reason]))
+		}
+		record = append(record, strconv.FormatInt(folder.Duration.Milliseconds(), 10), folder.Error)
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("failed to write report row for folder %s: %w", folder.FolderID, err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePerFolderReport(t *testing.T) {
+	metrics := &SyncMetrics{}
+	metrics.RecordFolder(FolderMetrics{
+		FolderID:   "2",
+		FolderName: "Campaigns, 2024",
+		Duration:   1500 * time.Millisecond,
+		Error:      "listing failed",
+	})
+	metrics.RecordFolder(FolderMetrics{
+		FolderID:                "1",
+		FolderName:              "Data Extensions",
+		DataExtensionsSucceeded: 5,
+		DataExtensionsFailed:    1,
+		RetentionSucceeded:      3,
+		RetentionFailed:         1,
+		Skipped:                 map[string]int{SkipReasonUnchanged: 2, SkipReasonRecycleBin: 1},
+		Duration:                250 * time.Millisecond,
+	})
+
+	var buf strings.Builder
+	if err := WritePerFolderReport(&buf, metrics); err != nil {
+		t.Fatalf("WritePerFolderReport() error = %v", err)
+	}
+
+	// Rows are ordered by folder ID; a name with a comma is quoted
+	want := "folder_id,folder_name,data_extensions_succeeded,data_extensions_failed,retention_succeeded,retention_failed," +
+		"skipped_unchanged,skipped_retention_matches,skipped_recycle_bin,duration_ms,error\n" +
+		"1,Data Extensions,5,1,3,1,2,0,1,250,\n" +
+		"2,\"Campaigns, 2024\",0,0,0,0,0,0,0,1500,listing failed\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WritePerFolderReport() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWritePerFolderReportEmpty(t *testing.T) {
+	var buf strings.Builder
+	if err := WritePerFolderReport(&buf, &SyncMetrics{}); err != nil {
+		t.Fatalf("WritePerFolderReport() error = %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 1 {
+		t.Errorf("report of a run without folders has %d lines, want only the header", lines)
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SyncResult is the machine-readable summary of a sync run
+type SyncResult struct {
+	RunID       uuid.UUID `json:"runId"`
+	ParentRunID string    `json:"parentRunId,omitempty"`
+	StartedAt   time.Time `json:"startedAt"`
+	FinishedAt  time.Time `json:"finishedAt"`
+	DurationMs  int64     `json:"durationMs"`
+	// CrawlComplete is false when part of the folder tree couldn't be listed
+	CrawlComplete  bool           `json:"crawlComplete"`
+	Folders        OutcomeCounts  `json:"folders"`
+	Subfolders     OutcomeCounts  `json:"subfolders"`
+	DataExtensions OutcomeCounts  `json:"dataExtensions"`
+	Total          OutcomeCounts  `json:"total"`
+	PerFolder      []FolderResult `json:"perFolder"`
+}
+
+// OutcomeCounts counts the successes and failures of one kind of operation
+type OutcomeCounts struct {
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// FolderResult is the outcome of syncing the data extensions of one folder
+type FolderResult struct {
+	FolderID       string         `json:"folderId"`
+	FolderName     string         `json:"folderName"`
+	DataExtensions OutcomeCounts  `json:"dataExtensions"`
+	Retention      OutcomeCounts  `json:"retention"`
+	Skipped        map[string]int `json:"skipped,omitempty"`
+	DurationMs     int64          `json:"durationMs"`
+	Error          string         `json:"error,omitempty"`
+	// Failures lists the data extensions that failed, with the stage and error
+	Failures []DataExtensionFailure `json:"failures,omitempty"`
+}
+
+// Result returns the summary of the run recorded in m
+func (m *SyncMetrics) Result() *SyncResult {
+	folders := m.PerFolder()
+	crawlComplete := m.CrawlComplete()
+
+	m.mu.Lock()
+	result := &SyncResult{
+		RunID:          m.RunID,
+		StartedAt:      m.StartedAt,
+		FinishedAt:     m.FinishedAt,
+		DurationMs:     m.FinishedAt.Sub(m.StartedAt).Milliseconds(),
+		CrawlComplete:  crawlComplete,
+		Folders:        OutcomeCounts{Succeeded: m.FoldersSucceeded, Failed: m.FoldersFailed},
+		Subfolders:     OutcomeCounts{Succeeded: m.SubfoldersSucceeded, Failed: m.SubfoldersFailed},
+		DataExtensions: OutcomeCounts{Succeeded: m.DataExtensionsSucceeded, Failed: m.DataExtensionsFailed},
+		Total: OutcomeCounts{
+			Succeeded: m.FoldersSucceeded + m.SubfoldersSucceeded + m.DataExtensionsSucceeded,
+			Failed:    m.FoldersFailed + m.SubfoldersFailed + m.DataExtensionsFailed,
+		},
+		PerFolder: make([]FolderResult, 0, len(folders)),
+	}
+	if m.ParentRunID != uuid.Nil {
+		result.ParentRunID = m.ParentRunID.String()
+	}
+	m.mu.Unlock()
+
+	for _, folder := range folders {
+		result.PerFolder = append(result.PerFolder, FolderResult{
+			FolderID:       folder.FolderID,
+			FolderName:     folder.FolderName,
+			DataExtensions: OutcomeCounts{Succeeded: folder.DataExtensionsSucceeded, Failed: folder.DataExtensionsFailed},
+			Retention:      OutcomeCounts{Succeeded: folder.RetentionSucceeded, Failed: folder.RetentionFailed},
+			Skipped:        folder.Skipped,
+			DurationMs:     folder.Duration.Milliseconds(),
+			Error:          folder.Error,
+			Failures:       folder.Failures,
+		})
+	}
+	return result
+}
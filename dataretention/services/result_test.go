@@ -0,0 +1,98 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSyncResultJSON(t *testing.T) {
+	metrics := &SyncMetrics{
+		RunID:      uuid.MustParse("6f1c2a9e-0b7d-4c35-9a52-3d8e1f0a4b21"),
+		StartedAt:  testTime,
+		FinishedAt: testTime.Add(90 * time.Second),
+	}
+	metrics.AddFolderSuccess("1")
+	metrics.AddSubfolderSuccess("1")
+	metrics.AddSubfolderFailure("1")
+	metrics.AddDataExtensions("1", 4, 1)
+	metrics.RecordFolder(FolderMetrics{
+		FolderID:                "1",
+		FolderName:              "Data Extensions",
+		DataExtensionsSucceeded: 4,
+		DataExtensionsFailed:    1,
+		RetentionSucceeded:      2,
+		Skipped:                 map[string]int{SkipReasonUnchanged: 3},
+		Duration:                1500 * time.Millisecond,
+	})
+
+	data, err := json.Marshal(metrics.Result())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	for key, want := range map[string]interface{}{
+		"runId":      "6f1c2a9e-0b7d-4c35-9a52-3d8e1f0a4b21",
+		"startedAt":  "2025-01-15T12:00:00Z",
+		"finishedAt": "2025-01-15T12:01:30Z",
+		"durationMs": float64(90000),
+		// The failures mean not everything was synced
+		"crawlComplete": false,
+	} {
+		if got[key] != want {
+			t.Errorf("%s = %v, want %v", key, got[key], want)
+		}
+	}
+	if _, ok := got["parentRunId"]; ok {
+		t.Error("parentRunId is set for a run without a parent")
+	}
+
+	counts := map[string]OutcomeCounts{
+		"folders":        {Succeeded: 1},
+		"subfolders":     {Succeeded: 1, Failed: 1},
+		"dataExtensions": {Succeeded: 4, Failed: 1},
+		"total":          {Succeeded: 6, Failed: 2},
+	}
+	for key, want := range counts {
+		outcome, _ := got[key].(map[string]interface{})
+		if outcome["succeeded"] != float64(want.Succeeded) || outcome["failed"] != float64(want.Failed) {
+			t.Errorf("%s = %v, want %+v", key, got[key], want)
+		}
+	}
+
+	perFolder, _ := got["perFolder"].([]interface{})
+	if len(perFolder) != 1 {
+		t.Fatalf("perFolder = %v, want one folder", got["perFolder"])
+	}
+	folder := perFolder[0].(map[string]interface{})
+	if folder["folderId"] != "1" || folder["folderName"] != "Data Extensions" || folder["durationMs"] != float64(1500) {
+		t.Errorf("perFolder[0] = %v, want folder 1 taking 1500ms", folder)
+	}
+	if skipped, _ := folder["skipped"].(map[string]interface{}); skipped[SkipReasonUnchanged] != float64(3) {
+		t.Errorf("perFolder[0].skipped = %v, want 3 unchanged", folder["skipped"])
+	}
+	if _, ok := folder["error"]; ok {
+		t.Error("perFolder[0].error is set for a folder without an error")
+	}
+}
+
+func TestSyncResultIncompleteCrawl(t *testing.T) {
+	metrics := &SyncMetrics{RunID: uuid.New(), ParentRunID: uuid.New()}
+	metrics.MarkIncomplete()
+	result := metrics.Result()
+	if result.CrawlComplete {
+		t.Error("CrawlComplete = true, want false after MarkIncomplete")
+	}
+	if result.ParentRunID != metrics.ParentRunID.String() {
+		t.Errorf("ParentRunID = %q, want %s", result.ParentRunID, metrics.ParentRunID)
+	}
+	if result.PerFolder == nil {
+		t.Error("PerFolder = nil, want an empty list so the JSON has []")
+	}
+}
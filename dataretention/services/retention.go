@@ -0,0 +1,412 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"go.uber.org/zap"
+)
+
+// DefaultRetentionPolicy returns the standard retention payload applied by the sync:
+// 1 month retention, row-based, no reset on import, no delete at end
+func DefaultRetentionPolicy() *sfmce.DataRetentionProperties {
+	return &sfmce.DataRetentionProperties{
+		DataRetentionPeriodLength:        1,
+		DataRetentionPeriodUnitOfMeasure: sfmce.RetentionUnitMonths,
+		IsDeleteAtEndOfRetentionPeriod:   false,
+		IsRowBasedRetention:              true,
+		IsResetRetentionPeriodOnImport:   false,
+	}
+}
+
+// LoadRetentionPolicy reads a retention policy from a JSON file holding the API's
+// dataRetentionProperties fields, and validates it
+func LoadRetentionPolicy(path string) (*sfmce.DataRetentionProperties, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retention policy %s: %w", path, err)
+	}
+
+	var policy sfmce.DataRetentionProperties
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse retention policy %s: %w", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid retention policy %s: %w", path, err)
+	}
+
+	return &policy, nil
+}
+
+// RetentionScope limits which data extensions a retention plan covers
+type RetentionScope struct {
+	// FolderIDs lists the folders (categories) whose data extensions, and those of
+	// their subfolders, are considered
+	FolderIDs []string
+}
+
+// RetentionChange describes a retention update that would be applied to a data extension
+type RetentionChange struct {
+	DataExtensionID   string                         `json:"dataExtensionId"`
+	DataExtensionName string                         `json:"dataExtensionName"`
+	FolderID          string                         `json:"folderId"`
+	Current           *sfmce.DataRetentionProperties `json:"current"`
+	Proposed          *sfmce.DataRetentionProperties `json:"proposed"`
+}
+
+// PlanRetentionChanges computes which data extensions in scope would change if the
+// policy were applied, without applying anything (a dry-run diff). Like the sync, it
+// walks the subtree of each folder in scope. Data extensions whose current retention
+// already matches the policy, or that are in the recycle bin, are left out of the plan.
+func (d *DataExtensionService) PlanRetentionChanges(ctx context.Context, client sfmce.SalesforceClient, policy *sfmce.DataRetentionProperties, scope RetentionScope) ([]RetentionChange, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid retention policy: %w", err)
+	}
+
+	var changes []RetentionChange
+	seen := make(map[string]bool)
+	visited := make(map[string]bool)
+	queue := slices.Clone(scope.FolderIDs)
+	unchanged := 0
+
+	for len(queue) > 0 {
+		folderID := queue[0]
+		queue = queue[1:]
+		if visited[folderID] {
+			continue
+		}
+		visited[folderID] = true
+
+		dataExtensions, err := d.GetDataExtensions(ctx, client, folderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan retention changes for folder %s: %w", folderID, err)
+		}
+
+		for _, de := range dataExtensions {
+			// Shared data extensions can be listed under several folders; deleted
+			// ones are left alone
+			if seen[de.ID] || sfmce.IsInRecycleBin(de) {
+				continue
+			}
+			seen[de.ID] = true
+
+			if de.DataRetentionProperties.Equal(policy) {
+				unchanged++
+				continue
+			}
+
+			proposed := *policy
+			changes = append(changes, RetentionChange{
+				DataExtensionID:   de.ID,
+				DataExtensionName: de.Name,
+				FolderID:          folderID,
+				Current:           de.DataRetentionProperties,
+				Proposed:          &proposed,
+			})
+		}
+
+		subfolders, err := client.GetSubFoldersCtx(ctx, folderID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch subfolders of folder %s: %w", folderID, err)
+		}
+		for _, subfolder := range subfolders.Entry {
+			queue = append(queue, subfolder.ID)
+		}
+	}
+
+	d.logger.Info("Planned retention changes",
+		zap.Int("folders", len(visited)),
+		zap.Int("changes", len(changes)),
+		zap.Int("unchanged", unchanged))
+
+	return changes, nil
+}
+
+// ErrPlanTokenMismatch is returned when applying a retention plan with a confirm token
+// that doesn't match the plan as it stands now
+var ErrPlanTokenMismatch = errors.New("confirm token does not match the retention plan")
+
+// RetentionPlan is a set of planned retention changes together with the token an
+// operator passes back to confirm they reviewed exactly these changes
+type RetentionPlan struct {
+	Policy  *sfmce.DataRetentionProperties `json:"policy"`
+	Changes []RetentionChange              `json:"changes"`
+	Token   string                         `json:"token"`
+}
+
+// Deletions returns the changes that turn on deleting the data extension at the end
+// of its retention period, which is the part of a plan that destroys data
+func (p *RetentionPlan) Deletions() []RetentionChange {
+	var deletions []RetentionChange
+	for _, change := range p.Changes {
+		alreadyDeletes := change.Current != nil && change.Current.IsDeleteAtEndOfRetentionPeriod
+		if change.Proposed.IsDeleteAtEndOfRetentionPeriod && !alreadyDeletes {
+			deletions = append(deletions, change)
+		}
+	}
+	return deletions
+}
+
+// PlanToken derives the confirm token of a plan from the policy and the current and
+// proposed retention of every data extension it changes. Any difference between the
+// reviewed plan and the one being applied yields a different token.
+func PlanToken(policy *sfmce.DataRetentionProperties, changes []RetentionChange) string {
+	type tokenChange struct {
+		ID       string                         `json:"id"`
+		Current  *sfmce.DataRetentionProperties `json:"current"`
+		Proposed *sfmce.DataRetentionProperties `json:"proposed"`
+	}
+
+	// Folder order isn't stable across runs, so hash the changes in ID order
+	sorted := make([]tokenChange, 0, len(changes))
+	for _, change := range changes {
+		sorted = append(sorted, tokenChange{ID: change.DataExtensionID, Current: change.Current, Proposed: change.Proposed})
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	// Marshaling plain structs of strings, bools and ints can't fail
+	payload, _ := json.Marshal(struct {
+		Policy  *sfmce.DataRetentionProperties `json:"policy"`
+		Changes []tokenChange                  `json:"changes"`
+	}{policy, sorted})
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// PlanRetention plans the retention changes for the policy in scope and derives the
+// token needed to apply them
+func (d *DataExtensionService) PlanRetention(ctx context.Context, client sfmce.SalesforceClient, policy *sfmce.DataRetentionProperties, scope RetentionScope) (*RetentionPlan, error) {
+	changes, err := d.PlanRetentionChanges(ctx, client, policy, scope)
+	if err != nil {
+		return nil, err
+	}
+	return &RetentionPlan{
+		Policy:  policy,
+		Changes: changes,
+		Token:   PlanToken(policy, changes),
+	}, nil
+}
+
+// ApplyRetentionPlan re-plans the policy in scope and applies the changes, but only if
+// confirmToken matches the token of the plan; otherwise it returns ErrPlanTokenMismatch
+// and changes nothing. Changes are applied like the sync's, so their status is stored
+// with the data extension and permanent failures are dead-lettered; each is also
+// logged with the plan token for the audit trail. Failed updates don't stop the rest
+// and are returned together.
+func (d *DataExtensionService) ApplyRetentionPlan(ctx context.Context, client sfmce.SalesforceClient, policy *sfmce.DataRetentionProperties, scope RetentionScope, confirmToken string) (*RetentionPlan, error) {
+	plan, err := d.PlanRetention(ctx, client, policy, scope)
+	if err != nil {
+		return nil, err
+	}
+	if confirmToken != plan.Token {
+		d.logger.Warn("Rejected retention plan with mismatched confirm token",
+			zap.String("plan_token", plan.Token),
+			zap.Int("changes", len(plan.Changes)))
+		return plan, fmt.Errorf("%w: plan the changes again and review them", ErrPlanTokenMismatch)
+	}
+
+	d.logger.Info("Applying confirmed retention plan",
+		zap.String("plan_token", plan.Token),
+		zap.String("policy", policy.String()),
+		zap.Int("changes", len(plan.Changes)),
+		zap.Int("deletions", len(plan.Deletions())))
+
+	var errs []error
+	for _, change := range plan.Changes {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		de := sfmce.DataExtension{
+			ID:                      change.DataExtensionID,
+			Name:                    change.DataExtensionName,
+			DataRetentionProperties: change.Current,
+		}
+		if _, err := d.UpdateDataRetentionPolicyViaAPI(ctx, client, de, change.Proposed); err != nil {
+			d.logger.Error("Failed to apply retention change",
+				zap.String("plan_token", plan.Token),
+				zap.String("data_extension_id", change.DataExtensionID),
+				zap.Error(err))
+			errs = append(errs, fmt.Errorf("failed to apply retention change: %w", err))
+			continue
+		}
+		d.logger.Info("Applied retention change",
+			zap.String("plan_token", plan.Token),
+			zap.String("data_extension_id", change.DataExtensionID),
+			zap.String("data_extension_name", change.DataExtensionName),
+			zap.String("folder_id", change.FolderID),
+			zap.String("previous", change.Current.String()),
+			zap.String("applied", change.Proposed.String()))
+	}
+
+	return plan, errors.Join(errs...)
+}
+
+// RetentionStatusRow counts the data extensions of a folder in one retention update status
+type RetentionStatusRow struct {
+	FolderID   string `json:"folderId"`
+	FolderName string `json:"folderName"`
+	Status     string `json:"status"`
+	Count      int64  `json:"count"`
+}
+
+// GetRetentionStatusReport counts the stored data extensions per folder by the status
+// of their last retention update (pending, succeeded, failed or skipped), for
+// compliance audits. Data extensions without a status are reported as pending.
+func (d *DataExtensionService) GetRetentionStatusReport(ctx context.Context) ([]RetentionStatusRow, error) {
+	rows, err := d.queries.GetRetentionStatusReport(ctx, d.db.Pool())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention status report: %w", err)
+	}
+
+	report := make([]RetentionStatusRow, 0, len(rows))
+	for _, row := range rows {
+		report = append(report, RetentionStatusRow{
+			FolderID:   row.FolderID,
+			FolderName: row.FolderName.String,
+			Status:     row.Status,
+			Count:      row.DataExtensionCount,
+		})
+	}
+
+	return report, nil
+}
+
+// ReadIDs reads one data extension ID per line, skipping blank lines, lines starting
+// with # and repeated IDs
+func ReadIDs(r io.Reader) ([]string, error) {
+	var ids []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		id := strings.TrimSpace(scanner.Text())
+		if id == "" || strings.HasPrefix(id, "#") || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids, scanner.Err()
+}
+
+// RetentionUpdateResult is the outcome of updating the retention of one data extension
+type RetentionUpdateResult struct {
+	DataExtensionID string
+	// Updated is false when the retention already matched, so the API wasn't called
+	Updated bool
+	Err     error
+}
+
+// UpdateDataRetentionBatch applies the policy to the data extensions with the given
+// IDs, at most concurrency at a time, using sfmce.BulkUpdate. Each data extension is
+// looked up first, so unknown IDs fail on their own and matching retention is
+// skipped. Results are returned in the order of ids; a failure doesn't stop the others.
+func (d *DataExtensionService) UpdateDataRetentionBatch(ctx context.Context, client sfmce.SalesforceClient, ids []string, policy *sfmce.DataRetentionProperties, concurrency int) []RetentionUpdateResult {
+	var mu sync.Mutex
+	updated := make(map[string]bool)
+	bulk := sfmce.BulkUpdate(ctx, ids, concurrency, func(ctx context.Context, id string) error {
+		de, err := client.GetDataExtensionCtx(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get data extension %s: %w", id, err)
+		}
+		ok, err := d.UpdateDataRetentionPolicyViaAPI(ctx, client, *de, policy)
+		mu.Lock()
+		updated[id] = ok
+		mu.Unlock()
+		return err
+	})
+
+	results := make([]RetentionUpdateResult, len(bulk.Results))
+	for i, result := range bulk.Results {
+		results[i] = RetentionUpdateResult{
+			DataExtensionID: result.DataExtensionID,
+			Updated:         updated[result.DataExtensionID],
+			Err:             result.Err,
+		}
+	}
+
+	d.logger.Info("Completed batch retention update",
+		zap.String("policy", policy.String()),
+		zap.Int("total", len(ids)),
+		zap.Int("failed", len(bulk.Failed())))
+
+	return results
+}
+
+// RetentionVerification is the retention Marketing Cloud reports for one data
+// extension, compared with the expected settings
+type RetentionVerification struct {
+	DataExtensionID   string
+	DataExtensionName string
+	Actual            *sfmce.DataRetentionProperties
+	// Mismatches names the settings that differ from the expected ones (see
+	// sfmce.DataRetentionProperties.Differences)
+	Mismatches []string
+	Err        error
+}
+
+// Verified reports whether the data extension was fetched and its retention matches
+func (v RetentionVerification) Verified() bool {
+	return v.Err == nil && len(v.Mismatches) == 0
+}
+
+// VerifyRetention fetches each data extension with the given IDs from Marketing Cloud
+// and compares its retention with expected, to catch updates the API accepted but
+// didn't apply. Results are returned in the order of ids; a failed fetch doesn't stop
+// the others. Only Marketing Cloud is read, so the database isn't used.
+func (d *DataExtensionService) VerifyRetention(ctx context.Context, client sfmce.SalesforceClient, ids []string, expected *sfmce.DataRetentionProperties) []RetentionVerification {
+	results := make([]RetentionVerification, 0, len(ids))
+	mismatched, failed := 0, 0
+	for _, id := range ids {
+		result := RetentionVerification{DataExtensionID: id}
+		if err := ctx.Err(); err != nil {
+			result.Err = err
+			results = append(results, result)
+			failed++
+			continue
+		}
+
+		de, err := client.GetDataExtensionCtx(ctx, id)
+		if err != nil {
+			result.Err = fmt.Errorf("failed to get data extension %s: %w", id, err)
+			results = append(results, result)
+			failed++
+			continue
+		}
+		result.DataExtensionName = de.Name
+		result.Actual = de.DataRetentionProperties
+		result.Mismatches = de.DataRetentionProperties.Differences(expected)
+		results = append(results, result)
+
+		if len(result.Mismatches) > 0 {
+			mismatched++
+			d.logger.Warn("Data extension retention does not match",
+				zap.String("data_extension_id", id),
+				zap.String("data_extension_name", de.Name),
+				zap.Strings("mismatches", result.Mismatches),
+				zap.String("expected", expected.String()),
+				zap.String("actual", de.DataRetentionProperties.String()))
+		}
+	}
+
+	d.logger.Info("Verified data extension retention",
+		zap.String("expected", expected.String()),
+		zap.Int("total", len(ids)),
+		zap.Int("mismatched", mismatched),
+		zap.Int("failed", failed))
+
+	return results
+}
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+	httpclient "github.com/natserract/sf/pkg/http"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+	"go.uber.org/zap"
+)
+
+// withRetention returns de with its current retention set to retention
+func withRetention(de sfmce.DataExtension, retention *sfmce.DataRetentionProperties) sfmce.DataExtension {
+	de.DataRetentionProperties = retention
+	return de
+}
+
+func TestPlanRetentionChanges(t *testing.T) {
+	policy := DefaultRetentionPolicy()
+	differing := *policy
+	differing.DataRetentionPeriodLength = 6
+	recycled := "/Data Extensions/Old"
+
+	inBin := testDataExtension("de-bin", "1")
+	inBin.CategoryFullPathForRecycleBin = &recycled
+
+	client := fake.NewClient()
+	client.AddDataExtension(
+		withRetention(testDataExtension("de-match", "1"), DefaultRetentionPolicy()),
+		withRetention(testDataExtension("de-differ", "1"), &differing),
+		testDataExtension("de-none", "1"),
+		withRetention(inBin, &differing),
+		withRetention(testDataExtension("de-other-folder", "2"), &differing),
+	)
+
+	svc := NewDataExtensionService(nil, zap.NewNop())
+	changes, err := svc.PlanRetentionChanges(context.Background(), client, policy, RetentionScope{FolderIDs: []string{"1"}})
+	if err != nil {
+		t.Fatalf("PlanRetentionChanges() error = %v", err)
+	}
+
+	var ids []string
+	for _, change := range changes {
+		ids = append(ids, change.DataExtensionID)
+		if !change.Proposed.Equal(policy) {
+			t.Errorf("change for %s proposes %s, want %s", change.DataExtensionID, change.Proposed, policy)
+		}
+		if change.FolderID != "1" {
+			t.Errorf("change for %s has folder %s, want 1", change.DataExtensionID, change.FolderID)
+		}
+	}
+	slices.Sort(ids)
+	if want := []string{"de-differ", "de-none"}; !slices.Equal(ids, want) {
+		t.Errorf("planned changes for %v, want %v", ids, want)
+	}
+	if updates := client.RetentionUpdates(); len(updates) != 0 {
+		t.Errorf("planning made %d retention updates, want none", len(updates))
+	}
+}
+
+func TestPlanRetentionChangesWalksSubfolders(t *testing.T) {
+	policy := DefaultRetentionPolicy()
+	client := fake.NewClient()
+	// 1 ── 2 ── 3, with 4 outside the scope
+	client.AddFolder(testFolder("1", ""), testFolder("2", "1"), testFolder("3", "2"), testFolder("4", ""))
+	client.AddDataExtension(
+		testDataExtension("de-1", "1"),
+		testDataExtension("de-3", "3"),
+		withRetention(testDataExtension("de-2-compliant", "2"), DefaultRetentionPolicy()),
+		testDataExtension("de-4", "4"),
+	)
+
+	svc := NewDataExtensionService(nil, zap.NewNop())
+	// Listing a subfolder in scope as well doesn't plan it twice
+	changes, err := svc.PlanRetentionChanges(context.Background(), client, policy, RetentionScope{FolderIDs: []string{"1", "3"}})
+	if err != nil {
+		t.Fatalf("PlanRetentionChanges() error = %v", err)
+	}
+
+	folders := make(map[string]string)
+	for _, change := range changes {
+		if _, dup := folders[change.DataExtensionID]; dup {
+			t.Errorf("%s planned twice", change.DataExtensionID)
+		}
+		folders[change.DataExtensionID] = change.FolderID
+	}
+	if want := map[string]string{"de-1": "1", "de-3": "3"}; !reflect.DeepEqual(folders, want) {
+		t.Errorf("planned changes by folder %v, want %v", folders, want)
+	}
+}
+
+func TestPlanRetentionChangesSubfolderError(t *testing.T) {
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""))
+	client.FailOn("GetSubFolders", errors.New("listing failed"))
+
+	svc := NewDataExtensionService(nil, zap.NewNop())
+	_, err := svc.PlanRetentionChanges(context.Background(), client, DefaultRetentionPolicy(), RetentionScope{FolderIDs: []string{"1"}})
+	if err == nil || !strings.Contains(err.Error(), "listing failed") {
+		t.Fatalf("PlanRetentionChanges() error = %v, want the subfolder listing failure", err)
+	}
+}
+
+func TestPlanRetentionChangesInvalidPolicy(t *testing.T) {
+	svc := NewDataExtensionService(nil, zap.NewNop())
+	policy := &sfmce.DataRetentionProperties{DataRetentionPeriodLength: -1, DataRetentionPeriodUnitOfMeasure: sfmce.RetentionUnitDays}
+	if _, err := svc.PlanRetentionChanges(context.Background(), fake.NewClient(), policy, RetentionScope{FolderIDs: []string{"1"}}); err == nil {
+		t.Fatal("PlanRetentionChanges() error = nil, want an invalid policy error")
+	}
+}
+
+func TestApplyRetentionPlanToken(t *testing.T) {
+	policy := DefaultRetentionPolicy()
+	differing := *policy
+	differing.DataRetentionPeriodLength = 6
+	scope := RetentionScope{FolderIDs: []string{"1"}}
+	newClient := func() *fake.Client {
+		client := fake.NewClient()
+		client.AddDataExtension(
+			withRetention(testDataExtension("de-match", "1"), DefaultRetentionPolicy()),
+			withRetention(testDataExtension("de-differ", "1"), &differing),
+			testDataExtension("de-none", "1"),
+		)
+		return client
+	}
+	svc := NewDataExtensionService(nil, zap.NewNop())
+
+	reviewed, err := svc.PlanRetention(context.Background(), newClient(), policy, scope)
+	if err != nil {
+		t.Fatalf("PlanRetention() error = %v", err)
+	}
+	if reviewed.Token == "" || len(reviewed.Changes) != 2 {
+		t.Fatalf("plan = %+v, want two changes and a token", reviewed)
+	}
+
+	// Data that changed after the review yields a different plan and so a different token
+	changed := newClient()
+	changed.AddDataExtension(testDataExtension("de-new", "1"))
+
+	tests := []struct {
+		name        string
+		client      *fake.Client
+		token       string
+		wantErr     bool
+		wantUpdates []string
+	}{
+		{"no token", newClient(), "", true, nil},
+		{"wrong token", newClient(), "not-the-token", true, nil},
+		{"plan changed since review", changed, reviewed.Token, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ApplyRetentionPlan(context.Background(), tt.client, policy, scope, tt.token)
+			if tt.wantErr != errors.Is(err, ErrPlanTokenMismatch) {
+				t.Fatalf("ApplyRetentionPlan() error = %v, want mismatch %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("ApplyRetentionPlan() error = %v", err)
+			}
+
+			var updated []string
+			for _, update := range tt.client.RetentionUpdates() {
+				updated = append(updated, update.DataExtensionID)
+				if !update.Retention.Equal(policy) {
+					t.Errorf("%s updated to %s, want %s", update.DataExtensionID, update.Retention.String(), policy)
+				}
+			}
+			slices.Sort(updated)
+			if !slices.Equal(updated, tt.wantUpdates) {
+				t.Errorf("updated %v, want %v", updated, tt.wantUpdates)
+			}
+		})
+	}
+}
+
+func TestApplyRetentionPlanRecordsStatus(t *testing.T) {
+	db := postgrestest.New(t)
+	saveTestFolders(t, db, testFolder("1", ""))
+	svc := NewDataExtensionService(db, zap.NewNop())
+	ctx := context.Background()
+
+	policy := DefaultRetentionPolicy()
+	policy.IsDeleteAtEndOfRetentionPeriod = true
+	scope := RetentionScope{FolderIDs: []string{"1"}}
+	client := fake.NewClient()
+	for _, id := range []string{"de-1", "de-2"} {
+		de := withRetention(testDataExtension(id, "1"), DefaultRetentionPolicy())
+		client.AddDataExtension(de)
+		if err := svc.SaveDataExtension(ctx, de); err != nil {
+			t.Fatalf("SaveDataExtension(%s) error = %v", id, err)
+		}
+	}
+
+	plan, err := svc.PlanRetention(ctx, client, policy, scope)
+	if err != nil {
+		t.Fatalf("PlanRetention() error = %v", err)
+	}
+	if _, err := svc.ApplyRetentionPlan(ctx, client, policy, scope, plan.Token); err != nil {
+		t.Fatalf("ApplyRetentionPlan() error = %v", err)
+	}
+
+	var updated []string
+	for _, update := range client.RetentionUpdates() {
+		updated = append(updated, update.DataExtensionID)
+	}
+	slices.Sort(updated)
+	if want := []string{"de-1", "de-2"}; !slices.Equal(updated, want) {
+		t.Errorf("updated %v, want %v", updated, want)
+	}
+	for _, id := range updated {
+		var status string
+		err := db.Pool().QueryRow(ctx, "SELECT last_api_update_status FROM data_retention_properties WHERE data_extension_id = $1", id).Scan(&status)
+		if err != nil {
+			t.Fatalf("failed to read retention status of %s: %v", id, err)
+		}
+		if status != "succeeded" {
+			t.Errorf("%s: last_api_update_status = %q, want succeeded", id, status)
+		}
+	}
+
+	// A rejected change is dead-lettered like a failed sync update
+	rejected := withRetention(testDataExtension("de-3", "1"), DefaultRetentionPolicy())
+	client.AddDataExtension(rejected)
+	if err := svc.SaveDataExtension(ctx, rejected); err != nil {
+		t.Fatalf("SaveDataExtension(de-3) error = %v", err)
+	}
+	plan, err = svc.PlanRetention(ctx, client, policy, scope)
+	if err != nil {
+		t.Fatalf("PlanRetention() error = %v", err)
+	}
+	client.FailOn("UpdateDataRetention", &httpclient.StatusError{StatusCode: http.StatusBadRequest})
+	if _, err := svc.ApplyRetentionPlan(ctx, client, policy, scope, plan.Token); err == nil {
+		t.Fatal("ApplyRetentionPlan() error = nil, want the rejected update")
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM retention_dead_letters WHERE data_extension_id = 'de-3'"); n != 1 {
+		t.Errorf("de-3 has %d dead letters, want 1", n)
+	}
+}
+
+func TestPlanTokenIgnoresChangeOrder(t *testing.T) {
+	policy := DefaultRetentionPolicy()
+	a := RetentionChange{DataExtensionID: "de-a", Proposed: policy}
+	b := RetentionChange{DataExtensionID: "de-b", Proposed: policy}
+
+	if PlanToken(policy, []RetentionChange{a, b}) != PlanToken(policy, []RetentionChange{b, a}) {
+		t.Error("PlanToken() depends on the order of the changes")
+	}
+	if PlanToken(policy, []RetentionChange{a, b}) == PlanToken(policy, []RetentionChange{a}) {
+		t.Error("PlanToken() is the same for different changes")
+	}
+}
+
+func TestGetRetentionStatusReport(t *testing.T) {
+	db := postgrestest.New(t)
+	saveTestFolders(t, db, testFolder("1", ""), testFolder("2", ""))
+	dataExtSvc := NewDataExtensionService(db, zap.NewNop())
+	ctx := context.Background()
+
+	statuses := map[string]string{
+		"de-1a": "succeeded",
+		"de-1b": "succeeded",
+		"de-1c": "failed",
+		"de-2a": "skipped",
+		"de-2b": "",
+		"de-2c": "failed",
+	}
+	for id, status := range statuses {
+		de := withRetention(testDataExtension(id, id[3:4]), DefaultRetentionPolicy())
+		if err := dataExtSvc.SaveDataExtension(ctx, de); err != nil {
+			t.Fatalf("SaveDataExtension(%s) error = %v", id, err)
+		}
+		if status == "" {
+			continue
+		}
+		if _, err := db.Pool().Exec(ctx, "UPDATE data_retention_properties SET last_api_update_status = $2 WHERE data_extension_id = $1", id, status); err != nil {
+			t.Fatalf("failed to set status of %s: %v", id, err)
+		}
+	}
+	// Deleted data extensions are left out of the report
+	if _, err := db.Pool().Exec(ctx, "UPDATE data_extensions SET deleted_at = now() WHERE id = 'de-2c'"); err != nil {
+		t.Fatalf("failed to soft-delete de-2c: %v", err)
+	}
+
+	report, err := dataExtSvc.GetRetentionStatusReport(ctx)
+	if err != nil {
+		t.Fatalf("GetRetentionStatusReport() error = %v", err)
+	}
+	want := []RetentionStatusRow{
+		{FolderID: "1", FolderName: "Folder 1", Status: "failed", Count: 1},
+		{FolderID: "1", FolderName: "Folder 1", Status: "succeeded", Count: 2},
+		{FolderID: "2", FolderName: "Folder 2", Status: "pending", Count: 1},
+		{FolderID: "2", FolderName: "Folder 2", Status: "skipped", Count: 1},
+	}
+	if !slices.Equal(report, want) {
+		t.Errorf("GetRetentionStatusReport() =\n%+v\nwant\n%+v", report, want)
+	}
+}
+
+func TestReadIDs(t *testing.T) {
+	input := "de-1\n\n  de-2  \n# a comment\nde-1\r\nde-3"
+	ids, err := ReadIDs(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadIDs() error = %v", err)
+	}
+	if want := []string{"de-1", "de-2", "de-3"}; !slices.Equal(ids, want) {
+		t.Errorf("ReadIDs() = %v, want %v", ids, want)
+	}
+}
+
+func TestUpdateDataRetentionBatchFromFile(t *testing.T) {
+	db := postgrestest.New(t)
+	saveTestFolders(t, db, testFolder("1", ""))
+	dataExtSvc := NewDataExtensionService(db, zap.NewNop())
+
+	outdated := *DefaultRetentionPolicy()
+	outdated.DataRetentionPeriodLength = 6
+	client := fake.NewClient()
+	for _, de := range []sfmce.DataExtension{
+		withRetention(testDataExtension("de-old", "1"), &outdated),
+		withRetention(testDataExtension("de-current", "1"), DefaultRetentionPolicy()),
+		withRetention(testDataExtension("de-other", "1"), &outdated),
+	} {
+		client.AddDataExtension(de)
+		if err := dataExtSvc.SaveDataExtension(context.Background(), de); err != nil {
+			t.Fatalf("SaveDataExtension(%s) error = %v", de.ID, err)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "ids.txt")
+	if err := os.WriteFile(path, []byte("de-old\nde-current\nde-missing\nde-other\n"), 0o600); err != nil {
+		t.Fatalf("failed to write IDs file: %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open IDs file: %v", err)
+	}
+	defer f.Close()
+	ids, err := ReadIDs(f)
+	if err != nil {
+		t.Fatalf("ReadIDs() error = %v", err)
+	}
+
+	results := dataExtSvc.UpdateDataRetentionBatch(context.Background(), client, ids, DefaultRetentionPolicy(), 2)
+
+	// Results keep the order of the file, and the unknown ID fails on its own
+	type outcome struct {
+		id      string
+		updated bool
+		failed  bool
+	}
+	var got []outcome
+	for _, result := range results {
+		got = append(got, outcome{result.DataExtensionID, result.Updated, result.Err != nil})
+	}
+	want := []outcome{{"de-old", true, false}, {"de-current", false, false}, {"de-missing", false, true}, {"de-other", true, false}}
+	if !slices.Equal(got, want) {
+		t.Errorf("results = %+v, want %+v", got, want)
+	}
+	if !errors.Is(results[2].Err, sfmce.ErrDataExtensionNotFound) {
+		t.Errorf("de-missing error = %v, want ErrDataExtensionNotFound", results[2].Err)
+	}
+	if n := len(client.RetentionUpdates()); n != 2 {
+		t.Errorf("%d retention updates sent, want 2", n)
+	}
+}
+
+func TestVerifyRetention(t *testing.T) {
+	policy := DefaultRetentionPolicy()
+	rejected := *policy
+	rejected.DataRetentionPeriodLength = 6
+	rejected.IsRowBasedRetention = false
+
+	client := fake.NewClient()
+	client.AddDataExtension(
+		withRetention(testDataExtension("de-applied", "1"), policy),
+		withRetention(testDataExtension("de-rejected", "1"), &rejected),
+		testDataExtension("de-none", "1"),
+	)
+	svc := NewDataExtensionService(nil, zap.NewNop())
+
+	ids := []string{"de-applied", "de-rejected", "de-none", "de-missing"}
+	results := svc.VerifyRetention(context.Background(), client, ids, policy)
+	if len(results) != len(ids) {
+		t.Fatalf("got %d results, want %d", len(results), len(ids))
+	}
+
+	tests := []struct {
+		id             string
+		wantMismatches []string
+		wantVerified   bool
+	}{
+		{"de-applied", nil, true},
+		{"de-rejected", []string{"dataRetentionPeriodLength", "isRowBasedRetention"}, false},
+		{"de-none", []string{"dataRetentionProperties"}, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			result := results[i]
+			if result.DataExtensionID != tt.id || result.DataExtensionName != "DE "+tt.id || result.Err != nil {
+				t.Fatalf("result = %+v, want %s fetched", result, tt.id)
+			}
+			if !slices.Equal(result.Mismatches, tt.wantMismatches) {
+				t.Errorf("Mismatches = %v, want %v", result.Mismatches, tt.wantMismatches)
+			}
+			if result.Verified() != tt.wantVerified {
+				t.Errorf("Verified() = %v, want %v", result.Verified(), tt.wantVerified)
+			}
+		})
+	}
+
+	missing := results[3]
+	if !errors.Is(missing.Err, sfmce.ErrDataExtensionNotFound) || missing.Verified() {
+		t.Errorf("unknown data extension result = %+v, want a not found error", missing)
+	}
+	if updates := client.RetentionUpdates(); len(updates) != 0 {
+		t.Errorf("verification made %d retention updates, want none", len(updates))
+	}
+}
+
+func TestVerifyRetentionCancelled(t *testing.T) {
+	client := fake.NewClient()
+	client.AddDataExtension(testDataExtension("de-1", "1"))
+	svc := NewDataExtensionService(nil, zap.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := svc.VerifyRetention(ctx, client, []string{"de-1", "de-2"}, DefaultRetentionPolicy())
+	for _, result := range results {
+		if !errors.Is(result.Err, context.Canceled) {
+			t.Errorf("result for %s = %v, want the context's error", result.DataExtensionID, result.Err)
+		}
+	}
+}
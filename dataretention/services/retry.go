@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sourcegraph/conc/pool"
+	"go.uber.org/zap"
+)
+
+// failedFolder identifies a folder whose sync job failed in a prior run
+type failedFolder struct {
+	ID   string
+	Name string
+}
+
+// SyncFromFolder re-syncs the data extensions of a single folder as part of the run tracked by metrics
+func (s *SyncService) SyncFromFolder(ctx context.Context, folderID, folderName string, metrics *SyncMetrics) error {
+	if _, err := s.SyncDataExtensions(ctx, folderID, folderName, metrics); err != nil {
+		metrics.AddFolderFailure(folderID)
+		return err
+	}
+	metrics.AddFolderSuccess(folderID)
+	return nil
+}
+
+// RetryFailedFolders re-syncs only the folders whose jobs failed in the given run.
+// The retry is a new run whose jobs point back to the original via parent_run_id.
+func (s *SyncService) RetryFailedFolders(ctx context.Context, runID uuid.UUID) (*SyncMetrics, error) {
+	startTime := time.Now()
+
+	folders, err := s.failedFolders(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &SyncMetrics{RunID: uuid.New(), ParentRunID: runID}
+	s.logger.Info("Retrying failed folders",
+		zap.String("run_id", metrics.RunID.String()),
+		zap.String("parent_run_id", runID.String()),
+		zap.Int("folder_count", len(folders)))
+
+	folderPool := pool.New().WithMaxGoroutines(s.config.FolderConcurrency)
+	for _, folder := range folders {
+		folder := folder // capture loop variable
+		folderPool.Go(func() {
+			if err := s.SyncFromFolder(ctx, folder.ID, folder.Name, metrics); err != nil {
+				s.logger.Warn("Retry of folder failed",
+					zap.String("folder_id", folder.ID),
+					zap.Error(err))
+			}
+		})
+	}
+	folderPool.Wait()
+
+	s.logger.Info("Completed retry of failed folders",
+		zap.String("run_id", metrics.RunID.String()),
+		zap.String("parent_run_id", runID.String()),
+		zap.Duration("duration", time.Since(startTime)),
+		zap.Int("folders_succeeded", metrics.FoldersSucceeded),
+		zap.Int("folders_failed", metrics.FoldersFailed),
+		zap.Int("data_extensions_succeeded", metrics.DataExtensionsSucceeded),
+		zap.Int("data_extensions_failed", metrics.DataExtensionsFailed))
+
+	return metrics, nil
+}
+
+// failedFolders reads the run's manifest (its sync jobs) and returns the distinct
+// folders that have at least one failed job
+func (s *SyncService) failedFolders(ctx context.Context, runID uuid.UUID) ([]failedFolder, error) {
+	jobs, err := s.queries.GetSyncJobsByRunID(ctx, s.db.Pool(), runID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sync jobs for run %s: %w", runID, err)
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no sync jobs found for run %s", runID)
+	}
+
+	var folders []failedFolder
+	seen := make(map[string]bool)
+	for _, job := range jobs {
+		if job.Status != "failed" {
+			continue
+		}
+
+		var meta struct {
+			FolderID   string `json:"folder_id"`
+			FolderName string `json:"folder_name"`
+		}
+		if err := json.Unmarshal(job.Metadata, &meta); err != nil || meta.FolderID == "" {
+			s.logger.Warn("Skipping failed sync job without folder metadata",
+				zap.String("job_id", job.ID.String()),
+				zap.Error(err))
+			continue
+		}
+		if seen[meta.FolderID] {
+			continue
+		}
+		seen[meta.FolderID] = true
+		folders = append(folders, failedFolder{ID: meta.FolderID, Name: meta.FolderName})
+	}
+
+	return folders, nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+	"go.uber.org/zap"
+)
+
+// listingClient is a fake client that records the folders whose data extensions were listed
+type listingClient struct {
+	*fake.Client
+	mu     sync.Mutex
+	listed []string
+}
+
+func (c *listingClient) GetDataExtensionsCtx(ctx context.Context, folderID string, page, pageSize int) (*sfmce.DataExtensionsResponse, error) {
+	c.mu.Lock()
+	c.listed = append(c.listed, folderID)
+	c.mu.Unlock()
+	return c.Client.GetDataExtensionsCtx(ctx, folderID, page, pageSize)
+}
+
+// seedJob stores a sync job of the run with the given status and folder metadata;
+// an empty folderID stores a job without metadata
+func seedJob(t *testing.T, db *postgres.DB, runID uuid.UUID, status, folderID string) {
+	t.Helper()
+	var metadata []byte
+	if folderID != "" {
+		metadata, _ = json.Marshal(map[string]string{
+			"folder_id":   folderID,
+			"folder_name": "Folder " + folderID,
+			"run_id":      runID.String(),
+		})
+	} else {
+		metadata, _ = json.Marshal(map[string]string{"run_id": runID.String()})
+	}
+	_, err := db.Pool().Exec(context.Background(),
+		"INSERT INTO sync_jobs (job_type, status, metadata) VALUES ('data_retention_update', $1, $2)", status, metadata)
+	if err != nil {
+		t.Fatalf("failed to seed sync job: %v", err)
+	}
+}
+
+func TestRetryFailedFolders(t *testing.T) {
+	client := &listingClient{Client: fake.NewClient()}
+	folders := []sfmce.Folder{testFolder("1", ""), testFolder("2", ""), testFolder("3", "")}
+	client.AddFolder(folders...)
+	for _, folder := range folders {
+		client.AddDataExtension(testDataExtension("de-"+folder.ID, folder.ID))
+	}
+	svc, db := newTestSync(t, client, nil)
+	ctx := context.Background()
+	folderSvc := NewFolderService(db, zap.NewNop())
+	for _, folder := range folders {
+		if err := folderSvc.SaveFolder(ctx, folder); err != nil {
+			t.Fatalf("SaveFolder() error = %v", err)
+		}
+	}
+
+	runID, otherRunID := uuid.New(), uuid.New()
+	seedJob(t, db, runID, "completed", "1")
+	seedJob(t, db, runID, "failed", "2")
+	seedJob(t, db, runID, "failed", "3")
+	seedJob(t, db, runID, "failed", "3")
+	seedJob(t, db, runID, "failed", "")
+	seedJob(t, db, otherRunID, "failed", "1")
+
+	metrics, err := svc.RetryFailedFolders(ctx, runID)
+	if err != nil {
+		t.Fatalf("RetryFailedFolders() error = %v", err)
+	}
+
+	listed := slices.Clone(client.listed)
+	slices.Sort(listed)
+	if want := []string{"2", "3"}; !slices.Equal(listed, want) {
+		t.Errorf("listed folders %v, want only the failed %v", listed, want)
+	}
+	if metrics.ParentRunID != runID || metrics.RunID == runID {
+		t.Errorf("retry run %s has parent %s, want a new run of %s", metrics.RunID, metrics.ParentRunID, runID)
+	}
+	if metrics.FoldersSucceeded != 2 || metrics.FoldersFailed != 0 {
+		t.Errorf("retried %d folders with %d failures, want 2 and 0", metrics.FoldersSucceeded, metrics.FoldersFailed)
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM sync_jobs WHERE metadata->>'parent_run_id' = $1", runID.String()); n != 2 {
+		t.Errorf("retry created %d sync jobs linked to the original run, want 2", n)
+	}
+}
+
+func TestRetryFailedFoldersUnknownRun(t *testing.T) {
+	svc, _ := newTestSync(t, fake.NewClient(), nil)
+	if _, err := svc.RetryFailedFolders(context.Background(), uuid.New()); err == nil {
+		t.Fatal("RetryFailedFolders() error = nil, want no sync jobs found")
+	}
+}
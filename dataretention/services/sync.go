@@ -3,7 +3,10 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,6 +14,7 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/natserract/sf/dataretention/schema/postgres"
 	"github.com/natserract/sf/dataretention/schema/postgres/gen"
+	"github.com/natserract/sf/pkg/logging"
 	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
 	"github.com/sourcegraph/conc/pool"
 	"go.uber.org/zap"
@@ -18,63 +22,349 @@ import (
 
 // SyncMetrics tracks the overall sync operation metrics
 type SyncMetrics struct {
+	// RunID identifies the run; it is recorded on every sync job the run creates
+	RunID uuid.UUID
+	// ParentRunID is the run this one retries, if any
+	ParentRunID uuid.UUID
+	// StartedAt and FinishedAt bound the run; FinishedAt is set once it completes
+	StartedAt               time.Time
+	FinishedAt              time.Time
 	FoldersSucceeded        int
 	FoldersFailed           int
 	SubfoldersSucceeded     int
 	SubfoldersFailed        int
 	DataExtensionsSucceeded int
 	DataExtensionsFailed    int
-	mu                      sync.Mutex
+	// visitedFolders holds the folders already synced in this run
+	visitedFolders map[string]bool
+	// seenDataExtensions holds the data extensions listed by the API in this run
+	seenDataExtensions map[string]bool
+	// knownFolders holds the folders listed by the API in this run, for progress reporting
+	knownFolders map[string]bool
+	// incomplete is set when part of the folder tree or a folder's data extensions couldn't be listed
+	incomplete bool
+	// folders holds the outcome of each folder whose data extensions were synced, by folder ID
+	folders map[string]*FolderMetrics
+	// countsByFolder holds the outcomes recorded against each folder, by folder ID
+	countsByFolder map[string]*FolderCounts
+	// folderTypes holds the type of each folder seen in this run, by folder ID
+	folderTypes map[string]string
+	// retentionByTags counts retention update outcomes per tag set
+	retentionByTags map[RetentionTags]*RetentionOutcome
+	mu              sync.Mutex
 }
 
-// AddFolderSuccess increments the folders succeeded count
-func (m *SyncMetrics) AddFolderSuccess() {
+// Reasons a data extension was skipped during a folder sync
+const (
+	// SkipReasonUnchanged means the stored copy was already up to date (incremental mode)
+	SkipReasonUnchanged = "unchanged"
+	// SkipReasonRetentionMatches means the retention already matched, so no update was sent
+	SkipReasonRetentionMatches = "retention_matches"
+	// SkipReasonRecycleBin means the data extension was deleted into the recycle bin
+	SkipReasonRecycleBin = "recycle_bin"
+)
+
+// SkipReasons lists every skip reason, in report order
+var SkipReasons = []string{SkipReasonUnchanged, SkipReasonRetentionMatches, SkipReasonRecycleBin}
+
+// FolderMetrics tracks the outcome of syncing the data extensions of one folder
+type FolderMetrics struct {
+	FolderID                string
+	FolderName              string
+	DataExtensionsSucceeded int
+	DataExtensionsFailed    int
+	RetentionSucceeded      int
+	RetentionFailed         int
+	// Skipped counts skipped data extensions by reason (see SkipReasons)
+	Skipped  map[string]int
+	Duration time.Duration
+	// Error is set when the folder's data extensions couldn't be fetched
+	Error string
+	// Failures lists the data extensions of the folder that failed to save or update
+	Failures []DataExtensionFailure
+}
+
+// Stages at which a data extension can fail during a folder sync
+const (
+	// FailureStageSave means the data extension couldn't be stored
+	FailureStageSave = "save"
+	// FailureStageRetention means the retention update via the API failed
+	FailureStageRetention = "retention"
+)
+
+// DataExtensionFailure identifies a data extension that failed during a folder sync
+type DataExtensionFailure struct {
+	DataExtensionID string `json:"dataExtensionId"`
+	FolderID        string `json:"folderId"`
+	Stage           string `json:"stage"`
+	Error           string `json:"error"`
+}
+
+// RecordFolder stores the outcome of a folder sync. A folder synced more than once
+// in a run (e.g. by a retry) keeps its latest outcome.
+func (m *SyncMetrics) RecordFolder(folder FolderMetrics) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.FoldersSucceeded++
+	if m.folders == nil {
+		m.folders = make(map[string]*FolderMetrics)
+	}
+	m.folders[folder.FolderID] = &folder
 }
 
-// AddFolderFailure increments the folders failed count
-func (m *SyncMetrics) AddFolderFailure() {
+// PerFolder returns the recorded folder outcomes ordered by folder ID
+func (m *SyncMetrics) PerFolder() []FolderMetrics {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.FoldersFailed++
+	folders := make([]FolderMetrics, 0, len(m.folders))
+	for _, folder := range m.folders {
+		folders = append(folders, *folder)
+	}
+	slices.SortFunc(folders, func(a, b FolderMetrics) int {
+		return strings.Compare(a.FolderID, b.FolderID)
+	})
+	return folders
+}
+
+// FailedDataExtensions returns the data extensions that failed in the run, ordered
+// by folder ID, so they can be re-run on their own
+func (m *SyncMetrics) FailedDataExtensions() []DataExtensionFailure {
+	var failures []DataExtensionFailure
+	for _, folder := range m.PerFolder() {
+		failures = append(failures, folder.Failures...)
+	}
+	return failures
 }
 
-// AddSubfolderSuccess increments the subfolders succeeded count
-func (m *SyncMetrics) AddSubfolderSuccess() {
+// MarkFolderVisited records that a folder is being synced in this run and reports
+// whether this is the first time it has been seen
+func (m *SyncMetrics) MarkFolderVisited(folderID string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.SubfoldersSucceeded++
+	if m.visitedFolders == nil {
+		m.visitedFolders = make(map[string]bool)
+	}
+	if m.visitedFolders[folderID] {
+		return false
+	}
+	m.visitedFolders[folderID] = true
+	return true
 }
 
-// AddSubfolderFailure increments the subfolders failed count
-func (m *SyncMetrics) AddSubfolderFailure() {
+// MarkDataExtensionsSeen records that the API listed these data extensions in this run
+func (m *SyncMetrics) MarkDataExtensionsSeen(dataExtensions []sfmce.DataExtension) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.SubfoldersFailed++
+	if m.seenDataExtensions == nil {
+		m.seenDataExtensions = make(map[string]bool)
+	}
+	for _, de := range dataExtensions {
+		m.seenDataExtensions[de.ID] = true
+	}
+}
+
+// MarkFoldersKnown records that the API listed these folders in this run
+func (m *SyncMetrics) MarkFoldersKnown(folders []sfmce.Folder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.knownFolders == nil {
+		m.knownFolders = make(map[string]bool)
+	}
+	for _, folder := range folders {
+		m.knownFolders[folder.ID] = true
+	}
+}
+
+// SyncProgress is a snapshot of how far a sync has got. The known totals grow as
+// the crawl lists more of the folder tree, so they are lower bounds until it ends.
+type SyncProgress struct {
+	// FoldersProcessed counts folders whose data extensions have been synced
+	FoldersProcessed int
+	// FoldersKnown counts the folders listed so far
+	FoldersKnown int
+	// DataExtensionsProcessed counts data extensions saved, failed or skipped as unchanged
+	DataExtensionsProcessed int
+	// DataExtensionsKnown counts the data extensions listed so far
+	DataExtensionsKnown int
+	// Succeeded and Failed are the run's totals (see TotalSucceeded and TotalFailed)
+	Succeeded int
+	Failed    int
+}
+
+// Progress returns a snapshot of the run's progress
+func (m *SyncMetrics) Progress() SyncProgress {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	unchanged := 0
+	for _, folder := range m.folders {
+		unchanged += folder.Skipped[SkipReasonUnchanged]
+	}
+	return SyncProgress{
+		FoldersProcessed:        len(m.folders),
+		FoldersKnown:            len(m.knownFolders),
+		DataExtensionsProcessed: m.DataExtensionsSucceeded + m.DataExtensionsFailed + unchanged,
+		DataExtensionsKnown:     len(m.seenDataExtensions),
+		Succeeded:               m.FoldersSucceeded + m.SubfoldersSucceeded + m.DataExtensionsSucceeded,
+		Failed:                  m.FoldersFailed + m.SubfoldersFailed + m.DataExtensionsFailed,
+	}
+}
+
+// MarkIncomplete records that the run couldn't list everything upstream, so the
+// folders and data extensions it saw are not the full set
+func (m *SyncMetrics) MarkIncomplete() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.incomplete = true
+}
+
+// CrawlComplete reports whether the run listed the whole folder tree and every
+// folder's data extensions without any failure
+func (m *SyncMetrics) CrawlComplete() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return !m.incomplete &&
+		m.FoldersFailed+m.SubfoldersFailed+m.DataExtensionsFailed == 0
+}
+
+// seenIDs returns the IDs of the folders and data extensions seen in this run
+func (m *SyncMetrics) seenIDs() (folderIDs, dataExtensionIDs []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.visitedFolders {
+		folderIDs = append(folderIDs, id)
+	}
+	for id := range m.seenDataExtensions {
+		dataExtensionIDs = append(dataExtensionIDs, id)
+	}
+	return folderIDs, dataExtensionIDs
+}
+
+// FolderCounts are the outcomes recorded against one folder. Summed over all
+// folders, they equal the run's global counts.
+type FolderCounts struct {
+	FoldersSucceeded        int
+	FoldersFailed           int
+	SubfoldersSucceeded     int
+	SubfoldersFailed        int
+	DataExtensionsSucceeded int
+	DataExtensionsFailed    int
+}
+
+// countsFor returns the counts of a folder, creating them if needed. m.mu must be held.
+func (m *SyncMetrics) countsFor(folderID string) *FolderCounts {
+	if m.countsByFolder == nil {
+		m.countsByFolder = make(map[string]*FolderCounts)
+	}
+	counts, ok := m.countsByFolder[folderID]
+	if !ok {
+		counts = &FolderCounts{}
+		m.countsByFolder[folderID] = counts
+	}
+	return counts
+}
+
+// CountsByFolder returns a snapshot of the counts recorded against each folder, by folder ID
+func (m *SyncMetrics) CountsByFolder() map[string]FolderCounts {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[string]FolderCounts, len(m.countsByFolder))
+	for folderID, counts := range m.countsByFolder {
+		snapshot[folderID] = *counts
+	}
+	return snapshot
 }
 
-// AddDataExtensionSuccess increments the data extensions succeeded count
-func (m *SyncMetrics) AddDataExtensionSuccess() {
+// AddFolderSuccess increments the folders succeeded count, for the folder and globally
+func (m *SyncMetrics) AddFolderSuccess(folderID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.DataExtensionsSucceeded++
+	m.FoldersSucceeded++
+	m.countsFor(folderID).FoldersSucceeded++
 }
 
-// AddDataExtensionFailure increments the data extensions failed count
-func (m *SyncMetrics) AddDataExtensionFailure() {
+// AddFolderFailure increments the folders failed count, for the folder and globally
+func (m *SyncMetrics) AddFolderFailure(folderID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.DataExtensionsFailed++
+	m.FoldersFailed++
+	m.countsFor(folderID).FoldersFailed++
+}
+
+// AddSubfolderSuccess increments the subfolders succeeded count, for the parent
+// folder being processed and globally
+func (m *SyncMetrics) AddSubfolderSuccess(parentID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.SubfoldersSucceeded++
+	m.countsFor(parentID).SubfoldersSucceeded++
+}
+
+// AddSubfolderFailure increments the subfolders failed count, for the parent
+// folder being processed and globally
+func (m *SyncMetrics) AddSubfolderFailure(parentID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.SubfoldersFailed++
+	m.countsFor(parentID).SubfoldersFailed++
+}
+
+// AddDataExtensionSuccess increments the data extensions succeeded count, for the
+// folder and globally
+func (m *SyncMetrics) AddDataExtensionSuccess(folderID string) {
+	m.AddDataExtensions(folderID, 1, 0)
+}
+
+// AddDataExtensionFailure increments the data extensions failed count, for the
+// folder and globally
+func (m *SyncMetrics) AddDataExtensionFailure(folderID string) {
+	m.AddDataExtensions(folderID, 0, 1)
 }
 
-// AddDataExtensions adds multiple data extension results
-func (m *SyncMetrics) AddDataExtensions(succeeded, failed int) {
+// AddDataExtensions adds multiple data extension results, for the folder and globally
+func (m *SyncMetrics) AddDataExtensions(folderID string, succeeded, failed int) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.DataExtensionsSucceeded += succeeded
 	m.DataExtensionsFailed += failed
+	counts := m.countsFor(folderID)
+	counts.DataExtensionsSucceeded += succeeded
+	counts.DataExtensionsFailed += failed
+}
+
+// Merge adds the counts of other into m
+func (m *SyncMetrics) Merge(other *SyncMetrics) {
+	other.mu.Lock()
+	foldersSucceeded, foldersFailed := other.FoldersSucceeded, other.FoldersFailed
+	subfoldersSucceeded, subfoldersFailed := other.SubfoldersSucceeded, other.SubfoldersFailed
+	dataExtensionsSucceeded, dataExtensionsFailed := other.DataExtensionsSucceeded, other.DataExtensionsFailed
+	other.mu.Unlock()
+	folders := other.PerFolder()
+	countsByFolder := other.CountsByFolder()
+
+	m.mu.Lock()
+	m.FoldersSucceeded += foldersSucceeded
+	m.FoldersFailed += foldersFailed
+	m.SubfoldersSucceeded += subfoldersSucceeded
+	m.SubfoldersFailed += subfoldersFailed
+	m.DataExtensionsSucceeded += dataExtensionsSucceeded
+	m.DataExtensionsFailed += dataExtensionsFailed
+	if len(folders) > 0 && m.folders == nil {
+		m.folders = make(map[string]*FolderMetrics)
+	}
+	for _, folder := range folders {
+		m.folders[folder.FolderID] = &folder
+	}
+	for folderID, other := range countsByFolder {
+		counts := m.countsFor(folderID)
+		counts.FoldersSucceeded += other.FoldersSucceeded
+		counts.FoldersFailed += other.FoldersFailed
+		counts.SubfoldersSucceeded += other.SubfoldersSucceeded
+		counts.SubfoldersFailed += other.SubfoldersFailed
+		counts.DataExtensionsSucceeded += other.DataExtensionsSucceeded
+		counts.DataExtensionsFailed += other.DataExtensionsFailed
+	}
+	m.mu.Unlock()
+
+	m.mergeRetentionByTags(other)
 }
 
 // TotalSucceeded returns the total number of succeeded operations
@@ -99,39 +389,171 @@ type SyncService struct {
 	folderSvc  *FolderService
 	queries    *gen.Queries
 	db         *postgres.DB
+	config     *SyncConfig
 	logger     *zap.Logger
+	// folderPaths caches resolved folder paths for the current run
+	folderPaths *folderPathCache
+	// jobs notes when the sync_jobs table is missing, to skip job tracking for the run
+	jobs *jobTracking
+	// throttle bounds API calls across the worker pools; nil unless AdaptiveConcurrency is set
+	throttle *concurrencyController
+	// progress receives progress snapshots; nil unless SetProgressFunc was called
+	progress *progressReporter
+	// lockName names the advisory lock SyncAll holds (see SetLockName)
+	lockName string
+}
+
+// ProgressFunc receives progress snapshots during a sync
+type ProgressFunc func(SyncProgress)
+
+// progressReporter serializes calls to a ProgressFunc, so the worker pools can
+// report concurrently and the snapshots still arrive in order
+type progressReporter struct {
+	mu sync.Mutex
+	fn ProgressFunc
+}
+
+// SetProgressFunc makes the sync call fn after each folder and each folder's batch
+// of data extensions. Calls never overlap, and since each snapshot is taken under
+// the same lock, counts never go down from one call to the next. fn runs on the
+// sync's worker goroutines, so it should return quickly. Passing nil disables it.
+func (s *SyncService) SetProgressFunc(fn ProgressFunc) {
+	if fn == nil {
+		s.progress = nil
+		return
+	}
+	s.progress = &progressReporter{fn: fn}
+}
+
+// reportProgress passes a snapshot of metrics to the progress func, if any
+func (s *SyncService) reportProgress(metrics *SyncMetrics) {
+	if s.progress == nil {
+		return
+	}
+	s.progress.mu.Lock()
+	defer s.progress.mu.Unlock()
+	s.progress.fn(metrics.Progress())
 }
 
-// NewSyncService creates a new sync service
+// NewSyncService creates a new sync service with the default configuration
 func NewSyncService(client sfmce.SalesforceClient, dataExtSvc *DataExtensionService, folderSvc *FolderService, db *postgres.DB, logger *zap.Logger) *SyncService {
+	return NewSyncServiceWithConfig(client, dataExtSvc, folderSvc, db, DefaultSyncConfig(), logger)
+}
+
+// NewSyncServiceWithConfig creates a new sync service with a custom configuration
+func NewSyncServiceWithConfig(client sfmce.SalesforceClient, dataExtSvc *DataExtensionService, folderSvc *FolderService, db *postgres.DB, cfg *SyncConfig, logger *zap.Logger) *SyncService {
+	// Data extensions are filtered up front in SyncDataExtensions instead, so their
+	// retention update is skipped along with the save
+	folderSvc.SetIncremental(cfg.Incremental)
+	dataExtSvc.SetPageSize(cfg.DataExtensionPageSize)
+
+	var throttle *concurrencyController
+	if cfg.AdaptiveConcurrency {
+		throttle = newConcurrencyController(cfg, logger)
+	}
+
 	return &SyncService{
-		client:     client,
-		dataExtSvc: dataExtSvc,
-		folderSvc:  folderSvc,
-		queries:    gen.New(),
-		db:         db,
-		logger:     logger,
+		client:      client,
+		dataExtSvc:  dataExtSvc,
+		folderSvc:   folderSvc,
+		queries:     gen.New(),
+		db:          db,
+		config:      cfg,
+		logger:      logger,
+		folderPaths: newFolderPathCache(),
+		jobs:        &jobTracking{},
+		throttle:    throttle,
+		lockName:    SyncLockName(""),
 	}
 }
 
+// SyncLockName returns the name of the advisory lock that keeps full syncs of an
+// account from running concurrently
+func SyncLockName(accountID string) string {
+	if accountID == "" {
+		return "dataretention-sync"
+	}
+	return "dataretention-sync:" + accountID
+}
+
+// SetLockName sets the advisory lock SyncAll holds while it runs; see SyncLockName.
+// Services syncing different accounts into one database need different names.
+func (s *SyncService) SetLockName(name string) {
+	s.lockName = name
+}
+
+// withLogger returns a shallow copy of the service (and the services it drives)
+// that logs through logger instead
+func (s *SyncService) withLogger(logger *zap.Logger) *SyncService {
+	dataExtSvc := *s.dataExtSvc
+	dataExtSvc.logger = logger
+	folderSvc := *s.folderSvc
+	folderSvc.logger = logger
+
+	svc := *s
+	svc.dataExtSvc = &dataExtSvc
+	svc.folderSvc = &folderSvc
+	svc.logger = logger
+	return &svc
+}
+
+// ErrSyncInProgress is returned by SyncAll when another full sync holds its lock
+var ErrSyncInProgress = errors.New("sync already in progress")
+
 // SyncAll performs a full sync of all folders, subfolders, and data extensions
-// Returns the sync metrics and any error that occurred
+// Returns the sync metrics and any error that occurred; metrics.Result() gives the
+// SyncResult summary of the run
+// It holds a Postgres advisory lock while it runs, so a second instance syncing the
+// same account fails with ErrSyncInProgress instead of racing on the same rows
 func (s *SyncService) SyncAll(ctx context.Context) (*SyncMetrics, error) {
 	startTime := time.Now()
+
+	lock, err := s.db.TryAdvisoryLock(ctx, postgres.AdvisoryLockKey(s.lockName))
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, fmt.Errorf("%w (lock %q is held by another process)", ErrSyncInProgress, s.lockName)
+	}
+	defer func() {
+		// The run's context may be done by now, but the lock must still be released
+		if err := lock.Release(context.WithoutCancel(ctx)); err != nil {
+			s.logger.Warn("Failed to release sync lock", zap.Error(err))
+		}
+	}()
+
 	s.logger.Info("Starting full sync operation")
 
 	// Initialize metrics accumulator
-	metrics := &SyncMetrics{}
+	metrics := &SyncMetrics{RunID: uuid.New(), StartedAt: startTime}
+	s.logger.Info("Assigned run ID", zap.String("run_id", metrics.RunID.String()))
+	s.folderPaths.reset()
+	s.jobs.reset()
+
+	// Jobs left running by a crashed run would otherwise stay running forever
+	if s.config.StaleJobAge > 0 {
+		if _, err := s.ReapStaleJobs(ctx, s.config.StaleJobAge); err != nil && !s.disableJobsIfMissing(err) {
+			s.logger.Warn("Failed to reap stale sync jobs", zap.Error(err))
+		}
+	}
 
 	// Sync folders
 	if err := s.SyncFolders(ctx, metrics); err != nil {
+		metrics.FinishedAt = time.Now()
 		return metrics, fmt.Errorf("failed to sync folders: %w", err)
 	}
 
-	duration := time.Since(startTime)
+	// Rows that weren't seen are only gone upstream if nothing was missed
+	if s.config.SoftDeleteMissing {
+		s.reconcileDeleted(ctx, metrics)
+	}
+
+	metrics.FinishedAt = time.Now()
+	duration := metrics.FinishedAt.Sub(startTime)
 
 	// Log final metrics
 	s.logger.Info("Completed full sync operation",
+		zap.String("run_id", metrics.RunID.String()),
 		zap.Duration("duration", duration),
 		zap.Int("folders_succeeded", metrics.FoldersSucceeded),
 		zap.Int("folders_failed", metrics.FoldersFailed),
@@ -142,6 +564,87 @@ func (s *SyncService) SyncAll(ctx context.Context) (*SyncMetrics, error) {
 		zap.Int("total_succeeded", metrics.TotalSucceeded()),
 		zap.Int("total_failed", metrics.TotalFailed()))
 
+	// One entry per tag set, so retention results can be sliced by folder and object type
+	for _, tagged := range metrics.RetentionByTags() {
+		s.logger.Info("Retention update outcome",
+			zap.String("run_id", metrics.RunID.String()),
+			zap.String("folder_type", tagged.FolderType),
+			zap.String("object_type", tagged.ObjectType),
+			zap.Int("succeeded", tagged.Succeeded),
+			zap.Int("failed", tagged.Failed),
+			zap.Int("skipped", tagged.Skipped))
+	}
+
+	return metrics, nil
+}
+
+// ErrFolderNotFound is returned by SyncFolderTree when the folder doesn't exist upstream
+var ErrFolderNotFound = errors.New("folder not found")
+
+// SyncFolderTree syncs a single folder and everything below it as a new run.
+// The folder is looked up in the full folder list, and its ancestors are saved
+// first so the folder rows satisfy their parent references. Deleted rows are not
+// reconciled, since the rest of the tree isn't seen.
+func (s *SyncService) SyncFolderTree(ctx context.Context, folderID string) (*SyncMetrics, error) {
+	startTime := time.Now()
+
+	foldersResp, err := s.client.GetFoldersCtx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch folders: %w", err)
+	}
+	folderMap := make(map[string]sfmce.Folder, len(foldersResp.Entry))
+	for _, folder := range foldersResp.Entry {
+		folderMap[folder.ID] = folder
+	}
+	folder, ok := folderMap[folderID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFolderNotFound, folderID)
+	}
+
+	// Walk up to the top-level folder, stopping on a cycle, then save top-down
+	var ancestors []sfmce.Folder
+	seen := map[string]bool{folder.ID: true}
+	for current := folder; !current.IsRoot(); {
+		parent, ok := folderMap[current.ParentID]
+		if !ok || seen[parent.ID] {
+			break
+		}
+		seen[parent.ID] = true
+		ancestors = append(ancestors, parent)
+		current = parent
+	}
+	if len(ancestors) > 0 {
+		slices.Reverse(ancestors)
+		if err := s.folderSvc.SaveFoldersBatch(ctx, ancestors); err != nil {
+			return nil, fmt.Errorf("failed to save ancestors of folder %s: %w", folderID, err)
+		}
+	}
+
+	metrics := &SyncMetrics{RunID: uuid.New()}
+	s.logger.Info("Starting folder tree sync",
+		zap.String("run_id", metrics.RunID.String()),
+		zap.String("folder_id", folder.ID),
+		zap.String("folder_name", folder.Name),
+		zap.Int("ancestor_count", len(ancestors)))
+	s.folderPaths.reset()
+	s.jobs.reset()
+	metrics.MarkFoldersKnown([]sfmce.Folder{folder})
+
+	if err := s.syncSubtree(ctx, folder, metrics); err != nil {
+		return metrics, fmt.Errorf("failed to sync folder %s: %w", folderID, err)
+	}
+
+	s.logger.Info("Completed folder tree sync",
+		zap.String("run_id", metrics.RunID.String()),
+		zap.String("folder_id", folder.ID),
+		zap.Duration("duration", time.Since(startTime)),
+		zap.Int("folders_succeeded", metrics.FoldersSucceeded),
+		zap.Int("folders_failed", metrics.FoldersFailed),
+		zap.Int("subfolders_succeeded", metrics.SubfoldersSucceeded),
+		zap.Int("subfolders_failed", metrics.SubfoldersFailed),
+		zap.Int("data_extensions_succeeded", metrics.DataExtensionsSucceeded),
+		zap.Int("data_extensions_failed", metrics.DataExtensionsFailed))
+
 	return metrics, nil
 }
 
@@ -149,7 +652,7 @@ func (s *SyncService) SyncAll(ctx context.Context) (*SyncMetrics, error) {
 func (s *SyncService) SyncFolders(ctx context.Context, metrics *SyncMetrics) error {
 	// Fetch all folders
 	s.logger.Info("Fetching folders...")
-	foldersResp, err := s.client.GetFolders()
+	foldersResp, err := s.client.GetFoldersCtx(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to fetch folders: %w", err)
 	}
@@ -158,16 +661,26 @@ func (s *SyncService) SyncFolders(ctx context.Context, metrics *SyncMetrics) err
 		zap.Int("total_folders", foldersResp.TotalResults),
 		zap.Int("items_count", len(foldersResp.Entry)))
 
-	// Separate top-level folders (parentId is "0" or empty) from subfolders
-	var topLevelFolders []sfmce.Folder
-	var subfolders []sfmce.Folder
 	folderMap := make(map[string]sfmce.Folder) // Map to track all folders by ID
-
 	for _, folder := range foldersResp.Entry {
 		folderMap[folder.ID] = folder
-		if folder.ParentID == "" || folder.ParentID == "0" {
-			topLevelFolders = append(topLevelFolders, folder)
-		} else {
+	}
+
+	// Drop the folders the filter excludes before anything is queued
+	folders := s.config.FolderFilter.filterListed(foldersResp.Entry, folderMap)
+	if len(folders) < len(foldersResp.Entry) {
+		s.logger.Info("Filtered folders",
+			zap.Int("kept", len(folders)),
+			zap.Int("skipped", len(foldersResp.Entry)-len(folders)))
+	}
+	metrics.MarkFoldersKnown(folders)
+
+	// Separate top-level folders (parentId is "0" or empty) from subfolders
+	adjacency := sfmce.BuildAdjacency(folders)
+	topLevelFolders := adjacency[sfmce.RootParentID]
+	var subfolders []sfmce.Folder
+	for _, folder := range folders {
+		if !folder.IsRoot() {
 			subfolders = append(subfolders, folder)
 		}
 	}
@@ -176,22 +689,26 @@ func (s *SyncService) SyncFolders(ctx context.Context, metrics *SyncMetrics) err
 		zap.Int("top_level_count", len(topLevelFolders)),
 		zap.Int("subfolder_count", len(subfolders)))
 
+	if s.config.PhaseMode == PhaseModeInterleaved {
+		return s.syncFoldersInterleaved(ctx, topLevelFolders, folders, metrics)
+	}
+
 	// Step 1: Save all top-level folders first (concurrently)
 	s.logger.Info("Saving top-level folders...")
-	topLevelPool := pool.New().WithMaxGoroutines(10).WithErrors()
+	topLevelPool := pool.New().WithMaxGoroutines(s.config.FolderConcurrency).WithErrors()
 	for _, folder := range topLevelFolders {
 		folder := folder // capture loop variable
 		topLevelPool.Go(func() error {
 			if err := s.folderSvc.SaveFolder(ctx, folder); err != nil {
-				metrics.AddFolderFailure()
+				metrics.AddFolderFailure(folder.ID)
 				s.logger.Error("Failed to save top-level folder",
 					zap.String("folder_id", folder.ID),
 					zap.String("folder_name", folder.Name),
 					zap.Error(err))
 				return fmt.Errorf("failed to save top-level folder %s: %w", folder.ID, err)
 			}
-			metrics.AddFolderSuccess()
-			s.logger.Info("Saved top-level folder",
+			metrics.AddFolderSuccess(folder.ID)
+			s.logger.Debug("Saved top-level folder",
 				zap.String("folder_id", folder.ID),
 				zap.String("folder_name", folder.Name))
 			return nil
@@ -213,13 +730,13 @@ func (s *SyncService) SyncFolders(ctx context.Context, metrics *SyncMetrics) err
 
 	// Step 3: Process all folders (top-level and subfolders) to fetch their subfolders and data extensions
 	s.logger.Info("Processing folders to fetch subfolders and data extensions...")
-	folderPool := pool.New().WithMaxGoroutines(10).WithErrors()
+	folderPool := pool.New().WithMaxGoroutines(s.config.FolderConcurrency).WithErrors()
 
 	// Process all folders
-	for _, folder := range foldersResp.Entry {
+	for _, folder := range folders {
 		folder := folder // capture loop variable
 		folderPool.Go(func() error {
-			return s.SyncFolder(ctx, folder, true, metrics)
+			return s.syncSubtree(ctx, folder, metrics)
 		})
 	}
 
@@ -231,55 +748,155 @@ func (s *SyncService) SyncFolders(ctx context.Context, metrics *SyncMetrics) err
 	return nil
 }
 
+// syncFoldersInterleaved syncs the subtree of one top-level folder at a time, so the
+// results of early folders are complete before later ones start and only one subtree
+// is in flight. Listed folders that no top-level subtree reached are synced last.
+func (s *SyncService) syncFoldersInterleaved(ctx context.Context, topLevelFolders, allFolders []sfmce.Folder, metrics *SyncMetrics) error {
+	s.logger.Info("Syncing folders one top-level subtree at a time",
+		zap.Int("top_level_count", len(topLevelFolders)))
+
+	var errs []error
+	for _, folder := range append(slices.Clip(topLevelFolders), allFolders...) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Folders reached through an earlier subtree are skipped by SyncFolder
+		if err := s.syncSubtree(ctx, folder, metrics); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("error processing folders: %w", err)
+	}
+	return nil
+}
+
+// syncSubtree syncs a folder and its subtree, keeping the folder's log lines
+// together when BufferFolderLogs is set
+func (s *SyncService) syncSubtree(ctx context.Context, folder sfmce.Folder, metrics *SyncMetrics) error {
+	// The run reads its data extension failures from metrics
+	if !s.config.BufferFolderLogs {
+		_, err := s.SyncFolder(ctx, folder, true, metrics)
+		return err
+	}
+
+	// Keep this folder's log lines together; they are written out once the
+	// folder (including its subfolders) is done, whether it failed or not
+	buffered := logging.NewBufferedLogger(s.logger)
+	defer func() {
+		if err := buffered.Flush(); err != nil {
+			s.logger.Warn("Failed to flush folder logs",
+				zap.String("folder_id", folder.ID),
+				zap.Error(err))
+		}
+	}()
+	_, err := s.withLogger(buffered.Logger).SyncFolder(ctx, folder, true, metrics)
+	return err
+}
+
 // SyncFolder syncs a single folder: saves it, fetches subfolders recursively, and data extensions
-func (s *SyncService) SyncFolder(ctx context.Context, folder sfmce.Folder, recursive bool, metrics *SyncMetrics) error {
+// Folders already synced in this run (tracked in metrics) are skipped, which also
+// stops the recursion when corrupted metadata makes the folder tree cyclic
+// The data extensions that failed in the folder and the subfolders it synced are
+// returned; they are also recorded on the folders' metrics.
+func (s *SyncService) SyncFolder(ctx context.Context, folder sfmce.Folder, recursive bool, metrics *SyncMetrics) ([]DataExtensionFailure, error) {
+	return s.syncFolder(ctx, folder, recursive, metrics, nil)
+}
+
+// syncFolder implements SyncFolder; ancestors holds the IDs of the folders on the
+// current recursion path, used to tell cycles apart from plain revisits
+func (s *SyncService) syncFolder(ctx context.Context, folder sfmce.Folder, recursive bool, metrics *SyncMetrics, ancestors []string) ([]DataExtensionFailure, error) {
+	if slices.Contains(ancestors, folder.ID) {
+		s.logger.Warn("Folder cycle detected, skipping",
+			zap.String("folder_id", folder.ID),
+			zap.String("folder_name", folder.Name),
+			zap.Strings("path", append(slices.Clip(ancestors), folder.ID)))
+		return nil, nil
+	}
+	if !metrics.MarkFolderVisited(folder.ID) {
+		s.logger.Debug("Folder already synced in this run, skipping",
+			zap.String("folder_id", folder.ID),
+			zap.String("folder_name", folder.Name))
+		return nil, nil
+	}
+	path := append(slices.Clip(ancestors), folder.ID)
+	metrics.RecordFolderType(folder.ID, folder.Type)
+	defer s.reportProgress(metrics)
+
 	// Save the folder
 	if err := s.folderSvc.SaveFolder(ctx, folder); err != nil {
-		metrics.AddFolderFailure()
+		metrics.AddFolderFailure(folder.ID)
 		s.logger.Error("Failed to save folder",
 			zap.String("folder_id", folder.ID),
 			zap.String("folder_name", folder.Name),
 			zap.Error(err))
-		return fmt.Errorf("failed to save folder %s: %w", folder.ID, err)
+		return nil, fmt.Errorf("failed to save folder %s: %w", folder.ID, err)
 	}
-	metrics.AddFolderSuccess()
-	s.logger.Info("Saved folder",
+	metrics.AddFolderSuccess(folder.ID)
+	s.logger.Debug("Saved folder",
 		zap.String("folder_id", folder.ID),
 		zap.String("folder_name", folder.Name))
 
+	// Subfolders add their failures concurrently
+	var (
+		failuresMu sync.Mutex
+		failures   []DataExtensionFailure
+	)
+	addFailures := func(more []DataExtensionFailure) {
+		failuresMu.Lock()
+		defer failuresMu.Unlock()
+		failures = append(failures, more...)
+	}
+
 	// Fetch subfolders
-	subfoldersResp, err := s.client.GetSubFolders(folder.ID)
+	var subfoldersResp *sfmce.FoldersResponse
+	err := s.throttle.do(ctx, func() (err error) {
+		subfoldersResp, err = s.client.GetSubFoldersCtx(ctx, folder.ID)
+		return err
+	})
 	if err != nil {
+		metrics.MarkIncomplete()
 		s.logger.Warn("Failed to fetch subfolders",
 			zap.String("folder_id", folder.ID),
 			zap.Error(err))
 		// Continue processing even if subfolders fail
 	} else {
-		s.logger.Info("Fetched subfolders",
+		s.logger.Debug("Fetched subfolders",
 			zap.String("folder_id", folder.ID),
 			zap.Int("subfolder_count", len(subfoldersResp.Entry)))
 
-		// Create a worker pool for processing subfolders (max 5 concurrent per folder)
-		subfolderPool := pool.New().WithMaxGoroutines(5).WithErrors()
+		// Subfolders the filter excludes are skipped with their subtrees
+		subfolders := slices.DeleteFunc(slices.Clone(subfoldersResp.Entry), func(subfolder sfmce.Folder) bool {
+			return !s.config.FolderFilter.admitsChild(subfolder)
+		})
+		metrics.MarkFoldersKnown(subfolders)
+
+		// Create a worker pool for processing subfolders (bounded per folder)
+		subfolderPool := pool.New().WithMaxGoroutines(s.config.SubfolderConcurrency).WithErrors()
 
 		// Process each subfolder concurrently
-		for _, subfolder := range subfoldersResp.Entry {
+		for _, subfolder := range subfolders {
 			subfolder := subfolder // capture loop variable
 			subfolderPool.Go(func() error {
+				metrics.RecordFolderType(subfolder.ID, subfolder.Type)
+
 				// Save the subfolder
 				if err := s.folderSvc.SaveFolder(ctx, subfolder); err != nil {
-					metrics.AddSubfolderFailure()
+					metrics.AddSubfolderFailure(folder.ID)
 					s.logger.Error("Failed to save subfolder",
 						zap.String("subfolder_id", subfolder.ID),
 						zap.String("subfolder_name", subfolder.Name),
 						zap.Error(err))
 					return fmt.Errorf("failed to save subfolder %s: %w", subfolder.ID, err)
 				}
-				metrics.AddSubfolderSuccess()
+				metrics.AddSubfolderSuccess(folder.ID)
 
 				// Recursively sync subfolder if recursive is true
 				if recursive {
-					if err := s.SyncFolder(ctx, subfolder, true, metrics); err != nil {
+					subfolderFailures, err := s.syncFolder(ctx, subfolder, true, metrics, path)
+					addFailures(subfolderFailures)
+					if err != nil {
 						s.logger.Warn("Failed to recursively sync subfolder",
 							zap.String("subfolder_id", subfolder.ID),
 							zap.Error(err))
@@ -287,7 +904,9 @@ func (s *SyncService) SyncFolder(ctx context.Context, folder sfmce.Folder, recur
 					}
 				} else {
 					// Just sync data extensions for this subfolder
-					if err := s.SyncDataExtensions(ctx, subfolder.ID, subfolder.Name, metrics); err != nil {
+					subfolderFailures, err := s.SyncDataExtensions(ctx, subfolder.ID, subfolder.Name, metrics)
+					addFailures(subfolderFailures)
+					if err != nil {
 						s.logger.Warn("Failed to sync data extensions for subfolder",
 							zap.String("subfolder_id", subfolder.ID),
 							zap.Error(err))
@@ -307,50 +926,82 @@ func (s *SyncService) SyncFolder(ctx context.Context, folder sfmce.Folder, recur
 	}
 
 	// Fetch and save data extensions for the folder itself (last 3 months)
-	if err := s.SyncDataExtensions(ctx, folder.ID, folder.Name, metrics); err != nil {
+	folderFailures, err := s.SyncDataExtensions(ctx, folder.ID, folder.Name, metrics)
+	addFailures(folderFailures)
+	if err != nil {
 		s.logger.Warn("Failed to fetch data extensions for folder",
 			zap.String("folder_id", folder.ID),
 			zap.Error(err))
 		// Don't return error, just log it
 	}
 
-	return nil
+	return failures, nil
 }
 
 // SyncDataExtensions fetches all data extensions for a folder (with pagination) and saves them
 // Only fetches data extensions modified in the last 3 months
 // After saving, updates data retention properties via API
 // Creates and tracks a sync job for durability
-func (s *SyncService) SyncDataExtensions(ctx context.Context, folderID string, folderName string, metrics *SyncMetrics) error {
+// A data extension that fails doesn't stop the others; the failures are returned and
+// recorded on the folder's metrics. The error is only set when the folder's data
+// extensions couldn't be listed.
+func (s *SyncService) SyncDataExtensions(ctx context.Context, folderID string, folderName string, metrics *SyncMetrics) ([]DataExtensionFailure, error) {
+	defer s.reportProgress(metrics)
 	startTime := time.Now()
 	totalSucceeded := 0
 	totalFailed := 0
 	retentionUpdateSucceeded := 0
 	retentionUpdateFailed := 0
+	retentionUpdateSkipped := 0
+	retentionUpdateApplied := 0
 
-	s.logger.Info("Fetching data extensions with date filter",
+	s.logger.Debug("Fetching data extensions with date filter",
 		zap.String("folder_id", folderID),
 		zap.String("folder_name", folderName))
 
 	// Fetch all data extensions (handles pagination internally)
-	dataExtensions, err := s.dataExtSvc.GetDataExtensions(ctx, s.client, folderID)
+	var dataExtensions []sfmce.DataExtension
+	err := s.throttle.do(ctx, func() (err error) {
+		dataExtensions, err = s.dataExtSvc.GetDataExtensions(ctx, s.client, folderID)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to fetch data extensions for folder %s: %w", folderID, err)
+		err = fmt.Errorf("failed to fetch data extensions for folder %s: %w", folderID, err)
+		metrics.MarkIncomplete()
+		metrics.RecordFolder(FolderMetrics{
+			FolderID:   folderID,
+			FolderName: folderName,
+			Duration:   time.Since(startTime),
+			Error:      err.Error(),
+		})
+		s.recordFailedJob(ctx, metrics, folderID, folderName, err)
+		return nil, err
 	}
 
-	s.logger.Info("Fetched all data extensions",
+	s.logger.Debug("Fetched all data extensions",
 		zap.String("folder_id", folderID),
 		zap.String("folder_name", folderName),
 		zap.Int("total_items", len(dataExtensions)))
 
+	// Deleted data extensions are neither stored nor given retention, and count as
+	// gone when missing rows are reconciled
+	skipped := make(map[string]int)
+	fetched := len(dataExtensions)
+	dataExtensions = slices.DeleteFunc(dataExtensions, sfmce.IsInRecycleBin)
+	skipped[SkipReasonRecycleBin] = fetched - len(dataExtensions)
+	metrics.MarkDataExtensionsSeen(dataExtensions)
+
+	// In incremental mode, leave data extensions that haven't changed alone entirely
+	if s.config.Incremental {
+		fetched = len(dataExtensions)
+		dataExtensions = s.changedDataExtensions(ctx, folderID, dataExtensions)
+		skipped[SkipReasonUnchanged] = fetched - len(dataExtensions)
+	}
+
 	// Create sync job for tracking retention updates
 	var syncJobID uuid.UUID
-	if len(dataExtensions) > 0 {
-		metadata, _ := json.Marshal(map[string]interface{}{
-			"folder_id":   folderID,
-			"folder_name": folderName,
-			"operation":   "data_retention_update",
-		})
+	if len(dataExtensions) > 0 && s.jobsEnabled() {
+		metadata := s.jobMetadata(metrics, folderID, folderName)
 		job, err := s.queries.CreateSyncJob(ctx, s.db.Pool(), gen.CreateSyncJobParams{
 			JobType:    "data_retention_update",
 			Status:     "running",
@@ -358,12 +1009,14 @@ func (s *SyncService) SyncDataExtensions(ctx context.Context, folderID string, f
 			Metadata:   metadata,
 		})
 		if err != nil {
-			s.logger.Warn("Failed to create sync job for retention updates",
-				zap.String("folder_id", folderID),
-				zap.Error(err))
+			if !s.disableJobsIfMissing(err) {
+				s.logger.Warn("Failed to create sync job for retention updates",
+					zap.String("folder_id", folderID),
+					zap.Error(err))
+			}
 		} else {
 			syncJobID = job.ID
-			s.logger.Info("Created sync job for retention updates",
+			s.logger.Debug("Created sync job for retention updates",
 				zap.String("job_id", syncJobID.String()),
 				zap.String("folder_id", folderID),
 				zap.Int("total_items", len(dataExtensions)))
@@ -372,9 +1025,10 @@ func (s *SyncService) SyncDataExtensions(ctx context.Context, folderID string, f
 
 	// Save all data extensions and update retention using worker pool
 	// Items are already filtered by GetDataExtensions to only include those modified in last 3 months
-	dataExtPool := pool.New().WithMaxGoroutines(10).WithErrors()
+	dataExtPool := pool.New().WithMaxGoroutines(s.config.DataExtensionConcurrency).WithErrors()
 	saveResults := make([]error, len(dataExtensions))
 	retentionResults := make([]error, len(dataExtensions))
+	retentionUpdated := make([]bool, len(dataExtensions))
 
 	for idx, de := range dataExtensions {
 		de := de // capture loop variable
@@ -392,16 +1046,29 @@ func (s *SyncService) SyncDataExtensions(ctx context.Context, folderID string, f
 				return err
 			}
 
+			s.storeCategoryFullPath(ctx, de)
+
+			// Save-only syncs leave retention in Marketing Cloud alone
+			if s.config.SaveOnly {
+				return nil
+			}
+
 			// After successful save, update data retention via API
-			retentionErr := s.dataExtSvc.UpdateDataRetentionViaAPI(ctx, s.client, de.ID)
+			var updated bool
+			retentionErr := s.throttle.do(ctx, func() (err error) {
+				updated, err = s.dataExtSvc.UpdateDataRetentionViaAPI(ctx, s.client, de)
+				return err
+			})
 			retentionResults[i] = retentionErr
+			retentionUpdated[i] = updated
+			metrics.AddRetentionOutcome(metrics.retentionTags(folderID, de.PartnerAPIObjectTypeName), updated, retentionErr)
 			if retentionErr != nil {
 				s.logger.Error("Failed to update data retention via API",
 					zap.String("data_extension_id", de.ID),
 					zap.String("data_extension_name", de.Name),
 					zap.String("folder_id", folderID),
 					zap.Error(retentionErr))
-			} else {
+			} else if updated {
 				s.logger.Debug("Successfully updated data retention via API",
 					zap.String("data_extension_id", de.ID),
 					zap.String("data_extension_name", de.Name))
@@ -417,20 +1084,42 @@ func (s *SyncService) SyncDataExtensions(ctx context.Context, folderID string, f
 	// Count save successes and failures
 	succeeded := 0
 	failed := 0
-	for _, err := range saveResults {
+	var failures []DataExtensionFailure
+	for i, err := range saveResults {
 		if err != nil {
 			failed++
+			failures = append(failures, DataExtensionFailure{
+				DataExtensionID: dataExtensions[i].ID,
+				FolderID:        folderID,
+				Stage:           FailureStageSave,
+				Error:           err.Error(),
+			})
 		} else {
 			succeeded++
 		}
 	}
 
-	// Count retention update successes and failures
-	for _, err := range retentionResults {
+	// Count retention update successes and failures; skipped updates count as succeeded.
+	// Save-only syncs make no retention updates, so there is nothing to count.
+	for i, err := range retentionResults {
+		if s.config.SaveOnly {
+			break
+		}
 		if err != nil {
 			retentionUpdateFailed++
+			failures = append(failures, DataExtensionFailure{
+				DataExtensionID: dataExtensions[i].ID,
+				FolderID:        folderID,
+				Stage:           FailureStageRetention,
+				Error:           err.Error(),
+			})
 		} else {
 			retentionUpdateSucceeded++
+			if retentionUpdated[i] {
+				retentionUpdateApplied++
+			} else if saveResults[i] == nil {
+				retentionUpdateSkipped++
+			}
 		}
 	}
 
@@ -438,15 +1127,31 @@ func (s *SyncService) SyncDataExtensions(ctx context.Context, folderID string, f
 	totalFailed += failed
 
 	// Update global metrics
-	metrics.AddDataExtensions(succeeded, failed)
+	metrics.AddDataExtensions(folderID, succeeded, failed)
+	skipped[SkipReasonRetentionMatches] = retentionUpdateSkipped
+	metrics.RecordFolder(FolderMetrics{
+		FolderID:                folderID,
+		FolderName:              folderName,
+		DataExtensionsSucceeded: succeeded,
+		DataExtensionsFailed:    failed,
+		RetentionSucceeded:      retentionUpdateApplied,
+		RetentionFailed:         retentionUpdateFailed,
+		Skipped:                 skipped,
+		Duration:                time.Since(startTime),
+		Failures:                failures,
+	})
 
 	// Update sync job progress and completion
 	if syncJobID != uuid.Nil {
-		// Update job with retention update progress
+		// Update job with retention update progress, or save progress in save-only syncs
+		jobSucceeded, jobFailed := retentionUpdateSucceeded, retentionUpdateFailed
+		if s.config.SaveOnly {
+			jobSucceeded, jobFailed = succeeded, failed
+		}
 		err := s.queries.UpdateSyncJobProgress(ctx, s.db.Pool(), gen.UpdateSyncJobProgressParams{
 			ProcessedItems: int32(len(dataExtensions)),
-			SucceededItems: int32(retentionUpdateSucceeded),
-			FailedItems:    int32(retentionUpdateFailed),
+			SucceededItems: int32(jobSucceeded),
+			FailedItems:    int32(jobFailed),
 			ID:             syncJobID,
 		})
 		if err != nil {
@@ -461,8 +1166,13 @@ func (s *SyncService) SyncDataExtensions(ctx context.Context, folderID string, f
 		if len(dataExtensions) > 0 {
 			avgProcessingTime = int32(duration / int64(len(dataExtensions)))
 		}
+		// Mark the job failed if anything in it failed, so the folder can be retried
+		status := "completed"
+		if failed > 0 || retentionUpdateFailed > 0 {
+			status = "failed"
+		}
 		err = s.queries.CompleteSyncJob(ctx, s.db.Pool(), gen.CompleteSyncJobParams{
-			Status:              "completed",
+			Status:              status,
 			DurationMs:          pgtype.Int4{Int32: int32(duration), Valid: true},
 			AvgProcessingTimeMs: pgtype.Int4{Int32: avgProcessingTime, Valid: true},
 			ID:                  syncJobID,
@@ -472,7 +1182,7 @@ func (s *SyncService) SyncDataExtensions(ctx context.Context, folderID string, f
 				zap.String("job_id", syncJobID.String()),
 				zap.Error(err))
 		} else {
-			s.logger.Info("Completed sync job for retention updates",
+			s.logger.Debug("Completed sync job for retention updates",
 				zap.String("job_id", syncJobID.String()),
 				zap.Int64("duration_ms", duration))
 		}
@@ -484,7 +1194,135 @@ func (s *SyncService) SyncDataExtensions(ctx context.Context, folderID string, f
 		zap.Int("total_succeeded", totalSucceeded),
 		zap.Int("total_failed", totalFailed),
 		zap.Int("retention_updates_succeeded", retentionUpdateSucceeded),
-		zap.Int("retention_updates_failed", retentionUpdateFailed))
+		zap.Int("retention_updates_failed", retentionUpdateFailed),
+		zap.Int("retention_updates_skipped", retentionUpdateSkipped))
 
-	return nil
+	return failures, nil
+}
+
+// jobMetadata builds the metadata stored on a folder's sync job, tying it to the run
+func (s *SyncService) jobMetadata(metrics *SyncMetrics, folderID, folderName string) []byte {
+	meta := map[string]interface{}{
+		"folder_id":   folderID,
+		"folder_name": folderName,
+		"operation":   "data_retention_update",
+		"run_id":      metrics.RunID.String(),
+	}
+	if metrics.ParentRunID != uuid.Nil {
+		meta["parent_run_id"] = metrics.ParentRunID.String()
+	}
+	metadata, _ := json.Marshal(meta)
+	return metadata
+}
+
+// recordFailedJob stores a failed sync job for a folder that could not be processed
+// at all, so the failure shows up in the run's manifest
+func (s *SyncService) recordFailedJob(ctx context.Context, metrics *SyncMetrics, folderID, folderName string, cause error) {
+	if !s.jobsEnabled() {
+		return
+	}
+	job, err := s.queries.CreateSyncJob(ctx, s.db.Pool(), gen.CreateSyncJobParams{
+		JobType:  "data_retention_update",
+		Status:   "running",
+		Metadata: s.jobMetadata(metrics, folderID, folderName),
+	})
+	if err != nil {
+		if s.disableJobsIfMissing(err) {
+			return
+		}
+		s.logger.Warn("Failed to record failed sync job",
+			zap.String("folder_id", folderID),
+			zap.Error(err))
+		return
+	}
+
+	err = s.queries.FailSyncJob(ctx, s.db.Pool(), gen.FailSyncJobParams{
+		Status:       "failed",
+		ErrorMessage: pgtype.Text{String: cause.Error(), Valid: true},
+		ID:           job.ID,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to mark sync job as failed",
+			zap.String("job_id", job.ID.String()),
+			zap.Error(err))
+	}
+}
+
+// changedDataExtensions drops the data extensions whose stored copy is already up
+// to date. When the stored state can't be read, the data extension is kept.
+func (s *SyncService) changedDataExtensions(ctx context.Context, folderID string, dataExtensions []sfmce.DataExtension) []sfmce.DataExtension {
+	changed := make([]sfmce.DataExtension, 0, len(dataExtensions))
+	for _, de := range dataExtensions {
+		unchanged, err := s.dataExtSvc.IsUnchanged(ctx, de)
+		if err != nil {
+			s.logger.Warn("Failed to check whether data extension changed, syncing it",
+				zap.String("data_extension_id", de.ID),
+				zap.Error(err))
+		}
+		if !unchanged {
+			changed = append(changed, de)
+		}
+	}
+
+	s.logger.Info("Skipped unchanged data extensions",
+		zap.String("folder_id", folderID),
+		zap.Int("unchanged", len(dataExtensions)-len(changed)),
+		zap.Int("changed", len(changed)))
+
+	return changed
+}
+
+// storeCategoryFullPath records the folder path of a saved data extension. A path
+// that can't be resolved is logged and left unset rather than failing the save.
+func (s *SyncService) storeCategoryFullPath(ctx context.Context, de sfmce.DataExtension) {
+	path, err := s.folderPaths.get(ctx, s.folderSvc, de.FolderID())
+	if err != nil {
+		s.logger.Warn("Failed to resolve folder path for data extension",
+			zap.String("data_extension_id", de.ID),
+			zap.String("folder_id", de.FolderID()),
+			zap.Error(err))
+		return
+	}
+	if err := s.dataExtSvc.SetCategoryFullPath(ctx, de.ID, path); err != nil {
+		s.logger.Warn("Failed to store folder path for data extension",
+			zap.String("data_extension_id", de.ID),
+			zap.Error(err))
+	}
+}
+
+// folderPathCache memoizes FolderService.GetFolderPath per folder, so data extensions
+// sharing a folder resolve its path once per run
+type folderPathCache struct {
+	mu    sync.Mutex
+	paths map[string]string
+}
+
+func newFolderPathCache() *folderPathCache {
+	return &folderPathCache{paths: make(map[string]string)}
+}
+
+func (c *folderPathCache) get(ctx context.Context, folderSvc *FolderService, folderID string) (string, error) {
+	c.mu.Lock()
+	path, ok := c.paths[folderID]
+	c.mu.Unlock()
+	if ok {
+		return path, nil
+	}
+
+	path, err := folderSvc.GetFolderPath(ctx, folderID)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.paths[folderID] = path
+	c.mu.Unlock()
+	return path, nil
+}
+
+// reset drops the cached paths, so folders renamed since the last run are picked up
+func (c *folderPathCache) reset() {
+	c.mu.Lock()
+	c.paths = make(map[string]string)
+	c.mu.Unlock()
 }
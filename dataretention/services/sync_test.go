@@ -0,0 +1,700 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/natserract/sf/dataretention/schema/postgres"
+	"github.com/natserract/sf/dataretention/schema/postgres/postgrestest"
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// countingClient is a fake client that records the most API calls it served at once
+type countingClient struct {
+	*fake.Client
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+// enter counts a call in, holding it briefly so overlapping calls would show
+func (c *countingClient) enter() {
+	n := c.inFlight.Add(1)
+	for {
+		highest := c.maxInFlight.Load()
+		if n <= highest || c.maxInFlight.CompareAndSwap(highest, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+}
+
+func (c *countingClient) leave() {
+	c.inFlight.Add(-1)
+}
+
+func (c *countingClient) GetSubFoldersCtx(ctx context.Context, folderID string) (*sfmce.FoldersResponse, error) {
+	c.enter()
+	defer c.leave()
+	return c.Client.GetSubFoldersCtx(ctx, folderID)
+}
+
+func (c *countingClient) GetDataExtensionsCtx(ctx context.Context, folderID string, page, pageSize int) (*sfmce.DataExtensionsResponse, error) {
+	c.enter()
+	defer c.leave()
+	return c.Client.GetDataExtensionsCtx(ctx, folderID, page, pageSize)
+}
+
+func (c *countingClient) UpdateDataRetentionCtx(ctx context.Context, dataExtensionID string, retention *sfmce.DataRetentionProperties) error {
+	c.enter()
+	defer c.leave()
+	return c.Client.UpdateDataRetentionCtx(ctx, dataExtensionID, retention)
+}
+
+func TestSyncAllConcurrencyOneIsSerial(t *testing.T) {
+	client := &countingClient{Client: fake.NewClient()}
+	client.AddFolder(testFolder("1", ""), testFolder("2", ""), testFolder("11", "1"), testFolder("12", "1"))
+	for _, folderID := range []string{"1", "2", "11", "12"} {
+		client.AddDataExtension(testDataExtension("de-"+folderID+"-a", folderID), testDataExtension("de-"+folderID+"-b", folderID))
+	}
+
+	cfg := DefaultSyncConfig()
+	cfg.FolderConcurrency = 1
+	cfg.SubfolderConcurrency = 1
+	cfg.DataExtensionConcurrency = 1
+	svc, _ := newTestSync(t, client, cfg)
+
+	metrics, err := svc.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAll() error = %v", err)
+	}
+	if metrics.DataExtensionsSucceeded != 8 || metrics.TotalFailed() != 0 {
+		t.Errorf("synced %d data extensions with %d failures, want 8 and 0", metrics.DataExtensionsSucceeded, metrics.TotalFailed())
+	}
+	if got := client.maxInFlight.Load(); got != 1 {
+		t.Errorf("at most %d API calls ran at once, want 1", got)
+	}
+}
+
+// cyclicClient is a fake client whose subfolder listings come from a fixed map,
+// so a folder can be listed under its own descendant
+type cyclicClient struct {
+	*fake.Client
+	children map[string][]sfmce.Folder
+	listings atomic.Int32
+}
+
+func (c *cyclicClient) GetSubFoldersCtx(ctx context.Context, folderID string) (*sfmce.FoldersResponse, error) {
+	c.listings.Add(1)
+	children := c.children[folderID]
+	return &sfmce.FoldersResponse{TotalResults: len(children), Entry: children}, nil
+}
+
+func TestSyncFolderStopsAtCycle(t *testing.T) {
+	// 1 → 2 → 3 → 1 again, as seen in corrupted folder metadata
+	client := &cyclicClient{Client: fake.NewClient(), children: map[string][]sfmce.Folder{
+		"1": {testFolder("2", "1")},
+		"2": {testFolder("3", "2")},
+		"3": {testFolder("1", "3")},
+	}}
+	svc, _ := newTestSync(t, client, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.SyncFolder(ctx, testFolder("1", ""), true, &SyncMetrics{})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("SyncFolder() error = %v", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("SyncFolder() did not terminate on a folder cycle")
+	}
+	if n := client.listings.Load(); n != 3 {
+		t.Errorf("listed subfolders %d times, want once per folder", n)
+	}
+}
+
+func TestSyncAllIncrementalSkipsUnchanged(t *testing.T) {
+	cfg := DefaultSyncConfig()
+	cfg.Incremental = true
+	newClient := func(modifiedB time.Time) *fake.Client {
+		client := fake.NewClient()
+		client.AddFolder(testFolder("1", ""))
+		deB := testDataExtension("de-b", "1")
+		deB.ModifiedDate.Time = modifiedB
+		client.AddDataExtension(testDataExtension("de-a", "1"), deB)
+		return client
+	}
+
+	first, db := newTestSync(t, newClient(testTime), cfg)
+	if _, err := first.SyncAll(context.Background()); err != nil {
+		t.Fatalf("first SyncAll() error = %v", err)
+	}
+
+	// Only de-b has changed since the first run
+	client := newClient(testTime.Add(time.Hour))
+	logger := zap.NewNop()
+	second := NewSyncServiceWithConfig(client, NewDataExtensionService(db, logger), NewFolderService(db, logger), db, cfg, logger)
+	metrics, err := second.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("second SyncAll() error = %v", err)
+	}
+
+	var folder *FolderMetrics
+	for _, m := range metrics.PerFolder() {
+		if m.FolderID == "1" {
+			folder = &m
+		}
+	}
+	if folder == nil {
+		t.Fatal("no metrics recorded for folder 1")
+	}
+	if folder.Skipped[SkipReasonUnchanged] != 1 || folder.DataExtensionsSucceeded != 1 {
+		t.Errorf("folder 1 skipped %d unchanged and synced %d, want 1 and 1", folder.Skipped[SkipReasonUnchanged], folder.DataExtensionsSucceeded)
+	}
+	updates := client.RetentionUpdates()
+	if len(updates) != 1 || updates[0].DataExtensionID != "de-b" {
+		t.Errorf("retention updates = %+v, want only de-b", updates)
+	}
+}
+
+func TestSyncAllStoresCategoryFullPath(t *testing.T) {
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""), testFolder("2", "1"), testFolder("3", "2"))
+	client.AddDataExtension(testDataExtension("de-top", "1"), testDataExtension("de-nested", "3"))
+	svc, db := newTestSync(t, client, nil)
+
+	if _, err := svc.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll() error = %v", err)
+	}
+
+	for id, want := range map[string]string{
+		"de-top":    "Folder 1",
+		"de-nested": "Folder 1 / Folder 2 / Folder 3",
+	} {
+		var got string
+		err := db.Pool().QueryRow(context.Background(), "SELECT category_full_path FROM data_extensions WHERE id = $1", id).Scan(&got)
+		if err != nil {
+			t.Fatalf("failed to read category path of %s: %v", id, err)
+		}
+		if got != want {
+			t.Errorf("category_full_path of %s = %q, want %q", id, got, want)
+		}
+	}
+}
+
+// orderClient is a fake client that records the folder of every listing and
+// retention update call, in call order
+type orderClient struct {
+	*fake.Client
+	mu      sync.Mutex
+	folders []string
+}
+
+func (c *orderClient) record(folderID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.folders = append(c.folders, folderID)
+}
+
+func (c *orderClient) GetSubFoldersCtx(ctx context.Context, folderID string) (*sfmce.FoldersResponse, error) {
+	c.record(folderID)
+	return c.Client.GetSubFoldersCtx(ctx, folderID)
+}
+
+func (c *orderClient) GetDataExtensionsCtx(ctx context.Context, folderID string, page, pageSize int) (*sfmce.DataExtensionsResponse, error) {
+	c.record(folderID)
+	return c.Client.GetDataExtensionsCtx(ctx, folderID, page, pageSize)
+}
+
+func (c *orderClient) UpdateDataRetentionCtx(ctx context.Context, dataExtensionID string, retention *sfmce.DataRetentionProperties) error {
+	// Test data extension IDs are de-<folder ID>
+	c.record(strings.TrimPrefix(dataExtensionID, "de-"))
+	return c.Client.UpdateDataRetentionCtx(ctx, dataExtensionID, retention)
+}
+
+func TestSyncAllInterleavedCompletesEachSubtree(t *testing.T) {
+	client := &orderClient{Client: fake.NewClient()}
+	client.AddFolder(
+		testFolder("1", ""), testFolder("11", "1"), testFolder("111", "11"),
+		testFolder("2", ""), testFolder("21", "2"),
+		testFolder("3", ""),
+	)
+	for _, folderID := range []string{"1", "11", "111", "2", "21", "3"} {
+		client.AddDataExtension(testDataExtension("de-"+folderID, folderID))
+	}
+	cfg := DefaultSyncConfig()
+	cfg.PhaseMode = PhaseModeInterleaved
+	svc, db := newTestSync(t, client, cfg)
+
+	metrics, err := svc.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAll() error = %v", err)
+	}
+	if metrics.TotalFailed() != 0 {
+		t.Errorf("SyncAll() failed %d items, want 0", metrics.TotalFailed())
+	}
+
+	subtree := map[string]string{"1": "1", "11": "1", "111": "1", "2": "2", "21": "2", "3": "3"}
+	finished := make(map[string]bool)
+	current := ""
+	for _, folderID := range client.folders {
+		root := subtree[folderID]
+		if root == current {
+			continue
+		}
+		if finished[root] {
+			t.Fatalf("call for folder %s after its subtree was left, order %v", folderID, client.folders)
+		}
+		if current != "" {
+			finished[current] = true
+		}
+		current = root
+	}
+	if len(finished) != 2 || current == "" {
+		t.Errorf("visited subtrees in order %v, want all three one after another", client.folders)
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM data_extensions"); n != 6 {
+		t.Errorf("stored %d data extensions, want 6", n)
+	}
+}
+
+func TestSyncAllAgainstFake(t *testing.T) {
+	recycled := "/Data Extensions/Old"
+	inBin := testDataExtension("de-bin", "3")
+	inBin.CategoryFullPathForRecycleBin = &recycled
+
+	outdated := *DefaultRetentionPolicy()
+	outdated.DataRetentionPeriodLength = 6
+
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""), testFolder("2", "1"), testFolder("3", "2"))
+	client.AddDataExtension(
+		withRetention(testDataExtension("de-new", "1"), &outdated),
+		withRetention(testDataExtension("de-current", "2"), DefaultRetentionPolicy()),
+		withRetention(testDataExtension("de-nested", "3"), &outdated),
+		inBin,
+	)
+	svc, db := newTestSync(t, client, nil)
+
+	metrics, err := svc.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAll() error = %v", err)
+	}
+
+	if metrics.DataExtensionsSucceeded != 3 || metrics.TotalFailed() != 0 || !metrics.CrawlComplete() {
+		t.Errorf("metrics = %d data extensions succeeded, %d failed, complete %v, want 3, 0, true",
+			metrics.DataExtensionsSucceeded, metrics.TotalFailed(), metrics.CrawlComplete())
+	}
+
+	// Only the data extensions whose retention differs from the policy are updated
+	var updated []string
+	for _, update := range client.RetentionUpdates() {
+		updated = append(updated, update.DataExtensionID)
+		if !update.Retention.Equal(DefaultRetentionPolicy()) {
+			t.Errorf("%s updated to %s, want the default policy", update.DataExtensionID, update.Retention.String())
+		}
+	}
+	slices.Sort(updated)
+	if want := []string{"de-nested", "de-new"}; !slices.Equal(updated, want) {
+		t.Errorf("updated retention of %v, want %v", updated, want)
+	}
+
+	if n := countRows(t, db, "SELECT COUNT(*) FROM folders"); n != 3 {
+		t.Errorf("stored %d folders, want 3", n)
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM data_extensions WHERE id <> 'de-bin'"); n != 3 {
+		t.Errorf("stored %d data extensions, want the 3 outside the recycle bin", n)
+	}
+	for id, want := range map[string]string{"de-new": "succeeded", "de-current": "skipped", "de-nested": "succeeded"} {
+		if n := countRows(t, db, "SELECT COUNT(*) FROM data_retention_properties WHERE data_extension_id = $1 AND last_api_update_status = $2", id, want); n != 1 {
+			t.Errorf("retention status of %s is not %q", id, want)
+		}
+	}
+}
+
+func TestSyncAllInfoLogsOnlySummaries(t *testing.T) {
+	// infoLogs runs a sync of two folders holding perFolder data extensions each and
+	// returns what it logged at info level
+	infoLogs := func(t *testing.T, perFolder int) []observer.LoggedEntry {
+		client := fake.NewClient()
+		client.AddFolder(testFolder("1", ""), testFolder("2", "1"))
+		for _, folderID := range []string{"1", "2"} {
+			for i := 0; i < perFolder; i++ {
+				client.AddDataExtension(testDataExtension(fmt.Sprintf("de-%s-%d", folderID, i), folderID))
+			}
+		}
+
+		db := postgrestest.New(t)
+		core, logs := observer.New(zapcore.InfoLevel)
+		logger := zap.New(core)
+		svc := NewSyncServiceWithConfig(client, NewDataExtensionService(db, logger), NewFolderService(db, logger), db, DefaultSyncConfig(), logger)
+		if _, err := svc.SyncAll(context.Background()); err != nil {
+			t.Fatalf("SyncAll() error = %v", err)
+		}
+		return logs.AllUntimed()
+	}
+
+	few, many := infoLogs(t, 1), infoLogs(t, 20)
+	if len(few) != len(many) {
+		t.Errorf("logged %d info lines for 2 data extensions and %d for 40, want the same summaries", len(few), len(many))
+	}
+	for _, entry := range many {
+		switch entry.Message {
+		case "Saved data extension", "Saved folder", "Saved top-level folder", "Saved subfolder",
+			"Successfully updated data retention via API":
+			t.Errorf("per-item line %q logged at info", entry.Message)
+		}
+	}
+	if !slices.ContainsFunc(many, func(e observer.LoggedEntry) bool { return e.Message == "Completed full sync operation" }) {
+		t.Error("the run summary was not logged at info")
+	}
+}
+
+func TestSyncAllReportsMonotonicProgress(t *testing.T) {
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""), testFolder("2", "1"), testFolder("3", "1"), testFolder("4", ""))
+	for i, folderID := range []string{"1", "2", "2", "3", "4", "4", "4"} {
+		client.AddDataExtension(testDataExtension(fmt.Sprintf("de-%d", i), folderID))
+	}
+	svc, _ := newTestSync(t, client, nil)
+
+	// The func is called serially, so events needs no lock of its own
+	var events []SyncProgress
+	svc.SetProgressFunc(func(p SyncProgress) { events = append(events, p) })
+
+	if _, err := svc.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll() error = %v", err)
+	}
+
+	if len(events) == 0 {
+		t.Fatal("no progress events reported")
+	}
+	for i := 1; i < len(events); i++ {
+		prev, cur := events[i-1], events[i]
+		if cur.FoldersProcessed < prev.FoldersProcessed || cur.FoldersKnown < prev.FoldersKnown ||
+			cur.DataExtensionsProcessed < prev.DataExtensionsProcessed || cur.DataExtensionsKnown < prev.DataExtensionsKnown ||
+			cur.Succeeded < prev.Succeeded || cur.Failed < prev.Failed {
+			t.Fatalf("progress went backwards from %+v to %+v", prev, cur)
+		}
+	}
+	for _, p := range events {
+		if p.FoldersProcessed > p.FoldersKnown || p.DataExtensionsProcessed > p.DataExtensionsKnown {
+			t.Errorf("progress %+v processed more than it knows of", p)
+		}
+	}
+	last := events[len(events)-1]
+	if last.FoldersProcessed != 4 || last.DataExtensionsProcessed != 7 {
+		t.Errorf("final progress = %+v, want 4 folders and 7 data extensions processed", last)
+	}
+}
+
+func TestSyncAllWithoutProgressFunc(t *testing.T) {
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""))
+	client.AddDataExtension(testDataExtension("de-1", "1"))
+	svc, _ := newTestSync(t, client, nil)
+	svc.SetProgressFunc(nil)
+
+	if _, err := svc.SyncAll(context.Background()); err != nil {
+		t.Fatalf("SyncAll() error = %v", err)
+	}
+}
+
+func TestSyncFolderTreeSyncsOnlySubtree(t *testing.T) {
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""), testFolder("2", "1"), testFolder("3", "2"), testFolder("4", "1"), testFolder("5", ""))
+	for _, folderID := range []string{"1", "2", "3", "4", "5"} {
+		client.AddDataExtension(testDataExtension("de-"+folderID, folderID))
+	}
+	svc, db := newTestSync(t, client, nil)
+
+	metrics, err := svc.SyncFolderTree(context.Background(), "2")
+	if err != nil {
+		t.Fatalf("SyncFolderTree() error = %v", err)
+	}
+	if metrics.DataExtensionsSucceeded != 2 {
+		t.Errorf("%d data extensions succeeded, want the 2 in the subtree", metrics.DataExtensionsSucceeded)
+	}
+
+	// The ancestor is stored so the parent reference holds, but not synced
+	for _, tc := range []struct {
+		table string
+		want  []string
+	}{
+		{"folders", []string{"1", "2", "3"}},
+		{"data_extensions", []string{"de-2", "de-3"}},
+	} {
+		rows, err := db.Pool().Query(context.Background(), "SELECT id FROM "+tc.table+" ORDER BY id")
+		if err != nil {
+			t.Fatalf("failed to list %s: %v", tc.table, err)
+		}
+		var got []string
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				t.Fatalf("failed to scan %s: %v", tc.table, err)
+			}
+			got = append(got, id)
+		}
+		rows.Close()
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("stored %s %v, want %v", tc.table, got, tc.want)
+		}
+	}
+}
+
+func TestSyncFolderTreeUnknownFolder(t *testing.T) {
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""))
+	svc, _ := newTestSync(t, client, nil)
+
+	if _, err := svc.SyncFolderTree(context.Background(), "missing"); !errors.Is(err, ErrFolderNotFound) {
+		t.Errorf("SyncFolderTree() error = %v, want ErrFolderNotFound", err)
+	}
+}
+
+// sumCounts adds up the per-folder counts of metrics
+func sumCounts(metrics *SyncMetrics) FolderCounts {
+	var sum FolderCounts
+	for _, counts := range metrics.CountsByFolder() {
+		sum.FoldersSucceeded += counts.FoldersSucceeded
+		sum.FoldersFailed += counts.FoldersFailed
+		sum.SubfoldersSucceeded += counts.SubfoldersSucceeded
+		sum.SubfoldersFailed += counts.SubfoldersFailed
+		sum.DataExtensionsSucceeded += counts.DataExtensionsSucceeded
+		sum.DataExtensionsFailed += counts.DataExtensionsFailed
+	}
+	return sum
+}
+
+// globalCounts returns the global totals of metrics in the shape of FolderCounts
+func globalCounts(metrics *SyncMetrics) FolderCounts {
+	return FolderCounts{
+		FoldersSucceeded:        metrics.FoldersSucceeded,
+		FoldersFailed:           metrics.FoldersFailed,
+		SubfoldersSucceeded:     metrics.SubfoldersSucceeded,
+		SubfoldersFailed:        metrics.SubfoldersFailed,
+		DataExtensionsSucceeded: metrics.DataExtensionsSucceeded,
+		DataExtensionsFailed:    metrics.DataExtensionsFailed,
+	}
+}
+
+func TestSyncMetricsCountsByFolderMerge(t *testing.T) {
+	first := &SyncMetrics{}
+	first.AddFolderSuccess("1")
+	first.AddSubfolderSuccess("1")
+	first.AddDataExtensions("1", 3, 1)
+	second := &SyncMetrics{}
+	second.AddFolderFailure("2")
+	second.AddSubfolderFailure("1")
+	second.AddDataExtensionSuccess("2")
+
+	total := &SyncMetrics{}
+	total.Merge(first)
+	total.Merge(second)
+
+	want := map[string]FolderCounts{
+		"1": {FoldersSucceeded: 1, SubfoldersSucceeded: 1, SubfoldersFailed: 1, DataExtensionsSucceeded: 3, DataExtensionsFailed: 1},
+		"2": {FoldersFailed: 1, DataExtensionsSucceeded: 1},
+	}
+	if got := total.CountsByFolder(); !reflect.DeepEqual(got, want) {
+		t.Errorf("CountsByFolder() = %+v, want %+v", got, want)
+	}
+	if sum, global := sumCounts(total), globalCounts(total); sum != global {
+		t.Errorf("per-folder counts sum to %+v, want the global %+v", sum, global)
+	}
+}
+
+func TestSyncAllCountsByFolderMatchTotals(t *testing.T) {
+	// The name column holds at most 500 characters, so this save fails
+	tooLong := testDataExtension("de-too-long", "2")
+	tooLong.Name = strings.Repeat("x", 501)
+
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""), testFolder("2", "1"), testFolder("3", "2"), testFolder("4", ""))
+	client.AddDataExtension(
+		testDataExtension("de-1", "1"),
+		testDataExtension("de-2", "2"),
+		tooLong,
+		testDataExtension("de-3a", "3"),
+		testDataExtension("de-3b", "3"),
+		testDataExtension("de-4", "4"),
+	)
+	svc, _ := newTestSync(t, client, nil)
+
+	metrics, err := svc.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAll() error = %v", err)
+	}
+	if metrics.DataExtensionsFailed == 0 {
+		t.Fatal("no data extension failed, want the long name to fail")
+	}
+	if sum, global := sumCounts(metrics), globalCounts(metrics); sum != global {
+		t.Errorf("per-folder counts sum to %+v, want the global %+v", sum, global)
+	}
+	counts := metrics.CountsByFolder()
+	if got := counts["2"]; got.DataExtensionsSucceeded != 1 || got.DataExtensionsFailed != 1 {
+		t.Errorf("folder 2 counts = %+v, want 1 data extension succeeded and 1 failed", got)
+	}
+	if got := counts["3"]; got.DataExtensionsSucceeded != 2 || got.DataExtensionsFailed != 0 {
+		t.Errorf("folder 3 counts = %+v, want 2 data extensions succeeded", got)
+	}
+}
+
+func TestSyncAllLockHeldByAnotherProcess(t *testing.T) {
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""))
+	svc, _ := newTestSync(t, client, nil)
+
+	// Another process syncing the same account holds the lock on its own connection
+	other, err := postgres.New(postgrestest.Config(t), zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to open second pool: %v", err)
+	}
+	defer other.Close()
+	ctx := context.Background()
+	lock, err := other.TryAdvisoryLock(ctx, postgres.AdvisoryLockKey(SyncLockName("")))
+	if err != nil || lock == nil {
+		t.Fatalf("TryAdvisoryLock = %v, %v; want a lock", lock, err)
+	}
+
+	if _, err := svc.SyncAll(ctx); !errors.Is(err, ErrSyncInProgress) {
+		t.Fatalf("SyncAll() error = %v, want ErrSyncInProgress", err)
+	}
+
+	// A sync of another account uses a lock of its own
+	svc.SetLockName(SyncLockName("other-account"))
+	if _, err := svc.SyncAll(ctx); err != nil {
+		t.Fatalf("SyncAll() with another lock name error = %v", err)
+	}
+
+	svc.SetLockName(SyncLockName(""))
+	if err := lock.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if _, err := svc.SyncAll(ctx); err != nil {
+		t.Fatalf("SyncAll() after release error = %v", err)
+	}
+}
+
+// retentionFailingClient is a fake client whose retention updates fail for the given data extensions
+type retentionFailingClient struct {
+	*fake.Client
+	fail map[string]bool
+}
+
+func (c *retentionFailingClient) UpdateDataRetentionCtx(ctx context.Context, dataExtensionID string, retention *sfmce.DataRetentionProperties) error {
+	if c.fail[dataExtensionID] {
+		return errors.New("retention update rejected")
+	}
+	return c.Client.UpdateDataRetentionCtx(ctx, dataExtensionID, retention)
+}
+
+// failureKeys returns the data extension, folder and stage of each failure, sorted
+func failureKeys(failures []DataExtensionFailure) []string {
+	keys := make([]string, len(failures))
+	for i, f := range failures {
+		if f.Error == "" {
+			keys[i] = "no error message: "
+		}
+		keys[i] += f.DataExtensionID + "/" + f.FolderID + "/" + f.Stage
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestSyncDataExtensionsReportsFailures(t *testing.T) {
+	tooLong := testDataExtension("de-too-long", "1")
+	tooLong.Name = strings.Repeat("x", 501)
+
+	client := &retentionFailingClient{Client: fake.NewClient(), fail: map[string]bool{"de-rejected": true}}
+	client.AddFolder(testFolder("1", ""))
+	client.AddDataExtension(testDataExtension("de-ok", "1"), tooLong, testDataExtension("de-rejected", "1"))
+	svc, _ := newTestSync(t, client, nil)
+
+	metrics := &SyncMetrics{}
+	failures, err := svc.SyncDataExtensions(context.Background(), "1", "Folder 1", metrics)
+	if err != nil {
+		t.Fatalf("SyncDataExtensions() error = %v", err)
+	}
+	want := []string{"de-rejected/1/" + FailureStageRetention, "de-too-long/1/" + FailureStageSave}
+	if got := failureKeys(failures); !reflect.DeepEqual(got, want) {
+		t.Errorf("failures = %v, want %v", got, want)
+	}
+	if got := failureKeys(metrics.FailedDataExtensions()); !reflect.DeepEqual(got, want) {
+		t.Errorf("metrics failures = %v, want %v", got, want)
+	}
+}
+
+func TestSyncFolderAggregatesFailures(t *testing.T) {
+	tooLong := testDataExtension("de-too-long", "2")
+	tooLong.Name = strings.Repeat("x", 501)
+
+	client := &retentionFailingClient{Client: fake.NewClient(), fail: map[string]bool{"de-1-rejected": true}}
+	client.AddFolder(testFolder("1", ""), testFolder("2", "1"))
+	client.AddDataExtension(
+		testDataExtension("de-1", "1"),
+		testDataExtension("de-1-rejected", "1"),
+		testDataExtension("de-2", "2"),
+		tooLong,
+	)
+	svc, _ := newTestSync(t, client, nil)
+
+	metrics := &SyncMetrics{}
+	failures, err := svc.SyncFolder(context.Background(), testFolder("1", ""), true, metrics)
+	if err != nil {
+		t.Fatalf("SyncFolder() error = %v", err)
+	}
+	want := []string{"de-1-rejected/1/" + FailureStageRetention, "de-too-long/2/" + FailureStageSave}
+	if got := failureKeys(failures); !reflect.DeepEqual(got, want) {
+		t.Errorf("failures = %v, want %v", got, want)
+	}
+	if got := failureKeys(metrics.FailedDataExtensions()); !reflect.DeepEqual(got, want) {
+		t.Errorf("metrics failures = %v, want %v", got, want)
+	}
+}
+
+func TestSyncAllSaveOnly(t *testing.T) {
+	client := fake.NewClient()
+	client.AddFolder(testFolder("1", ""), testFolder("2", "1"))
+	client.AddDataExtension(testDataExtension("de-1", "1"), testDataExtension("de-2", "2"), testDataExtension("de-3", "2"))
+	cfg := DefaultSyncConfig()
+	cfg.SaveOnly = true
+	svc, db := newTestSync(t, client, cfg)
+
+	metrics, err := svc.SyncAll(context.Background())
+	if err != nil {
+		t.Fatalf("SyncAll() error = %v", err)
+	}
+	if updates := client.RetentionUpdates(); len(updates) != 0 {
+		t.Errorf("save-only sync made %d retention updates, want none", len(updates))
+	}
+	if n := countRows(t, db, "SELECT COUNT(*) FROM data_extensions"); n != 3 {
+		t.Errorf("saved %d data extensions, want 3", n)
+	}
+	if metrics.DataExtensionsSucceeded != 3 || metrics.DataExtensionsFailed != 0 {
+		t.Errorf("data extensions = %d succeeded, %d failed, want 3 and 0",
+			metrics.DataExtensionsSucceeded, metrics.DataExtensionsFailed)
+	}
+	if failures := metrics.FailedDataExtensions(); len(failures) != 0 {
+		t.Errorf("failures = %+v, want none", failures)
+	}
+}
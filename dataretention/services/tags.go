@@ -0,0 +1,133 @@
+package services
+
+import (
+	"cmp"
+	"slices"
+)
+
+// MaxRetentionTagSets bounds how many distinct tag sets a run tracks retention
+// outcomes for. Outcomes for further tag sets are counted under OverflowTag, so an
+// account with many object types can't grow the metrics without bound.
+const MaxRetentionTagSets = 50
+
+// OverflowTag replaces the tags of outcomes beyond MaxRetentionTagSets
+const OverflowTag = "other"
+
+// unknownTag stands in for a folder or object type the API didn't provide
+const unknownTag = "unknown"
+
+// RetentionTags identifies the kind of data extension a retention update was for
+type RetentionTags struct {
+	// FolderType is the type of the folder the data extension was synced from
+	FolderType string
+	// ObjectType is the data extension's partner API object type name
+	ObjectType string
+}
+
+// RetentionOutcome counts retention updates by result
+type RetentionOutcome struct {
+	Succeeded int
+	Failed    int
+	// Skipped counts updates not sent because the retention already matched
+	Skipped int
+}
+
+// TaggedRetentionOutcome is the retention outcome of one tag set
+type TaggedRetentionOutcome struct {
+	RetentionTags
+	RetentionOutcome
+}
+
+// RecordFolderType remembers the type of a folder, so the retention outcomes of its
+// data extensions can be tagged with it
+func (m *SyncMetrics) RecordFolderType(folderID, folderType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.folderTypes == nil {
+		m.folderTypes = make(map[string]string)
+	}
+	m.folderTypes[folderID] = folderType
+}
+
+// retentionTags builds the tags of a data extension synced from folderID
+func (m *SyncMetrics) retentionTags(folderID, objectType string) RetentionTags {
+	m.mu.Lock()
+	folderType := m.folderTypes[folderID]
+	m.mu.Unlock()
+
+	if folderType == "" {
+		folderType = unknownTag
+	}
+	if objectType == "" {
+		objectType = unknownTag
+	}
+	return RetentionTags{FolderType: folderType, ObjectType: objectType}
+}
+
+// AddRetentionOutcome counts the result of one retention update under tags
+func (m *SyncMetrics) AddRetentionOutcome(tags RetentionTags, updated bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcome := m.retentionOutcome(tags)
+	switch {
+	case err != nil:
+		outcome.Failed++
+	case updated:
+		outcome.Succeeded++
+	default:
+		outcome.Skipped++
+	}
+}
+
+// retentionOutcome returns the counters of tags, falling back to the overflow tag
+// set once MaxRetentionTagSets is reached. Callers must hold m.mu.
+func (m *SyncMetrics) retentionOutcome(tags RetentionTags) *RetentionOutcome {
+	if m.retentionByTags == nil {
+		m.retentionByTags = make(map[RetentionTags]*RetentionOutcome)
+	}
+	if outcome, ok := m.retentionByTags[tags]; ok {
+		return outcome
+	}
+
+	overflow := RetentionTags{FolderType: OverflowTag, ObjectType: OverflowTag}
+	// Keep one slot free for the overflow tag set itself
+	if len(m.retentionByTags) >= MaxRetentionTagSets-1 {
+		tags = overflow
+		if outcome, ok := m.retentionByTags[tags]; ok {
+			return outcome
+		}
+	}
+
+	outcome := &RetentionOutcome{}
+	m.retentionByTags[tags] = outcome
+	return outcome
+}
+
+// RetentionByTags returns the retention outcomes of the run per tag set, ordered by
+// folder type and then object type
+func (m *SyncMetrics) RetentionByTags() []TaggedRetentionOutcome {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcomes := make([]TaggedRetentionOutcome, 0, len(m.retentionByTags))
+	for tags, outcome := range m.retentionByTags {
+		outcomes = append(outcomes, TaggedRetentionOutcome{RetentionTags: tags, RetentionOutcome: *outcome})
+	}
+	slices.SortFunc(outcomes, func(a, b TaggedRetentionOutcome) int {
+		return cmp.Or(cmp.Compare(a.FolderType, b.FolderType), cmp.Compare(a.ObjectType, b.ObjectType))
+	})
+	return outcomes
+}
+
+// mergeRetentionByTags adds the tagged outcomes of other into m
+func (m *SyncMetrics) mergeRetentionByTags(other *SyncMetrics) {
+	outcomes := other.RetentionByTags()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, tagged := range outcomes {
+		outcome := m.retentionOutcome(tagged.RetentionTags)
+		outcome.Succeeded += tagged.Succeeded
+		outcome.Failed += tagged.Failed
+		outcome.Skipped += tagged.Skipped
+	}
+}
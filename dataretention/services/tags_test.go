@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestRetentionByTags(t *testing.T) {
+	metrics := &SyncMetrics{}
+	metrics.RecordFolderType("1", "dataextension")
+	metrics.RecordFolderType("2", "shared_dataextension")
+
+	failed := errors.New("update failed")
+	for _, outcome := range []struct {
+		folderID   string
+		objectType string
+		updated    bool
+		err        error
+	}{
+		{"1", "DataExtension", true, nil},
+		{"1", "DataExtension", true, nil},
+		{"1", "DataExtension", false, nil},
+		{"1", "SendableDataExtension", false, failed},
+		{"2", "DataExtension", true, nil},
+		{"3", "", true, nil},
+	} {
+		tags := metrics.retentionTags(outcome.folderID, outcome.objectType)
+		metrics.AddRetentionOutcome(tags, outcome.updated, outcome.err)
+	}
+
+	want := []TaggedRetentionOutcome{
+		{RetentionTags{"dataextension", "DataExtension"}, RetentionOutcome{Succeeded: 2, Skipped: 1}},
+		{RetentionTags{"dataextension", "SendableDataExtension"}, RetentionOutcome{Failed: 1}},
+		{RetentionTags{"shared_dataextension", "DataExtension"}, RetentionOutcome{Succeeded: 1}},
+		// A folder whose type was never recorded and a missing object type
+		{RetentionTags{unknownTag, unknownTag}, RetentionOutcome{Succeeded: 1}},
+	}
+	if got := metrics.RetentionByTags(); !slices.Equal(got, want) {
+		t.Errorf("RetentionByTags() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// Merging keeps the tags apart
+	totals := &SyncMetrics{}
+	totals.mergeRetentionByTags(metrics)
+	totals.mergeRetentionByTags(metrics)
+	if got := totals.RetentionByTags()[0].Succeeded; got != 4 {
+		t.Errorf("merged succeeded = %d, want 4", got)
+	}
+}
+
+func TestRetentionByTagsBoundsCardinality(t *testing.T) {
+	metrics := &SyncMetrics{}
+	for i := 0; i < MaxRetentionTagSets*2; i++ {
+		metrics.AddRetentionOutcome(RetentionTags{FolderType: "dataextension", ObjectType: fmt.Sprintf("Type%03d", i)}, true, nil)
+	}
+
+	outcomes := metrics.RetentionByTags()
+	if len(outcomes) != MaxRetentionTagSets {
+		t.Fatalf("tracked %d tag sets, want at most %d", len(outcomes), MaxRetentionTagSets)
+	}
+	total := 0
+	var overflow RetentionOutcome
+	for _, outcome := range outcomes {
+		total += outcome.Succeeded
+		if outcome.FolderType == OverflowTag && outcome.ObjectType == OverflowTag {
+			overflow = outcome.RetentionOutcome
+		}
+	}
+	if total != MaxRetentionTagSets*2 {
+		t.Errorf("counted %d outcomes, want all %d", total, MaxRetentionTagSets*2)
+	}
+	if want := MaxRetentionTagSets*2 - (MaxRetentionTagSets - 1); overflow.Succeeded != want {
+		t.Errorf("overflow counted %d outcomes, want %d", overflow.Succeeded, want)
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	httpclient "github.com/natserract/sf/pkg/http"
+	"go.uber.org/zap"
+)
+
+// concurrencyController bounds how many API calls the sync's worker pools make at
+// once, across all of them, and adapts the bound AIMD-style: every overload failure
+// cuts the limit by a factor and every success grows it by about one per limit's
+// worth of calls. When the API starts returning server errors org-wide, the
+// workers back off together instead of each retrying at full concurrency.
+type concurrencyController struct {
+	logger   *zap.Logger
+	min      float64
+	max      float64
+	decrease float64
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	// lastDecrease is when the limit was last cut; only calls started after it
+	// cut it again, so one spike of failures counts once
+	lastDecrease time.Time
+	// changed is closed and replaced whenever a slot frees up or the limit changes
+	changed chan struct{}
+}
+
+// newConcurrencyController creates a controller starting at the maximum limit
+func newConcurrencyController(cfg *SyncConfig, logger *zap.Logger) *concurrencyController {
+	return &concurrencyController{
+		logger:   logger,
+		min:      float64(cfg.AdaptiveMinInFlight),
+		max:      float64(cfg.AdaptiveMaxInFlight),
+		decrease: cfg.AdaptiveDecreaseFactor,
+		limit:    float64(cfg.AdaptiveMaxInFlight),
+		changed:  make(chan struct{}),
+	}
+}
+
+// do runs call once a slot is free and feeds its outcome back into the limit.
+// A nil controller runs call directly.
+func (c *concurrencyController) do(ctx context.Context, call func() error) error {
+	if c == nil {
+		return call()
+	}
+
+	started, err := c.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	err = call()
+	c.release(started, err)
+	return err
+}
+
+func (c *concurrencyController) acquire(ctx context.Context) (time.Time, error) {
+	for {
+		c.mu.Lock()
+		if c.inFlight < int(c.limit) {
+			c.inFlight++
+			c.mu.Unlock()
+			return time.Now(), nil
+		}
+		changed := c.changed
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return time.Time{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func (c *concurrencyController) release(started time.Time, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inFlight--
+	previous := int(c.limit)
+	switch {
+	case isOverloadError(err):
+		if started.After(c.lastDecrease) {
+			c.limit = math.Max(c.min, c.limit*c.decrease)
+			c.lastDecrease = time.Now()
+		}
+	case err == nil:
+		c.limit = math.Min(c.max, c.limit+1/c.limit)
+	}
+
+	if current := int(c.limit); current < previous {
+		c.logger.Warn("API calls failing, reducing sync concurrency",
+			zap.Int("previous_limit", previous),
+			zap.Int("limit", current),
+			zap.Error(err))
+	} else if current > previous && current == int(c.max) {
+		c.logger.Info("Sync concurrency recovered", zap.Int("limit", current))
+	}
+
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// isOverloadError reports whether err suggests the API is struggling as a whole:
+// server errors, rate limiting, timeouts and network failures. Client errors and
+// validation failures concern a single request, so they don't count.
+func isOverloadError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if statusErr, ok := httpclient.AsStatusError(err); ok {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
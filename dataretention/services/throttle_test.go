@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	httpclient "github.com/natserract/sf/pkg/http"
+	"go.uber.org/zap"
+)
+
+// runConcurrent makes n calls through c at once, each taking a moment and returning
+// err, and returns the most that were in flight together
+func runConcurrent(c *concurrencyController, n int, err error) int32 {
+	var inFlight, maxInFlight atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.do(context.Background(), func() error {
+				current := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					highest := maxInFlight.Load()
+					if current <= highest || maxInFlight.CompareAndSwap(highest, current) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+	return maxInFlight.Load()
+}
+
+func (c *concurrencyController) currentLimit() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return int(c.limit)
+}
+
+func TestConcurrencyControllerThrottlesAndRecovers(t *testing.T) {
+	cfg := DefaultSyncConfig()
+	cfg.AdaptiveMaxInFlight = 8
+	cfg.AdaptiveMinInFlight = 1
+	cfg.AdaptiveDecreaseFactor = 0.5
+	c := newConcurrencyController(cfg, zap.NewNop())
+
+	if got := runConcurrent(c, 16, nil); got > 8 {
+		t.Errorf("%d calls in flight, want at most the maximum 8", got)
+	}
+
+	// A spike of server errors: each failure of a call started after the last cut
+	// halves the limit, down to the floor
+	overloaded := &httpclient.StatusError{StatusCode: http.StatusServiceUnavailable}
+	for i := 0; i < 5; i++ {
+		c.do(context.Background(), func() error { return overloaded })
+	}
+	if got := c.currentLimit(); got != 1 {
+		t.Fatalf("limit after the spike = %d, want the floor 1", got)
+	}
+	if got := runConcurrent(c, 4, overloaded); got != 1 {
+		t.Errorf("%d calls in flight while throttled, want 1", got)
+	}
+
+	// Successes ramp the limit back up to the maximum
+	for i := 0; i < 100 && c.currentLimit() < 8; i++ {
+		runConcurrent(c, 8, nil)
+	}
+	if got := c.currentLimit(); got != 8 {
+		t.Errorf("limit after recovering = %d, want 8", got)
+	}
+}
+
+func TestConcurrencyControllerCountsSpikeOnce(t *testing.T) {
+	cfg := DefaultSyncConfig()
+	cfg.AdaptiveMaxInFlight = 8
+	cfg.AdaptiveMinInFlight = 1
+	cfg.AdaptiveDecreaseFactor = 0.5
+	c := newConcurrencyController(cfg, zap.NewNop())
+
+	// Calls already in flight when the first failure cuts the limit don't cut it again
+	var started sync.WaitGroup
+	started.Add(8)
+	var done sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			c.do(context.Background(), func() error {
+				started.Done()
+				started.Wait()
+				return &httpclient.StatusError{StatusCode: http.StatusBadGateway}
+			})
+		}()
+	}
+	done.Wait()
+	if got := c.currentLimit(); got != 4 {
+		t.Errorf("limit = %d after one spike of concurrent failures, want one cut to 4", got)
+	}
+}
+
+func TestIsOverloadError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{&httpclient.StatusError{StatusCode: http.StatusInternalServerError}, true},
+		{fmt.Errorf("update failed: %w", &httpclient.StatusError{StatusCode: http.StatusTooManyRequests}), true},
+		{&httpclient.StatusError{StatusCode: http.StatusBadRequest}, false},
+		{&httpclient.StatusError{StatusCode: http.StatusNotFound}, false},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{errors.New("invalid retention settings"), false},
+	}
+	for _, tt := range tests {
+		if got := isOverloadError(tt.err); got != tt.want {
+			t.Errorf("isOverloadError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+	"go.uber.org/zap"
+)
+
+func TestBackOffFactoryRetriesImmediately(t *testing.T) {
+	server, hits := newFailingServer(t)
+	client := newRetryingClient(3, nil)
+
+	start := time.Now()
+	if _, err := client.Get(context.Background(), server.URL, nil); err == nil {
+		t.Fatal("Get() error = nil, want the 503")
+	}
+	// The first attempt and three retries
+	if got := hits.Load(); got != 4 {
+		t.Errorf("server got %d requests, want 4", got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retries took %s, want no backoff between them", elapsed)
+	}
+}
+
+func TestBackOffFactoryPerRequest(t *testing.T) {
+	server, hits := newFailingServer(t)
+	client := NewClientWithLogger(zap.NewNop())
+	// The client's own strategy would wait an hour before the first retry
+	client.SetBackOffFactory(func() backoff.BackOff { return backoff.NewConstantBackOff(time.Hour) })
+
+	start := time.Now()
+	_, err := client.Do(RequestOptions{
+		Method:         http.MethodGet,
+		URL:            server.URL,
+		Context:        context.Background(),
+		MaxRetries:     2,
+		MaxElapsed:     time.Minute,
+		BackOffFactory: func() backoff.BackOff { return &backoff.ZeroBackOff{} },
+	})
+	if err == nil {
+		t.Fatal("Do() error = nil, want the 503")
+	}
+	if got := hits.Load(); got != 3 {
+		t.Errorf("server got %d requests, want 3", got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retries took %s, want the request's zero backoff", elapsed)
+	}
+}
+
+func TestBackOffMultiplier(t *testing.T) {
+	tests := []struct {
+		name       string
+		multiplier float64
+		want       []time.Duration
+	}{
+		{"default", 0, []time.Duration{100 * time.Millisecond, 150 * time.Millisecond, 225 * time.Millisecond}},
+		{"double", 2, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}},
+		{"triple", 3, []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond}},
+	}
+	client := NewClientWithLogger(zap.NewNop())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, ok := client.newBackOff(RequestOptions{
+				InitialInterval: 100 * time.Millisecond,
+				MaxInterval:     time.Hour,
+				Multiplier:      tt.multiplier,
+			}).(*backoff.ExponentialBackOff)
+			if !ok {
+				t.Fatal("newBackOff() did not return an exponential backoff")
+			}
+			// Without jitter the intervals are exact
+			b.RandomizationFactor = 0
+			for i, want := range tt.want {
+				if got := b.NextBackOff(); got != want {
+					t.Errorf("interval %d = %s, want %s", i, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRunBudgetExhausted is returned for requests started after the run budget's
+// deadline has passed
+var ErrRunBudgetExhausted = errors.New("run budget exhausted")
+
+// RunBudget tracks the time left in an overall run (e.g. a full sync) and derives
+// per-request timeouts from it, so requests issued close to the deadline get
+// shorter timeouts instead of a fixed value that could overrun the run.
+type RunBudget struct {
+	deadline time.Time
+	floor    time.Duration
+}
+
+// NewRunBudget creates a budget that expires after total. Requests started before
+// the deadline get a timeout of at least floor; none are allowed once it has passed.
+func NewRunBudget(total, floor time.Duration) *RunBudget {
+	return &RunBudget{
+		deadline: time.Now().Add(total),
+		floor:    floor,
+	}
+}
+
+// Deadline returns the absolute deadline of the run
+func (b *RunBudget) Deadline() time.Time {
+	return b.deadline
+}
+
+// Remaining returns the time left before the deadline (zero once it has passed)
+func (b *RunBudget) Remaining() time.Duration {
+	remaining := time.Until(b.deadline)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// RequestTimeout returns the timeout for a request started now: the remaining
+// budget, clamped so it never goes below the floor. It is zero once the deadline
+// has passed.
+func (b *RunBudget) RequestTimeout() time.Duration {
+	timeout := b.Remaining()
+	if timeout == 0 {
+		return 0
+	}
+	if timeout < b.floor {
+		return b.floor
+	}
+	return timeout
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestRunBudgetRequestTimeout(t *testing.T) {
+	const floor = 5 * time.Second
+
+	tests := []struct {
+		name     string
+		left     time.Duration
+		min, max time.Duration
+	}{
+		{"plenty left", 10 * time.Minute, 9 * time.Minute, 10 * time.Minute},
+		{"deadline nearing", 30 * time.Second, 29 * time.Second, 30 * time.Second},
+		{"below the floor", time.Second, floor, floor},
+		{"deadline passed", -time.Minute, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := &RunBudget{deadline: time.Now().Add(tt.left), floor: floor}
+			got := budget.RequestTimeout()
+			if got < tt.min || got > tt.max {
+				t.Errorf("RequestTimeout() = %s, want between %s and %s", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRunBudgetTimeoutShrinks(t *testing.T) {
+	budget := NewRunBudget(time.Hour, time.Second)
+	first := budget.RequestTimeout()
+	time.Sleep(10 * time.Millisecond)
+	if second := budget.RequestTimeout(); second >= first {
+		t.Errorf("RequestTimeout() = %s after %s, want it to shrink", second, first)
+	}
+}
+
+func TestRunBudgetBoundsRequests(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClientWithLogger(zap.NewNop())
+	// The deadline is about to pass, so the request gets the floor as its timeout
+	client.SetRunBudget(&RunBudget{deadline: time.Now().Add(10 * time.Millisecond), floor: 50 * time.Millisecond})
+
+	start := time.Now()
+	_, err := client.Get(context.Background(), server.URL, nil)
+	if err == nil {
+		t.Fatal("Get() error = nil, want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Get() returned after %s, want it bounded by the budget floor", elapsed)
+	}
+}
+
+func TestRunBudgetExhausted(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer server.Close()
+
+	client := NewClientWithLogger(zap.NewNop())
+	client.SetRunBudget(&RunBudget{deadline: time.Now().Add(-time.Minute), floor: time.Second})
+
+	_, err := client.Get(context.Background(), server.URL, nil)
+	if !errors.Is(err, ErrRunBudgetExhausted) {
+		t.Fatalf("Get() error = %v, want ErrRunBudgetExhausted", err)
+	}
+	if hits != 0 {
+		t.Errorf("server got %d requests, want none once the budget is exhausted", hits)
+	}
+}
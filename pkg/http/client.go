@@ -6,20 +6,45 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/url"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff/v5"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
+// BackOffFactory creates the backoff strategy used to space out the retries of a single request
+type BackOffFactory func() backoff.BackOff
+
 type Client struct {
-	httpClient *http.Client
-	logger     *zap.Logger
+	httpClient     *http.Client
+	logger         *zap.Logger
+	budget         *RunBudget
+	retryBudget    *RetryBudget
+	limiter        *rate.Limiter
+	backOffFactory BackOffFactory
+	// maxRetries and maxElapsed apply to requests that don't set their own
+	maxRetries int
+	maxElapsed time.Duration
+	// defaultHeaders are set on every request; the request's own headers win
+	defaultHeaders map[string]string
+	// idempotencyHeader carries a generated key on POST and PATCH requests; empty turns that off
+	idempotencyHeader string
 }
 
+// DefaultIdempotencyHeader is the header idempotency keys are sent in unless
+// SetIdempotencyHeader names another
+const DefaultIdempotencyHeader = "X-Idempotency-Key"
+
+// RequestOptions describes a request for Do. MaxRetries caps the attempts after the
+// first one; zero uses the client's retry policy (SetRetryPolicy), and when that has
+// none either, only MaxElapsed bounds the retries.
 type RequestOptions struct {
 	Method          string
 	URL             string
@@ -30,6 +55,35 @@ type RequestOptions struct {
 	MaxElapsed      time.Duration
 	InitialInterval time.Duration
 	MaxInterval     time.Duration
+	// DisableRetry makes the request a single attempt, for calls that aren't safe to
+	// repeat when an attempt may have reached the server
+	DisableRetry bool
+	// Multiplier is how much the retry interval grows per attempt (default: backoff.DefaultMultiplier)
+	Multiplier float64
+	// BackOffFactory overrides the client's backoff strategy for this request
+	BackOffFactory BackOffFactory
+	// SuccessCodes decides which status codes count as success (default: DefaultSuccessCodes).
+	// When set, redirects are not followed, so 3xx responses are checked against it too.
+	SuccessCodes func(statusCode int) bool
+	// IdempotencyKey is sent in the idempotency header on every attempt, so the server
+	// can tell retries of this request from new ones. POST and PATCH requests get a
+	// generated key when the client has an idempotency header set.
+	IdempotencyKey string
+
+	// raw is the caller's request when sent through DoRequest; each attempt copies it
+	raw *http.Request
+}
+
+// DefaultSuccessCodes treats every status below 400 as success
+func DefaultSuccessCodes(statusCode int) bool {
+	return statusCode < 400
+}
+
+// StatusIn returns a SuccessCodes predicate accepting exactly the given status codes
+func StatusIn(codes ...int) func(statusCode int) bool {
+	return func(statusCode int) bool {
+		return slices.Contains(codes, statusCode)
+	}
 }
 
 type Response struct {
@@ -58,8 +112,200 @@ func NewClientWithLogger(logger *zap.Logger) *Client {
 	}
 }
 
+// NewClientWithLimiter creates a new HTTP client whose requests all pass through the
+// given rate limiter. Share one limiter between clients to enforce a global quota.
+func NewClientWithLimiter(logger *zap.Logger, limiter *rate.Limiter) *Client {
+	c := NewClientWithLogger(logger)
+	c.limiter = limiter
+	return c
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerSecond on average with bursts of up to burst requests
+func NewRateLimiter(requestsPerSecond float64, burst int) *rate.Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+}
+
+// SetRateLimiter replaces the rate limiter every request waits on. Passing nil disables it.
+func (c *Client) SetRateLimiter(limiter *rate.Limiter) {
+	c.limiter = limiter
+}
+
+// SetTimeout sets the timeout of each non-streaming attempt (30 seconds by default)
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// SetRetryPolicy sets how many times and for how long requests that don't set their
+// own MaxRetries and MaxElapsed are retried. Zero keeps the default for either: no
+// cap on the retry count, and 5 minutes of retrying.
+func (c *Client) SetRetryPolicy(maxRetries int, maxElapsed time.Duration) {
+	c.maxRetries = maxRetries
+	c.maxElapsed = maxElapsed
+}
+
+// SetRunBudget makes every request derive its timeout from the remaining run budget
+// instead of relying only on the fixed client timeout. Passing nil disables it.
+func (c *Client) SetRunBudget(budget *RunBudget) {
+	c.budget = budget
+}
+
+// SetRetryBudget caps the retries of all requests together. Passing nil disables it.
+func (c *Client) SetRetryBudget(budget *RetryBudget) {
+	c.retryBudget = budget
+}
+
+// SetDefaultHeaders sets headers sent with every request, e.g. for audit or
+// correlation. Headers set on a request override them. Passing nil removes them.
+func (c *Client) SetDefaultHeaders(headers map[string]string) {
+	c.defaultHeaders = maps.Clone(headers)
+}
+
+// SetIdempotencyHeader makes POST and PATCH requests carry a key in the named header
+// that stays the same across their retries, generated per request unless the request
+// sets IdempotencyKey. Passing an empty name turns the generated keys off.
+func (c *Client) SetIdempotencyHeader(name string) {
+	c.idempotencyHeader = name
+}
+
+// withIdempotencyKey adds the request's idempotency key, if it has one, to its headers
+func (c *Client) withIdempotencyKey(opts RequestOptions) RequestOptions {
+	key := opts.IdempotencyKey
+	if key == "" && c.idempotencyHeader != "" && (opts.Method == http.MethodPost || opts.Method == http.MethodPatch) {
+		key = uuid.NewString()
+	}
+	if key == "" {
+		return opts
+	}
+
+	header := c.idempotencyHeader
+	if header == "" {
+		header = DefaultIdempotencyHeader
+	}
+	headers := make(map[string]string, len(opts.Headers)+1)
+	maps.Copy(headers, opts.Headers)
+	if _, ok := headers[header]; !ok {
+		headers[header] = key
+	}
+	opts.Headers = headers
+	return opts
+}
+
+// SetBackOffFactory replaces the backoff strategy used between retries. Passing nil
+// restores the default exponential backoff built from the request options.
+func (c *Client) SetBackOffFactory(factory BackOffFactory) {
+	c.backOffFactory = factory
+}
+
+// newBackOff returns the backoff strategy for a request: the per-request factory,
+// then the client's factory, then an exponential backoff from the request options
+func (c *Client) newBackOff(opts RequestOptions) backoff.BackOff {
+	if opts.BackOffFactory != nil {
+		return opts.BackOffFactory()
+	}
+	if c.backOffFactory != nil {
+		return c.backOffFactory()
+	}
+
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.InitialInterval = opts.InitialInterval
+	expBackoff.MaxInterval = opts.MaxInterval
+	if opts.Multiplier > 0 {
+		expBackoff.Multiplier = opts.Multiplier
+	}
+	expBackoff.Reset()
+	return expBackoff
+}
+
+// allowRetry takes a retry from the retry budget, if any, and logs when none is left
+func (c *Client) allowRetry() bool {
+	if c.retryBudget == nil || c.retryBudget.Allow() {
+		return true
+	}
+	c.logger.Warn("Retry budget exhausted, not retrying")
+	return false
+}
+
+// lastAttempt reports whether attempt is the last one MaxRetries allows; it is never
+// retried, so it mustn't take from the retry budget
+func lastAttempt(opts RequestOptions, attempt int) bool {
+	return opts.MaxRetries > 0 && attempt > opts.MaxRetries
+}
+
+// clientFor returns the http.Client to use for a request. Requests with explicit
+// success codes don't follow redirects, so the caller sees the 3xx itself.
+func (c *Client) clientFor(opts RequestOptions) *http.Client {
+	if opts.SuccessCodes == nil {
+		return c.httpClient
+	}
+	noRedirect := *c.httpClient
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return &noRedirect
+}
+
 func (c *Client) Do(opts RequestOptions) (*Response, error) {
+	httpResp, err := c.send(opts, c.clientFor(opts))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		c.logger.Error("Failed to read response body", zap.Error(err))
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	c.logger.Debug("HTTP request completed successfully",
+		zap.Int("status_code", httpResp.StatusCode),
+		zap.String("method", opts.Method),
+		zap.String("url", opts.URL))
+
+	return &Response{
+		StatusCode: httpResp.StatusCode,
+		Headers:    httpResp.Header,
+		Body:       body,
+	}, nil
+}
+
+// DoStreaming performs the request with the same rate limiting, success codes and
+// retries as Do, but returns the live response with its body unread, so large
+// results can be consumed incrementally. The caller owns the body and must close it.
+// Rejected responses are read and closed here and returned as a *StatusError.
+//
+// The client's fixed timeout would cut off long reads, so it doesn't apply; bound
+// the request and the read with opts.Context instead.
+func (c *Client) DoStreaming(opts RequestOptions) (*http.Response, error) {
+	streaming := *c.clientFor(opts)
+	streaming.Timeout = 0
+
+	httpResp, err := c.send(opts, &streaming)
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("HTTP request streaming response",
+		zap.Int("status_code", httpResp.StatusCode),
+		zap.String("method", opts.Method),
+		zap.String("url", opts.URL))
+
+	return httpResp, nil
+}
+
+// send performs the request through httpClient, retrying network and server errors,
+// and returns the accepted response with its body unread
+func (c *Client) send(opts RequestOptions, httpClient *http.Client) (*http.Response, error) {
 	// Set default backoff configuration
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = c.maxRetries
+	}
+	if opts.MaxElapsed == 0 {
+		opts.MaxElapsed = c.maxElapsed
+	}
 	if opts.MaxElapsed == 0 {
 		opts.MaxElapsed = 5 * time.Minute
 	}
@@ -70,11 +316,20 @@ func (c *Client) Do(opts RequestOptions) (*Response, error) {
 		opts.MaxInterval = 30 * time.Second
 	}
 
-	// Create exponential backoff
-	expBackoff := backoff.NewExponentialBackOff()
-	expBackoff.InitialInterval = opts.InitialInterval
-	expBackoff.MaxInterval = opts.MaxInterval
-	expBackoff.Reset()
+	// The key is chosen once, so every attempt of the request sends the same one
+	opts = c.withIdempotencyKey(opts)
+
+	// Don't keep retrying past the end of the run budget
+	if c.budget != nil {
+		remaining := c.budget.RequestTimeout()
+		if remaining == 0 {
+			c.logger.Warn("Run budget exhausted, request not sent", zap.String("method", opts.Method), zap.String("url", opts.URL))
+			return nil, ErrRunBudgetExhausted
+		}
+		if remaining < opts.MaxElapsed {
+			opts.MaxElapsed = remaining
+		}
+	}
 
 	// Use context if provided
 	ctx := opts.Context
@@ -82,9 +337,32 @@ func (c *Client) Do(opts RequestOptions) (*Response, error) {
 		ctx = context.Background()
 	}
 
-	operation := func() (*Response, error) {
-		req, err := c.buildRequest(ctx, opts)
+	attempt := 0
+	operation := func() (*http.Response, error) {
+		attempt++
+
+		// Wait for the rate limiter before every attempt, retries included
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				c.logger.Warn("Rate limiter wait aborted", zap.Error(err), zap.String("method", opts.Method), zap.String("url", opts.URL))
+				return nil, backoff.Permanent(fmt.Errorf("rate limiter wait aborted: %w", err))
+			}
+		}
+
+		// Each attempt gets a timeout derived from what's left of the run budget.
+		// An accepted response keeps it until its body is closed.
+		reqCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.budget != nil {
+			timeout := c.budget.RequestTimeout()
+			if timeout == 0 {
+				return nil, backoff.Permanent(ErrRunBudgetExhausted)
+			}
+			reqCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+
+		req, err := c.buildRequest(reqCtx, opts)
 		if err != nil {
+			cancel()
 			c.logger.Error("Failed to build request", zap.Error(err), zap.String("method", opts.Method), zap.String("url", opts.URL))
 			return nil, backoff.Permanent(err)
 		}
@@ -93,46 +371,60 @@ func (c *Client) Do(opts RequestOptions) (*Response, error) {
 			zap.String("method", opts.Method),
 			zap.String("url", opts.URL))
 
-		httpResp, err := c.httpClient.Do(req)
+		httpResp, err := httpClient.Do(req)
 		if err != nil {
+			cancel()
+			if opts.DisableRetry || lastAttempt(opts, attempt) {
+				return nil, backoff.Permanent(err)
+			}
 			// Network errors are retryable
+			if !c.allowRetry() {
+				return nil, backoff.Permanent(fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, err))
+			}
 			c.logger.Warn("HTTP request failed, will retry",
 				zap.Error(err),
 				zap.String("method", opts.Method),
 				zap.String("url", opts.URL))
 			return nil, err
 		}
-		defer httpResp.Body.Close()
 
-		body, err := io.ReadAll(httpResp.Body)
-		if err != nil {
-			c.logger.Error("Failed to read response body", zap.Error(err))
-			return nil, backoff.Permanent(fmt.Errorf("failed to read response body: %w", err))
+		isSuccess := opts.SuccessCodes
+		if isSuccess == nil {
+			isSuccess = DefaultSuccessCodes
 		}
 
-		resp := &Response{
-			StatusCode: httpResp.StatusCode,
-			Headers:    httpResp.Header,
-			Body:       body,
-		}
+		if !isSuccess(httpResp.StatusCode) {
+			body, err := io.ReadAll(httpResp.Body)
+			httpResp.Body.Close()
+			cancel()
+			if err != nil {
+				c.logger.Error("Failed to read response body", zap.Error(err))
+				return nil, backoff.Permanent(fmt.Errorf("failed to read response body: %w", err))
+			}
 
-		// Check if status code indicates retryable error
-		if httpResp.StatusCode >= 500 {
-			c.logger.Warn("Server error, will retry",
-				zap.Int("status_code", httpResp.StatusCode),
-				zap.String("method", opts.Method),
-				zap.String("url", opts.URL))
-			return nil, fmt.Errorf("server error: %d - %s", httpResp.StatusCode, string(body))
-		}
+			// Check if status code indicates retryable error
+			if httpResp.StatusCode >= 500 {
+				statusErr := &StatusError{StatusCode: httpResp.StatusCode, Body: body}
+				if opts.DisableRetry || lastAttempt(opts, attempt) {
+					return nil, backoff.Permanent(statusErr)
+				}
+				if !c.allowRetry() {
+					return nil, backoff.Permanent(fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, statusErr))
+				}
+				c.logger.Warn("Server error, will retry",
+					zap.Int("status_code", httpResp.StatusCode),
+					zap.String("method", opts.Method),
+					zap.String("url", opts.URL))
+				return nil, statusErr
+			}
 
-		// 4xx errors are not retryable
-		if httpResp.StatusCode >= 400 {
-			c.logger.Error("Client error, not retryable",
+			// Anything else the caller doesn't accept (4xx, or a rejected 2xx/3xx) is not retryable
+			c.logger.Error("Unexpected status, not retryable",
 				zap.Int("status_code", httpResp.StatusCode),
 				zap.String("method", opts.Method),
 				zap.String("url", opts.URL),
 				zap.String("response", string(body)))
-			return nil, backoff.Permanent(fmt.Errorf("client error: %d - %s", httpResp.StatusCode, string(body)))
+			return nil, backoff.Permanent(&StatusError{StatusCode: httpResp.StatusCode, Body: body})
 		}
 
 		c.logger.Debug("HTTP request successful",
@@ -140,15 +432,19 @@ func (c *Client) Do(opts RequestOptions) (*Response, error) {
 			zap.String("method", opts.Method),
 			zap.String("url", opts.URL))
 
-		return resp, nil
+		httpResp.Body = &cancelOnClose{ReadCloser: httpResp.Body, cancel: cancel}
+		return httpResp, nil
 	}
 
 	retryOpts := []backoff.RetryOption{
-		backoff.WithBackOff(expBackoff),
+		backoff.WithBackOff(c.newBackOff(opts)),
 		backoff.WithMaxElapsedTime(opts.MaxElapsed),
 	}
+	if opts.MaxRetries > 0 {
+		retryOpts = append(retryOpts, backoff.WithMaxTries(uint(opts.MaxRetries)+1))
+	}
 
-	resp, err := backoff.Retry(ctx, operation, retryOpts...)
+	httpResp, err := backoff.Retry(ctx, operation, retryOpts...)
 	if err != nil {
 		c.logger.Error("HTTP request failed after retries",
 			zap.Error(err),
@@ -157,15 +453,26 @@ func (c *Client) Do(opts RequestOptions) (*Response, error) {
 		return nil, err
 	}
 
-	c.logger.Info("HTTP request completed successfully",
-		zap.Int("status_code", resp.StatusCode),
-		zap.String("method", opts.Method),
-		zap.String("url", opts.URL))
+	return httpResp, nil
+}
 
-	return resp, nil
+// cancelOnClose releases the attempt's context once the response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
 
 func (c *Client) buildRequest(ctx context.Context, opts RequestOptions) (*http.Request, error) {
+	if opts.raw != nil {
+		return c.buildRawRequest(ctx, opts)
+	}
+
 	var bodyReader io.Reader
 	if opts.Body != nil {
 		if bodyBytes, ok := opts.Body.([]byte); ok {
@@ -233,6 +540,9 @@ func (c *Client) buildRequest(ctx context.Context, opts RequestOptions) (*http.R
 		req.Header.Set("Content-Type", "application/json")
 	}
 	req.Header.Set("Accept", "application/json")
+	for key, value := range c.defaultHeaders {
+		req.Header.Set(key, value)
+	}
 
 	// Set custom headers
 	for key, value := range opts.Headers {
@@ -263,8 +573,45 @@ func (c *Client) Post(ctx context.Context, url string, headers map[string]string
 
 // DoRequest executes a fully-constructed net/http request. This is useful for
 // calling endpoints that don't fit the typed helper methods (custom/native APIs).
+// It goes through the same rate limiter, run budget, default headers and idempotency
+// keys as Do. Network errors are retried when the body can be replayed (req.GetBody
+// is set, as it is for bytes and strings readers). The response is returned whatever
+// its status, with its body unread; the caller closes it.
 func (c *Client) DoRequest(req *http.Request) (*http.Response, error) {
-	return c.httpClient.Do(req)
+	return c.send(RequestOptions{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Context: req.Context(),
+		// The caller checks the status itself
+		SuccessCodes: func(int) bool { return true },
+		DisableRetry: req.Body != nil && req.Body != http.NoBody && req.GetBody == nil,
+		raw:          req,
+	}, c.httpClient)
+}
+
+// buildRawRequest prepares an attempt of a request passed to DoRequest: a copy bound
+// to ctx with a fresh body, plus the default headers and idempotency key it doesn't
+// set itself
+func (c *Client) buildRawRequest(ctx context.Context, opts RequestOptions) (*http.Request, error) {
+	req := opts.raw.Clone(ctx)
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	if opts.raw.GetBody != nil {
+		body, err := opts.raw.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to reset request body: %w", err)
+		}
+		req.Body = body
+	}
+	for _, headers := range []map[string]string{c.defaultHeaders, opts.Headers} {
+		for key, value := range headers {
+			if req.Header.Get(key) == "" {
+				req.Header.Set(key, value)
+			}
+		}
+	}
+	return req, nil
 }
 
 func (c *Client) Patch(ctx context.Context, url string, headers map[string]string, body interface{}) (*Response, error) {
@@ -0,0 +1,237 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestSuccessCodes(t *testing.T) {
+	var hits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/multi", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusMultiStatus)
+		w.Write([]byte(`{"items":[{"status":500}]}`))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/target", http.StatusFound)
+	})
+	mux.HandleFunc("/target", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("target"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		path         string
+		successCodes func(int) bool
+		wantStatus   int
+		wantErr      bool
+	}{
+		{"207 succeeds by default", "/multi", nil, http.StatusMultiStatus, false},
+		{"207 fails outside the success set", "/multi", StatusIn(http.StatusOK), http.StatusMultiStatus, true},
+		{"redirects are followed by default", "/moved", nil, http.StatusOK, false},
+		{"redirects are returned with a success set", "/moved", StatusIn(http.StatusOK), http.StatusFound, true},
+		{"a success set may accept a redirect", "/moved", StatusIn(http.StatusFound), http.StatusFound, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits.Store(0)
+			client := NewClientWithLogger(zap.NewNop())
+			resp, err := client.Do(RequestOptions{
+				Method:       http.MethodGet,
+				URL:          server.URL + tt.path,
+				Context:      context.Background(),
+				SuccessCodes: tt.successCodes,
+			})
+
+			if tt.wantErr {
+				statusErr, ok := AsStatusError(err)
+				if !ok || statusErr.StatusCode != tt.wantStatus {
+					t.Fatalf("Do() error = %v, want a %d status error", err, tt.wantStatus)
+				}
+				if tt.path == "/multi" && string(statusErr.Body) != `{"items":[{"status":500}]}` {
+					t.Errorf("status error body = %s, want the response body", statusErr.Body)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+				if resp.StatusCode != tt.wantStatus {
+					t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+				}
+			}
+			// A rejected 2xx is not retried
+			if tt.path == "/multi" && hits.Load() != 1 {
+				t.Errorf("server got %d requests, want 1", hits.Load())
+			}
+		})
+	}
+}
+
+func TestDoStreamingReadsIncrementally(t *testing.T) {
+	const chunk = 64 * 1024
+	const chunks = 64
+	firstRead := make(chan struct{})
+	var waitedForReader atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), chunk))
+		w.(http.Flusher).Flush()
+		// The rest is only sent once the client has consumed the first chunk, which
+		// it can't do if the whole body is read before DoStreaming returns
+		select {
+		case <-firstRead:
+			waitedForReader.Store(true)
+		case <-time.After(5 * time.Second):
+		}
+		for i := 1; i < chunks; i++ {
+			w.Write(bytes.Repeat([]byte("b"), chunk))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClientWithLogger(zap.NewNop())
+	resp, err := client.DoStreaming(RequestOptions{Method: http.MethodGet, URL: server.URL, Context: context.Background()})
+	if err != nil {
+		t.Fatalf("DoStreaming() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	first := make([]byte, chunk)
+	if _, err := io.ReadFull(resp.Body, first); err != nil {
+		t.Fatalf("failed to read the first chunk: %v", err)
+	}
+	close(firstRead)
+	rest, err := io.Copy(io.Discard, resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read the rest of the body: %v", err)
+	}
+
+	if total := int64(chunk) + rest; total != chunk*chunks {
+		t.Errorf("read %d bytes, want %d", total, chunk*chunks)
+	}
+	if !waitedForReader.Load() {
+		t.Error("the body was read before DoStreaming returned")
+	}
+}
+
+func TestDoStreamingRetriesAndRejects(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n := hits.Add(1); {
+		case r.URL.Path == "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("no such result"))
+		case n == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("result"))
+		}
+	}))
+	defer server.Close()
+	client := NewClientWithLogger(zap.NewNop())
+
+	resp, err := client.DoStreaming(RequestOptions{Method: http.MethodGet, URL: server.URL, Context: context.Background(), InitialInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("DoStreaming() error = %v, want the 503 retried", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "result" || hits.Load() != 2 {
+		t.Errorf("body = %q after %d requests, want the retried result", body, hits.Load())
+	}
+
+	_, err = client.DoStreaming(RequestOptions{Method: http.MethodGet, URL: server.URL + "/missing", Context: context.Background()})
+	statusErr, ok := AsStatusError(err)
+	if !ok || statusErr.StatusCode != http.StatusNotFound || string(statusErr.Body) != "no such result" {
+		t.Errorf("DoStreaming() error = %v, want a 404 status error with the body", err)
+	}
+}
+
+func TestDefaultHeaders(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClientWithLogger(zap.NewNop())
+	defaults := map[string]string{"X-Request-Source": "dataretention-sync", "X-Correlation-ID": "run-1"}
+	client.SetDefaultHeaders(defaults)
+	// The client keeps its own copy
+	defaults["X-Request-Source"] = "changed"
+
+	tests := []struct {
+		name       string
+		do         func() error
+		wantSource string
+		wantID     string
+	}{
+		{
+			name: "request without headers",
+			do: func() error {
+				_, err := client.Do(RequestOptions{Method: http.MethodGet, URL: server.URL})
+				return err
+			},
+			wantSource: "dataretention-sync",
+			wantID:     "run-1",
+		},
+		{
+			name: "request header overrides",
+			do: func() error {
+				_, err := client.Do(RequestOptions{Method: http.MethodGet, URL: server.URL, Headers: map[string]string{"X-Correlation-ID": "own"}})
+				return err
+			},
+			wantSource: "dataretention-sync",
+			wantID:     "own",
+		},
+		{
+			name: "raw request header overrides",
+			do: func() error {
+				req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+				if err != nil {
+					return err
+				}
+				req.Header.Set("X-Request-Source", "raw")
+				resp, err := client.DoRequest(req)
+				if err == nil {
+					resp.Body.Close()
+				}
+				return err
+			},
+			wantSource: "raw",
+			wantID:     "run-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.do(); err != nil {
+				t.Fatalf("request error = %v", err)
+			}
+			got := <-headers
+			if got.Get("X-Request-Source") != tt.wantSource || got.Get("X-Correlation-ID") != tt.wantID {
+				t.Errorf("headers X-Request-Source %q, X-Correlation-ID %q, want %q, %q",
+					got.Get("X-Request-Source"), got.Get("X-Correlation-ID"), tt.wantSource, tt.wantID)
+			}
+		})
+	}
+
+	client.SetDefaultHeaders(nil)
+	if _, err := client.Do(RequestOptions{Method: http.MethodGet, URL: server.URL}); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if got := <-headers; got.Get("X-Request-Source") != "" {
+		t.Errorf("X-Request-Source = %q after SetDefaultHeaders(nil), want none", got.Get("X-Request-Source"))
+	}
+}
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/natserract/sf/pkg/sferrors"
+)
+
+// StatusError is returned by Do when the server responds with a status the request doesn't accept
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	kind := "client error"
+	switch {
+	case e.StatusCode >= 500:
+		kind = "server error"
+	case e.StatusCode < 400:
+		kind = "unexpected status"
+	}
+	return fmt.Sprintf("%s: %d - %s", kind, e.StatusCode, string(e.Body))
+}
+
+// Is reports whether target is the sferrors sentinel for the status code, so that
+// errors.Is(err, sferrors.ErrNotFound) holds for a 404
+func (e *StatusError) Is(target error) bool {
+	sentinel := sferrors.ForStatus(e.StatusCode)
+	return sentinel != nil && sentinel == target
+}
+
+// AsStatusError returns the StatusError wrapped in err, if any
+func AsStatusError(err error) (*StatusError, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr, true
+	}
+	return nil, false
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// keyServer fails the first two attempts of every request with a 503 and records the
+// idempotency key of each attempt, in order
+func keyServer(t *testing.T, header string) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var keys []string
+	attempts := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := r.Header.Get(header)
+		keys = append(keys, key)
+		attempts[r.URL.Path]++
+		if attempts[r.URL.Path] <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), keys...)
+	}
+}
+
+func TestIdempotencyKeyStableAcrossRetries(t *testing.T) {
+	server, keys := keyServer(t, "X-Request-Key")
+	client := newRetryingClient(3, nil)
+	client.SetIdempotencyHeader("X-Request-Key")
+	ctx := context.Background()
+
+	if _, err := client.Post(ctx, server.URL+"/first", nil, map[string]string{"a": "b"}); err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	if _, err := client.Patch(ctx, server.URL+"/second", nil, map[string]string{"a": "b"}); err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+
+	got := keys()
+	if len(got) != 6 {
+		t.Fatalf("server got %d requests, want 3 attempts of each call", len(got))
+	}
+	first, second := got[:3], got[3:]
+	for _, attempts := range [][]string{first, second} {
+		if attempts[0] == "" || attempts[1] != attempts[0] || attempts[2] != attempts[0] {
+			t.Errorf("keys across retries = %q, want one key", attempts)
+		}
+	}
+	if first[0] == second[0] {
+		t.Errorf("distinct calls share the key %q", first[0])
+	}
+}
+
+func TestIdempotencyKeyOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		opts    RequestOptions
+		wantKey func(string) bool
+	}{
+		{
+			name:    "GET gets no key",
+			header:  DefaultIdempotencyHeader,
+			opts:    RequestOptions{Method: http.MethodGet},
+			wantKey: func(key string) bool { return key == "" },
+		},
+		{
+			name:    "generated keys off",
+			header:  "",
+			opts:    RequestOptions{Method: http.MethodPost},
+			wantKey: func(key string) bool { return key == "" },
+		},
+		{
+			name:    "explicit key",
+			header:  DefaultIdempotencyHeader,
+			opts:    RequestOptions{Method: http.MethodPost, IdempotencyKey: "op-42"},
+			wantKey: func(key string) bool { return key == "op-42" },
+		},
+		{
+			name:    "explicit key without a configured header",
+			header:  "",
+			opts:    RequestOptions{Method: http.MethodPut, IdempotencyKey: "op-43"},
+			wantKey: func(key string) bool { return key == "op-43" },
+		},
+		{
+			name:    "caller header wins",
+			header:  DefaultIdempotencyHeader,
+			opts:    RequestOptions{Method: http.MethodPost, Headers: map[string]string{DefaultIdempotencyHeader: "mine"}},
+			wantKey: func(key string) bool { return key == "mine" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, keys := keyServer(t, DefaultIdempotencyHeader)
+			client := newRetryingClient(3, nil)
+			client.SetIdempotencyHeader(tt.header)
+
+			opts := tt.opts
+			opts.URL = server.URL
+			opts.Context = context.Background()
+			if _, err := client.Do(opts); err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			for _, key := range keys() {
+				if !tt.wantKey(key) {
+					t.Errorf("got key %q", key)
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeyDoRequest(t *testing.T) {
+	server, keys := keyServer(t, DefaultIdempotencyHeader)
+	client := newRetryingClient(3, nil)
+	client.SetIdempotencyHeader(DefaultIdempotencyHeader)
+
+	// DoRequest hands back any status, so each call is a single attempt here
+	for range 2 {
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"a":"b"}`))
+		if err != nil {
+			t.Fatalf("failed to build request: %v", err)
+		}
+		resp, err := client.DoRequest(req)
+		if err != nil {
+			t.Fatalf("DoRequest() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	got := keys()
+	if len(got) != 2 || got[0] == "" || got[1] == "" || got[0] == got[1] {
+		t.Errorf("keys = %q, want a distinct key per call", got)
+	}
+}
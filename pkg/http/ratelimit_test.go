@@ -0,0 +1,60 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func newOKServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	server := newOKServer(t)
+	client := NewClientWithLogger(zap.NewNop())
+	// One request up front, then one every 50ms
+	client.SetRateLimiter(NewRateLimiter(20, 1))
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := client.Get(context.Background(), server.URL, nil); err != nil {
+			t.Fatalf("Get() #%d error = %v", i+1, err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 140*time.Millisecond {
+		t.Errorf("4 requests took %s, want about 150ms at 20 requests per second", elapsed)
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	server := newOKServer(t)
+	client := NewClientWithLogger(zap.NewNop())
+	client.SetRateLimiter(NewRateLimiter(0.1, 1))
+
+	if _, err := client.Get(context.Background(), server.URL, nil); err != nil {
+		t.Fatalf("first Get() error = %v", err)
+	}
+
+	// The next token is 10s away; cancelling must end the wait
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := client.Get(ctx, server.URL, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Get() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Get() returned after %s, want prompt return", elapsed)
+	}
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRetryBudgetExhausted is returned for failed requests that weren't retried
+// because the client's retry budget ran out
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// RetryBudget caps the retries of all requests together. It is a token bucket: each
+// retry takes a token and tokens refill evenly over the window. Once it is empty,
+// failed requests are returned at once instead of retried, so a widespread outage
+// fails a run quickly rather than retrying every request to its own limit.
+type RetryBudget struct {
+	tokens *rate.Limiter
+}
+
+// NewRetryBudget creates a budget allowing up to retries retries per window. Share
+// one budget between clients to cap their retries together.
+func NewRetryBudget(retries int, window time.Duration) *RetryBudget {
+	if retries < 1 {
+		retries = 1
+	}
+	return &RetryBudget{
+		tokens: rate.NewLimiter(rate.Every(window/time.Duration(retries)), retries),
+	}
+}
+
+// Allow takes a token for one retry, reporting false when none is left
+func (b *RetryBudget) Allow() bool {
+	return b.tokens.Allow()
+}
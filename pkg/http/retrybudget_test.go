@@ -0,0 +1,178 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v5"
+	"go.uber.org/zap"
+)
+
+// newFailingServer returns a server answering every request with a 503 and a
+// counter of the requests it received
+func newFailingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+// newRetryingClient returns a client that retries at once, up to maxRetries times
+func newRetryingClient(maxRetries int, budget *RetryBudget) *Client {
+	client := NewClientWithLogger(zap.NewNop())
+	client.SetRetryPolicy(maxRetries, time.Minute)
+	client.SetRetryBudget(budget)
+	client.SetBackOffFactory(func() backoff.BackOff { return &backoff.ZeroBackOff{} })
+	return client
+}
+
+func TestRetryBudgetExhausted(t *testing.T) {
+	server, hits := newFailingServer(t)
+	client := newRetryingClient(10, NewRetryBudget(3, time.Hour))
+
+	_, err := client.Get(context.Background(), server.URL, nil)
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("first Get() error = %v, want ErrRetryBudgetExhausted", err)
+	}
+	// The first attempt and the three retries the budget allows
+	if got := hits.Load(); got != 4 {
+		t.Errorf("server got %d requests, want 4", got)
+	}
+
+	// With the budget used up, a new failure is returned without a retry
+	hits.Store(0)
+	_, err = client.Get(context.Background(), server.URL, nil)
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("second Get() error = %v, want ErrRetryBudgetExhausted", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server got %d requests, want 1", got)
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("second Get() error = %v, want it to wrap the 503", err)
+	}
+}
+
+func TestRetryBudgetSkipsLastAttempt(t *testing.T) {
+	server, hits := newFailingServer(t)
+	// Each request may retry once; its last attempt must not take a token, so two
+	// tokens cover both requests' retries
+	client := newRetryingClient(1, NewRetryBudget(2, time.Hour))
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(context.Background(), server.URL, nil)
+		if err == nil || errors.Is(err, ErrRetryBudgetExhausted) {
+			t.Fatalf("Get() #%d error = %v, want the 503 after a retry", i+1, err)
+		}
+	}
+	if got := hits.Load(); got != 4 {
+		t.Errorf("server got %d requests, want 4", got)
+	}
+}
+
+// newDroppingServer returns a server that closes every connection without a response,
+// and the number of requests it got
+func newDroppingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("failed to hijack connection: %v", err)
+			return
+		}
+		conn.Close()
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestDisableRetry(t *testing.T) {
+	tests := []struct {
+		name   string
+		server func(*testing.T) (*httptest.Server, *atomic.Int32)
+	}{
+		{"server error", newFailingServer},
+		{"network error", newDroppingServer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, hits := tt.server(t)
+			budget := NewRetryBudget(1, time.Hour)
+			client := newRetryingClient(5, budget)
+
+			_, err := client.Do(RequestOptions{
+				Method:       http.MethodPatch,
+				URL:          server.URL,
+				Body:         map[string]string{"a": "b"},
+				Context:      context.Background(),
+				DisableRetry: true,
+			})
+			if err == nil {
+				t.Fatal("Do() error = nil, want the failure")
+			}
+			if got := hits.Load(); got != 1 {
+				t.Errorf("server got %d requests, want 1", got)
+			}
+			if !budget.Allow() {
+				t.Error("the single attempt took from the retry budget")
+			}
+		})
+	}
+}
+
+func TestMaxRetriesAttempts(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxRetries   int
+		wantRequests int32
+	}{
+		{"one retry", 1, 2},
+		{"three retries", 3, 4},
+		{"five retries", 5, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, hits := newFailingServer(t)
+			client := NewClientWithLogger(zap.NewNop())
+
+			_, err := client.Do(RequestOptions{
+				Method:         http.MethodGet,
+				URL:            server.URL,
+				Context:        context.Background(),
+				MaxRetries:     tt.maxRetries,
+				MaxElapsed:     time.Minute,
+				BackOffFactory: func() backoff.BackOff { return &backoff.ZeroBackOff{} },
+			})
+			if err == nil {
+				t.Fatal("Do() error = nil, want the 503")
+			}
+			if got := hits.Load(); got != tt.wantRequests {
+				t.Errorf("server got %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestMaxRetriesFromRetryPolicy(t *testing.T) {
+	server, hits := newFailingServer(t)
+	client := newRetryingClient(2, nil)
+
+	if _, err := client.Get(context.Background(), server.URL, nil); err == nil {
+		t.Fatal("Get() error = nil, want the 503")
+	}
+	if got := hits.Load(); got != 3 {
+		t.Errorf("server got %d requests, want 3", got)
+	}
+}
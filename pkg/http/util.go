@@ -3,8 +3,12 @@ package http
 import (
 	"fmt"
 	"net/url"
+	"strings"
 )
 
+// BuildURL replaces the path of baseURL with path and sets the query parameters.
+// path is taken as already escaped, so segments escaped with url.PathEscape (see
+// JoinPath) keep their escaped slashes and special characters.
 func BuildURL(baseURL, path string, queryParams map[string]string) (string, error) {
 	// Parse the base URL
 	parsedURL, err := url.Parse(baseURL)
@@ -13,7 +17,12 @@ func BuildURL(baseURL, path string, queryParams map[string]string) (string, erro
 	}
 
 	// Append the path
-	parsedURL.Path = path
+	unescaped, err := url.PathUnescape(path)
+	if err != nil {
+		return "", fmt.Errorf("error parsing path: %w", err)
+	}
+	parsedURL.Path = unescaped
+	parsedURL.RawPath = path
 
 	// Set query parameters dynamically
 	q := url.Values{}
@@ -25,3 +34,18 @@ func BuildURL(baseURL, path string, queryParams map[string]string) (string, erro
 	// Return the full URL as a string
 	return parsedURL.String(), nil
 }
+
+// BuildURLSegments is BuildURL with a path joined from segments, each escaped, so
+// IDs with slashes, spaces or other special characters stay a single segment
+func BuildURLSegments(baseURL string, segments []string, queryParams map[string]string) (string, error) {
+	return BuildURL(baseURL, JoinPath(segments...), queryParams)
+}
+
+// JoinPath returns the absolute path made of segments, each escaped with url.PathEscape
+func JoinPath(segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+	return "/" + strings.Join(escaped, "/")
+}
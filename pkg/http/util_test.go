@@ -0,0 +1,66 @@
+package http
+
+import "testing"
+
+func TestJoinPath(t *testing.T) {
+	tests := []struct {
+		segments []string
+		want     string
+	}{
+		{[]string{"data", "v1", "customobjects", "de-1"}, "/data/v1/customobjects/de-1"},
+		{[]string{"folder", "a/b"}, "/folder/a%2Fb"},
+		{[]string{"folder", "with space"}, "/folder/with%20space"},
+		{[]string{"folder", "50%?#"}, "/folder/50%25%3F%23"},
+	}
+	for _, tt := range tests {
+		if got := JoinPath(tt.segments...); got != tt.want {
+			t.Errorf("JoinPath(%q) = %q, want %q", tt.segments, got, tt.want)
+		}
+	}
+}
+
+func TestBuildURLSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []string
+		query    map[string]string
+		want     string
+	}{
+		{
+			name:     "plain ID",
+			segments: []string{"data", "v1", "customobjects", "de-1"},
+			want:     "https://rest.example.com/data/v1/customobjects/de-1",
+		},
+		{
+			name:     "ID with a slash stays one segment",
+			segments: []string{"legacy", "v1", "beta", "folder", "12/34", "children"},
+			want:     "https://rest.example.com/legacy/v1/beta/folder/12%2F34/children",
+		},
+		{
+			name:     "ID with spaces",
+			segments: []string{"data", "v1", "customobjects", "Old Orders DE"},
+			query:    map[string]string{"$page": "1"},
+			want:     "https://rest.example.com/data/v1/customobjects/Old%20Orders%20DE?%24page=1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildURLSegments("https://rest.example.com", tt.segments, tt.query)
+			if err != nil {
+				t.Fatalf("BuildURLSegments() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BuildURLSegments() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildURLInvalid(t *testing.T) {
+	if _, err := BuildURL("https://rest.example.com", "/bad%zz", nil); err == nil {
+		t.Error("BuildURL() with an invalid escape error = nil")
+	}
+	if _, err := BuildURL("://no-scheme", "/path", nil); err == nil {
+		t.Error("BuildURL() with an invalid base URL error = nil")
+	}
+}
@@ -0,0 +1,116 @@
+package logging
+
+import (
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// flushMu serializes flushes across all buffered loggers so that one buffer's
+// entries are never interleaved with another's
+var flushMu sync.Mutex
+
+// BufferedLogger is a zap.Logger that holds every entry logged through it (and
+// through loggers derived from it with With/Named) in memory until Flush is
+// called. It is meant for a single unit of concurrent work, such as one folder
+// of a sync, so that its log lines end up contiguous in the output.
+type BufferedLogger struct {
+	*zap.Logger
+	buf *entryBuffer
+}
+
+// NewBufferedLogger wraps logger so that its entries are buffered until Flush
+func NewBufferedLogger(logger *zap.Logger) *BufferedLogger {
+	buf := &entryBuffer{}
+	return &BufferedLogger{
+		Logger: logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &bufferedCore{inner: core, buf: buf}
+		})),
+		buf: buf,
+	}
+}
+
+// Flush writes all buffered entries to the underlying logger in one contiguous
+// block and empties the buffer. It is safe to call more than once.
+func (b *BufferedLogger) Flush() error {
+	return b.buf.flush()
+}
+
+type bufferedEntry struct {
+	core   zapcore.Core
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+type entryBuffer struct {
+	mu      sync.Mutex
+	entries []bufferedEntry
+}
+
+func (b *entryBuffer) add(core zapcore.Core, entry zapcore.Entry, fields []zapcore.Field) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = append(b.entries, bufferedEntry{
+		core:   core,
+		entry:  entry,
+		fields: append([]zapcore.Field(nil), fields...),
+	})
+}
+
+func (b *entryBuffer) flush() error {
+	b.mu.Lock()
+	entries := b.entries
+	b.entries = nil
+	b.mu.Unlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	flushMu.Lock()
+	defer flushMu.Unlock()
+
+	var err error
+	for _, e := range entries {
+		err = multierr.Append(err, e.core.Write(e.entry, e.fields))
+	}
+	return err
+}
+
+// bufferedCore is a zapcore.Core that records entries into a shared buffer
+// instead of writing them to the wrapped core
+type bufferedCore struct {
+	inner zapcore.Core
+	buf   *entryBuffer
+}
+
+func (c *bufferedCore) Enabled(level zapcore.Level) bool {
+	return c.inner.Enabled(level)
+}
+
+func (c *bufferedCore) With(fields []zapcore.Field) zapcore.Core {
+	return &bufferedCore{inner: c.inner.With(fields), buf: c.buf}
+}
+
+func (c *bufferedCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *bufferedCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	c.buf.add(c.inner, entry, fields)
+	// Panic and fatal entries terminate the goroutine or process, so write them
+	// out straight away instead of losing them in the buffer
+	if entry.Level > zapcore.ErrorLevel {
+		return c.buf.flush()
+	}
+	return nil
+}
+
+func (c *bufferedCore) Sync() error {
+	return c.inner.Sync()
+}
@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestBufferedLoggerKeepsFoldersContiguous(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+
+	const linesPerFolder = 50
+	folders := []string{"a", "b"}
+
+	var wg sync.WaitGroup
+	// Both folders log before either flushes, so their lines are written concurrently
+	var logged sync.WaitGroup
+	logged.Add(len(folders))
+	for _, folder := range folders {
+		wg.Add(1)
+		go func(folder string) {
+			defer wg.Done()
+			folderLogger := NewBufferedLogger(logger)
+			// Loggers derived with With share the folder's buffer
+			withFolder := folderLogger.With(zap.String("folder", folder))
+			for i := 0; i < linesPerFolder; i++ {
+				withFolder.Info(fmt.Sprintf("line %d", i))
+			}
+			logged.Done()
+			logged.Wait()
+			if err := folderLogger.Flush(); err != nil {
+				t.Errorf("Flush() error = %v", err)
+			}
+		}(folder)
+	}
+	wg.Wait()
+
+	entries := logs.AllUntimed()
+	if len(entries) != len(folders)*linesPerFolder {
+		t.Fatalf("got %d entries, want %d", len(entries), len(folders)*linesPerFolder)
+	}
+	// Each folder's lines form one block, in the order they were logged
+	for block := 0; block < len(folders); block++ {
+		first := entries[block*linesPerFolder].ContextMap()["folder"]
+		for i := 0; i < linesPerFolder; i++ {
+			entry := entries[block*linesPerFolder+i]
+			if folder := entry.ContextMap()["folder"]; folder != first {
+				t.Fatalf("entry %d is from folder %v inside folder %v's block", block*linesPerFolder+i, folder, first)
+			}
+			if want := fmt.Sprintf("line %d", i); entry.Message != want {
+				t.Fatalf("entry %d of folder %v = %q, want %q", i, first, entry.Message, want)
+			}
+		}
+	}
+}
+
+func TestBufferedLoggerHoldsUntilFlush(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := NewBufferedLogger(zap.New(core))
+
+	logger.Info("held")
+	logger.Debug("below the level")
+	if n := logs.Len(); n != 0 {
+		t.Fatalf("got %d entries before Flush, want 0", n)
+	}
+
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if n := logs.Len(); n != 1 {
+		t.Fatalf("got %d entries after Flush, want 1", n)
+	}
+
+	// A second flush has nothing left to write
+	if err := logger.Flush(); err != nil {
+		t.Fatalf("second Flush() error = %v", err)
+	}
+	if n := logs.Len(); n != 1 {
+		t.Errorf("got %d entries after the second Flush, want 1", n)
+	}
+}
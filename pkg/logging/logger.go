@@ -0,0 +1,29 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// New builds the production (JSON) logger the commands log through. It logs at
+// info level, or at the level in LOG_LEVEL (e.g. "debug" to see every saved folder
+// and data extension). A quiet logger only writes warnings and errors, which keeps
+// CI output short; summaries the commands print for people go to stdout and are
+// not affected.
+func New(quiet bool) (*zap.Logger, error) {
+	cfg := zap.NewProductionConfig()
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		level, err := zapcore.ParseLevel(v)
+		if err != nil {
+			return nil, fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+		cfg.Level = zap.NewAtomicLevelAt(level)
+	}
+	if quiet {
+		cfg.Level = zap.NewAtomicLevelAt(zap.WarnLevel)
+	}
+	return cfg.Build()
+}
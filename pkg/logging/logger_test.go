@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		quiet    bool
+		logLevel string
+		want     zapcore.Level
+	}{
+		{"default", false, "", zapcore.InfoLevel},
+		{"LOG_LEVEL", false, "debug", zapcore.DebugLevel},
+		{"quiet", true, "", zapcore.WarnLevel},
+		// Quiet wins, so a debug LOG_LEVEL left in the environment can't flood CI
+		{"quiet overrides LOG_LEVEL", true, "debug", zapcore.WarnLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LOG_LEVEL", tt.logLevel)
+			logger, err := New(tt.quiet)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			for _, level := range []zapcore.Level{zapcore.DebugLevel, zapcore.InfoLevel, zapcore.WarnLevel, zapcore.ErrorLevel} {
+				if got, want := logger.Core().Enabled(level), level >= tt.want; got != want {
+					t.Errorf("%s enabled = %v, want %v", level, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewInvalidLogLevel(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "loud")
+	if _, err := New(false); err == nil {
+		t.Fatal("New() error = nil for an unknown LOG_LEVEL")
+	}
+}
@@ -0,0 +1,28 @@
+package paging
+
+// PageUntil fetches numbered pages (starting at 1) and collects their items until a
+// page comes back empty or shorter than pageSize, or until stop reports true for an
+// item. The item stop matched is not included, and no further pages are fetched.
+// A nil stop pages through to the end. The first fetch error is returned as is,
+// together with nothing collected so far.
+func PageUntil[T any](pageSize int, fetch func(page int) ([]T, error), stop func(T) bool) ([]T, error) {
+	var all []T
+	for page := 1; ; page++ {
+		items, err := fetch(page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			if stop != nil && stop(item) {
+				return all, nil
+			}
+			all = append(all, item)
+		}
+
+		// A short page means we've reached the end
+		if len(items) < pageSize {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package paging
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// pages returns a fetch function serving items in pages of pageSize, and the
+// page numbers it was asked for
+func pages(items []int, pageSize int) (func(page int) ([]int, error), *[]int) {
+	var fetched []int
+	return func(page int) ([]int, error) {
+		fetched = append(fetched, page)
+		start := min((page-1)*pageSize, len(items))
+		end := min(start+pageSize, len(items))
+		return items[start:end], nil
+	}, &fetched
+}
+
+func TestPageUntil(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7}
+
+	tests := []struct {
+		name        string
+		items       []int
+		stop        func(int) bool
+		want        []int
+		wantFetched []int
+	}{
+		{"to the end", items, nil, items, []int{1, 2, 3}},
+		{"full last page", items[:6], nil, items[:6], []int{1, 2, 3}},
+		{"empty", nil, nil, nil, []int{1}},
+		{"stop mid page", items, func(i int) bool { return i == 5 }, []int{1, 2, 3, 4}, []int{1, 2}},
+		{"stop at first item", items, func(i int) bool { return true }, nil, []int{1}},
+		{"stop never matches", items, func(i int) bool { return i > 100 }, items, []int{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetch, fetched := pages(tt.items, 3)
+			got, err := PageUntil(3, fetch, tt.stop)
+			if err != nil {
+				t.Fatalf("PageUntil() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("PageUntil() = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(*fetched, tt.wantFetched) {
+				t.Errorf("fetched pages %v, want %v", *fetched, tt.wantFetched)
+			}
+		})
+	}
+}
+
+func TestPageUntilError(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+	got, err := PageUntil(2, func(page int) ([]int, error) {
+		if page == 2 {
+			return nil, errFetch
+		}
+		return []int{1, 2}, nil
+	}, nil)
+	if !errors.Is(err, errFetch) {
+		t.Fatalf("PageUntil() error = %v, want the fetch error", err)
+	}
+	if got != nil {
+		t.Errorf("PageUntil() = %v, want nothing on error", got)
+	}
+}
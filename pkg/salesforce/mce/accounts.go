@@ -0,0 +1,101 @@
+package sfmce
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AccountsConfig lists several accounts (business units) to sync in one go. Settings
+// in Defaults apply to every account that leaves them empty, which is typically
+// everything but the account ID. RateLimit and RateBurst configure a single limiter
+// shared by all accounts, since they count against the same tenant limits.
+type AccountsConfig struct {
+	Defaults  Config    `yaml:"defaults" json:"defaults"`
+	RateLimit float64   `yaml:"rateLimit" json:"rateLimit"`
+	RateBurst int       `yaml:"rateBurst" json:"rateBurst"`
+	Accounts  []*Config `yaml:"accounts" json:"accounts"`
+}
+
+// LoadAccountsFromFile loads a multi-account configuration from a YAML or JSON file
+func LoadAccountsFromFile(path string) (*AccountsConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounts file: %w", err)
+	}
+
+	cfg := &AccountsConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse accounts file %s: %w", path, err)
+	}
+
+	if len(cfg.Accounts) == 0 {
+		return nil, fmt.Errorf("accounts file %s lists no accounts", path)
+	}
+	if cfg.RateLimit < 0 {
+		return nil, fmt.Errorf("rateLimit must not be negative")
+	}
+	if cfg.RateBurst < 0 {
+		return nil, fmt.Errorf("rateBurst must not be negative")
+	}
+
+	for i, account := range cfg.Accounts {
+		if account == nil {
+			return nil, fmt.Errorf("account %d is empty", i)
+		}
+		account.applyDefaults(&cfg.Defaults)
+		if err := account.Validate(); err != nil {
+			return nil, fmt.Errorf("account %d (%s): %w", i, account.AccountID, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// applyDefaults fills every empty field of c from defaults
+func (c *Config) applyDefaults(defaults *Config) {
+	setString := func(field *string, value string) {
+		if *field == "" {
+			*field = value
+		}
+	}
+	setString(&c.AuthBaseURI, defaults.AuthBaseURI)
+	setString(&c.RestBaseURI, defaults.RestBaseURI)
+	setString(&c.ClientID, defaults.ClientID)
+	setString(&c.ClientSecret, defaults.ClientSecret)
+	setString(&c.Scope, defaults.Scope)
+	setString(&c.AccountID, defaults.AccountID)
+	setString(&c.APITimeZone, defaults.APITimeZone)
+	setString(&c.PrivateKeyFile, defaults.PrivateKeyFile)
+	setString(&c.Subject, defaults.Subject)
+	setString(&c.IdempotencyHeader, defaults.IdempotencyHeader)
+
+	if c.RateLimit == 0 {
+		c.RateLimit = defaults.RateLimit
+	}
+	if c.RateBurst == 0 {
+		c.RateBurst = defaults.RateBurst
+	}
+	if c.FolderPageSize == 0 {
+		c.FolderPageSize = defaults.FolderPageSize
+	}
+	if c.HTTPTimeout == 0 {
+		c.HTTPTimeout = defaults.HTTPTimeout
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = defaults.MaxRetries
+	}
+	if c.MaxElapsed == 0 {
+		c.MaxElapsed = defaults.MaxElapsed
+	}
+	if c.RetryBudget == 0 {
+		c.RetryBudget = defaults.RetryBudget
+	}
+	if c.RetryBudgetWindow == 0 {
+		c.RetryBudgetWindow = defaults.RetryBudgetWindow
+	}
+	if c.SubFolderCacheTTL == 0 {
+		c.SubFolderCacheTTL = defaults.SubFolderCacheTTL
+	}
+}
@@ -4,11 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
+	httpclient "github.com/natserract/sf/pkg/http"
 	"go.uber.org/zap"
 )
 
+const (
+	// tokenRefreshLead is how long before expiry the background refresher re-authenticates
+	tokenRefreshLead = time.Minute
+	// tokenRefreshRetryInterval is how long the background refresher waits after a failed attempt
+	tokenRefreshRetryInterval = 10 * time.Second
+)
+
 // getAccessToken retrieves a valid access token, using cache if available.
 // If the token is expired or not available, it calls Authenticate() to get a new token.
 // Tokens are valid for 20 minutes, so we cache them and refresh when expired.
@@ -27,7 +37,12 @@ func (s *Salesforce) getAccessToken(ctx context.Context) (string, error) {
 	// Token expired or not available, call Authenticate() to get a new token
 	// Tokens are valid for 20 minutes, so we need to re-authenticate when expired
 	s.logger.Info("Access token expired or not available, authenticating")
-	authResp, err := s.Authenticate()
+	return s.refreshToken(ctx)
+}
+
+// refreshToken authenticates and stores the new token in the cache
+func (s *Salesforce) refreshToken(ctx context.Context) (string, error) {
+	authResp, err := s.AuthenticateCtx(ctx)
 	if err != nil {
 		s.logger.Error("Failed to authenticate", zap.Error(err))
 		return "", fmt.Errorf("failed to authenticate: %w", err)
@@ -39,40 +54,123 @@ func (s *Salesforce) getAccessToken(ctx context.Context) (string, error) {
 		expiresIn = 20 * time.Minute // Default to 20 minutes if not provided
 	}
 
+	// Set expiration time, refreshing 30 seconds before actual expiry to avoid using expired tokens
+	expiresAt := time.Now().Add(expiresIn - 30*time.Second)
+
 	s.tokenCache.mu.Lock()
 	s.tokenCache.accessToken = authResp.AccessToken
-	// Set expiration time, refreshing 30 seconds before actual expiry to avoid using expired tokens
-	s.tokenCache.expiresAt = time.Now().Add(expiresIn - 30*time.Second)
+	s.tokenCache.expiresAt = expiresAt
 	s.tokenCache.mu.Unlock()
 
 	s.logger.Info("Successfully authenticated and cached access token",
 		zap.Duration("expires_in", expiresIn),
-		zap.Time("expires_at", s.tokenCache.expiresAt))
+		zap.Time("expires_at", expiresAt))
 
 	return authResp.AccessToken, nil
 }
 
+// withReauth runs an API request authorized through headers. If the API rejects the
+// token with a 401, e.g. because it was revoked before its computed expiry, the
+// cached token is dropped and the request is retried once with a fresh token.
+// Rejected requests whose body is the API's error envelope fail with an *APIError.
+func (s *Salesforce) withReauth(ctx context.Context, headers map[string]string, request func() (*httpclient.Response, error)) (*httpclient.Response, error) {
+	resp, err := request()
+	statusErr, ok := httpclient.AsStatusError(err)
+	if !ok || statusErr.StatusCode != http.StatusUnauthorized {
+		return resp, withAPIError(err)
+	}
+
+	s.logger.Warn("Access token rejected, re-authenticating and retrying once")
+	s.invalidateToken(strings.TrimPrefix(headers["Authorization"], "Bearer "))
+	token, err := s.getAccessToken(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get access token", zap.Error(err))
+		return nil, err
+	}
+	headers["Authorization"] = fmt.Sprintf("Bearer %s", token)
+
+	resp, err = request()
+	return resp, withAPIError(err)
+}
+
+// invalidateToken drops the cached token if it is still the rejected one, so that
+// requests rejected together only trigger a refresh if no other one has yet
+func (s *Salesforce) invalidateToken(rejected string) {
+	s.tokenCache.mu.Lock()
+	defer s.tokenCache.mu.Unlock()
+	if s.tokenCache.accessToken == rejected {
+		s.tokenCache.accessToken = ""
+		s.tokenCache.expiresAt = time.Time{}
+	}
+}
+
+// StartTokenRefresher starts a background goroutine that re-authenticates about a
+// minute before the cached token expires, so requests never have to wait for a
+// refresh. It stops when ctx is cancelled.
+func (s *Salesforce) StartTokenRefresher(ctx context.Context) {
+	go func() {
+		for {
+			wait := s.nextTokenRefresh()
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if _, err := s.refreshToken(ctx); err != nil {
+				s.logger.Warn("Background token refresh failed, will retry",
+					zap.Duration("retry_in", tokenRefreshRetryInterval),
+					zap.Error(err))
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(tokenRefreshRetryInterval):
+				}
+			}
+		}
+	}()
+}
+
+// nextTokenRefresh returns how long to wait before refreshing the cached token
+func (s *Salesforce) nextTokenRefresh() time.Duration {
+	s.tokenCache.mu.RLock()
+	hasToken := s.tokenCache.accessToken != ""
+	remaining := time.Until(s.tokenCache.expiresAt)
+	s.tokenCache.mu.RUnlock()
+
+	if !hasToken || remaining <= 0 {
+		return 0
+	}
+	if wait := remaining - tokenRefreshLead; wait > 0 {
+		return wait
+	}
+	// Tokens shorter-lived than the lead time are refreshed halfway through
+	return remaining / 2
+}
+
 // Authenticate retrieves an OAuth access token
 func (s *Salesforce) Authenticate() (*AuthResponse, error) {
+	return s.AuthenticateCtx(context.Background())
+}
+
+// AuthenticateCtx is Authenticate with a context that bounds its requests
+func (s *Salesforce) AuthenticateCtx(ctx context.Context) (*AuthResponse, error) {
 	url := fmt.Sprintf("%s/v2/token", s.config.AuthBaseURI)
 	s.logger.Info("Authenticating with Salesforce", zap.String("url", url))
 
-	authReq := AuthRequest{
-		GrantType:    "client_credentials",
-		ClientID:     s.config.ClientID,
-		ClientSecret: s.config.ClientSecret,
-		Scope:        s.config.Scope,
-	}
-
-	if s.config.AccountID != "" {
-		authReq.AccountID = s.config.AccountID
+	authReq, err := s.authRequest(url)
+	if err != nil {
+		s.logger.Error("Failed to build authentication request", zap.Error(err))
+		return nil, err
 	}
 
 	headers := map[string]string{
 		"Content-Type": "application/json",
 	}
 
-	resp, err := s.httpClient.Post(context.Background(), url, headers, authReq)
+	resp, err := s.httpClient.Post(ctx, url, headers, authReq)
 	if err != nil {
 		s.logger.Error("Authentication request failed", zap.Error(err), zap.String("url", url))
 		return nil, fmt.Errorf("authentication request failed: %w", err)
@@ -97,3 +195,32 @@ func (s *Salesforce) Authenticate() (*AuthResponse, error) {
 
 	return &authResp, nil
 }
+
+// authRequest returns the token request body: a signed JWT assertion when the config
+// has a private key and subject, client credentials otherwise
+func (s *Salesforce) authRequest(tokenURL string) (interface{}, error) {
+	if !s.config.UsesJWTBearer() {
+		return AuthRequest{
+			GrantType:    "client_credentials",
+			ClientID:     s.config.ClientID,
+			ClientSecret: s.config.ClientSecret,
+			Scope:        s.config.Scope,
+			AccountID:    s.config.AccountID,
+		}, nil
+	}
+
+	key, err := loadRSAPrivateKey(s.config.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	assertion, err := buildJWTAssertion(key, s.config.ClientID, s.config.Subject, tokenURL, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return JWTAuthRequest{
+		GrantType: jwtBearerGrantType,
+		Assertion: assertion,
+		Scope:     s.config.Scope,
+		AccountID: s.config.AccountID,
+	}, nil
+}
@@ -0,0 +1,169 @@
+package sfmce
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newHungServer returns a server whose handlers block until the client gives up
+func newHungServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		server.Close()
+	})
+	return server
+}
+
+func TestAuthenticateCtxReturnsOnDeadline(t *testing.T) {
+	server := newHungServer(t)
+	client := NewSalesforceWithLogger(&Config{AuthBaseURI: server.URL, RestBaseURI: server.URL, ClientID: "id", ClientSecret: "secret"}, zap.NewNop())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.AuthenticateCtx(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("AuthenticateCtx() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("AuthenticateCtx() returned after %s, want prompt return", elapsed)
+	}
+}
+
+func TestGetAccessTokenPassesContext(t *testing.T) {
+	server := newHungServer(t)
+	client := NewSalesforceWithLogger(&Config{AuthBaseURI: server.URL, RestBaseURI: server.URL, ClientID: "id", ClientSecret: "secret"}, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.GetDataExtensionCtx(ctx, "de-1")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetDataExtensionCtx() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("GetDataExtensionCtx() returned after %s, want prompt return", elapsed)
+	}
+}
+
+func TestTokenRefresherRefreshesBeforeExpiry(t *testing.T) {
+	client, server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	// The client treats a token as expired 30s early, so this one lasts a second
+	// and, being shorter than the refresh lead, is refreshed halfway through
+	server.expiresIn.Store(31)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if token, err := client.getAccessToken(ctx); err != nil || token != "token-1" {
+		t.Fatalf("getAccessToken() = %q, %v, want token-1", token, err)
+	}
+	client.StartTokenRefresher(ctx)
+
+	deadline := time.Now().Add(900 * time.Millisecond)
+	for server.tokens.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("token was not refreshed before it expired")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Requests get the refreshed token from the cache instead of waiting for one
+	token, err := client.getAccessToken(ctx)
+	if err != nil {
+		t.Fatalf("getAccessToken() error = %v", err)
+	}
+	if token == "token-1" {
+		t.Errorf("getAccessToken() = %q, want a refreshed token", token)
+	}
+}
+
+func TestTokenRefresherStopsOnCancel(t *testing.T) {
+	client, server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	server.expiresIn.Store(31)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := client.getAccessToken(ctx); err != nil {
+		t.Fatalf("getAccessToken() error = %v", err)
+	}
+	client.StartTokenRefresher(ctx)
+	cancel()
+
+	time.Sleep(700 * time.Millisecond)
+	if n := server.tokens.Load(); n != 1 {
+		t.Errorf("issued %d tokens after the refresher was cancelled, want 1", n)
+	}
+}
+
+func TestRevokedTokenIsRefreshedOnce(t *testing.T) {
+	var requests atomic.Int32
+	client, ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		// The first token is revoked server-side before its computed expiry
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"message": "Not Authorized"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": "de-1", "name": "Orders"})
+	})
+
+	de, err := client.GetDataExtension("de-1")
+	if err != nil {
+		t.Fatalf("GetDataExtension() error = %v", err)
+	}
+	if de.ID != "de-1" {
+		t.Errorf("GetDataExtension() = %+v, want de-1", de)
+	}
+	if got := ts.tokens.Load(); got != 2 {
+		t.Errorf("issued %d tokens, want a refresh after the 401", got)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("made %d API requests, want the rejected one and its retry", got)
+	}
+
+	// The fresh token is cached for the next request
+	if _, err := client.GetDataExtension("de-1"); err != nil {
+		t.Fatalf("GetDataExtension() error = %v", err)
+	}
+	if got := ts.tokens.Load(); got != 2 {
+		t.Errorf("issued %d tokens, want the refreshed one reused", got)
+	}
+}
+
+func TestUnauthorizedIsRetriedOnlyOnce(t *testing.T) {
+	var requests atomic.Int32
+	client, ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{"message": "Not Authorized"})
+	})
+
+	if _, err := client.GetDataExtension("de-1"); err == nil {
+		t.Fatal("GetDataExtension() error = nil, want the second 401")
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("made %d API requests, want exactly one retry", got)
+	}
+	if got := ts.tokens.Load(); got != 2 {
+		t.Errorf("issued %d tokens, want 2", got)
+	}
+}
@@ -0,0 +1,101 @@
+package sfmce
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// BulkUpdateConcurrency is how many retention updates BulkUpdateDataRetention keeps in
+// flight at once. Marketing Cloud has no batch endpoint for retention, so each data
+// extension still takes its own PATCH; the client's rate limiter applies as usual.
+const BulkUpdateConcurrency = 8
+
+// BulkItemResult is the outcome of updating one data extension in a bulk update
+type BulkItemResult struct {
+	DataExtensionID string
+	// Err is nil if the update succeeded
+	Err error
+}
+
+// BulkResult holds the outcome of a bulk retention update, one entry per requested
+// data extension in request order
+type BulkResult struct {
+	Results []BulkItemResult
+}
+
+// Succeeded returns the IDs of the data extensions that were updated
+func (r *BulkResult) Succeeded() []string {
+	var ids []string
+	for _, result := range r.Results {
+		if result.Err == nil {
+			ids = append(ids, result.DataExtensionID)
+		}
+	}
+	return ids
+}
+
+// Failed returns the results of the data extensions that couldn't be updated
+func (r *BulkResult) Failed() []BulkItemResult {
+	var failed []BulkItemResult
+	for _, result := range r.Results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// BulkUpdateDataRetention applies the same retention settings to many data extensions,
+// with up to BulkUpdateConcurrency updates in flight. A failed update doesn't stop
+// the others; per-item outcomes are in the result. The error is only set when the
+// retention settings themselves are invalid, in which case nothing is sent.
+func (s *Salesforce) BulkUpdateDataRetention(ids []string, retention *DataRetentionProperties) (*BulkResult, error) {
+	return s.BulkUpdateDataRetentionCtx(context.Background(), ids, retention)
+}
+
+// BulkUpdateDataRetentionCtx is BulkUpdateDataRetention with a context that bounds its requests
+func (s *Salesforce) BulkUpdateDataRetentionCtx(ctx context.Context, ids []string, retention *DataRetentionProperties) (*BulkResult, error) {
+	if err := retention.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid retention settings: %w", err)
+	}
+
+	s.logger.Info("Bulk updating data retention",
+		zap.Int("data_extensions", len(ids)),
+		zap.Int("concurrency", BulkUpdateConcurrency))
+
+	result := BulkUpdate(ctx, ids, BulkUpdateConcurrency, func(ctx context.Context, id string) error {
+		return s.UpdateDataRetentionCtx(ctx, id, retention)
+	})
+
+	s.logger.Info("Completed bulk data retention update",
+		zap.Int("succeeded", len(ids)-len(result.Failed())),
+		zap.Int("failed", len(result.Failed())))
+
+	return result, nil
+}
+
+// BulkUpdate runs update for each of ids with up to concurrency calls in flight and
+// reports each outcome in request order, like BulkUpdateDataRetention. Callers that
+// need more per data extension than a plain retention PATCH pass their own update.
+func BulkUpdate(ctx context.Context, ids []string, concurrency int, update func(ctx context.Context, id string) error) *BulkResult {
+	result := &BulkResult{Results: make([]BulkItemResult, len(ids))}
+	sem := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result.Results[i] = BulkItemResult{
+				DataExtensionID: id,
+				Err:             update(ctx, id),
+			}
+		}()
+	}
+	wg.Wait()
+	return result
+}
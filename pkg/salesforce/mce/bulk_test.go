@@ -0,0 +1,136 @@
+package sfmce
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBulkUpdateDataRetentionAttributesResults(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if n <= max || maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		id := strings.TrimPrefix(r.URL.Path, "/data/v1/customobjects/")
+		switch {
+		case strings.HasPrefix(id, "missing"):
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"message": "Not found"})
+		case strings.HasPrefix(id, "bad"):
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{"message": "Invalid retention for " + id})
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+
+	var ids, wantSucceeded []string
+	for i := 0; i < 20; i++ {
+		var id string
+		switch i % 5 {
+		case 1:
+			id = fmt.Sprintf("bad-%d", i)
+		case 3:
+			id = fmt.Sprintf("missing-%d", i)
+		default:
+			id = fmt.Sprintf("de-%d", i)
+			wantSucceeded = append(wantSucceeded, id)
+		}
+		ids = append(ids, id)
+	}
+
+	result, err := client.BulkUpdateDataRetention(ids, &DataRetentionProperties{
+		DataRetentionPeriodLength:        6,
+		DataRetentionPeriodUnitOfMeasure: RetentionUnitMonths,
+	})
+	if err != nil {
+		t.Fatalf("BulkUpdateDataRetention() error = %v", err)
+	}
+
+	if len(result.Results) != len(ids) {
+		t.Fatalf("got %d results, want one per ID", len(result.Results))
+	}
+	for i, item := range result.Results {
+		if item.DataExtensionID != ids[i] {
+			t.Errorf("result %d is for %s, want %s in request order", i, item.DataExtensionID, ids[i])
+		}
+		wantFailed := !strings.HasPrefix(ids[i], "de-")
+		if (item.Err != nil) != wantFailed {
+			t.Errorf("result for %s error = %v, want failed %v", ids[i], item.Err, wantFailed)
+		}
+		// Each error belongs to its own request, not to a neighbour's
+		if item.Err != nil && strings.HasPrefix(ids[i], "bad") && !strings.Contains(item.Err.Error(), ids[i]) {
+			t.Errorf("result for %s error = %v, want its own error", ids[i], item.Err)
+		}
+	}
+	if got := result.Succeeded(); !slices.Equal(got, wantSucceeded) {
+		t.Errorf("Succeeded() = %v, want %v", got, wantSucceeded)
+	}
+	if got := len(result.Failed()); got != len(ids)-len(wantSucceeded) {
+		t.Errorf("Failed() has %d results, want %d", got, len(ids)-len(wantSucceeded))
+	}
+	if max := maxInFlight.Load(); max > BulkUpdateConcurrency {
+		t.Errorf("%d updates in flight, want at most %d", max, BulkUpdateConcurrency)
+	}
+}
+
+func TestBulkUpdateDataRetentionInvalidSettings(t *testing.T) {
+	var requests atomic.Int32
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	_, err := client.BulkUpdateDataRetention([]string{"de-1"}, &DataRetentionProperties{DataRetentionPeriodLength: -1})
+	if err == nil {
+		t.Fatal("BulkUpdateDataRetention() error = nil, want invalid settings")
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("sent %d updates with invalid settings, want none", got)
+	}
+}
+
+func TestBulkUpdateBoundsConcurrency(t *testing.T) {
+	const concurrency = 2
+	var inFlight, peak atomic.Int32
+	ids := []string{"de-1", "de-2", "de-3", "de-4", "de-5"}
+
+	result := BulkUpdate(context.Background(), ids, concurrency, func(ctx context.Context, id string) error {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if id == "de-3" {
+			return fmt.Errorf("rejected")
+		}
+		return nil
+	})
+
+	if got := peak.Load(); got > concurrency {
+		t.Errorf("%d updates in flight, want at most %d", got, concurrency)
+	}
+	for i, item := range result.Results {
+		if item.DataExtensionID != ids[i] {
+			t.Errorf("result %d is %s, want %s", i, item.DataExtensionID, ids[i])
+		}
+	}
+	if failed := result.Failed(); len(failed) != 1 || failed[0].DataExtensionID != "de-3" {
+		t.Errorf("failed = %+v, want only de-3", failed)
+	}
+}
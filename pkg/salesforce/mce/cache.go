@@ -0,0 +1,70 @@
+package sfmce
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// subFolderCache keeps GetSubFolders results for a limited time, so a run that
+// lists the same folder's children more than once only asks the API once.
+// A nil cache caches nothing. It is safe for concurrent use.
+type subFolderCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]subFolderCacheEntry
+}
+
+type subFolderCacheEntry struct {
+	resp      FoldersResponse
+	expiresAt time.Time
+}
+
+func newSubFolderCache(ttl time.Duration) *subFolderCache {
+	return &subFolderCache{ttl: ttl, entries: make(map[string]subFolderCacheEntry)}
+}
+
+// get returns a copy of the cached children of a folder, if they haven't expired
+func (c *subFolderCache) get(parentFolderID string) (*FoldersResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[parentFolderID]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, parentFolderID)
+		return nil, false
+	}
+	resp := entry.resp
+	resp.Entry = slices.Clone(resp.Entry)
+	return &resp, true
+}
+
+// put caches a copy of the children of a folder
+func (c *subFolderCache) put(parentFolderID string, resp *FoldersResponse) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := subFolderCacheEntry{resp: *resp, expiresAt: time.Now().Add(c.ttl)}
+	entry.resp.Entry = slices.Clone(resp.Entry)
+	c.entries[parentFolderID] = entry
+}
+
+// clear drops every cached entry, e.g. after the folder tree was changed
+func (c *subFolderCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
@@ -32,6 +32,13 @@ type Salesforce struct {
 	httpClient *httpclient.Client
 	tokenCache *tokenCache
 	logger     *zap.Logger
+	// dumpDir receives the raw folder listing responses when set (see SetDumpDir)
+	dumpDir string
+	// subFolders caches GetSubFolders results when set (see SetSubFolderCacheTTL)
+	subFolders *subFolderCache
+	// apiTimeLocation is where API timestamps without an offset are interpreted
+	// (Config.APITimeZone); nil means UTC
+	apiTimeLocation *time.Location
 }
 
 // tokenCache manages the OAuth access token with thread-safe access
@@ -44,20 +51,63 @@ type tokenCache struct {
 // NewSalesforce creates a new Salesforce client with default production logger
 func NewSalesforce(cfg *Config) *Salesforce {
 	logger, _ := zap.NewProduction()
-	return &Salesforce{
-		config:     cfg,
-		httpClient: httpclient.NewClientWithLogger(logger),
-		tokenCache: &tokenCache{},
-		logger:     logger,
-	}
+	return NewSalesforceWithLogger(cfg, logger)
 }
 
 // NewSalesforceWithLogger creates a new Salesforce client with a custom logger
 func NewSalesforceWithLogger(cfg *Config, logger *zap.Logger) *Salesforce {
-	return &Salesforce{
+	httpClient := httpclient.NewClientWithLogger(logger)
+	if cfg.RateLimit > 0 {
+		httpClient.SetRateLimiter(httpclient.NewRateLimiter(cfg.RateLimit, cfg.RateBurst))
+	}
+	if cfg.HTTPTimeout > 0 {
+		httpClient.SetTimeout(cfg.HTTPTimeout)
+	}
+	httpClient.SetRetryPolicy(cfg.MaxRetries, cfg.MaxElapsed)
+	if cfg.RetryBudget > 0 {
+		window := cfg.RetryBudgetWindow
+		if window == 0 {
+			window = DefaultRetryBudgetWindow
+		}
+		httpClient.SetRetryBudget(httpclient.NewRetryBudget(cfg.RetryBudget, window))
+	}
+	if cfg.IdempotencyHeader != "" {
+		httpClient.SetIdempotencyHeader(cfg.IdempotencyHeader)
+	}
+
+	s := &Salesforce{
 		config:     cfg,
-		httpClient: httpclient.NewClientWithLogger(logger),
+		httpClient: httpClient,
 		tokenCache: &tokenCache{},
 		logger:     logger,
 	}
+	if cfg.APITimeZone != "" {
+		// Validate rejects unknown zones; a config that skipped it falls back to UTC
+		loc, err := time.LoadLocation(cfg.APITimeZone)
+		if err != nil {
+			logger.Error("Unknown API time zone, interpreting timestamps as UTC",
+				zap.String("api_time_zone", cfg.APITimeZone),
+				zap.Error(err))
+		}
+		s.apiTimeLocation = loc
+	}
+	s.SetSubFolderCacheTTL(cfg.SubFolderCacheTTL)
+	return s
+}
+
+// SetSubFolderCacheTTL makes GetSubFolders serve a folder's children from memory
+// for ttl after they were fetched. Creating or updating a folder through the
+// client empties the cache. A zero ttl disables caching, which is the default.
+// It must not be called while requests are in flight.
+func (s *Salesforce) SetSubFolderCacheTTL(ttl time.Duration) {
+	s.subFolders = nil
+	if ttl > 0 {
+		s.subFolders = newSubFolderCache(ttl)
+	}
+}
+
+// HTTPClient returns the underlying HTTP client, e.g. to attach a run budget or
+// a rate limiter shared with other clients
+func (s *Salesforce) HTTPClient() *httpclient.Client {
+	return s.httpClient
 }
@@ -0,0 +1,139 @@
+package sfmce
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestCtxMethodsReturnOnCancelMidCall(t *testing.T) {
+	var started atomic.Int32
+	inCall := make(chan struct{}, 1)
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		started.Add(1)
+		select {
+		case inCall <- struct{}{}:
+		default:
+		}
+		// Hang until the client gives up, which the server only notices once the
+		// request body has been read
+		io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	})
+
+	retention := &DataRetentionProperties{DataRetentionPeriodLength: 6, DataRetentionPeriodUnitOfMeasure: RetentionUnitMonths}
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+	}{
+		{"GetFoldersCtx", func(ctx context.Context) error { _, err := client.GetFoldersCtx(ctx); return err }},
+		{"GetSubFoldersCtx", func(ctx context.Context) error { _, err := client.GetSubFoldersCtx(ctx, "1"); return err }},
+		{"GetDataExtensionsCtx", func(ctx context.Context) error { _, err := client.GetDataExtensionsCtx(ctx, "1", 1, 50); return err }},
+		{"GetDataExtensionCtx", func(ctx context.Context) error { _, err := client.GetDataExtensionCtx(ctx, "de-1"); return err }},
+		{"UpdateDataRetentionCtx", func(ctx context.Context) error { return client.UpdateDataRetentionCtx(ctx, "de-1", retention) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started.Store(0)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			// Cancel once the request has reached the server, i.e. mid-call
+			go func() {
+				<-inCall
+				cancel()
+			}()
+
+			start := time.Now()
+			err := tt.call(ctx)
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("%s() error = %v, want context.Canceled", tt.name, err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("%s() returned after %s, want prompt return", tt.name, elapsed)
+			}
+			if n := started.Load(); n != 1 {
+				t.Errorf("server got %d requests, want no retry after cancelling", n)
+			}
+		})
+	}
+}
+
+// envClient points the MCE_ environment at ts, sets env on top and returns a client
+// built from LoadConfig
+func envClient(t *testing.T, ts *testServer, env map[string]string) *Salesforce {
+	t.Helper()
+	clearEnv(t)
+	cfg := ts.config()
+	t.Setenv("MCE_AUTH_BASE_URI", cfg.AuthBaseURI)
+	t.Setenv("MCE_REST_BASE_URI", cfg.RestBaseURI)
+	t.Setenv("MCE_CLIENT_ID", cfg.ClientID)
+	t.Setenv("MCE_CLIENT_SECRET", cfg.ClientSecret)
+	t.Setenv("MCE_SCOPE", cfg.Scope)
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	loaded, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	return NewSalesforceWithLogger(loaded, zap.NewNop())
+}
+
+func TestEnvRetryPolicyReachesClient(t *testing.T) {
+	var attempts atomic.Int32
+	_, ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	t.Run("max retries", func(t *testing.T) {
+		attempts.Store(0)
+		client := envClient(t, ts, map[string]string{"MCE_MAX_RETRIES": "2"})
+		if _, err := client.GetDataExtension("de-1"); err == nil {
+			t.Fatal("GetDataExtension() error = nil, want the 503")
+		}
+		if n := attempts.Load(); n != 3 {
+			t.Errorf("%d attempts, want the first and MCE_MAX_RETRIES=2 retries", n)
+		}
+	})
+
+	t.Run("max elapsed", func(t *testing.T) {
+		attempts.Store(0)
+		client := envClient(t, ts, map[string]string{"MCE_MAX_ELAPSED": "300ms"})
+		start := time.Now()
+		if _, err := client.GetDataExtension("de-1"); err == nil {
+			t.Fatal("GetDataExtension() error = nil, want the 503")
+		}
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("retried for %v, want MCE_MAX_ELAPSED=300ms to stop it well before the default", elapsed)
+		}
+		if attempts.Load() < 2 {
+			t.Errorf("%d attempts, want the request retried within MCE_MAX_ELAPSED", attempts.Load())
+		}
+	})
+}
+
+func TestEnvHTTPTimeoutReachesClient(t *testing.T) {
+	_, ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	client := envClient(t, ts, map[string]string{"MCE_HTTP_TIMEOUT": "50ms", "MCE_MAX_RETRIES": "1"})
+
+	start := time.Now()
+	if _, err := client.GetDataExtension("de-1"); err == nil {
+		t.Fatal("GetDataExtension() error = nil, want the attempts to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("took %v, want MCE_HTTP_TIMEOUT=50ms to cut each attempt short", elapsed)
+	}
+}
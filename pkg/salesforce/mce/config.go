@@ -3,30 +3,139 @@ package sfmce
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	AuthBaseURI  string
-	RestBaseURI  string
-	ClientID     string
-	ClientSecret string
-	Scope        string
-	AccountID    string
+	AuthBaseURI  string `yaml:"authBaseUri" json:"authBaseUri"`
+	RestBaseURI  string `yaml:"restBaseUri" json:"restBaseUri"`
+	ClientID     string `yaml:"clientId" json:"clientId"`
+	ClientSecret string `yaml:"clientSecret" json:"clientSecret"`
+	Scope        string `yaml:"scope" json:"scope"`
+	AccountID    string `yaml:"accountId" json:"accountId"`
+	// PrivateKeyFile is a PEM encoded RSA key; with Subject set, authentication uses the
+	// JWT bearer flow instead of client credentials and ClientSecret isn't needed
+	PrivateKeyFile string `yaml:"privateKeyFile" json:"privateKeyFile"`
+	// Subject is the user the JWT bearer assertion is issued for
+	Subject string `yaml:"subject" json:"subject"`
+	// RateLimit caps outgoing requests per second (zero disables client-side limiting)
+	RateLimit float64 `yaml:"rateLimit" json:"rateLimit"`
+	// RateBurst is the number of requests allowed to exceed RateLimit momentarily
+	RateBurst int `yaml:"rateBurst" json:"rateBurst"`
+	// FolderPageSize is the number of folders requested per page (zero uses DefaultFolderPageSize)
+	FolderPageSize int `yaml:"folderPageSize" json:"folderPageSize"`
+	// APITimeZone is the IANA time zone timestamps without an offset are interpreted in (empty means UTC)
+	APITimeZone string `yaml:"apiTimeZone" json:"apiTimeZone"`
+	// HTTPTimeout bounds each HTTP attempt (zero keeps the HTTP client's default of 30s)
+	HTTPTimeout time.Duration `yaml:"httpTimeout" json:"httpTimeout"`
+	// MaxRetries caps the retries of a failed request (zero means no cap besides MaxElapsed)
+	MaxRetries int `yaml:"maxRetries" json:"maxRetries"`
+	// MaxElapsed bounds the time spent retrying a request (zero keeps the default of 5m)
+	MaxElapsed time.Duration `yaml:"maxElapsed" json:"maxElapsed"`
+	// RetryBudget caps the retries of all requests together per RetryBudgetWindow, so
+	// an outage fails requests quickly once it is used up (zero means no budget)
+	RetryBudget int `yaml:"retryBudget" json:"retryBudget"`
+	// RetryBudgetWindow is the period over which RetryBudget refills (zero uses DefaultRetryBudgetWindow)
+	RetryBudgetWindow time.Duration `yaml:"retryBudgetWindow" json:"retryBudgetWindow"`
+	// SubFolderCacheTTL keeps subfolder listings in memory this long (zero disables the cache)
+	SubFolderCacheTTL time.Duration `yaml:"subFolderCacheTtl" json:"subFolderCacheTtl"`
+	// IdempotencyHeader, when set, sends a key in this header with every POST and PATCH
+	// that stays the same across its retries, so the server can drop duplicates
+	IdempotencyHeader string `yaml:"idempotencyHeader" json:"idempotencyHeader"`
+	// Environments holds named sets of base URIs (e.g. sandbox, production) that
+	// ForEnvironment switches between
+	Environments map[string]EnvironmentURIs `yaml:"environments" json:"environments"`
 }
 
+// EnvironmentURIs are the base URIs of one Marketing Cloud environment
+type EnvironmentURIs struct {
+	AuthBaseURI string `yaml:"authBaseUri" json:"authBaseUri"`
+	RestBaseURI string `yaml:"restBaseUri" json:"restBaseUri"`
+}
+
+// WithBaseURI returns a copy of the config that uses the given base URIs
+func (c *Config) WithBaseURI(authBaseURI, restBaseURI string) *Config {
+	override := *c
+	override.AuthBaseURI = authBaseURI
+	override.RestBaseURI = restBaseURI
+	return &override
+}
+
+// ForEnvironment returns a copy of the config that uses the base URIs of the named
+// environment. Both URIs must be set for it.
+func (c *Config) ForEnvironment(name string) (*Config, error) {
+	uris, ok := c.Environments[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown environment %q", name)
+	}
+	if uris.AuthBaseURI == "" || uris.RestBaseURI == "" {
+		return nil, fmt.Errorf("environment %q needs both an auth and a REST base URI", name)
+	}
+	return c.WithBaseURI(uris.AuthBaseURI, uris.RestBaseURI), nil
+}
+
+// applyEnvironment switches to the environment named by MCE_ENVIRONMENT, if set. The
+// environment's URIs come from the config file or MCE_<NAME>_AUTH_BASE_URI and
+// MCE_<NAME>_REST_BASE_URI, which take precedence.
+func (c *Config) applyEnvironment() error {
+	name := os.Getenv("MCE_ENVIRONMENT")
+	if name == "" {
+		return nil
+	}
+
+	uris := c.Environments[name]
+	prefix := "MCE_" + strings.ToUpper(name) + "_"
+	if v := os.Getenv(prefix + "AUTH_BASE_URI"); v != "" {
+		uris.AuthBaseURI = v
+	}
+	if v := os.Getenv(prefix + "REST_BASE_URI"); v != "" {
+		uris.RestBaseURI = v
+	}
+	if c.Environments == nil {
+		c.Environments = make(map[string]EnvironmentURIs)
+	}
+	c.Environments[name] = uris
+
+	env, err := c.ForEnvironment(name)
+	if err != nil {
+		return fmt.Errorf("MCE_ENVIRONMENT: %w (set %sAUTH_BASE_URI and %sREST_BASE_URI or configure it in the config file)", err, prefix, prefix)
+	}
+	*c = *env
+	return nil
+}
+
+// UsesJWTBearer reports whether authentication uses the JWT bearer flow rather than
+// client credentials
+func (c *Config) UsesJWTBearer() bool {
+	return c.PrivateKeyFile != "" && c.Subject != ""
+}
+
+// DefaultRetryBudgetWindow is the period the retry budget refills over when not configured
+const DefaultRetryBudgetWindow = time.Minute
+
+// DefaultFolderPageSize is the number of folders requested per page when not configured
+const DefaultFolderPageSize = 1000
+
+// DefaultDataExtensionPageSize is the number of data extensions requested per page when
+// not configured. Paging stops at the first short page, so a size above what the API
+// serves per page would drop data extensions; 96 is known to be served in full.
+const DefaultDataExtensionPageSize = 96
+
 func LoadConfig() (*Config, error) {
 	// Try to load .env file, but don't fail if it doesn't exist
 	_ = godotenv.Load()
 
-	cfg := &Config{
-		AuthBaseURI:  os.Getenv("MCE_AUTH_BASE_URI"),
-		RestBaseURI:  os.Getenv("MCE_REST_BASE_URI"),
-		ClientID:     os.Getenv("MCE_CLIENT_ID"),
-		ClientSecret: os.Getenv("MCE_CLIENT_SECRET"),
-		Scope:        os.Getenv("MCE_SCOPE"),
-		AccountID:    os.Getenv("MCE_ACCOUNT_ID"),
+	cfg := &Config{}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.applyEnvironment(); err != nil {
+		return nil, err
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -36,6 +145,122 @@ func LoadConfig() (*Config, error) {
 	return cfg, nil
 }
 
+// LoadFromFile loads the configuration from a YAML or JSON file. Environment
+// variables that are set take precedence over the values in the file.
+func LoadFromFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// JSON is valid YAML, so a single decoder covers both formats
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	// Try to load .env file, but don't fail if it doesn't exist
+	_ = godotenv.Load()
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.applyEnvironment(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// applyEnv overrides config fields with the matching environment variables that are set
+func (c *Config) applyEnv() error {
+	setString := func(field *string, key string) {
+		if v := os.Getenv(key); v != "" {
+			*field = v
+		}
+	}
+	setString(&c.AuthBaseURI, "MCE_AUTH_BASE_URI")
+	setString(&c.RestBaseURI, "MCE_REST_BASE_URI")
+	setString(&c.ClientID, "MCE_CLIENT_ID")
+	setString(&c.ClientSecret, "MCE_CLIENT_SECRET")
+	setString(&c.Scope, "MCE_SCOPE")
+	setString(&c.AccountID, "MCE_ACCOUNT_ID")
+	setString(&c.APITimeZone, "MCE_API_TIME_ZONE")
+	setString(&c.PrivateKeyFile, "MCE_PRIVATE_KEY_FILE")
+	setString(&c.Subject, "MCE_SUBJECT")
+	setString(&c.IdempotencyHeader, "MCE_IDEMPOTENCY_HEADER")
+
+	if v := os.Getenv("MCE_RATE_LIMIT"); v != "" {
+		rateLimit, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("MCE_RATE_LIMIT must be a number: %w", err)
+		}
+		c.RateLimit = rateLimit
+	}
+	if v := os.Getenv("MCE_RATE_BURST"); v != "" {
+		burst, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("MCE_RATE_BURST must be an integer: %w", err)
+		}
+		c.RateBurst = burst
+	}
+	if v := os.Getenv("MCE_FOLDER_PAGE_SIZE"); v != "" {
+		pageSize, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("MCE_FOLDER_PAGE_SIZE must be an integer: %w", err)
+		}
+		c.FolderPageSize = pageSize
+	}
+	if v := os.Getenv("MCE_HTTP_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("MCE_HTTP_TIMEOUT must be a duration: %w", err)
+		}
+		c.HTTPTimeout = timeout
+	}
+	if v := os.Getenv("MCE_MAX_RETRIES"); v != "" {
+		maxRetries, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("MCE_MAX_RETRIES must be an integer: %w", err)
+		}
+		c.MaxRetries = maxRetries
+	}
+	if v := os.Getenv("MCE_MAX_ELAPSED"); v != "" {
+		maxElapsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("MCE_MAX_ELAPSED must be a duration: %w", err)
+		}
+		c.MaxElapsed = maxElapsed
+	}
+	if v := os.Getenv("MCE_RETRY_BUDGET"); v != "" {
+		retryBudget, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("MCE_RETRY_BUDGET must be an integer: %w", err)
+		}
+		c.RetryBudget = retryBudget
+	}
+	if v := os.Getenv("MCE_RETRY_BUDGET_WINDOW"); v != "" {
+		window, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("MCE_RETRY_BUDGET_WINDOW must be a duration: %w", err)
+		}
+		c.RetryBudgetWindow = window
+	}
+	if v := os.Getenv("MCE_SUBFOLDER_CACHE_TTL"); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("MCE_SUBFOLDER_CACHE_TTL must be a duration: %w", err)
+		}
+		c.SubFolderCacheTTL = ttl
+	}
+
+	return nil
+}
+
 func (c *Config) Validate() error {
 	if c.AuthBaseURI == "" {
 		return fmt.Errorf("MCE_AUTH_BASE_URI is required")
@@ -46,12 +271,47 @@ func (c *Config) Validate() error {
 	if c.ClientID == "" {
 		return fmt.Errorf("MCE_CLIENT_ID is required")
 	}
-	if c.ClientSecret == "" {
+	if (c.PrivateKeyFile == "") != (c.Subject == "") {
+		return fmt.Errorf("MCE_PRIVATE_KEY_FILE and MCE_SUBJECT must be set together")
+	}
+	if c.ClientSecret == "" && !c.UsesJWTBearer() {
 		return fmt.Errorf("MCE_CLIENT_SECRET is required")
 	}
 	if c.Scope == "" {
 		return fmt.Errorf("MCE_SCOPE is required")
 	}
+	if c.RateLimit < 0 {
+		return fmt.Errorf("MCE_RATE_LIMIT must not be negative")
+	}
+	if c.RateBurst < 0 {
+		return fmt.Errorf("MCE_RATE_BURST must not be negative")
+	}
+	if c.FolderPageSize < 0 {
+		return fmt.Errorf("MCE_FOLDER_PAGE_SIZE must not be negative")
+	}
+	if c.HTTPTimeout < 0 {
+		return fmt.Errorf("MCE_HTTP_TIMEOUT must not be negative")
+	}
+	if c.MaxRetries < 0 {
+		return fmt.Errorf("MCE_MAX_RETRIES must not be negative")
+	}
+	if c.MaxElapsed < 0 {
+		return fmt.Errorf("MCE_MAX_ELAPSED must not be negative")
+	}
+	if c.RetryBudget < 0 {
+		return fmt.Errorf("MCE_RETRY_BUDGET must not be negative")
+	}
+	if c.RetryBudgetWindow < 0 {
+		return fmt.Errorf("MCE_RETRY_BUDGET_WINDOW must not be negative")
+	}
+	if c.SubFolderCacheTTL < 0 {
+		return fmt.Errorf("MCE_SUBFOLDER_CACHE_TTL must not be negative")
+	}
+	if c.APITimeZone != "" {
+		if _, err := time.LoadLocation(c.APITimeZone); err != nil {
+			return fmt.Errorf("MCE_API_TIME_ZONE is not a valid time zone: %w", err)
+		}
+	}
 	// AccountID is optional, so we don't validate it
 	return nil
 }
@@ -0,0 +1,208 @@
+package sfmce
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets the MCE_ variables a config can be read from, for the test's duration
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, kv := range os.Environ() {
+		if key, _, _ := strings.Cut(kv, "="); strings.HasPrefix(key, "MCE_") {
+			t.Setenv(key, "")
+		}
+	}
+}
+
+// writeConfigFile writes content to a file named name in a temp dir and returns its path
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+const yamlConfig = `
+authBaseUri: https://auth.example.com
+restBaseUri: https://rest.example.com
+clientId: file-client
+clientSecret: file-secret
+scope: data_extensions_read
+accountId: "12345"
+rateLimit: 2.5
+httpTimeout: 45s
+`
+
+func TestLoadFromFileYAML(t *testing.T) {
+	clearEnv(t)
+	cfg, err := LoadFromFile(writeConfigFile(t, "config.yaml", yamlConfig))
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	if cfg.AuthBaseURI != "https://auth.example.com" || cfg.RestBaseURI != "https://rest.example.com" {
+		t.Errorf("base URIs = %q, %q", cfg.AuthBaseURI, cfg.RestBaseURI)
+	}
+	if cfg.ClientID != "file-client" || cfg.ClientSecret != "file-secret" || cfg.AccountID != "12345" {
+		t.Errorf("credentials = %q, %q, account %q", cfg.ClientID, cfg.ClientSecret, cfg.AccountID)
+	}
+	if cfg.RateLimit != 2.5 {
+		t.Errorf("RateLimit = %v, want 2.5", cfg.RateLimit)
+	}
+	if cfg.HTTPTimeout != 45*time.Second {
+		t.Errorf("HTTPTimeout = %s, want 45s", cfg.HTTPTimeout)
+	}
+}
+
+func TestLoadFromFileJSON(t *testing.T) {
+	clearEnv(t)
+	path := writeConfigFile(t, "config.json", `{
+		"authBaseUri": "https://auth.example.com",
+		"restBaseUri": "https://rest.example.com",
+		"clientId": "json-client",
+		"clientSecret": "json-secret",
+		"scope": "data_extensions_read"
+	}`)
+
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if cfg.ClientID != "json-client" {
+		t.Errorf("ClientID = %q, want json-client", cfg.ClientID)
+	}
+}
+
+func TestLoadFromFileEnvOverrides(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("MCE_CLIENT_ID", "env-client")
+	t.Setenv("MCE_RATE_LIMIT", "7")
+
+	cfg, err := LoadFromFile(writeConfigFile(t, "config.yaml", yamlConfig))
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if cfg.ClientID != "env-client" {
+		t.Errorf("ClientID = %q, want the environment's env-client", cfg.ClientID)
+	}
+	if cfg.RateLimit != 7 {
+		t.Errorf("RateLimit = %v, want the environment's 7", cfg.RateLimit)
+	}
+	// Fields without an environment variable set keep the file's value
+	if cfg.ClientSecret != "file-secret" {
+		t.Errorf("ClientSecret = %q, want the file's file-secret", cfg.ClientSecret)
+	}
+}
+
+func TestLoadFromFileValidates(t *testing.T) {
+	clearEnv(t)
+	path := writeConfigFile(t, "config.yaml", strings.Replace(yamlConfig, "scope: data_extensions_read\n", "", 1))
+	if _, err := LoadFromFile(path); err == nil || !strings.Contains(err.Error(), "MCE_SCOPE is required") {
+		t.Fatalf("LoadFromFile() error = %v, want the missing scope", err)
+	}
+}
+
+func TestLoadFromFileErrors(t *testing.T) {
+	clearEnv(t)
+	if _, err := LoadFromFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("LoadFromFile() of a missing file error = nil")
+	}
+	if _, err := LoadFromFile(writeConfigFile(t, "bad.yaml", "clientId: [unclosed")); err == nil {
+		t.Error("LoadFromFile() of a malformed file error = nil")
+	}
+}
+
+func TestWithBaseURI(t *testing.T) {
+	cfg := &Config{AuthBaseURI: "https://auth.prod", RestBaseURI: "https://rest.prod", ClientID: "client"}
+	override := cfg.WithBaseURI("https://auth.sandbox", "https://rest.sandbox")
+	if override.AuthBaseURI != "https://auth.sandbox" || override.RestBaseURI != "https://rest.sandbox" || override.ClientID != "client" {
+		t.Errorf("WithBaseURI() = %+v, want the sandbox URIs and the same client", override)
+	}
+	if cfg.AuthBaseURI != "https://auth.prod" || cfg.RestBaseURI != "https://rest.prod" {
+		t.Errorf("WithBaseURI() changed the original config to %+v", cfg)
+	}
+}
+
+func TestForEnvironment(t *testing.T) {
+	cfg := &Config{
+		AuthBaseURI: "https://auth.default",
+		RestBaseURI: "https://rest.default",
+		Environments: map[string]EnvironmentURIs{
+			"sandbox":    {AuthBaseURI: "https://auth.sandbox", RestBaseURI: "https://rest.sandbox"},
+			"production": {AuthBaseURI: "https://auth.prod", RestBaseURI: "https://rest.prod"},
+			"partial":    {AuthBaseURI: "https://auth.partial"},
+		},
+	}
+	tests := []struct {
+		name     string
+		wantAuth string
+		wantRest string
+		wantErr  string
+	}{
+		{name: "sandbox", wantAuth: "https://auth.sandbox", wantRest: "https://rest.sandbox"},
+		{name: "production", wantAuth: "https://auth.prod", wantRest: "https://rest.prod"},
+		{name: "partial", wantErr: "needs both"},
+		{name: "staging", wantErr: "unknown environment"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := cfg.ForEnvironment(tt.name)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ForEnvironment() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ForEnvironment() error = %v", err)
+			}
+			if env.AuthBaseURI != tt.wantAuth || env.RestBaseURI != tt.wantRest {
+				t.Errorf("ForEnvironment() URIs = %s, %s, want %s, %s", env.AuthBaseURI, env.RestBaseURI, tt.wantAuth, tt.wantRest)
+			}
+		})
+	}
+	if cfg.AuthBaseURI != "https://auth.default" {
+		t.Errorf("ForEnvironment() changed the original config to %+v", cfg)
+	}
+}
+
+func TestLoadFromFileEnvironment(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("MCE_ENVIRONMENT", "sandbox")
+	content := yamlConfig + `environments:
+  sandbox:
+    authBaseUri: https://auth.sandbox.example.com
+    restBaseUri: https://rest.sandbox.example.com
+`
+	cfg, err := LoadFromFile(writeConfigFile(t, "config.yaml", content))
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if cfg.AuthBaseURI != "https://auth.sandbox.example.com" || cfg.RestBaseURI != "https://rest.sandbox.example.com" {
+		t.Errorf("URIs = %s, %s, want the sandbox ones", cfg.AuthBaseURI, cfg.RestBaseURI)
+	}
+
+	// The environment's own variables take precedence over the file
+	t.Setenv("MCE_SANDBOX_REST_BASE_URI", "https://rest.override.example.com")
+	cfg, err = LoadFromFile(writeConfigFile(t, "config.yaml", content))
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if cfg.RestBaseURI != "https://rest.override.example.com" {
+		t.Errorf("RestBaseURI = %s, want MCE_SANDBOX_REST_BASE_URI", cfg.RestBaseURI)
+	}
+}
+
+func TestLoadFromFileUnknownEnvironment(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("MCE_ENVIRONMENT", "staging")
+	if _, err := LoadFromFile(writeConfigFile(t, "config.yaml", yamlConfig)); err == nil || !strings.Contains(err.Error(), "MCE_STAGING_AUTH_BASE_URI") {
+		t.Errorf("LoadFromFile() error = %v, want a pointer to the staging variables", err)
+	}
+}
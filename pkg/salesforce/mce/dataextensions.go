@@ -1,23 +1,34 @@
 package sfmce
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	httpclient "github.com/natserract/sf/pkg/http"
+	"github.com/natserract/sf/pkg/sferrors"
 	"go.uber.org/zap"
 )
 
 // GetDataExtensions retrieves data extensions for a given category ID with pagination
 func (s *Salesforce) GetDataExtensions(folderID string, page, pageSize int) (*DataExtensionsResponse, error) {
-	s.logger.Info("Getting data extensions",
+	return s.GetDataExtensionsCtx(context.Background(), folderID, page, pageSize)
+}
+
+// GetDataExtensionsCtx is GetDataExtensions with a context that bounds its requests
+func (s *Salesforce) GetDataExtensionsCtx(ctx context.Context, folderID string, page, pageSize int) (*DataExtensionsResponse, error) {
+	s.logger.Debug("Getting data extensions",
 		zap.String("folder_id", folderID),
 		zap.Int("page", page),
 		zap.Int("page_size", pageSize))
-	token, err := s.getAccessToken(context.Background())
+	token, err := s.getAccessToken(ctx)
 	if err != nil {
 		s.logger.Error("Failed to get access token", zap.Error(err))
 		return nil, err
@@ -38,7 +49,7 @@ func (s *Salesforce) GetDataExtensions(folderID string, page, pageSize int) (*Da
 		"_":             strconv.FormatInt(time.Now().Unix(), 10),
 	}
 
-	endpoint, err := httpclient.BuildURL(s.config.RestBaseURI, fmt.Sprintf("/data/v1/customobjects/category/%s", folderID), queryParams)
+	endpoint, err := httpclient.BuildURLSegments(s.config.RestBaseURI, []string{"data", "v1", "customobjects", "category", folderID}, queryParams)
 	if err != nil {
 		s.logger.Error("Failed to build URL", zap.Error(err))
 		return nil, fmt.Errorf("failed to build URL: %w", err)
@@ -49,7 +60,9 @@ func (s *Salesforce) GetDataExtensions(folderID string, page, pageSize int) (*Da
 	}
 
 	s.logger.Debug("Making GET request", zap.String("endpoint", endpoint))
-	resp, err := s.httpClient.Get(context.Background(), endpoint, headers)
+	resp, err := s.withReauth(ctx, headers, func() (*httpclient.Response, error) {
+		return s.httpClient.Get(ctx, endpoint, headers)
+	})
 	if err != nil {
 		s.logger.Error("Get data extensions request failed", zap.Error(err), zap.String("endpoint", endpoint))
 		return nil, fmt.Errorf("get data extensions request failed: %w", err)
@@ -67,28 +80,188 @@ func (s *Salesforce) GetDataExtensions(folderID string, page, pageSize int) (*Da
 		s.logger.Error("Failed to parse data extensions response", zap.Error(err))
 		return nil, fmt.Errorf("failed to parse data extensions response: %w", err)
 	}
+	for i := range dataExtResp.Items {
+		dataExtResp.Items[i].inLocation(s.apiTimeLocation)
+	}
 
-	s.logger.Info("Successfully retrieved data extensions",
+	s.logger.Debug("Successfully retrieved data extensions",
 		zap.String("folder_id", folderID),
 		zap.Int("items_count", len(dataExtResp.Items)))
 
 	return &dataExtResp, nil
 }
 
-// UpdateDataRetention updates the data retention properties for a data extension
+// ErrDataExtensionNotFound is returned by GetDataExtension when no data extension has the
+// given ID, and by GetDataExtensionFields when none has the given key.
+// It wraps sferrors.ErrNotFound.
+var ErrDataExtensionNotFound = fmt.Errorf("data extension %w", sferrors.ErrNotFound)
+
+// GetDataExtension retrieves a single data extension, including its current retention settings
+func (s *Salesforce) GetDataExtension(id string) (*DataExtension, error) {
+	return s.GetDataExtensionCtx(context.Background(), id)
+}
+
+// GetDataExtensionCtx is GetDataExtension with a context that bounds its requests
+func (s *Salesforce) GetDataExtensionCtx(ctx context.Context, id string) (*DataExtension, error) {
+	if id == "" {
+		return nil, fmt.Errorf("data extension id is required")
+	}
+
+	s.logger.Info("Getting data extension", zap.String("data_extension_id", id))
+	token, err := s.getAccessToken(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get access token", zap.Error(err))
+		return nil, err
+	}
+
+	endpoint, err := httpclient.BuildURLSegments(s.config.RestBaseURI, []string{"data", "v1", "customobjects", id}, nil)
+	if err != nil {
+		s.logger.Error("Failed to build URL", zap.Error(err))
+		return nil, fmt.Errorf("failed to build URL: %w", err)
+	}
+
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", token),
+	}
+
+	s.logger.Debug("Making GET request", zap.String("endpoint", endpoint))
+	resp, err := s.withReauth(ctx, headers, func() (*httpclient.Response, error) {
+		return s.httpClient.Get(ctx, endpoint, headers)
+	})
+	if err != nil {
+		if statusErr, ok := httpclient.AsStatusError(err); ok && statusErr.StatusCode == http.StatusNotFound {
+			s.logger.Warn("Data extension not found", zap.String("data_extension_id", id))
+			return nil, fmt.Errorf("%w: %s", ErrDataExtensionNotFound, id)
+		}
+		s.logger.Error("Get data extension request failed", zap.Error(err), zap.String("endpoint", endpoint))
+		return nil, fmt.Errorf("get data extension request failed: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		s.logger.Error("Get data extension failed",
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("response", string(resp.Body)))
+		return nil, fmt.Errorf("get data extension failed with status %d: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	var de DataExtension
+	if err := json.Unmarshal(resp.Body, &de); err != nil {
+		s.logger.Error("Failed to parse data extension response", zap.Error(err))
+		return nil, fmt.Errorf("failed to parse data extension response: %w", err)
+	}
+	de.inLocation(s.apiTimeLocation)
+
+	s.logger.Info("Successfully retrieved data extension",
+		zap.String("data_extension_id", de.ID),
+		zap.String("data_extension_name", de.Name))
+
+	return &de, nil
+}
+
+// GetDataExtensionFields retrieves the field definitions of the data extension with
+// the given customer key, ordered by ordinal. A missing one yields ErrDataExtensionNotFound.
+func (s *Salesforce) GetDataExtensionFields(key string) ([]DataExtensionField, error) {
+	return s.GetDataExtensionFieldsCtx(context.Background(), key)
+}
+
+// GetDataExtensionFieldsCtx is GetDataExtensionFields with a context that bounds its requests
+func (s *Salesforce) GetDataExtensionFieldsCtx(ctx context.Context, key string) ([]DataExtensionField, error) {
+	if key == "" {
+		return nil, fmt.Errorf("data extension key is required")
+	}
+
+	s.logger.Info("Getting data extension fields", zap.String("data_extension_key", key))
+	token, err := s.getAccessToken(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get access token", zap.Error(err))
+		return nil, err
+	}
+
+	endpoint, err := httpclient.BuildURLSegments(s.config.RestBaseURI, []string{"data", "v1", "customobjects", key, "fields"}, nil)
+	if err != nil {
+		s.logger.Error("Failed to build URL", zap.Error(err))
+		return nil, fmt.Errorf("failed to build URL: %w", err)
+	}
+
+	headers := map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", token),
+	}
+
+	s.logger.Debug("Making GET request", zap.String("endpoint", endpoint))
+	resp, err := s.withReauth(ctx, headers, func() (*httpclient.Response, error) {
+		return s.httpClient.Get(ctx, endpoint, headers)
+	})
+	if err != nil {
+		if statusErr, ok := httpclient.AsStatusError(err); ok && statusErr.StatusCode == http.StatusNotFound {
+			s.logger.Warn("Data extension not found", zap.String("data_extension_key", key))
+			return nil, fmt.Errorf("%w: key %s", ErrDataExtensionNotFound, key)
+		}
+		s.logger.Error("Get data extension fields request failed", zap.Error(err), zap.String("endpoint", endpoint))
+		return nil, fmt.Errorf("get data extension fields request failed: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		s.logger.Error("Get data extension fields failed",
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("response", string(resp.Body)))
+		return nil, fmt.Errorf("get data extension fields failed with status %d: %s", resp.StatusCode, string(resp.Body))
+	}
+
+	var fieldsResp DataExtensionFieldsResponse
+	if err := json.Unmarshal(resp.Body, &fieldsResp); err != nil {
+		s.logger.Error("Failed to parse data extension fields response", zap.Error(err))
+		return nil, fmt.Errorf("failed to parse data extension fields response: %w", err)
+	}
+	slices.SortStableFunc(fieldsResp.Fields, func(a, b DataExtensionField) int {
+		return cmp.Compare(a.Ordinal, b.Ordinal)
+	})
+
+	s.logger.Info("Successfully retrieved data extension fields",
+		zap.String("data_extension_key", key),
+		zap.Int("field_count", len(fieldsResp.Fields)))
+
+	return fieldsResp.Fields, nil
+}
+
+// ErrRetentionFlagsDropped is returned by UpdateDataRetention when the data extension
+// rejected the full retention payload and only the period length and unit were
+// applied. The retention flags (row-based, delete at end, reset on import) keep their
+// previous values.
+var ErrRetentionFlagsDropped = errors.New("retention flags not applied")
+
+// UpdateDataRetention updates the data retention properties for a data extension.
+// When the data extension rejects the optional retention flags, the update is retried
+// with only the period length and unit; if that succeeds, ErrRetentionFlagsDropped is
+// returned so the caller knows the flags weren't applied.
 func (s *Salesforce) UpdateDataRetention(dataExtensionID string, retention *DataRetentionProperties) error {
-	s.logger.Info("Updating data retention",
+	return s.UpdateDataRetentionCtx(context.Background(), dataExtensionID, retention)
+}
+
+// UpdateDataRetentionCtx is UpdateDataRetention with a context that bounds its requests
+func (s *Salesforce) UpdateDataRetentionCtx(ctx context.Context, dataExtensionID string, retention *DataRetentionProperties) error {
+	if err := retention.Validate(); err != nil {
+		s.logger.Error("Rejected inconsistent retention settings",
+			zap.String("data_extension_id", dataExtensionID),
+			zap.Error(err))
+		return fmt.Errorf("invalid retention settings: %w", err)
+	}
+
+	s.logger.Debug("Updating data retention",
 		zap.String("data_extension_id", dataExtensionID),
 		zap.Int("retention_period_length", retention.DataRetentionPeriodLength),
 		zap.Int("retention_period_unit", retention.DataRetentionPeriodUnitOfMeasure),
 		zap.Bool("row_based_retention", retention.IsRowBasedRetention))
-	token, err := s.getAccessToken(context.Background())
+	token, err := s.getAccessToken(ctx)
 	if err != nil {
 		s.logger.Error("Failed to get access token", zap.Error(err))
 		return err
 	}
 
-	endpoint := fmt.Sprintf("%s/data/v1/customobjects/%s", s.config.RestBaseURI, dataExtensionID)
+	endpoint, err := httpclient.BuildURLSegments(s.config.RestBaseURI, []string{"data", "v1", "customobjects", dataExtensionID}, nil)
+	if err != nil {
+		s.logger.Error("Failed to build URL", zap.Error(err))
+		return fmt.Errorf("failed to build URL: %w", err)
+	}
 
 	headers := map[string]string{
 		"Authorization": fmt.Sprintf("Bearer %s", token),
@@ -99,7 +272,30 @@ func (s *Salesforce) UpdateDataRetention(dataExtensionID string, retention *Data
 	}
 
 	s.logger.Debug("Making PATCH request", zap.String("endpoint", endpoint))
-	resp, err := s.httpClient.Patch(context.Background(), endpoint, headers, requestBody)
+	resp, err := s.withReauth(ctx, headers, func() (*httpclient.Response, error) {
+		return s.patchOnce(ctx, endpoint, headers, requestBody)
+	})
+	if err != nil && isUnsupportedFieldError(err) {
+		// Some data extensions reject the optional retention flags; retry once
+		// with only the core retention fields
+		s.logger.Warn("Full retention payload rejected, retrying with minimal payload",
+			zap.String("data_extension_id", dataExtensionID),
+			zap.Error(err))
+		minimalBody := MinimalUpdateDataRetentionRequest{
+			DataRetentionProperties: &MinimalDataRetentionProperties{
+				DataRetentionPeriodLength:        retention.DataRetentionPeriodLength,
+				DataRetentionPeriodUnitOfMeasure: retention.DataRetentionPeriodUnitOfMeasure,
+			},
+		}
+		resp, err = s.withReauth(ctx, headers, func() (*httpclient.Response, error) {
+			return s.patchOnce(ctx, endpoint, headers, minimalBody)
+		})
+		if err == nil && (resp.StatusCode == 200 || resp.StatusCode == 204) {
+			s.logger.Warn("Updated data retention without its flags",
+				zap.String("data_extension_id", dataExtensionID))
+			return fmt.Errorf("%w to %s: only the period length and unit were accepted", ErrRetentionFlagsDropped, dataExtensionID)
+		}
+	}
 	if err != nil {
 		s.logger.Error("Update data retention request failed", zap.Error(err), zap.String("endpoint", endpoint))
 		return fmt.Errorf("update data retention request failed: %w", err)
@@ -112,6 +308,35 @@ func (s *Salesforce) UpdateDataRetention(dataExtensionID string, retention *Data
 		return fmt.Errorf("update data retention failed with status %d: %s", resp.StatusCode, string(resp.Body))
 	}
 
-	s.logger.Info("Successfully updated data retention", zap.String("data_extension_id", dataExtensionID))
+	s.logger.Debug("Successfully updated data retention", zap.String("data_extension_id", dataExtensionID))
 	return nil
 }
+
+// patchOnce sends a PATCH without retrying it: an attempt that failed on our side
+// may still have been applied, and repeating it must not apply it twice
+func (s *Salesforce) patchOnce(ctx context.Context, endpoint string, headers map[string]string, body interface{}) (*httpclient.Response, error) {
+	return s.httpClient.Do(httpclient.RequestOptions{
+		Method:       http.MethodPatch,
+		URL:          endpoint,
+		Headers:      headers,
+		Body:         body,
+		Context:      ctx,
+		DisableRetry: true,
+	})
+}
+
+// isUnsupportedFieldError reports whether err is a 400 response complaining about
+// a field the data extension doesn't support
+func isUnsupportedFieldError(err error) bool {
+	statusErr, ok := httpclient.AsStatusError(err)
+	if !ok || statusErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	body := strings.ToLower(string(statusErr.Body))
+	for _, marker := range []string{"unsupported", "not supported", "unknown field", "unrecognized field"} {
+		if strings.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
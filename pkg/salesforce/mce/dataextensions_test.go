@@ -0,0 +1,266 @@
+package sfmce
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/natserract/sf/pkg/sferrors"
+	"go.uber.org/zap"
+)
+
+func TestUpdateDataRetentionFallsBackToMinimalPayload(t *testing.T) {
+	var mu sync.Mutex
+	var payloads []map[string]interface{}
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/data/v1/customobjects/de-1" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		props, _ := readJSON(t, r)["dataRetentionProperties"].(map[string]interface{})
+		mu.Lock()
+		payloads = append(payloads, props)
+		mu.Unlock()
+		if _, ok := props["isRowBasedRetention"]; ok {
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"message": "Field isRowBasedRetention is not supported for this data extension",
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err := client.UpdateDataRetention("de-1", &DataRetentionProperties{
+		DataRetentionPeriodLength:        6,
+		DataRetentionPeriodUnitOfMeasure: RetentionUnitMonths,
+		IsRowBasedRetention:              true,
+	})
+	if !errors.Is(err, ErrRetentionFlagsDropped) {
+		t.Fatalf("UpdateDataRetention() error = %v, want ErrRetentionFlagsDropped", err)
+	}
+
+	if len(payloads) != 2 {
+		t.Fatalf("got %d PATCH requests, want the full and the minimal one", len(payloads))
+	}
+	minimal := payloads[1]
+	if len(minimal) != 2 || minimal["dataRetentionPeriodLength"] != 6.0 || minimal["dataRetentionPeriodUnitOfMeasure"] != float64(RetentionUnitMonths) {
+		t.Errorf("minimal payload = %v, want only the period length and unit", minimal)
+	}
+}
+
+func TestUpdateDataRetentionOtherBadRequestFails(t *testing.T) {
+	var requests atomic.Int32
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"message": "Invalid retention period"})
+	})
+
+	err := client.UpdateDataRetention("de-1", &DataRetentionProperties{
+		DataRetentionPeriodLength:        6,
+		DataRetentionPeriodUnitOfMeasure: RetentionUnitMonths,
+	})
+	if err == nil || errors.Is(err, ErrRetentionFlagsDropped) {
+		t.Fatalf("UpdateDataRetention() error = %v, want a plain failure", err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d PATCH requests, want no fallback", n)
+	}
+}
+
+func TestUpdateDataRetentionRejectsInconsistentSettings(t *testing.T) {
+	var requests atomic.Int32
+	client, server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err := client.UpdateDataRetention("de-1", &DataRetentionProperties{
+		DataRetentionPeriodLength:        1,
+		DataRetentionPeriodUnitOfMeasure: RetentionUnitMonths,
+		IsRowBasedRetention:              true,
+		IsDeleteAtEndOfRetentionPeriod:   true,
+	})
+	if err == nil {
+		t.Fatal("UpdateDataRetention() error = nil, want the settings rejected")
+	}
+	if n := requests.Load() + server.tokens.Load(); n != 0 {
+		t.Errorf("sent %d requests, want none", n)
+	}
+}
+
+func TestGetDataExtensionAPITimeZone(t *testing.T) {
+	_, ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"de-1","name":"Orders","createdDate":"2024-07-05T14:30:15","modifiedDate":"2024-07-05T14:30:15Z"}`))
+	})
+
+	cfg := ts.config()
+	cfg.APITimeZone = "America/Chicago"
+	chicagoClient := NewSalesforceWithLogger(cfg, zap.NewNop())
+	if chicagoClient.apiTimeLocation == nil {
+		t.Skip("time zone database unavailable")
+	}
+	utcClient := NewSalesforceWithLogger(ts.config(), zap.NewNop())
+
+	tests := []struct {
+		name        string
+		client      *Salesforce
+		wantCreated time.Time
+	}{
+		{"America/Chicago", chicagoClient, time.Date(2024, 7, 5, 19, 30, 15, 0, time.UTC)},
+		// The other client's zone must not leak into this one
+		{"default UTC", utcClient, time.Date(2024, 7, 5, 14, 30, 15, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			de, err := tt.client.GetDataExtension("de-1")
+			if err != nil {
+				t.Fatalf("GetDataExtension() error = %v", err)
+			}
+			if !de.CreatedDate.Equal(tt.wantCreated) {
+				t.Errorf("CreatedDate = %s, want %s", de.CreatedDate.UTC(), tt.wantCreated)
+			}
+			// A timestamp with an offset is the same instant in any zone
+			if want := time.Date(2024, 7, 5, 14, 30, 15, 0, time.UTC); !de.ModifiedDate.Equal(want) {
+				t.Errorf("ModifiedDate = %s, want %s", de.ModifiedDate.UTC(), want)
+			}
+		})
+	}
+}
+
+func TestGetDataExtension(t *testing.T) {
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token-1" {
+			t.Errorf("Authorization = %q, want the issued token", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/data/v1/customobjects/de-1":
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"id":   "de-1",
+				"name": "Orders",
+				"key":  "orders",
+				"dataRetentionProperties": map[string]interface{}{
+					"dataRetentionPeriodLength":        6,
+					"dataRetentionPeriodUnitOfMeasure": RetentionUnitMonths,
+				},
+			})
+		default:
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"message": "Not found"})
+		}
+	})
+
+	de, err := client.GetDataExtension("de-1")
+	if err != nil {
+		t.Fatalf("GetDataExtension() error = %v", err)
+	}
+	if de.ID != "de-1" || de.Name != "Orders" || de.Key != "orders" {
+		t.Errorf("GetDataExtension() = %+v, want de-1 Orders", de)
+	}
+	if de.DataRetentionProperties == nil || de.DataRetentionProperties.DataRetentionPeriodLength != 6 {
+		t.Errorf("retention = %+v, want 6 months", de.DataRetentionProperties)
+	}
+
+	_, err = client.GetDataExtension("missing")
+	if !errors.Is(err, ErrDataExtensionNotFound) || !errors.Is(err, sferrors.ErrNotFound) {
+		t.Errorf("GetDataExtension(missing) error = %v, want ErrDataExtensionNotFound", err)
+	}
+	if _, err := client.GetDataExtension(""); err == nil {
+		t.Error("GetDataExtension(\"\") error = nil, want the id to be required")
+	}
+}
+
+// sampleFieldsResponse is a fields response as the API returns it, out of ordinal order
+const sampleFieldsResponse = `{
+  "id": "de-1",
+  "fields": [
+    {"id": "f-2", "name": "Email", "type": "EmailAddress", "length": 254, "ordinal": 1, "isPrimaryKey": false, "isNullable": true, "isHidden": false, "isReadOnly": false},
+    {"id": "f-1", "name": "SubscriberKey", "type": "Text", "length": 50, "ordinal": 0, "isPrimaryKey": true, "isNullable": false, "isHidden": false, "isReadOnly": false},
+    {"id": "f-3", "name": "Amount", "type": "Decimal", "length": 18, "scale": 2, "ordinal": 2, "isPrimaryKey": false, "isNullable": true, "isHidden": false, "isReadOnly": false, "defaultValue": "0.00"}
+  ]
+}`
+
+func TestGetDataExtensionFields(t *testing.T) {
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data/v1/customobjects/Orders 2024/fields":
+			w.Write([]byte(sampleFieldsResponse))
+		default:
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"message": "Not found"})
+		}
+	})
+
+	fields, err := client.GetDataExtensionFields("Orders 2024")
+	if err != nil {
+		t.Fatalf("GetDataExtensionFields() error = %v", err)
+	}
+	want := []DataExtensionField{
+		{ID: "f-1", Name: "SubscriberKey", Type: "Text", Length: 50, Ordinal: 0, IsPrimaryKey: true},
+		{ID: "f-2", Name: "Email", Type: "EmailAddress", Length: 254, Ordinal: 1, IsNullable: true},
+		{ID: "f-3", Name: "Amount", Type: "Decimal", Length: 18, Scale: 2, Ordinal: 2, IsNullable: true, DefaultValue: "0.00"},
+	}
+	if !slices.Equal(fields, want) {
+		t.Errorf("GetDataExtensionFields() =\n%+v\nwant\n%+v", fields, want)
+	}
+
+	if _, err := client.GetDataExtensionFields("missing"); !errors.Is(err, ErrDataExtensionNotFound) {
+		t.Errorf("GetDataExtensionFields(missing) error = %v, want ErrDataExtensionNotFound", err)
+	}
+	if _, err := client.GetDataExtensionFields(""); err == nil {
+		t.Error("GetDataExtensionFields(\"\") error = nil, want the key to be required")
+	}
+}
+
+func TestRequestPathsEscapeIDs(t *testing.T) {
+	var paths []string
+	var mu sync.Mutex
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.EscapedPath())
+		mu.Unlock()
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/legacy/"):
+			writeJSON(w, http.StatusOK, map[string]interface{}{"entry": []interface{}{}})
+		default:
+			writeJSON(w, http.StatusOK, map[string]interface{}{"id": "x", "items": []interface{}{}})
+		}
+	})
+
+	if _, err := client.GetDataExtension("Old Orders/2019"); err != nil {
+		t.Fatalf("GetDataExtension() error = %v", err)
+	}
+	if _, err := client.GetSubFolders("12/34"); err != nil {
+		t.Fatalf("GetSubFolders() error = %v", err)
+	}
+
+	want := []string{
+		"/data/v1/customobjects/Old%20Orders%2F2019",
+		"/legacy/v1/beta/folder/12%2F34/children",
+	}
+	if !slices.Equal(paths, want) {
+		t.Errorf("requested paths %q, want %q", paths, want)
+	}
+}
+
+func TestUpdateDataRetentionIsNotRetried(t *testing.T) {
+	var requests atomic.Int32
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	err := client.UpdateDataRetention("de-1", &DataRetentionProperties{
+		DataRetentionPeriodLength:        6,
+		DataRetentionPeriodUnitOfMeasure: RetentionUnitMonths,
+	})
+	if err == nil {
+		t.Fatal("UpdateDataRetention() error = nil, want the 503")
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("got %d PATCH requests, want 1", n)
+	}
+}
@@ -0,0 +1,39 @@
+package sfmce
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// SetDumpDir makes the client write the raw body of every folder listing response
+// into dir, one file per request, e.g. to analyze the hierarchy offline or to use
+// as test fixtures. Passing an empty dir disables dumping.
+func (s *Salesforce) SetDumpDir(dir string) error {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create dump dir: %w", err)
+		}
+	}
+	s.dumpDir = dir
+	return nil
+}
+
+// dumpResponse writes body to the dump dir under a name derived from the request
+// path and page offset. Failures are logged and otherwise ignored.
+func (s *Salesforce) dumpResponse(path string, skip int, body []byte) {
+	if s.dumpDir == "" {
+		return
+	}
+
+	name := strings.ReplaceAll(strings.Trim(strings.TrimPrefix(path, "/legacy/v1/beta"), "/"), "/", "_")
+	file := filepath.Join(s.dumpDir, fmt.Sprintf("%s_skip%d.json", name, skip))
+	if err := os.WriteFile(file, body, 0644); err != nil {
+		s.logger.Warn("Failed to dump response", zap.String("file", file), zap.Error(err))
+		return
+	}
+	s.logger.Debug("Dumped response", zap.String("file", file))
+}
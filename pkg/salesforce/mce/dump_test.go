@@ -0,0 +1,83 @@
+package sfmce
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDumpDirWritesRawResponses(t *testing.T) {
+	// Odd spacing and key order show the bytes are written as received
+	const foldersBody = `{"startIndex":0, "totalResults":1,
+  "entry":[{"id":"1","type":"dataextension","parentId":"0","name":"Data Extensions"}]}`
+	const childrenBody = `{"entry":[ {"name":"Campaigns","id":"11","parentId":"1","type":"dataextension"} ],"totalResults":1}`
+
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/legacy/v1/beta/folder":
+			w.Write([]byte(foldersBody))
+		case "/legacy/v1/beta/folder/1/children":
+			w.Write([]byte(childrenBody))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	dir := filepath.Join(t.TempDir(), "dump")
+	if err := client.SetDumpDir(dir); err != nil {
+		t.Fatalf("SetDumpDir() error = %v", err)
+	}
+
+	if _, err := client.GetFolders(); err != nil {
+		t.Fatalf("GetFolders() error = %v", err)
+	}
+	if _, err := client.GetSubFolders("1"); err != nil {
+		t.Fatalf("GetSubFolders() error = %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read dump dir: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if want := []string{"folder_1_children_skip0.json", "folder_skip0.json"}; !slices.Equal(names, want) {
+		t.Fatalf("dumped files %v, want %v", names, want)
+	}
+
+	for name, want := range map[string]string{
+		"folder_skip0.json":            foldersBody,
+		"folder_1_children_skip0.json": childrenBody,
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s =\n%s\nwant the exact response\n%s", name, got, want)
+		}
+	}
+}
+
+func TestDumpDirDisabled(t *testing.T) {
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"entry":[],"totalResults":0}`))
+	})
+	dir := t.TempDir()
+	if err := client.SetDumpDir(dir); err != nil {
+		t.Fatalf("SetDumpDir() error = %v", err)
+	}
+	if err := client.SetDumpDir(""); err != nil {
+		t.Fatalf("SetDumpDir(\"\") error = %v", err)
+	}
+
+	if _, err := client.GetSubFolders("1"); err != nil {
+		t.Fatalf("GetSubFolders() error = %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("dumped %d files with dumping disabled, want none", len(entries))
+	}
+}
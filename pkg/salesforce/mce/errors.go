@@ -0,0 +1,55 @@
+package sfmce
+
+import (
+	"encoding/json"
+	"fmt"
+
+	httpclient "github.com/natserract/sf/pkg/http"
+)
+
+// APIError is a failed Marketing Cloud request whose response carried the API's JSON
+// error envelope, e.g. {"message":"...","errorcode":118,"documentation":""}.
+// It wraps the underlying *httpclient.StatusError, so callers can branch on either.
+type APIError struct {
+	StatusCode    int
+	ErrorCode     int
+	Message       string
+	Documentation string
+	err           *httpclient.StatusError
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("status %d, errorcode %d: %s", e.StatusCode, e.ErrorCode, e.Message)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// apiErrorEnvelope is the body of a failed Marketing Cloud request
+type apiErrorEnvelope struct {
+	Message       string `json:"message"`
+	ErrorCode     int    `json:"errorcode"`
+	Documentation string `json:"documentation"`
+}
+
+// withAPIError returns err as an *APIError when it is a StatusError whose body is
+// the API's error envelope. Any other error, including a StatusError with a plain
+// text body, is returned unchanged, so its message keeps the raw body.
+func withAPIError(err error) error {
+	statusErr, ok := httpclient.AsStatusError(err)
+	if !ok {
+		return err
+	}
+	var envelope apiErrorEnvelope
+	if json.Unmarshal(statusErr.Body, &envelope) != nil || (envelope.Message == "" && envelope.ErrorCode == 0) {
+		return err
+	}
+	return &APIError{
+		StatusCode:    statusErr.StatusCode,
+		ErrorCode:     envelope.ErrorCode,
+		Message:       envelope.Message,
+		Documentation: envelope.Documentation,
+		err:           statusErr,
+	}
+}
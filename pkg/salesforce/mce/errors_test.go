@@ -0,0 +1,107 @@
+package sfmce
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	httpclient "github.com/natserract/sf/pkg/http"
+	"github.com/natserract/sf/pkg/sferrors"
+)
+
+func TestAPIErrorEnvelope(t *testing.T) {
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"message":       "Data extension key is invalid",
+			"errorcode":     118,
+			"documentation": "https://example.com/errors/118",
+		})
+	})
+
+	_, err := client.GetDataExtension("de-1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("GetDataExtension() error = %v, want an *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.ErrorCode != 118 ||
+		apiErr.Message != "Data extension key is invalid" || apiErr.Documentation != "https://example.com/errors/118" {
+		t.Errorf("APIError = %+v, want status 400, errorcode 118 and the message", apiErr)
+	}
+	if !strings.Contains(err.Error(), "errorcode 118") {
+		t.Errorf("error %q does not mention the errorcode", err)
+	}
+	// The status error stays reachable for existing status checks
+	if statusErr, ok := httpclient.AsStatusError(err); !ok || statusErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("AsStatusError(%v) = %v, %v, want the 400", err, statusErr, ok)
+	}
+}
+
+func TestAPIErrorPlainText(t *testing.T) {
+	for name, body := range map[string]string{
+		"plain text":       "upstream unavailable",
+		"unrelated json":   `{"status":"error"}`,
+		"truncated object": `{"message":`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(body))
+			})
+
+			_, err := client.GetDataExtension("de-1")
+			if err == nil {
+				t.Fatal("GetDataExtension() error = nil, want the 400")
+			}
+			var apiErr *APIError
+			if errors.As(err, &apiErr) {
+				t.Errorf("GetDataExtension() error = %v, want no *APIError for body %q", err, body)
+			}
+			if statusErr, ok := httpclient.AsStatusError(err); !ok || string(statusErr.Body) != body {
+				t.Errorf("error %v does not carry the raw body %q", err, body)
+			}
+		})
+	}
+}
+
+func TestStatusSentinels(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusUnauthorized, sferrors.ErrUnauthorized},
+		{http.StatusForbidden, sferrors.ErrForbidden},
+		{http.StatusNotFound, sferrors.ErrNotFound},
+		{http.StatusTooManyRequests, sferrors.ErrRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				// The envelope makes the error an *APIError, which must still match
+				writeJSON(w, tt.status, map[string]interface{}{"message": "rejected", "errorcode": 1})
+			})
+
+			_, err := client.GetDataExtension("de-1")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("GetDataExtension() error = %v, want errors.Is %v", err, tt.want)
+			}
+			for _, other := range tests {
+				if other.want != tt.want && errors.Is(err, other.want) {
+					t.Errorf("GetDataExtension() error = %v also matches %v", err, other.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOtherStatusesMatchNoSentinel(t *testing.T) {
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	_, err := client.GetDataExtension("de-1")
+	for _, sentinel := range []error{sferrors.ErrUnauthorized, sferrors.ErrForbidden, sferrors.ErrNotFound, sferrors.ErrRateLimited} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("400 error %v matches %v", err, sentinel)
+		}
+	}
+}
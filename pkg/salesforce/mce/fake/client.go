@@ -0,0 +1,401 @@
+// Package fake provides an in-memory sfmce.SalesforceClient for exercising code that
+// talks to Marketing Cloud, such as the sync services, without a live account.
+package fake
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/sferrors"
+)
+
+// RetentionUpdate records one UpdateDataRetention call
+type RetentionUpdate struct {
+	DataExtensionID string
+	Retention       sfmce.DataRetentionProperties
+}
+
+// Client is an in-memory sfmce.SalesforceClient. Folders and data extensions are
+// set up with AddFolder and AddDataExtension; GetSubFolders and GetDataExtensions
+// serve them by parent and category. Retention updates are applied to the stored
+// data extensions and recorded. It is safe for concurrent use.
+type Client struct {
+	mu             sync.Mutex
+	folders        []sfmce.Folder
+	dataExtensions []sfmce.DataExtension
+	fields         map[string][]sfmce.DataExtensionField
+	errs           map[string]error
+	updates        []RetentionUpdate
+	nextFolderID   int
+	handler        http.Handler
+}
+
+var _ sfmce.SalesforceClient = (*Client)(nil)
+
+// NewClient creates an empty fake client
+func NewClient() *Client {
+	return &Client{
+		fields:       make(map[string][]sfmce.DataExtensionField),
+		errs:         make(map[string]error),
+		nextFolderID: 1000,
+	}
+}
+
+// AddFolder adds folders. GetFolders returns all of them; GetSubFolders returns
+// those whose ParentID matches.
+func (c *Client) AddFolder(folders ...sfmce.Folder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.folders = append(c.folders, folders...)
+}
+
+// AddDataExtension adds data extensions, served by GetDataExtensions for their CategoryID
+func (c *Client) AddDataExtension(dataExtensions ...sfmce.DataExtension) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dataExtensions = append(c.dataExtensions, dataExtensions...)
+}
+
+// SetFields sets the fields GetDataExtensionFields returns for the data extension with
+// the given customer key
+func (c *Client) SetFields(key string, fields []sfmce.DataExtensionField) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fields[key] = fields
+}
+
+// FailOn makes every call to the named method (e.g. "GetSubFolders") return err,
+// for calls both with and without a context. Passing a nil err clears it.
+func (c *Client) FailOn(method string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err == nil {
+		delete(c.errs, method)
+		return
+	}
+	c.errs[method] = err
+}
+
+// SetHandler sets the handler CallAPI serves requests with
+func (c *Client) SetHandler(handler http.Handler) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.handler = handler
+}
+
+// RetentionUpdates returns the successful UpdateDataRetention calls in the order they were made
+func (c *Client) RetentionUpdates() []RetentionUpdate {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.updates)
+}
+
+// check returns the error a call should fail with: the context's, then any set with FailOn.
+// Callers hold c.mu.
+func (c *Client) check(ctx context.Context, method string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.errs[method]
+}
+
+func (c *Client) Authenticate() (*sfmce.AuthResponse, error) {
+	return c.AuthenticateCtx(context.Background())
+}
+
+func (c *Client) AuthenticateCtx(ctx context.Context) (*sfmce.AuthResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx, "Authenticate"); err != nil {
+		return nil, err
+	}
+	return &sfmce.AuthResponse{AccessToken: "fake-token", TokenType: "Bearer", ExpiresIn: 1200}, nil
+}
+
+func (c *Client) GetFolders() (*sfmce.FoldersResponse, error) {
+	return c.GetFoldersCtx(context.Background())
+}
+
+func (c *Client) GetFoldersCtx(ctx context.Context) (*sfmce.FoldersResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx, "GetFolders"); err != nil {
+		return nil, err
+	}
+	return foldersResponse(slices.Clone(c.folders)), nil
+}
+
+func (c *Client) GetFoldersFiltered(allowedTypes ...string) (*sfmce.FoldersResponse, error) {
+	return c.GetFoldersFilteredCtx(context.Background(), allowedTypes...)
+}
+
+// GetFoldersFilteredCtx returns the folders whose Type is one of allowedTypes, or
+// all of them when no types are given
+func (c *Client) GetFoldersFilteredCtx(ctx context.Context, allowedTypes ...string) (*sfmce.FoldersResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx, "GetFoldersFiltered"); err != nil {
+		return nil, err
+	}
+	if len(allowedTypes) == 0 {
+		return foldersResponse(slices.Clone(c.folders)), nil
+	}
+
+	var folders []sfmce.Folder
+	for _, folder := range c.folders {
+		if slices.ContainsFunc(allowedTypes, func(t string) bool { return strings.EqualFold(t, folder.Type) }) {
+			folders = append(folders, folder)
+		}
+	}
+	return foldersResponse(folders), nil
+}
+
+func (c *Client) GetSubFolders(folderID string) (*sfmce.FoldersResponse, error) {
+	return c.GetSubFoldersCtx(context.Background(), folderID)
+}
+
+func (c *Client) GetSubFoldersCtx(ctx context.Context, folderID string) (*sfmce.FoldersResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx, "GetSubFolders"); err != nil {
+		return nil, err
+	}
+
+	var children []sfmce.Folder
+	for _, folder := range c.folders {
+		if folder.ParentID == folderID {
+			children = append(children, folder)
+		}
+	}
+	return foldersResponse(children), nil
+}
+
+func (c *Client) CreateFolder(parentID, name, folderType string) (*sfmce.Folder, error) {
+	return c.CreateFolderCtx(context.Background(), parentID, name, folderType)
+}
+
+func (c *Client) CreateFolderCtx(ctx context.Context, parentID, name, folderType string) (*sfmce.Folder, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx, "CreateFolder"); err != nil {
+		return nil, err
+	}
+
+	if sfmce.NormalizeParentID(parentID) == sfmce.RootParentID {
+		parentID = ""
+		for _, folder := range c.folders {
+			if folder.Type == folderType && folder.IsRoot() {
+				parentID = folder.ID
+				break
+			}
+		}
+		if parentID == "" {
+			return nil, fmt.Errorf("no root folder found for type %s", folderType)
+		}
+	}
+
+	c.nextFolderID++
+	folder := sfmce.Folder{
+		ID:          strconv.Itoa(c.nextFolderID),
+		Type:        folderType,
+		LastUpdated: time.Now(),
+		ParentID:    parentID,
+		Name:        name,
+	}
+	c.folders = append(c.folders, folder)
+	return &folder, nil
+}
+
+func (c *Client) UpdateFolder(id, name string) error {
+	return c.UpdateFolderCtx(context.Background(), id, name)
+}
+
+func (c *Client) UpdateFolderCtx(ctx context.Context, id, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx, "UpdateFolder"); err != nil {
+		return err
+	}
+
+	for i := range c.folders {
+		if c.folders[i].ID == id {
+			c.folders[i].Name = name
+			c.folders[i].LastUpdated = time.Now()
+			return nil
+		}
+	}
+	return fmt.Errorf("folder %s: %w", id, sferrors.ErrNotFound)
+}
+
+func (c *Client) GetDataExtensions(folderID string, page, pageSize int) (*sfmce.DataExtensionsResponse, error) {
+	return c.GetDataExtensionsCtx(context.Background(), folderID, page, pageSize)
+}
+
+// GetDataExtensionsCtx pages through the folder's data extensions newest first, like the API
+func (c *Client) GetDataExtensionsCtx(ctx context.Context, folderID string, page, pageSize int) (*sfmce.DataExtensionsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx, "GetDataExtensions"); err != nil {
+		return nil, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 25
+	}
+
+	var inFolder []sfmce.DataExtension
+	for _, de := range c.dataExtensions {
+		if de.FolderID() == folderID {
+			inFolder = append(inFolder, de)
+		}
+	}
+	slices.SortStableFunc(inFolder, func(a, b sfmce.DataExtension) int {
+		return b.ModifiedDate.Time.Compare(a.ModifiedDate.Time)
+	})
+
+	start := min((page-1)*pageSize, len(inFolder))
+	end := min(start+pageSize, len(inFolder))
+	return &sfmce.DataExtensionsResponse{
+		Count:    len(inFolder),
+		Page:     page,
+		PageSize: pageSize,
+		Items:    slices.Clone(inFolder[start:end]),
+	}, nil
+}
+
+func (c *Client) GetDataExtension(id string) (*sfmce.DataExtension, error) {
+	return c.GetDataExtensionCtx(context.Background(), id)
+}
+
+func (c *Client) GetDataExtensionCtx(ctx context.Context, id string) (*sfmce.DataExtension, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx, "GetDataExtension"); err != nil {
+		return nil, err
+	}
+
+	i, ok := c.dataExtensionIndex(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", sfmce.ErrDataExtensionNotFound, id)
+	}
+	de := c.dataExtensions[i]
+	return &de, nil
+}
+
+func (c *Client) GetDataExtensionFields(key string) ([]sfmce.DataExtensionField, error) {
+	return c.GetDataExtensionFieldsCtx(context.Background(), key)
+}
+
+func (c *Client) GetDataExtensionFieldsCtx(ctx context.Context, key string) ([]sfmce.DataExtensionField, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx, "GetDataExtensionFields"); err != nil {
+		return nil, err
+	}
+
+	if !slices.ContainsFunc(c.dataExtensions, func(de sfmce.DataExtension) bool { return de.Key == key }) {
+		return nil, fmt.Errorf("%w: key %s", sfmce.ErrDataExtensionNotFound, key)
+	}
+	fields := slices.Clone(c.fields[key])
+	slices.SortStableFunc(fields, func(a, b sfmce.DataExtensionField) int {
+		return cmp.Compare(a.Ordinal, b.Ordinal)
+	})
+	return fields, nil
+}
+
+func (c *Client) UpdateDataRetention(dataExtensionID string, retention *sfmce.DataRetentionProperties) error {
+	return c.UpdateDataRetentionCtx(context.Background(), dataExtensionID, retention)
+}
+
+// UpdateDataRetentionCtx validates the settings like the real client, then stores
+// them on the data extension and records the call
+func (c *Client) UpdateDataRetentionCtx(ctx context.Context, dataExtensionID string, retention *sfmce.DataRetentionProperties) error {
+	if err := retention.Validate(); err != nil {
+		return fmt.Errorf("invalid retention settings: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.check(ctx, "UpdateDataRetention"); err != nil {
+		return err
+	}
+
+	i, ok := c.dataExtensionIndex(dataExtensionID)
+	if !ok {
+		return fmt.Errorf("%w: %s", sfmce.ErrDataExtensionNotFound, dataExtensionID)
+	}
+	stored := *retention
+	c.dataExtensions[i].DataRetentionProperties = &stored
+	c.updates = append(c.updates, RetentionUpdate{DataExtensionID: dataExtensionID, Retention: stored})
+	return nil
+}
+
+func (c *Client) BulkUpdateDataRetention(ids []string, retention *sfmce.DataRetentionProperties) (*sfmce.BulkResult, error) {
+	return c.BulkUpdateDataRetentionCtx(context.Background(), ids, retention)
+}
+
+func (c *Client) BulkUpdateDataRetentionCtx(ctx context.Context, ids []string, retention *sfmce.DataRetentionProperties) (*sfmce.BulkResult, error) {
+	if err := retention.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid retention settings: %w", err)
+	}
+
+	result := &sfmce.BulkResult{Results: make([]sfmce.BulkItemResult, len(ids))}
+	for i, id := range ids {
+		result.Results[i] = sfmce.BulkItemResult{
+			DataExtensionID: id,
+			Err:             c.UpdateDataRetentionCtx(ctx, id, retention),
+		}
+	}
+	return result, nil
+}
+
+// PrepareRequest builds the request like the real client, for CallAPI
+func (c *Client) PrepareRequest(ctx context.Context, method string, urlOrPath string, headers map[string]string, queryParams map[string]string, body interface{}) (*http.Request, error) {
+	return sfmce.NewRawRequest(ctx, method, urlOrPath, headers, queryParams, body)
+}
+
+// CallAPI serves the request with the handler set by SetHandler, or answers 404
+// when there is none
+func (c *Client) CallAPI(request *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	handler := c.handler
+	err := c.check(request.Context(), "CallAPI")
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if handler == nil {
+		handler = http.NotFoundHandler()
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder.Result(), nil
+}
+
+// dataExtensionIndex finds a stored data extension by ID. Callers hold c.mu.
+func (c *Client) dataExtensionIndex(id string) (int, bool) {
+	i := slices.IndexFunc(c.dataExtensions, func(de sfmce.DataExtension) bool {
+		return de.ID == id
+	})
+	return i, i >= 0
+}
+
+func foldersResponse(folders []sfmce.Folder) *sfmce.FoldersResponse {
+	return &sfmce.FoldersResponse{
+		ItemsPerPage: len(folders),
+		TotalResults: len(folders),
+		Entry:        folders,
+	}
+}
@@ -0,0 +1,142 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+)
+
+func dataExtension(id string, folderID int, modified time.Time) sfmce.DataExtension {
+	return sfmce.DataExtension{ID: id, Key: "key-" + id, CategoryID: folderID, ModifiedDate: sfmce.APITime{Time: modified}}
+}
+
+func TestClientServesFixtures(t *testing.T) {
+	client := fake.NewClient()
+	client.AddFolder(
+		sfmce.Folder{ID: "1", Type: "dataextension", Name: "Data Extensions"},
+		sfmce.Folder{ID: "2", Type: "dataextension", ParentID: "1", Name: "Campaigns"},
+		sfmce.Folder{ID: "3", Type: "email", Name: "Emails"},
+	)
+	base := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	client.AddDataExtension(
+		dataExtension("old", 2, base),
+		dataExtension("newest", 2, base.Add(2*time.Hour)),
+		dataExtension("middle", 2, base.Add(time.Hour)),
+		dataExtension("elsewhere", 1, base),
+	)
+
+	folders, err := client.GetFolders()
+	if err != nil || len(folders.Entry) != 3 {
+		t.Fatalf("GetFolders() = %v, %v, want all 3 folders", folders, err)
+	}
+	filtered, err := client.GetFoldersFiltered("DataExtension")
+	if err != nil || len(filtered.Entry) != 2 {
+		t.Errorf("GetFoldersFiltered() = %v, %v, want the 2 data extension folders", filtered, err)
+	}
+	children, err := client.GetSubFolders("1")
+	if err != nil || len(children.Entry) != 1 || children.Entry[0].ID != "2" {
+		t.Errorf("GetSubFolders(1) = %v, %v, want folder 2", children, err)
+	}
+
+	// Pages are served newest-modified first
+	var ids []string
+	for page := 1; page <= 2; page++ {
+		resp, err := client.GetDataExtensions("2", page, 2)
+		if err != nil {
+			t.Fatalf("GetDataExtensions() error = %v", err)
+		}
+		if resp.Count != 3 {
+			t.Errorf("Count = %d, want 3", resp.Count)
+		}
+		for _, de := range resp.Items {
+			ids = append(ids, de.ID)
+		}
+	}
+	if want := []string{"newest", "middle", "old"}; !slices.Equal(ids, want) {
+		t.Errorf("paged data extensions %v, want %v", ids, want)
+	}
+
+	if _, err := client.GetDataExtension("missing"); !errors.Is(err, sfmce.ErrDataExtensionNotFound) {
+		t.Errorf("GetDataExtension(missing) error = %v, want ErrDataExtensionNotFound", err)
+	}
+}
+
+func TestClientRecordsRetentionUpdates(t *testing.T) {
+	client := fake.NewClient()
+	client.AddDataExtension(dataExtension("de-1", 1, time.Time{}), dataExtension("de-2", 1, time.Time{}))
+	retention := &sfmce.DataRetentionProperties{DataRetentionPeriodLength: 6, DataRetentionPeriodUnitOfMeasure: sfmce.RetentionUnitMonths}
+
+	if err := client.UpdateDataRetention("de-2", retention); err != nil {
+		t.Fatalf("UpdateDataRetention() error = %v", err)
+	}
+	if err := client.UpdateDataRetention("missing", retention); !errors.Is(err, sfmce.ErrDataExtensionNotFound) {
+		t.Errorf("UpdateDataRetention(missing) error = %v, want ErrDataExtensionNotFound", err)
+	}
+	if err := client.UpdateDataRetention("de-1", &sfmce.DataRetentionProperties{DataRetentionPeriodLength: -1}); err == nil {
+		t.Error("UpdateDataRetention() error = nil for invalid settings")
+	}
+
+	updates := client.RetentionUpdates()
+	if len(updates) != 1 || updates[0].DataExtensionID != "de-2" || !updates[0].Retention.Equal(retention) {
+		t.Errorf("RetentionUpdates() = %+v, want only the de-2 update", updates)
+	}
+	// The update is applied to the stored data extension
+	de, err := client.GetDataExtension("de-2")
+	if err != nil || !de.DataRetentionProperties.Equal(retention) {
+		t.Errorf("GetDataExtension(de-2) retention = %v, %v, want the update applied", de, err)
+	}
+}
+
+func TestClientFailOn(t *testing.T) {
+	client := fake.NewClient()
+	errListing := errors.New("listing failed")
+	client.FailOn("GetSubFolders", errListing)
+
+	if _, err := client.GetSubFolders("1"); !errors.Is(err, errListing) {
+		t.Errorf("GetSubFolders() error = %v, want the injected error", err)
+	}
+	if _, err := client.GetSubFoldersCtx(context.Background(), "1"); !errors.Is(err, errListing) {
+		t.Errorf("GetSubFoldersCtx() error = %v, want the injected error", err)
+	}
+	if _, err := client.GetFolders(); err != nil {
+		t.Errorf("GetFolders() error = %v, want other methods unaffected", err)
+	}
+
+	client.FailOn("GetSubFolders", nil)
+	if _, err := client.GetSubFolders("1"); err != nil {
+		t.Errorf("GetSubFolders() error = %v after clearing the failure", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.GetFoldersCtx(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetFoldersCtx() error = %v, want context.Canceled", err)
+	}
+}
+
+func ExampleClient() {
+	client := fake.NewClient()
+	client.AddFolder(sfmce.Folder{ID: "1", Type: "dataextension", Name: "Data Extensions"})
+	client.AddDataExtension(sfmce.DataExtension{ID: "de-1", Name: "Orders", CategoryID: 1})
+
+	// Code under test lists and updates through the sfmce.SalesforceClient interface
+	var api sfmce.SalesforceClient = client
+	resp, _ := api.GetDataExtensions("1", 1, 50)
+	for _, de := range resp.Items {
+		api.UpdateDataRetention(de.ID, &sfmce.DataRetentionProperties{
+			DataRetentionPeriodLength:        6,
+			DataRetentionPeriodUnitOfMeasure: sfmce.RetentionUnitMonths,
+		})
+	}
+
+	for _, update := range client.RetentionUpdates() {
+		fmt.Println(update.DataExtensionID, update.Retention.DataRetentionPeriodLength)
+	}
+	// Output: de-1 6
+}
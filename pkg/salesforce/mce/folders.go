@@ -5,101 +5,322 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	httpclient "github.com/natserract/sf/pkg/http"
 	"go.uber.org/zap"
 )
 
+// DefaultFolderTypes are the folder types GetFolders lists
+var DefaultFolderTypes = []string{"synchronizeddataextension", "dataextension", "shared_data", "recyclebin"}
+
 // GetFolders retrieves all folders matching the allowed types
 func (s *Salesforce) GetFolders() (*FoldersResponse, error) {
-	s.logger.Info("Getting folders")
-	token, err := s.getAccessToken(context.Background())
+	return s.GetFoldersCtx(context.Background())
+}
+
+// GetFoldersCtx is GetFolders with a context that bounds its requests
+func (s *Salesforce) GetFoldersCtx(ctx context.Context) (*FoldersResponse, error) {
+	return s.GetFoldersFilteredCtx(ctx, DefaultFolderTypes...)
+}
+
+// GetFoldersFiltered retrieves all folders of the given types, e.g. "dataextension"
+// or "shared_data". Without types it lists DefaultFolderTypes.
+func (s *Salesforce) GetFoldersFiltered(allowedTypes ...string) (*FoldersResponse, error) {
+	return s.GetFoldersFilteredCtx(context.Background(), allowedTypes...)
+}
+
+// GetFoldersFilteredCtx is GetFoldersFiltered with a context that bounds its requests
+func (s *Salesforce) GetFoldersFilteredCtx(ctx context.Context, allowedTypes ...string) (*FoldersResponse, error) {
+	if len(allowedTypes) == 0 {
+		allowedTypes = DefaultFolderTypes
+	}
+	s.logger.Info("Getting folders", zap.Strings("allowed_types", allowedTypes))
+
+	foldersResp, err := s.getFolderPages(ctx, "/legacy/v1/beta/folder", map[string]string{
+		"$where":       allowedTypesClause(allowedTypes),
+		"Localization": "true",
+		"_":            strconv.FormatInt(time.Now().Unix(), 10),
+	}, "get folders")
 	if err != nil {
-		s.logger.Error("Failed to get access token", zap.Error(err))
 		return nil, err
 	}
 
-	endpoint, err := httpclient.BuildURL(s.config.RestBaseURI, "/legacy/v1/beta/folder", map[string]string{
-		"$where":       "allowedtypes in ('synchronizeddataextension', 'dataextension', 'shared_data', 'recyclebin')",
+	s.logger.Info("Successfully retrieved folders",
+		zap.Int("total_results", foldersResp.TotalResults),
+		zap.Int("items_count", len(foldersResp.Entry)))
+
+	return foldersResp, nil
+}
+
+// allowedTypesClause builds the $where clause that limits a folder listing to the
+// given types, quoting each one as a string literal
+func allowedTypesClause(allowedTypes []string) string {
+	quoted := make([]string, len(allowedTypes))
+	for i, t := range allowedTypes {
+		quoted[i] = "'" + strings.ReplaceAll(t, "'", "''") + "'"
+	}
+	return "allowedtypes in (" + strings.Join(quoted, ", ") + ")"
+}
+
+// GetSubFolders retrieves all subfolders for a given category ID, following pages
+// until every child has been read
+func (s *Salesforce) GetSubFolders(parentFolderID string) (*FoldersResponse, error) {
+	return s.GetSubFoldersCtx(context.Background(), parentFolderID)
+}
+
+// GetSubFoldersCtx is GetSubFolders with a context that bounds its requests
+func (s *Salesforce) GetSubFoldersCtx(ctx context.Context, parentFolderID string) (*FoldersResponse, error) {
+	if cached, ok := s.subFolders.get(parentFolderID); ok {
+		s.logger.Debug("Serving subfolders from cache", zap.String("parent_folder_id", parentFolderID))
+		return cached, nil
+	}
+	s.logger.Debug("Getting subfolders", zap.String("parent_folder_id", parentFolderID))
+
+	foldersResp, err := s.getFolderPages(ctx, httpclient.JoinPath("legacy", "v1", "beta", "folder", parentFolderID, "children"), map[string]string{
 		"Localization": "true",
-		"_":            strconv.FormatInt(time.Now().Unix(), 10),
-	})
+	}, "get subfolders")
 	if err != nil {
-		s.logger.Error("Failed to build URL", zap.Error(err))
-		return nil, fmt.Errorf("failed to build URL: %w", err)
+		return nil, err
+	}
+
+	s.logger.Debug("Successfully retrieved subfolders",
+		zap.String("parent_folder_id", parentFolderID),
+		zap.Int("total_results", foldersResp.TotalResults),
+		zap.Int("items_count", len(foldersResp.Entry)))
+
+	s.subFolders.put(parentFolderID, foldersResp)
+	return foldersResp, nil
+}
+
+// getFolderPages reads every page of a legacy folder listing using $top/$skip and
+// returns the entries of all pages in a single response. The API may cap a page below
+// $top, so it keeps reading until it has totalResults entries or a page comes back empty.
+func (s *Salesforce) getFolderPages(ctx context.Context, path string, queryParams map[string]string, operation string) (*FoldersResponse, error) {
+	pageSize := s.config.FolderPageSize
+	if pageSize <= 0 {
+		pageSize = DefaultFolderPageSize
+	}
+
+	result := &FoldersResponse{}
+	var page FoldersResponse
+	for skip := 0; ; skip += len(page.Entry) {
+		token, err := s.getAccessToken(ctx)
+		if err != nil {
+			s.logger.Error("Failed to get access token", zap.Error(err))
+			return nil, err
+		}
+
+		params := map[string]string{
+			"$top":  strconv.Itoa(pageSize),
+			"$skip": strconv.Itoa(skip),
+		}
+		for k, v := range queryParams {
+			params[k] = v
+		}
+
+		endpoint, err := httpclient.BuildURL(s.config.RestBaseURI, path, params)
+		if err != nil {
+			s.logger.Error("Failed to build URL", zap.Error(err))
+			return nil, fmt.Errorf("failed to build URL: %w", err)
+		}
+
+		headers := map[string]string{
+			"Authorization": fmt.Sprintf("Bearer %s", token),
+		}
+
+		s.logger.Debug("Making GET request", zap.String("endpoint", endpoint))
+		resp, err := s.withReauth(ctx, headers, func() (*httpclient.Response, error) {
+			return s.httpClient.Get(ctx, endpoint, headers)
+		})
+		if err != nil {
+			s.logger.Error("Folder request failed", zap.String("operation", operation), zap.Error(err), zap.String("endpoint", endpoint))
+			return nil, fmt.Errorf("%s request failed: %w", operation, err)
+		}
+
+		if resp.StatusCode != 200 {
+			s.logger.Error("Folder request failed",
+				zap.String("operation", operation),
+				zap.Int("status_code", resp.StatusCode),
+				zap.String("response", string(resp.Body)))
+			return nil, fmt.Errorf("%s failed with status %d: %s", operation, resp.StatusCode, string(resp.Body))
+		}
+
+		s.dumpResponse(path, skip, resp.Body)
+
+		page = FoldersResponse{}
+		if err := json.Unmarshal(resp.Body, &page); err != nil {
+			s.logger.Error("Failed to parse folders response", zap.String("operation", operation), zap.Error(err))
+			return nil, fmt.Errorf("failed to parse %s response: %w", operation, err)
+		}
+
+		if skip == 0 {
+			result.StartIndex = page.StartIndex
+			result.TotalResults = page.TotalResults
+		}
+		result.Entry = append(result.Entry, page.Entry...)
+
+		// Without a total, a short page means we've reached the end
+		if len(page.Entry) == 0 ||
+			(result.TotalResults > 0 && len(result.Entry) >= result.TotalResults) ||
+			(result.TotalResults == 0 && len(page.Entry) < pageSize) {
+			break
+		}
+	}
+
+	result.ItemsPerPage = len(result.Entry)
+	if result.TotalResults < len(result.Entry) {
+		result.TotalResults = len(result.Entry)
+	}
+
+	return result, nil
+}
+
+// CreateFolder creates a folder under parentID and returns it. When parentID is
+// empty (or "0"), the folder is created under the root folder of folderType.
+func (s *Salesforce) CreateFolder(parentID, name, folderType string) (*Folder, error) {
+	return s.CreateFolderCtx(context.Background(), parentID, name, folderType)
+}
+
+// CreateFolderCtx is CreateFolder with a context that bounds its requests
+func (s *Salesforce) CreateFolderCtx(ctx context.Context, parentID, name, folderType string) (*Folder, error) {
+	if name == "" {
+		return nil, fmt.Errorf("folder name is required")
+	}
+	if folderType == "" {
+		return nil, fmt.Errorf("folder type is required")
 	}
 
+	if NormalizeParentID(parentID) == RootParentID {
+		rootID, err := s.rootFolderID(ctx, folderType)
+		if err != nil {
+			return nil, err
+		}
+		parentID = rootID
+	}
+
+	s.logger.Info("Creating folder",
+		zap.String("parent_folder_id", parentID),
+		zap.String("name", name),
+		zap.String("type", folderType))
+	token, err := s.getAccessToken(ctx)
+	if err != nil {
+		s.logger.Error("Failed to get access token", zap.Error(err))
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/legacy/v1/beta/folder", s.config.RestBaseURI)
+
 	headers := map[string]string{
 		"Authorization": fmt.Sprintf("Bearer %s", token),
 	}
 
-	s.logger.Debug("Making GET request", zap.String("endpoint", endpoint))
-	resp, err := s.httpClient.Get(context.Background(), endpoint, headers)
+	requestBody := CreateFolderRequest{
+		ParentID: parentID,
+		Name:     name,
+		Type:     folderType,
+	}
+
+	s.logger.Debug("Making POST request", zap.String("endpoint", endpoint))
+	resp, err := s.withReauth(ctx, headers, func() (*httpclient.Response, error) {
+		return s.httpClient.Post(ctx, endpoint, headers, requestBody)
+	})
 	if err != nil {
-		s.logger.Error("Get folders request failed", zap.Error(err), zap.String("endpoint", endpoint))
-		return nil, fmt.Errorf("get folders request failed: %w", err)
+		s.logger.Error("Create folder request failed", zap.Error(err), zap.String("endpoint", endpoint))
+		return nil, fmt.Errorf("create folder request failed: %w", err)
 	}
 
-	if resp.StatusCode != 200 {
-		s.logger.Error("Get folders failed",
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		s.logger.Error("Create folder failed",
 			zap.Int("status_code", resp.StatusCode),
 			zap.String("response", string(resp.Body)))
-		return nil, fmt.Errorf("get folders failed with status %d: %s", resp.StatusCode, string(resp.Body))
+		return nil, fmt.Errorf("create folder failed with status %d: %s", resp.StatusCode, string(resp.Body))
 	}
 
-	var foldersResp FoldersResponse
-	if err := json.Unmarshal(resp.Body, &foldersResp); err != nil {
-		s.logger.Error("Failed to parse folders response", zap.Error(err))
-		return nil, fmt.Errorf("failed to parse folders response: %w", err)
+	var folder Folder
+	if err := json.Unmarshal(resp.Body, &folder); err != nil {
+		s.logger.Error("Failed to parse create folder response", zap.Error(err))
+		return nil, fmt.Errorf("failed to parse create folder response: %w", err)
 	}
 
-	s.logger.Info("Successfully retrieved folders",
-		zap.Int("total_results", foldersResp.TotalResults),
-		zap.Int("items_count", len(foldersResp.Entry)))
+	s.logger.Info("Successfully created folder",
+		zap.String("folder_id", folder.ID),
+		zap.String("parent_folder_id", folder.ParentID))
 
-	return &foldersResp, nil
+	s.subFolders.clear()
+	return &folder, nil
 }
 
-// GetSubFolders retrieves subfolders for a given category ID
-func (s *Salesforce) GetSubFolders(parentFolderID string) (*FoldersResponse, error) {
-	s.logger.Info("Getting subfolders", zap.String("parent_folder_id", parentFolderID))
-	token, err := s.getAccessToken(context.Background())
+// UpdateFolder renames a folder
+func (s *Salesforce) UpdateFolder(id, name string) error {
+	return s.UpdateFolderCtx(context.Background(), id, name)
+}
+
+// UpdateFolderCtx is UpdateFolder with a context that bounds its requests
+func (s *Salesforce) UpdateFolderCtx(ctx context.Context, id, name string) error {
+	if id == "" {
+		return fmt.Errorf("folder id is required")
+	}
+	if name == "" {
+		return fmt.Errorf("folder name is required")
+	}
+
+	s.logger.Info("Updating folder", zap.String("folder_id", id), zap.String("name", name))
+	token, err := s.getAccessToken(ctx)
 	if err != nil {
 		s.logger.Error("Failed to get access token", zap.Error(err))
-		return nil, err
+		return err
 	}
 
-	endpoint := fmt.Sprintf("%s/legacy/v1/beta/folder/%s/children?Localization=true&$top=1000&$skip=0", s.config.RestBaseURI, parentFolderID)
+	endpoint, err := httpclient.BuildURLSegments(s.config.RestBaseURI, []string{"legacy", "v1", "beta", "folder", id}, nil)
+	if err != nil {
+		s.logger.Error("Failed to build URL", zap.Error(err))
+		return fmt.Errorf("failed to build URL: %w", err)
+	}
 
 	headers := map[string]string{
 		"Authorization": fmt.Sprintf("Bearer %s", token),
 	}
 
-	s.logger.Debug("Making GET request", zap.String("endpoint", endpoint))
-	resp, err := s.httpClient.Get(context.Background(), endpoint, headers)
+	requestBody := UpdateFolderRequest{
+		ID:   id,
+		Name: name,
+	}
+
+	s.logger.Debug("Making PATCH request", zap.String("endpoint", endpoint))
+	resp, err := s.withReauth(ctx, headers, func() (*httpclient.Response, error) {
+		return s.httpClient.Patch(ctx, endpoint, headers, requestBody)
+	})
 	if err != nil {
-		s.logger.Error("Get subfolders request failed", zap.Error(err), zap.String("endpoint", endpoint))
-		return nil, fmt.Errorf("get subfolders request failed: %w", err)
+		s.logger.Error("Update folder request failed", zap.Error(err), zap.String("endpoint", endpoint))
+		return fmt.Errorf("update folder request failed: %w", err)
 	}
 
-	if resp.StatusCode != 200 {
-		s.logger.Error("Get subfolders failed",
+	if resp.StatusCode != 200 && resp.StatusCode != 204 {
+		s.logger.Error("Update folder failed",
 			zap.Int("status_code", resp.StatusCode),
 			zap.String("response", string(resp.Body)))
-		return nil, fmt.Errorf("get subfolders failed with status %d: %s", resp.StatusCode, string(resp.Body))
+		return fmt.Errorf("update folder failed with status %d: %s", resp.StatusCode, string(resp.Body))
 	}
 
-	var foldersResp FoldersResponse
-	if err := json.Unmarshal(resp.Body, &foldersResp); err != nil {
-		s.logger.Error("Failed to parse subfolders response", zap.Error(err))
-		return nil, fmt.Errorf("failed to parse subfolders response: %w", err)
+	s.logger.Info("Successfully updated folder", zap.String("folder_id", id))
+	s.subFolders.clear()
+	return nil
+}
+
+// rootFolderID returns the ID of the top-level folder of the given type
+func (s *Salesforce) rootFolderID(ctx context.Context, folderType string) (string, error) {
+	foldersResp, err := s.GetFoldersCtx(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve root folder: %w", err)
 	}
 
-	s.logger.Info("Successfully retrieved subfolders",
-		zap.String("parent_folder_id", parentFolderID),
-		zap.Int("total_results", foldersResp.TotalResults),
-		zap.Int("items_count", len(foldersResp.Entry)))
+	for _, folder := range foldersResp.Entry {
+		if folder.Type == folderType && folder.IsRoot() {
+			return folder.ID, nil
+		}
+	}
 
-	return &foldersResp, nil
+	return "", fmt.Errorf("no root folder found for type %s", folderType)
 }
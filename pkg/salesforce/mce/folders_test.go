@@ -0,0 +1,397 @@
+package sfmce
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestCreateFolder(t *testing.T) {
+	var body map[string]interface{}
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/legacy/v1/beta/folder" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token-1" {
+			t.Errorf("Authorization = %q", got)
+		}
+		body = readJSON(t, r)
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"id":          "5001",
+			"type":        "dataextension",
+			"parentId":    "200",
+			"name":        "Campaigns",
+			"lastUpdated": "2025-01-15T12:00:00Z",
+		})
+	})
+
+	folder, err := client.CreateFolder("200", "Campaigns", "dataextension")
+	if err != nil {
+		t.Fatalf("CreateFolder() error = %v", err)
+	}
+
+	want := map[string]interface{}{"parentId": "200", "name": "Campaigns", "type": "dataextension", "description": ""}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("request body = %v, want %v", body, want)
+	}
+	if folder.ID != "5001" || folder.ParentID != "200" || folder.Name != "Campaigns" || folder.LastUpdated.IsZero() {
+		t.Errorf("CreateFolder() = %+v", folder)
+	}
+}
+
+func TestCreateFolderResolvesRoot(t *testing.T) {
+	var parentID interface{}
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, FoldersResponse{TotalResults: 3, Entry: []Folder{
+				{ID: "100", Type: "shared_data", ParentID: "0"},
+				{ID: "200", Type: "dataextension", ParentID: "0"},
+				{ID: "201", Type: "dataextension", ParentID: "200"},
+			}})
+		case http.MethodPost:
+			parentID = readJSON(t, r)["parentId"]
+			writeJSON(w, http.StatusOK, Folder{ID: "5001", ParentID: "200"})
+		}
+	})
+
+	if _, err := client.CreateFolder("", "Campaigns", "dataextension"); err != nil {
+		t.Fatalf("CreateFolder() error = %v", err)
+	}
+	if parentID != "200" {
+		t.Errorf("created under %v, want the dataextension root 200", parentID)
+	}
+}
+
+func TestCreateFolderErrors(t *testing.T) {
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{"message": "A folder named Campaigns already exists"})
+	})
+
+	if _, err := client.CreateFolder("200", "Campaigns", "dataextension"); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("CreateFolder() error = %v, want the API's message", err)
+	}
+	if _, err := client.CreateFolder("200", "", "dataextension"); err == nil {
+		t.Error("CreateFolder() without a name error = nil")
+	}
+	if _, err := client.CreateFolder("200", "Campaigns", ""); err == nil {
+		t.Error("CreateFolder() without a type error = nil")
+	}
+}
+
+func TestUpdateFolder(t *testing.T) {
+	var body map[string]interface{}
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/legacy/v1/beta/folder/5001" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		body = readJSON(t, r)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := client.UpdateFolder("5001", "Campaigns 2025"); err != nil {
+		t.Fatalf("UpdateFolder() error = %v", err)
+	}
+	if want := map[string]interface{}{"id": "5001", "name": "Campaigns 2025"}; !reflect.DeepEqual(body, want) {
+		t.Errorf("request body = %v, want %v", body, want)
+	}
+	if err := client.UpdateFolder("", "x"); err == nil {
+		t.Error("UpdateFolder() without an id error = nil")
+	}
+}
+
+// pagedFolders serves total folders in $top/$skip pages, capping a page at maxPage
+// entries when it is positive and reporting totalResults when withTotal is set. It
+// records the $skip of every request.
+func pagedFolders(t *testing.T, total, maxPage int, withTotal bool) (http.HandlerFunc, *[]int) {
+	var skips []int
+	return func(w http.ResponseWriter, r *http.Request) {
+		top, err := strconv.Atoi(r.URL.Query().Get("$top"))
+		if err != nil {
+			t.Errorf("bad $top: %v", err)
+		}
+		skip, err := strconv.Atoi(r.URL.Query().Get("$skip"))
+		if err != nil {
+			t.Errorf("bad $skip: %v", err)
+		}
+		skips = append(skips, skip)
+		if maxPage > 0 {
+			top = min(top, maxPage)
+		}
+
+		resp := FoldersResponse{StartIndex: skip}
+		for i := skip; i < min(skip+top, total); i++ {
+			resp.Entry = append(resp.Entry, Folder{ID: strconv.Itoa(10000 + i), ParentID: "1", Type: "dataextension"})
+		}
+		resp.ItemsPerPage = len(resp.Entry)
+		if withTotal {
+			resp.TotalResults = total
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}, &skips
+}
+
+func TestGetSubFoldersPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		pageSize  int
+		maxPage   int
+		withTotal bool
+		wantSkips []int
+	}{
+		{"default page size", 0, 0, true, []int{0, 1000}},
+		{"configured page size", 600, 0, true, []int{0, 600, 1200}},
+		{"without a total", 0, 0, false, []int{0, 1000}},
+		{"server caps the page", 0, 500, true, []int{0, 500, 1000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, skips := pagedFolders(t, 1500, tt.maxPage, tt.withTotal)
+			client, server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/legacy/v1/beta/folder/1/children" {
+					t.Errorf("unexpected request %s", r.URL.Path)
+				}
+				handler(w, r)
+			})
+			cfg := server.config()
+			cfg.FolderPageSize = tt.pageSize
+			client = NewSalesforceWithLogger(cfg, zap.NewNop())
+
+			resp, err := client.GetSubFolders("1")
+			if err != nil {
+				t.Fatalf("GetSubFolders() error = %v", err)
+			}
+			if len(resp.Entry) != 1500 || resp.TotalResults != 1500 {
+				t.Fatalf("got %d children (total %d), want 1500", len(resp.Entry), resp.TotalResults)
+			}
+			seen := make(map[string]bool)
+			for _, folder := range resp.Entry {
+				seen[folder.ID] = true
+			}
+			if len(seen) != 1500 {
+				t.Errorf("got %d distinct children, want 1500", len(seen))
+			}
+			if !reflect.DeepEqual(*skips, tt.wantSkips) {
+				t.Errorf("requested $skip %v, want %v", *skips, tt.wantSkips)
+			}
+		})
+	}
+}
+
+// childrenServer serves two subfolders of any folder and counts the listings it served
+func childrenServer(t *testing.T) (*Salesforce, *atomic.Int32) {
+	var listings atomic.Int32
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/children"):
+			listings.Add(1)
+			writeJSON(w, http.StatusOK, FoldersResponse{
+				TotalResults: 2,
+				Entry:        []Folder{{ID: "11", ParentID: "1"}, {ID: "12", ParentID: "1"}},
+			})
+		case r.Method == http.MethodPost:
+			writeJSON(w, http.StatusCreated, map[string]interface{}{"id": "13", "parentId": "1", "name": "New"})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return client, &listings
+}
+
+func TestGetSubFoldersCache(t *testing.T) {
+	t.Run("second call within TTL is cached", func(t *testing.T) {
+		client, listings := childrenServer(t)
+		client.SetSubFolderCacheTTL(time.Minute)
+
+		first, err := client.GetSubFolders("1")
+		if err != nil {
+			t.Fatalf("GetSubFolders() error = %v", err)
+		}
+		// Changing a returned listing doesn't change the cached one
+		first.Entry[0].ID = "changed"
+		second, err := client.GetSubFolders("1")
+		if err != nil {
+			t.Fatalf("GetSubFolders() error = %v", err)
+		}
+		if n := listings.Load(); n != 1 {
+			t.Errorf("server listed children %d times, want the second call served from the cache", n)
+		}
+		if len(second.Entry) != 2 || second.Entry[0].ID != "11" {
+			t.Errorf("cached listing = %+v, want the original subfolders", second.Entry)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		client, listings := childrenServer(t)
+		for i := 0; i < 2; i++ {
+			if _, err := client.GetSubFolders("1"); err != nil {
+				t.Fatalf("GetSubFolders() error = %v", err)
+			}
+		}
+		if n := listings.Load(); n != 2 {
+			t.Errorf("server listed children %d times, want 2 without a cache", n)
+		}
+	})
+
+	t.Run("expires after TTL", func(t *testing.T) {
+		client, listings := childrenServer(t)
+		client.SetSubFolderCacheTTL(10 * time.Millisecond)
+		if _, err := client.GetSubFolders("1"); err != nil {
+			t.Fatalf("GetSubFolders() error = %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if _, err := client.GetSubFolders("1"); err != nil {
+			t.Fatalf("GetSubFolders() error = %v", err)
+		}
+		if n := listings.Load(); n != 2 {
+			t.Errorf("server listed children %d times, want the expired entry refetched", n)
+		}
+	})
+
+	t.Run("creating a folder clears it", func(t *testing.T) {
+		client, listings := childrenServer(t)
+		client.SetSubFolderCacheTTL(time.Minute)
+		if _, err := client.GetSubFolders("1"); err != nil {
+			t.Fatalf("GetSubFolders() error = %v", err)
+		}
+		if _, err := client.CreateFolder("1", "New", "dataextension"); err != nil {
+			t.Fatalf("CreateFolder() error = %v", err)
+		}
+		if _, err := client.GetSubFolders("1"); err != nil {
+			t.Fatalf("GetSubFolders() error = %v", err)
+		}
+		if n := listings.Load(); n != 2 {
+			t.Errorf("server listed children %d times, want a refetch after CreateFolder", n)
+		}
+	})
+
+	t.Run("concurrent calls", func(t *testing.T) {
+		client, _ := childrenServer(t)
+		client.SetSubFolderCacheTTL(time.Minute)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := client.GetSubFolders("1")
+				if err != nil {
+					t.Errorf("GetSubFolders() error = %v", err)
+					return
+				}
+				resp.Entry[0].Name = "mine"
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestAllowedTypesClause(t *testing.T) {
+	tests := []struct {
+		name  string
+		types []string
+		want  string
+	}{
+		{"single", []string{"dataextension"}, "allowedtypes in ('dataextension')"},
+		{"several", []string{"dataextension", "shared_data"}, "allowedtypes in ('dataextension', 'shared_data')"},
+		{"quote escaped", []string{"it's"}, "allowedtypes in ('it''s')"},
+		{"defaults", DefaultFolderTypes, "allowedtypes in ('synchronizeddataextension', 'dataextension', 'shared_data', 'recyclebin')"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allowedTypesClause(tt.types); got != tt.want {
+				t.Errorf("allowedTypesClause(%v) = %q, want %q", tt.types, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetFoldersFilteredQuery(t *testing.T) {
+	var where string
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		where = r.URL.Query().Get("$where")
+		writeJSON(w, http.StatusOK, FoldersResponse{})
+	})
+
+	tests := []struct {
+		name  string
+		types []string
+		want  string
+	}{
+		{"chosen types", []string{"dataextension"}, "allowedtypes in ('dataextension')"},
+		{"no types uses the defaults", nil, allowedTypesClause(DefaultFolderTypes)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.GetFoldersFiltered(tt.types...); err != nil {
+				t.Fatalf("GetFoldersFiltered() error = %v", err)
+			}
+			if where != tt.want {
+				t.Errorf("$where = %q, want %q", where, tt.want)
+			}
+		})
+	}
+
+	if _, err := client.GetFolders(); err != nil {
+		t.Fatalf("GetFolders() error = %v", err)
+	}
+	if want := allowedTypesClause(DefaultFolderTypes); where != want {
+		t.Errorf("GetFolders() $where = %q, want %q", where, want)
+	}
+}
+
+func TestGetFoldersPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		total     int
+		maxPage   int
+		withTotal bool
+		wantSkips []int
+	}{
+		{"single page", 20, 0, true, []int{0}},
+		{"several pages", 2500, 0, true, []int{0, 1000, 2000}},
+		{"without a total", 2500, 0, false, []int{0, 1000, 2000}},
+		{"server caps the page", 1200, 400, true, []int{0, 400, 800}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, skips := pagedFolders(t, tt.total, tt.maxPage, tt.withTotal)
+			client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/legacy/v1/beta/folder" {
+					t.Errorf("unexpected request %s", r.URL.Path)
+				}
+				handler(w, r)
+			})
+
+			resp, err := client.GetFolders()
+			if err != nil {
+				t.Fatalf("GetFolders() error = %v", err)
+			}
+			if len(resp.Entry) != tt.total || resp.TotalResults != tt.total {
+				t.Fatalf("got %d folders (total %d), want %d", len(resp.Entry), resp.TotalResults, tt.total)
+			}
+			seen := make(map[string]bool)
+			for _, folder := range resp.Entry {
+				seen[folder.ID] = true
+			}
+			if len(seen) != tt.total {
+				t.Errorf("got %d distinct folders, want %d", len(seen), tt.total)
+			}
+			if !reflect.DeepEqual(*skips, tt.wantSkips) {
+				t.Errorf("requested $skip %v, want %v", *skips, tt.wantSkips)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package sfmce
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// testServer is a fake Marketing Cloud that issues tokens on /v2/token and passes
+// every other request to the test's API handler
+type testServer struct {
+	*httptest.Server
+	// tokens counts the tokens issued; token N is "token-N"
+	tokens atomic.Int32
+	// expiresIn is the lifetime in seconds of the issued tokens
+	expiresIn atomic.Int32
+}
+
+// newTestServer starts a fake Marketing Cloud serving api and returns a client of it
+func newTestServer(t *testing.T, api http.HandlerFunc) (*Salesforce, *testServer) {
+	t.Helper()
+	ts := &testServer{}
+	ts.expiresIn.Store(1200)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v2/token", func(w http.ResponseWriter, r *http.Request) {
+		n := ts.tokens.Add(1)
+		writeJSON(w, http.StatusOK, AuthResponse{
+			AccessToken: fmt.Sprintf("token-%d", n),
+			TokenType:   "Bearer",
+			ExpiresIn:   int(ts.expiresIn.Load()),
+		})
+	})
+	mux.HandleFunc("/", api)
+	ts.Server = httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	client := NewSalesforceWithLogger(ts.config(), zap.NewNop())
+	return client, ts
+}
+
+// config returns a client configuration pointing at the server
+func (ts *testServer) config() *Config {
+	return &Config{
+		AuthBaseURI:  ts.URL,
+		RestBaseURI:  ts.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		Scope:        "data_extensions_read",
+	}
+}
+
+// writeJSON writes v as the JSON response body with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// readJSON decodes the request body into a generic map
+func readJSON(t *testing.T, r *http.Request) map[string]interface{} {
+	t.Helper()
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		t.Errorf("failed to decode request body: %v", err)
+	}
+	return body
+}
@@ -1,19 +1,61 @@
 package sfmce
 
-// SalesforceClient defines the interface for Salesforce API operations
+import (
+	"context"
+	"net/http"
+)
+
+// SalesforceClient defines the interface for Salesforce API operations. Each
+// operation has a Ctx variant whose context bounds its requests; the plain
+// variant uses context.Background().
 type SalesforceClient interface {
 	// Authenticate retrieves an OAuth access token
 	Authenticate() (*AuthResponse, error)
+	AuthenticateCtx(ctx context.Context) (*AuthResponse, error)
 
 	// GetFolders retrieves all folders matching the allowed types
 	GetFolders() (*FoldersResponse, error)
+	GetFoldersCtx(ctx context.Context) (*FoldersResponse, error)
+
+	// GetFoldersFiltered retrieves all folders of the given types (default: DefaultFolderTypes)
+	GetFoldersFiltered(allowedTypes ...string) (*FoldersResponse, error)
+	GetFoldersFilteredCtx(ctx context.Context, allowedTypes ...string) (*FoldersResponse, error)
 
 	// GetSubFolders retrieves subfolders for a given category ID
 	GetSubFolders(folderID string) (*FoldersResponse, error)
+	GetSubFoldersCtx(ctx context.Context, folderID string) (*FoldersResponse, error)
+
+	// CreateFolder creates a folder under parentID (or the root folder of folderType when empty)
+	CreateFolder(parentID, name, folderType string) (*Folder, error)
+	CreateFolderCtx(ctx context.Context, parentID, name, folderType string) (*Folder, error)
+
+	// UpdateFolder renames a folder
+	UpdateFolder(id, name string) error
+	UpdateFolderCtx(ctx context.Context, id, name string) error
 
 	// GetDataExtensions retrieves data extensions for a given category ID with pagination
 	GetDataExtensions(folderID string, page, pageSize int) (*DataExtensionsResponse, error)
+	GetDataExtensionsCtx(ctx context.Context, folderID string, page, pageSize int) (*DataExtensionsResponse, error)
+
+	// GetDataExtension retrieves a single data extension; a missing one yields ErrDataExtensionNotFound
+	GetDataExtension(id string) (*DataExtension, error)
+	GetDataExtensionCtx(ctx context.Context, id string) (*DataExtension, error)
+
+	// GetDataExtensionFields retrieves the field definitions of a data extension by customer key
+	GetDataExtensionFields(key string) ([]DataExtensionField, error)
+	GetDataExtensionFieldsCtx(ctx context.Context, key string) ([]DataExtensionField, error)
 
 	// UpdateDataRetention updates the data retention properties for a data extension
 	UpdateDataRetention(dataExtensionID string, retention *DataRetentionProperties) error
+	UpdateDataRetentionCtx(ctx context.Context, dataExtensionID string, retention *DataRetentionProperties) error
+
+	// BulkUpdateDataRetention applies the same retention settings to many data extensions, reporting each outcome
+	BulkUpdateDataRetention(ids []string, retention *DataRetentionProperties) (*BulkResult, error)
+	BulkUpdateDataRetentionCtx(ctx context.Context, ids []string, retention *DataRetentionProperties) (*BulkResult, error)
+
+	// PrepareRequest builds a request for an endpoint without a typed method, to be executed via CallAPI
+	PrepareRequest(ctx context.Context, method string, urlOrPath string, headers map[string]string, queryParams map[string]string, body interface{}) (*http.Request, error)
+
+	// CallAPI sends a prepared request with the access token and returns the raw response
+	CallAPI(request *http.Request) (*http.Response, error)
 }
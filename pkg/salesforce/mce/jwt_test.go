@@ -0,0 +1,170 @@
+package sfmce
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// writeTestKey writes key as PEM in the given form ("PKCS1" or "PKCS8") and returns the path
+func writeTestKey(t *testing.T, key *rsa.PrivateKey, form string) string {
+	t.Helper()
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if form == "PKCS8" {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("failed to marshal key: %v", err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return path
+}
+
+// verifyJWT checks the RS256 signature of token against key and returns its header and claims
+func verifyJWT(t *testing.T, token string, key *rsa.PublicKey) (map[string]string, jwtClaims) {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("assertion %q has %d parts, want 3", token, len(parts))
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("failed to decode signature: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		t.Fatalf("assertion signature does not verify: %v", err)
+	}
+
+	var header map[string]string
+	var claims jwtClaims
+	for i, v := range []interface{}{&header, &claims} {
+		data, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			t.Fatalf("failed to decode part %d: %v", i, err)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatalf("failed to parse part %d: %v", i, err)
+		}
+	}
+	return header, claims
+}
+
+func TestAuthenticateJWTBearer(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	for _, form := range []string{"PKCS1", "PKCS8"} {
+		t.Run(form, func(t *testing.T) {
+			var body map[string]interface{}
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v2/token" {
+					http.NotFound(w, r)
+					return
+				}
+				body = readJSON(t, r)
+				writeJSON(w, http.StatusOK, AuthResponse{AccessToken: "jwt-token", TokenType: "Bearer", ExpiresIn: 1200})
+			}))
+			defer ts.Close()
+
+			cfg := &Config{
+				AuthBaseURI:    ts.URL,
+				RestBaseURI:    ts.URL,
+				ClientID:       "client",
+				Scope:          "data_extensions_read",
+				AccountID:      "12345",
+				PrivateKeyFile: writeTestKey(t, key, form),
+				Subject:        "integration-user",
+			}
+			client := NewSalesforceWithLogger(cfg, zap.NewNop())
+			before := time.Now()
+			resp, err := client.Authenticate()
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if resp.AccessToken != "jwt-token" {
+				t.Errorf("AccessToken = %q, want jwt-token", resp.AccessToken)
+			}
+
+			if body["grant_type"] != jwtBearerGrantType {
+				t.Errorf("grant_type = %v, want %s", body["grant_type"], jwtBearerGrantType)
+			}
+			if _, ok := body["client_secret"]; ok {
+				t.Error("the JWT bearer request carries a client secret")
+			}
+			if body["scope"] != "data_extensions_read" || body["account_id"] != "12345" {
+				t.Errorf("scope, account_id = %v, %v", body["scope"], body["account_id"])
+			}
+
+			assertion, _ := body["assertion"].(string)
+			header, claims := verifyJWT(t, assertion, &key.PublicKey)
+			if header["alg"] != "RS256" || header["typ"] != "JWT" {
+				t.Errorf("header = %v, want RS256 JWT", header)
+			}
+			if claims.Issuer != "client" || claims.Subject != "integration-user" || claims.Audience != ts.URL+"/v2/token" {
+				t.Errorf("claims = %+v, want the client issuing for the subject to the token endpoint", claims)
+			}
+			if claims.IssuedAt < before.Unix() || claims.ExpiresAt != claims.IssuedAt+int64(jwtAssertionLifetime/time.Second) {
+				t.Errorf("claims iat %d, exp %d, want now and %v later", claims.IssuedAt, claims.ExpiresAt, jwtAssertionLifetime)
+			}
+		})
+	}
+}
+
+func TestAuthenticateClientCredentialsByDefault(t *testing.T) {
+	var body map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = readJSON(t, r)
+		writeJSON(w, http.StatusOK, AuthResponse{AccessToken: "token", ExpiresIn: 1200})
+	}))
+	defer ts.Close()
+
+	cfg := &Config{AuthBaseURI: ts.URL, RestBaseURI: ts.URL, ClientID: "client", ClientSecret: "secret", Scope: "s"}
+	if _, err := NewSalesforceWithLogger(cfg, zap.NewNop()).Authenticate(); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if body["grant_type"] != "client_credentials" || body["client_secret"] != "secret" {
+		t.Errorf("request = %v, want client credentials", body)
+	}
+	if _, ok := body["assertion"]; ok {
+		t.Error("the client credentials request carries an assertion")
+	}
+}
+
+func TestLoadRSAPrivateKeyErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "not.pem")
+	os.WriteFile(notPEM, []byte("not a key"), 0o600)
+	garbage := filepath.Join(dir, "garbage.pem")
+	os.WriteFile(garbage, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("garbage")}), 0o600)
+
+	for name, path := range map[string]string{
+		"missing": filepath.Join(dir, "missing.pem"),
+		"not PEM": notPEM,
+		"garbage": garbage,
+	} {
+		if _, err := loadRSAPrivateKey(path); err == nil {
+			t.Errorf("loadRSAPrivateKey(%s) error = nil", name)
+		}
+	}
+}
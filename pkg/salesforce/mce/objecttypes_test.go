@@ -0,0 +1,72 @@
+package sfmce_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	sfmce "github.com/natserract/sf/pkg/salesforce/mce"
+	"github.com/natserract/sf/pkg/salesforce/mce/fake"
+)
+
+func TestParseObjectTypes(t *testing.T) {
+	types, err := sfmce.ParseObjectTypes(" DataExtension, synchronizeddataextension ,")
+	if err != nil {
+		t.Fatalf("ParseObjectTypes() error = %v", err)
+	}
+	want := map[string]bool{sfmce.ObjectTypeDataExtension: true, sfmce.ObjectTypeSynchronizedDataExtension: true}
+	if len(types) != len(want) || !types[sfmce.ObjectTypeDataExtension] || !types[sfmce.ObjectTypeSynchronizedDataExtension] {
+		t.Errorf("ParseObjectTypes() = %v, want %v", types, want)
+	}
+
+	if types, err := sfmce.ParseObjectTypes(""); err != nil || types != nil {
+		t.Errorf("ParseObjectTypes(\"\") = %v, %v, want nil for every type", types, err)
+	}
+	if _, err := sfmce.ParseObjectTypes("dataextension,list"); err == nil || !strings.Contains(err.Error(), `"list"`) {
+		t.Errorf("ParseObjectTypes() error = %v, want the unknown type named", err)
+	}
+}
+
+func TestHasObjectTypeFiltersListing(t *testing.T) {
+	client := fake.NewClient()
+	for id, objectType := range map[string]string{
+		"de-standard": "DataExtension",
+		"de-synced":   "SynchronizedDataExtension",
+		"de-shared":   "Shared_DataExtension",
+	} {
+		client.AddDataExtension(sfmce.DataExtension{ID: id, CategoryID: 1, PartnerAPIObjectTypeName: objectType})
+	}
+	resp, err := client.GetDataExtensionsCtx(context.Background(), "1", 1, 50)
+	if err != nil {
+		t.Fatalf("GetDataExtensionsCtx() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		types string
+		want  []string
+	}{
+		{"all", "", []string{"de-shared", "de-standard", "de-synced"}},
+		{"one", "synchronizeddataextension", []string{"de-synced"}},
+		{"two", "dataextension,shared_dataextension", []string{"de-shared", "de-standard"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			types, err := sfmce.ParseObjectTypes(tt.types)
+			if err != nil {
+				t.Fatalf("ParseObjectTypes() error = %v", err)
+			}
+			var got []string
+			for _, de := range resp.Items {
+				if de.HasObjectType(types) {
+					got = append(got, de.ID)
+				}
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
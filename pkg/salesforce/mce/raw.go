@@ -0,0 +1,192 @@
+package sfmce
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// PrepareRequest creates an *http.Request suitable for passing to CallAPI, for
+// endpoints the typed methods don't cover (e.g. Journey Builder). See NewRawRequest.
+func (s *Salesforce) PrepareRequest(
+	ctx context.Context,
+	method string,
+	urlOrPath string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+) (*http.Request, error) {
+	return NewRawRequest(ctx, method, urlOrPath, headers, queryParams, body)
+}
+
+// NewRawRequest creates an *http.Request for CallAPI.
+// - urlOrPath may be an absolute URL or a relative path (resolved against Config.RestBaseURI in CallAPI).
+// - body defaults to JSON encoding unless Content-Type is application/x-www-form-urlencoded.
+func NewRawRequest(
+	ctx context.Context,
+	method string,
+	urlOrPath string,
+	headers map[string]string,
+	queryParams map[string]string,
+	body interface{},
+) (*http.Request, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if method == "" {
+		return nil, fmt.Errorf("method is required")
+	}
+	if urlOrPath == "" {
+		return nil, fmt.Errorf("urlOrPath is required")
+	}
+
+	u, err := url.Parse(urlOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse urlOrPath: %w", err)
+	}
+
+	if len(queryParams) > 0 {
+		q := u.Query()
+		for k, v := range queryParams {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		contentType := headers["Content-Type"]
+		if contentType == "" {
+			contentType = headers["content-type"]
+		}
+
+		switch v := body.(type) {
+		case io.Reader:
+			bodyReader = v
+		case []byte:
+			bodyReader = bytes.NewReader(v)
+		case string:
+			bodyReader = strings.NewReader(v)
+		default:
+			if strings.HasPrefix(strings.ToLower(contentType), "application/x-www-form-urlencoded") {
+				form, err := formValues(body)
+				if err != nil {
+					return nil, err
+				}
+				bodyReader = strings.NewReader(form.Encode())
+			} else {
+				b, err := json.Marshal(body)
+				if err != nil {
+					return nil, fmt.Errorf("failed to marshal json body: %w", err)
+				}
+				bodyReader = bytes.NewReader(b)
+			}
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Apply headers passed by caller.
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	// Sensible defaults when a body is present.
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	return req, nil
+}
+
+// formValues converts a form body to url.Values. Structs go through their JSON
+// field names; nil values are left out.
+func formValues(body interface{}) (url.Values, error) {
+	form := url.Values{}
+	switch v := body.(type) {
+	case url.Values:
+		return v, nil
+	case map[string]string:
+		for k, val := range v {
+			form.Set(k, val)
+		}
+		return form, nil
+	case map[string]interface{}:
+		for k, val := range v {
+			if val == nil {
+				continue
+			}
+			form.Set(k, fmt.Sprint(val))
+		}
+		return form, nil
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal form body: %w", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal form body: %w", err)
+	}
+	return formValues(m)
+}
+
+// CallAPI sends a request built by PrepareRequest. A relative URL is resolved
+// against Config.RestBaseURI, and the access token is set as the Authorization
+// header unless the request already has one. Like the typed methods, it goes through
+// the client's rate limiter, run budget and default headers (see
+// httpclient.Client.DoRequest). The response is returned as is, whatever its status;
+// the caller closes its body.
+func (s *Salesforce) CallAPI(request *http.Request) (*http.Response, error) {
+	if request == nil {
+		return nil, http.ErrMissingFile
+	}
+
+	// If caller provided a relative URL, resolve it against RestBaseURI.
+	if request.URL != nil && !request.URL.IsAbs() && s.config != nil && s.config.RestBaseURI != "" {
+		base, err := url.Parse(s.config.RestBaseURI)
+		if err != nil {
+			return nil, err
+		}
+		request.URL = base.ResolveReference(request.URL)
+	}
+
+	token, err := s.getAccessToken(request.Context())
+	if err != nil {
+		s.logger.Error("Failed to get access token", zap.Error(err))
+		return nil, err
+	}
+
+	// Add Authorization header unless caller already set it.
+	if request.Header == nil {
+		request.Header = make(http.Header)
+	}
+	if request.Header.Get("Authorization") == "" && token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	if request.Header.Get("Accept") == "" {
+		request.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := s.httpClient.DoRequest(request)
+	if err != nil {
+		s.logger.Error("Call API request failed", zap.Error(err), zap.String("url", request.URL.String()), zap.String("method", request.Method))
+		return nil, err
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,130 @@
+package sfmce
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestCallAPIRelativePath(t *testing.T) {
+	var body map[string]interface{}
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/interaction/v1/interactions" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer token-1" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q", got)
+		}
+		if got := r.URL.Query().Get("$page"); got != "2" {
+			t.Errorf("$page = %q, want 2", got)
+		}
+		body = readJSON(t, r)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": "journey-1"})
+	})
+
+	req, err := client.PrepareRequest(context.Background(), http.MethodPost, "/interaction/v1/interactions",
+		nil, map[string]string{"$page": "2"}, map[string]interface{}{"name": "Welcome"})
+	if err != nil {
+		t.Fatalf("PrepareRequest() error = %v", err)
+	}
+	resp, err := client.CallAPI(req)
+	if err != nil {
+		t.Fatalf("CallAPI() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if want := map[string]interface{}{"name": "Welcome"}; !reflect.DeepEqual(body, want) {
+		t.Errorf("request body = %v, want %v", body, want)
+	}
+}
+
+func TestCallAPIKeepsCallerAuthorization(t *testing.T) {
+	client, ts := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer custom" {
+			t.Errorf("Authorization = %q, want the caller's", got)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// An absolute URL is used as is
+	req, err := client.PrepareRequest(context.Background(), http.MethodGet, ts.URL+"/custom",
+		map[string]string{"Authorization": "Bearer custom"}, nil, nil)
+	if err != nil {
+		t.Fatalf("PrepareRequest() error = %v", err)
+	}
+	resp, err := client.CallAPI(req)
+	if err != nil {
+		t.Fatalf("CallAPI() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("status = %d, want 204", resp.StatusCode)
+	}
+}
+
+func TestCallAPIReturnsErrorStatus(t *testing.T) {
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"message": "Not Found"})
+	})
+
+	req, err := client.PrepareRequest(context.Background(), http.MethodGet, "/missing", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("PrepareRequest() error = %v", err)
+	}
+	resp, err := client.CallAPI(req)
+	if err != nil {
+		t.Fatalf("CallAPI() error = %v, want the response", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestNewRawRequestFormBody(t *testing.T) {
+	req, err := NewRawRequest(context.Background(), http.MethodPost, "/form",
+		map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, nil,
+		map[string]interface{}{"a": "1", "b": 2, "skipped": nil})
+	if err != nil {
+		t.Fatalf("NewRawRequest() error = %v", err)
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	got, err := url.ParseQuery(string(b))
+	if err != nil {
+		t.Fatalf("body %q is not a form: %v", b, err)
+	}
+	if want := (url.Values{"a": {"1"}, "b": {"2"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("form = %v, want %v", got, want)
+	}
+}
+
+func TestNewRawRequestValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		urlOrPath string
+	}{
+		{"no method", "", "/path"},
+		{"no path", http.MethodGet, ""},
+		{"bad url", http.MethodGet, "://bad"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRawRequest(context.Background(), tt.method, tt.urlOrPath, nil, nil, nil); err == nil {
+				t.Error("NewRawRequest() error = nil")
+			}
+		})
+	}
+}
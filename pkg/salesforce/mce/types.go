@@ -3,22 +3,40 @@ package sfmce
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // APITime is a custom time type that handles Salesforce API date formats
-// The API returns dates without timezone (e.g., "2020-09-09T04:04:02.257")
+// The API returns dates without timezone (e.g., "2020-09-09T04:04:02.257"), and some
+// endpoints use Microsoft JSON dates ("/Date(1599624242257)/") or plain dates ("2020-09-09")
 type APITime struct {
 	time.Time
+	// naive is set when the timestamp carried no offset and was parsed as UTC
+	naive bool
+}
+
+// InLocation returns t with a timestamp that carried no offset reinterpreted as wall
+// clock time in loc. Timestamps with an offset or epoch milliseconds are returned as is.
+func (t APITime) InLocation(loc *time.Location) APITime {
+	if !t.naive || loc == nil || loc == time.UTC {
+		return t
+	}
+	year, month, day := t.Date()
+	hour, minute, sec := t.Clock()
+	return APITime{Time: time.Date(year, month, day, hour, minute, sec, t.Nanosecond(), loc)}
 }
 
 // UnmarshalJSON implements json.Unmarshaler for APITime
+// Timestamps without a timezone are parsed as UTC; see InLocation
 func (t *APITime) UnmarshalJSON(data []byte) error {
 	var timeStr string
 	if err := json.Unmarshal(data, &timeStr); err != nil {
 		return err
 	}
+	t.naive = false
 
 	// Handle empty string
 	if timeStr == "" {
@@ -26,6 +44,16 @@ func (t *APITime) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 
+	// Microsoft JSON dates, e.g. "/Date(1599624242257)/" or "/Date(1599624242257-0500)/"
+	if strings.HasPrefix(timeStr, "/Date(") && strings.HasSuffix(timeStr, ")/") {
+		parsed, err := parseEpochDate(strings.TrimSuffix(strings.TrimPrefix(timeStr, "/Date("), ")/"))
+		if err != nil {
+			return fmt.Errorf("unable to parse time string %s: %w", timeStr, err)
+		}
+		t.Time = parsed
+		return nil
+	}
+
 	// Try different date formats that the API might use
 	// First, try RFC3339 formats (with timezone)
 	formats := []string{
@@ -48,7 +76,7 @@ func (t *APITime) UnmarshalJSON(data []byte) error {
 		if len(parts) == 2 {
 			// Parse the date/time part (without milliseconds)
 			if parsed, err := time.Parse("2006-01-02T15:04:05", parts[0]); err == nil {
-				t.Time = parsed
+				t.Time, t.naive = parsed, true
 				return nil
 			}
 		}
@@ -56,7 +84,13 @@ func (t *APITime) UnmarshalJSON(data []byte) error {
 
 	// Try parsing without milliseconds
 	if parsed, err := time.Parse("2006-01-02T15:04:05", timeStr); err == nil {
-		t.Time = parsed
+		t.Time, t.naive = parsed, true
+		return nil
+	}
+
+	// Date-only values, e.g. "2020-09-09"
+	if parsed, err := time.Parse(time.DateOnly, timeStr); err == nil {
+		t.Time, t.naive = parsed, true
 		return nil
 	}
 
@@ -64,6 +98,33 @@ func (t *APITime) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("unable to parse time string: %s", timeStr)
 }
 
+// parseEpochDate parses the inside of a "/Date(...)/" value: milliseconds since the
+// Unix epoch, optionally followed by a "+hhmm"/"-hhmm" offset. The offset only says
+// which zone the time was recorded in; the instant is given by the milliseconds.
+func parseEpochDate(value string) (time.Time, error) {
+	ms, offset := value, ""
+	// Skip the first character so a negative epoch isn't mistaken for an offset
+	if i := strings.IndexAny(value[min(1, len(value)):], "+-"); i >= 0 {
+		ms, offset = value[:i+1], value[i+1:]
+	}
+
+	millis, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid epoch milliseconds %q", ms)
+	}
+	parsed := time.UnixMilli(millis).UTC()
+
+	if offset != "" {
+		zone, err := time.Parse("-0700", offset)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid offset %q", offset)
+		}
+		_, seconds := zone.Zone()
+		parsed = parsed.In(time.FixedZone("", seconds))
+	}
+	return parsed, nil
+}
+
 // MarshalJSON implements json.Marshaler for APITime
 func (t APITime) MarshalJSON() ([]byte, error) {
 	if t.Time.IsZero() {
@@ -103,6 +164,34 @@ type Folder struct {
 	IconType    string    `json:"iconType"`
 }
 
+// RootParentID is the normalized parent ID of top-level folders, and the key
+// BuildAdjacency groups them under
+const RootParentID = ""
+
+// NormalizeParentID maps the API's "no parent" sentinels ("0" or empty) to RootParentID
+func NormalizeParentID(parentID string) string {
+	if parentID == "0" {
+		return RootParentID
+	}
+	return parentID
+}
+
+// IsRoot reports whether the folder is a top-level folder
+func (f Folder) IsRoot() bool {
+	return NormalizeParentID(f.ParentID) == RootParentID
+}
+
+// BuildAdjacency groups folders by their normalized parent ID, preserving the
+// input order within each group. Top-level folders are keyed by RootParentID.
+func BuildAdjacency(folders []Folder) map[string][]Folder {
+	adjacency := make(map[string][]Folder)
+	for _, folder := range folders {
+		parentID := NormalizeParentID(folder.ParentID)
+		adjacency[parentID] = append(adjacency[parentID], folder)
+	}
+	return adjacency
+}
+
 // FoldersResponse represents the response from GetFolders and GetSubFolders
 type FoldersResponse struct {
 	StartIndex   int      `json:"startIndex"`
@@ -111,6 +200,20 @@ type FoldersResponse struct {
 	Entry        []Folder `json:"entry"`
 }
 
+// CreateFolderRequest represents the request body for creating a folder
+type CreateFolderRequest struct {
+	ParentID    string `json:"parentId"`
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// UpdateFolderRequest represents the request body for renaming a folder
+type UpdateFolderRequest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
 // DataRetentionProperties represents data retention settings
 type DataRetentionProperties struct {
 	DataRetentionPeriodLength        int  `json:"dataRetentionPeriodLength"`
@@ -120,6 +223,124 @@ type DataRetentionProperties struct {
 	IsResetRetentionPeriodOnImport   bool `json:"isResetRetentionPeriodOnImport"`
 }
 
+// Retention period units of measure (DataRetentionPeriodUnitOfMeasure)
+const (
+	RetentionUnitDays   = 1
+	RetentionUnitWeeks  = 2
+	RetentionUnitYears  = 3
+	RetentionUnitMonths = 5
+)
+
+// RetentionUnitNames names the retention period units of measure
+var RetentionUnitNames = map[int]string{
+	RetentionUnitDays:   "days",
+	RetentionUnitWeeks:  "weeks",
+	RetentionUnitMonths: "months",
+	RetentionUnitYears:  "years",
+}
+
+// ParseRetentionUnit returns the unit of measure named by name: days, weeks, months
+// or years. Names are matched case-insensitively and may be singular.
+func ParseRetentionUnit(name string) (int, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for unit, unitName := range RetentionUnitNames {
+		if name == unitName || name+"s" == unitName {
+			return unit, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown retention unit %q (known: days, weeks, months, years)", name)
+}
+
+// Validate checks that the retention settings are internally consistent.
+// Row-based retention deletes individual records once they age out, so it needs a
+// period and cannot be combined with options that only apply to retaining the
+// data extension as a whole (deleting it at the end, resetting on import).
+func (p *DataRetentionProperties) Validate() error {
+	if p == nil {
+		return fmt.Errorf("retention properties are required")
+	}
+	if p.DataRetentionPeriodLength < 0 {
+		return fmt.Errorf("retention period length must not be negative, got %d", p.DataRetentionPeriodLength)
+	}
+
+	switch p.DataRetentionPeriodUnitOfMeasure {
+	case 0:
+		if p.DataRetentionPeriodLength > 0 {
+			return fmt.Errorf("retention period length %d has no unit of measure", p.DataRetentionPeriodLength)
+		}
+	case RetentionUnitDays, RetentionUnitWeeks, RetentionUnitYears, RetentionUnitMonths:
+		if p.DataRetentionPeriodLength == 0 {
+			return fmt.Errorf("retention unit of measure %d is set without a period length", p.DataRetentionPeriodUnitOfMeasure)
+		}
+	default:
+		return fmt.Errorf("unknown retention unit of measure %d", p.DataRetentionPeriodUnitOfMeasure)
+	}
+
+	if p.IsRowBasedRetention {
+		if p.DataRetentionPeriodLength == 0 {
+			return fmt.Errorf("row-based retention requires a retention period")
+		}
+		if p.IsDeleteAtEndOfRetentionPeriod {
+			return fmt.Errorf("row-based retention cannot delete the data extension at the end of the period")
+		}
+		if p.IsResetRetentionPeriodOnImport {
+			return fmt.Errorf("row-based retention cannot reset the retention period on import")
+		}
+	}
+
+	return nil
+}
+
+// Equal reports whether both retention settings are identical (nil only equals nil)
+func (p *DataRetentionProperties) Equal(other *DataRetentionProperties) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return *p == *other
+}
+
+// Differences returns the JSON names of the settings in which p differs from other.
+// When only one of them is nil, the whole of dataRetentionProperties differs.
+func (p *DataRetentionProperties) Differences(other *DataRetentionProperties) []string {
+	if p == nil || other == nil {
+		if p == other {
+			return nil
+		}
+		return []string{"dataRetentionProperties"}
+	}
+
+	var fields []string
+	if p.DataRetentionPeriodLength != other.DataRetentionPeriodLength {
+		fields = append(fields, "dataRetentionPeriodLength")
+	}
+	if p.DataRetentionPeriodUnitOfMeasure != other.DataRetentionPeriodUnitOfMeasure {
+		fields = append(fields, "dataRetentionPeriodUnitOfMeasure")
+	}
+	if p.IsDeleteAtEndOfRetentionPeriod != other.IsDeleteAtEndOfRetentionPeriod {
+		fields = append(fields, "isDeleteAtEndOfRetentionPeriod")
+	}
+	if p.IsRowBasedRetention != other.IsRowBasedRetention {
+		fields = append(fields, "isRowBasedRetention")
+	}
+	if p.IsResetRetentionPeriodOnImport != other.IsResetRetentionPeriodOnImport {
+		fields = append(fields, "isResetRetentionPeriodOnImport")
+	}
+	return fields
+}
+
+// String returns a compact, human-readable description of the retention settings
+func (p *DataRetentionProperties) String() string {
+	if p == nil {
+		return "none"
+	}
+	return fmt.Sprintf("length=%d unit=%d rowBased=%t deleteAtEnd=%t resetOnImport=%t",
+		p.DataRetentionPeriodLength,
+		p.DataRetentionPeriodUnitOfMeasure,
+		p.IsRowBasedRetention,
+		p.IsDeleteAtEndOfRetentionPeriod,
+		p.IsResetRetentionPeriodOnImport)
+}
+
 // DataExtension represents a Salesforce data extension
 type DataExtension struct {
 	ID                            string                   `json:"id"`
@@ -152,6 +373,65 @@ type DataExtension struct {
 	CategoryFullPathForRecycleBin *string                  `json:"categoryFullPathForRecyclebin"`
 }
 
+// inLocation reinterprets the data extension's timestamps that carried no offset in
+// loc (see APITime.InLocation)
+func (de *DataExtension) inLocation(loc *time.Location) {
+	de.CreatedDate = de.CreatedDate.InLocation(loc)
+	de.ModifiedDate = de.ModifiedDate.InLocation(loc)
+}
+
+// FolderID returns the data extension's category ID in the string form used by
+// Folder.ID, so data extensions can be joined to their folders
+func (de DataExtension) FolderID() string {
+	return strconv.Itoa(de.CategoryID)
+}
+
+// IsInRecycleBin reports whether the data extension has been deleted into the
+// recycle bin, which the API marks by setting its original category path
+func IsInRecycleBin(de DataExtension) bool {
+	return de.CategoryFullPathForRecycleBin != nil && *de.CategoryFullPathForRecycleBin != ""
+}
+
+// Known values of DataExtension.PartnerAPIObjectTypeName
+const (
+	ObjectTypeDataExtension             = "dataextension"
+	ObjectTypeSynchronizedDataExtension = "synchronizeddataextension"
+	ObjectTypeSharedDataExtension       = "shared_dataextension"
+)
+
+// KnownObjectTypes lists the PartnerAPIObjectTypeName values the client understands
+var KnownObjectTypes = []string{
+	ObjectTypeDataExtension,
+	ObjectTypeSynchronizedDataExtension,
+	ObjectTypeSharedDataExtension,
+}
+
+// ParseObjectTypes parses a comma-separated list of object type names, rejecting
+// unknown ones. Names are matched case-insensitively; an empty list returns nil.
+func ParseObjectTypes(list string) (map[string]bool, error) {
+	types := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(KnownObjectTypes, name) {
+			return nil, fmt.Errorf("unknown object type %q (known: %s)", name, strings.Join(KnownObjectTypes, ", "))
+		}
+		types[name] = true
+	}
+	if len(types) == 0 {
+		return nil, nil
+	}
+	return types, nil
+}
+
+// HasObjectType reports whether the data extension's PartnerAPIObjectTypeName is one
+// of types, as returned by ParseObjectTypes. A nil types matches every data extension.
+func (d DataExtension) HasObjectType(types map[string]bool) bool {
+	return types == nil || types[strings.ToLower(d.PartnerAPIObjectTypeName)]
+}
+
 // DataExtensionItem represents a single data extension item in the response (legacy structure)
 type DataExtensionItem struct {
 	DataExtension DataExtension `json:"0"`
@@ -166,7 +446,72 @@ type DataExtensionsResponse struct {
 	Items    []DataExtension        `json:"items"`
 }
 
+// UnmarshalJSON implements json.Unmarshaler for DataExtensionsResponse
+// Older stacks wrap each item in an object keyed by "0" (see DataExtensionItem);
+// those items are unwrapped, so Items is the same for both shapes
+func (r *DataExtensionsResponse) UnmarshalJSON(data []byte) error {
+	type response DataExtensionsResponse
+	var raw struct {
+		response
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*r = DataExtensionsResponse(raw.response)
+	for i, item := range raw.Items {
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal(item, &keys); err != nil {
+			return fmt.Errorf("item %d: %w", i, err)
+		}
+		if legacy, ok := keys["0"]; ok {
+			item = legacy
+		}
+		var de DataExtension
+		if err := json.Unmarshal(item, &de); err != nil {
+			return fmt.Errorf("item %d: %w", i, err)
+		}
+		r.Items = append(r.Items, de)
+	}
+	return nil
+}
+
+// DataExtensionField is the definition of one field of a data extension
+type DataExtensionField struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Type         string `json:"type"`
+	Length       int    `json:"length,omitempty"`
+	Scale        int    `json:"scale,omitempty"`
+	Ordinal      int    `json:"ordinal"`
+	IsPrimaryKey bool   `json:"isPrimaryKey"`
+	IsNullable   bool   `json:"isNullable"`
+	IsHidden     bool   `json:"isHidden"`
+	IsReadOnly   bool   `json:"isReadOnly"`
+	DefaultValue string `json:"defaultValue,omitempty"`
+}
+
+// DataExtensionFieldsResponse represents the API response for a data extension's fields
+type DataExtensionFieldsResponse struct {
+	ID     string               `json:"id"`
+	Fields []DataExtensionField `json:"fields"`
+}
+
 // UpdateDataRetentionRequest represents the request body for updating data retention
 type UpdateDataRetentionRequest struct {
 	DataRetentionProperties *DataRetentionProperties `json:"dataRetentionProperties"`
 }
+
+// MinimalDataRetentionProperties holds only the core retention fields, for data
+// extensions that reject the optional retention flags
+type MinimalDataRetentionProperties struct {
+	DataRetentionPeriodLength        int `json:"dataRetentionPeriodLength"`
+	DataRetentionPeriodUnitOfMeasure int `json:"dataRetentionPeriodUnitOfMeasure"`
+}
+
+// MinimalUpdateDataRetentionRequest is the fallback request body used when the full payload is rejected
+type MinimalUpdateDataRetentionRequest struct {
+	DataRetentionProperties *MinimalDataRetentionProperties `json:"dataRetentionProperties"`
+}
@@ -0,0 +1,318 @@
+package sfmce
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDataRetentionPropertiesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		props   *DataRetentionProperties
+		wantErr string
+	}{
+		{
+			name:  "row-based",
+			props: &DataRetentionProperties{DataRetentionPeriodLength: 1, DataRetentionPeriodUnitOfMeasure: RetentionUnitMonths, IsRowBasedRetention: true},
+		},
+		{
+			name:  "date-based deleting the data extension",
+			props: &DataRetentionProperties{DataRetentionPeriodLength: 2, DataRetentionPeriodUnitOfMeasure: RetentionUnitYears, IsDeleteAtEndOfRetentionPeriod: true, IsResetRetentionPeriodOnImport: true},
+		},
+		{
+			name:  "no retention",
+			props: &DataRetentionProperties{},
+		},
+		{
+			name:    "row-based deleting the data extension",
+			props:   &DataRetentionProperties{DataRetentionPeriodLength: 1, DataRetentionPeriodUnitOfMeasure: RetentionUnitMonths, IsRowBasedRetention: true, IsDeleteAtEndOfRetentionPeriod: true},
+			wantErr: "cannot delete the data extension",
+		},
+		{
+			name:    "row-based resetting on import",
+			props:   &DataRetentionProperties{DataRetentionPeriodLength: 1, DataRetentionPeriodUnitOfMeasure: RetentionUnitMonths, IsRowBasedRetention: true, IsResetRetentionPeriodOnImport: true},
+			wantErr: "cannot reset the retention period on import",
+		},
+		{
+			name:    "row-based without a period",
+			props:   &DataRetentionProperties{IsRowBasedRetention: true},
+			wantErr: "requires a retention period",
+		},
+		{
+			name:    "length without a unit",
+			props:   &DataRetentionProperties{DataRetentionPeriodLength: 3},
+			wantErr: "has no unit of measure",
+		},
+		{
+			name:    "unit without a length",
+			props:   &DataRetentionProperties{DataRetentionPeriodUnitOfMeasure: RetentionUnitDays},
+			wantErr: "without a period length",
+		},
+		{
+			name:    "unknown unit",
+			props:   &DataRetentionProperties{DataRetentionPeriodLength: 1, DataRetentionPeriodUnitOfMeasure: 4},
+			wantErr: "unknown retention unit",
+		},
+		{
+			name:    "negative length",
+			props:   &DataRetentionProperties{DataRetentionPeriodLength: -1, DataRetentionPeriodUnitOfMeasure: RetentionUnitDays},
+			wantErr: "must not be negative",
+		},
+		{
+			name:    "nil",
+			wantErr: "required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.props.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildAdjacency(t *testing.T) {
+	folders := []Folder{
+		{ID: "1", ParentID: "0"},
+		{ID: "2", ParentID: ""},
+		{ID: "11", ParentID: "1"},
+		{ID: "12", ParentID: "1"},
+		{ID: "111", ParentID: "11"},
+		{ID: "21", ParentID: "2"},
+	}
+
+	adjacency := BuildAdjacency(folders)
+
+	want := map[string][]string{
+		RootParentID: {"1", "2"},
+		"1":          {"11", "12"},
+		"11":         {"111"},
+		"2":          {"21"},
+	}
+	if len(adjacency) != len(want) {
+		t.Errorf("got %d parents, want %d", len(adjacency), len(want))
+	}
+	for parentID, wantIDs := range want {
+		var ids []string
+		for _, folder := range adjacency[parentID] {
+			ids = append(ids, folder.ID)
+		}
+		if !slices.Equal(ids, wantIDs) {
+			t.Errorf("children of %q = %v, want %v", parentID, ids, wantIDs)
+		}
+	}
+	if _, ok := adjacency["0"]; ok {
+		t.Error(`folders are grouped under "0", want it normalized to RootParentID`)
+	}
+	if len(BuildAdjacency(nil)) != 0 {
+		t.Error("BuildAdjacency(nil) is not empty")
+	}
+}
+
+func TestFolderIsRoot(t *testing.T) {
+	for parentID, want := range map[string]bool{"": true, "0": true, "1": false, "00": false} {
+		if got := (Folder{ParentID: parentID}).IsRoot(); got != want {
+			t.Errorf("IsRoot() with parent %q = %v, want %v", parentID, got, want)
+		}
+	}
+}
+
+func TestAPITimeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"RFC3339", `"2024-03-05T14:30:15Z"`, time.Date(2024, 3, 5, 14, 30, 15, 0, time.UTC), false},
+		{"RFC3339 with offset", `"2024-03-05T14:30:15-06:00"`, time.Date(2024, 3, 5, 20, 30, 15, 0, time.UTC), false},
+		{"RFC3339 with fraction", `"2024-03-05T14:30:15.123Z"`, time.Date(2024, 3, 5, 14, 30, 15, 123000000, time.UTC), false},
+		{"without offset", `"2024-03-05T14:30:15"`, time.Date(2024, 3, 5, 14, 30, 15, 0, time.UTC), false},
+		{"without offset with milliseconds", `"2024-03-05T14:30:15.123"`, time.Date(2024, 3, 5, 14, 30, 15, 0, time.UTC), false},
+		{"Microsoft JSON", `"/Date(1599624242257)/"`, time.UnixMilli(1599624242257), false},
+		{"Microsoft JSON with offset", `"/Date(1599624242257-0500)/"`, time.UnixMilli(1599624242257), false},
+		{"Microsoft JSON before the epoch", `"/Date(-86400000)/"`, time.UnixMilli(-86400000), false},
+		{"date only", `"2020-09-09"`, time.Date(2020, 9, 9, 0, 0, 0, 0, time.UTC), false},
+		{"empty string", `""`, time.Time{}, false},
+		{"null", `null`, time.Time{}, false},
+		{"invalid", `"yesterday"`, time.Time{}, true},
+		{"invalid Microsoft JSON", `"/Date(soon)/"`, time.Time{}, true},
+		{"not a string", `1599624242257`, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got APITime
+			err := json.Unmarshal([]byte(tt.json), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Unmarshal(%s) = %s, want an error", tt.json, got.Time)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", tt.json, err)
+			}
+			if !got.Time.Equal(tt.want) {
+				t.Errorf("Unmarshal(%s) = %s, want %s", tt.json, got.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPITimeInDataExtension(t *testing.T) {
+	var de DataExtension
+	err := json.Unmarshal([]byte(`{"id":"de-1","createdDate":"/Date(1599624242257)/","modifiedDate":"2020-09-09"}`), &de)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !de.CreatedDate.Equal(time.UnixMilli(1599624242257)) || !de.ModifiedDate.Equal(time.Date(2020, 9, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("dates = %s, %s", de.CreatedDate.Time, de.ModifiedDate.Time)
+	}
+}
+
+func TestAPITimeInLocation(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		json string
+		want time.Time
+	}{
+		// 14:30 in Chicago during daylight saving time is 19:30 UTC
+		{"without offset", `"2024-07-05T14:30:15"`, time.Date(2024, 7, 5, 19, 30, 15, 0, time.UTC)},
+		{"without offset in winter", `"2024-01-05T14:30:15"`, time.Date(2024, 1, 5, 20, 30, 15, 0, time.UTC)},
+		{"with offset", `"2024-07-05T14:30:15Z"`, time.Date(2024, 7, 5, 14, 30, 15, 0, time.UTC)},
+		{"Microsoft JSON", `"/Date(1599624242257)/"`, time.UnixMilli(1599624242257)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var parsed APITime
+			if err := json.Unmarshal([]byte(tt.json), &parsed); err != nil {
+				t.Fatalf("Unmarshal(%s) error = %v", tt.json, err)
+			}
+			if got := parsed.InLocation(chicago); !got.Equal(tt.want) {
+				t.Errorf("InLocation(%s) = %s, want %s", tt.json, got.Time.UTC(), tt.want)
+			}
+			if got := parsed.InLocation(nil); !got.Equal(parsed.Time) {
+				t.Errorf("InLocation(nil) = %s, want %s unchanged", got.Time, parsed.Time)
+			}
+		})
+	}
+}
+
+func TestIsInRecycleBin(t *testing.T) {
+	empty := ""
+	path := "/Data Extensions/Old"
+	tests := []struct {
+		name string
+		path *string
+		want bool
+	}{
+		{"nil path", nil, false},
+		{"empty path", &empty, false},
+		{"populated path", &path, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			de := DataExtension{ID: "de-1", CategoryFullPathForRecycleBin: tt.path}
+			if got := IsInRecycleBin(de); got != tt.want {
+				t.Errorf("IsInRecycleBin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataExtensionsResponseShapes(t *testing.T) {
+	const modern = `{"count":2,"page":1,"pageSize":50,"items":[
+		{"id":"de-1","name":"Orders","key":"orders","rowCount":12,"modifiedDate":"2024-03-05T14:30:15.000Z"},
+		{"id":"de-2","name":"Contacts","key":"contacts","rowCount":0}
+	]}`
+	const legacy = `{"count":2,"page":1,"pageSize":50,"items":[
+		{"0":{"id":"de-1","name":"Orders","key":"orders","rowCount":12,"modifiedDate":"2024-03-05T14:30:15.000Z"}},
+		{"0":{"id":"de-2","name":"Contacts","key":"contacts","rowCount":0}}
+	]}`
+
+	var modernResp, legacyResp DataExtensionsResponse
+	if err := json.Unmarshal([]byte(modern), &modernResp); err != nil {
+		t.Fatalf("Unmarshal(modern) error = %v", err)
+	}
+	if err := json.Unmarshal([]byte(legacy), &legacyResp); err != nil {
+		t.Fatalf("Unmarshal(legacy) error = %v", err)
+	}
+
+	if len(modernResp.Items) != 2 || modernResp.Items[0].ID != "de-1" || modernResp.Items[0].RowCount != 12 {
+		t.Fatalf("modern items = %+v, want de-1 and de-2", modernResp.Items)
+	}
+	if !reflect.DeepEqual(legacyResp, modernResp) {
+		t.Errorf("legacy response = %+v, want the same as the modern %+v", legacyResp, modernResp)
+	}
+	if modernResp.Count != 2 || modernResp.PageSize != 50 {
+		t.Errorf("paging fields = count %d, page size %d, want 2 and 50", modernResp.Count, modernResp.PageSize)
+	}
+}
+
+func TestDataExtensionsResponseInvalidItem(t *testing.T) {
+	var resp DataExtensionsResponse
+	if err := json.Unmarshal([]byte(`{"items":["de-1"]}`), &resp); err == nil {
+		t.Error("Unmarshal() of a non-object item error = nil")
+	}
+}
+
+func TestDataRetentionPropertiesDifferences(t *testing.T) {
+	base := &DataRetentionProperties{
+		DataRetentionPeriodLength:        1,
+		DataRetentionPeriodUnitOfMeasure: RetentionUnitMonths,
+		IsRowBasedRetention:              true,
+	}
+	changed := func(fn func(p *DataRetentionProperties)) *DataRetentionProperties {
+		p := *base
+		fn(&p)
+		return &p
+	}
+
+	tests := []struct {
+		name  string
+		p     *DataRetentionProperties
+		other *DataRetentionProperties
+		want  []string
+	}{
+		{"equal", base, changed(func(*DataRetentionProperties) {}), nil},
+		{"both nil", nil, nil, nil},
+		{"one nil", nil, base, []string{"dataRetentionProperties"}},
+		{"other nil", base, nil, []string{"dataRetentionProperties"}},
+		{"period", base, changed(func(p *DataRetentionProperties) {
+			p.DataRetentionPeriodLength = 6
+			p.DataRetentionPeriodUnitOfMeasure = RetentionUnitDays
+		}), []string{"dataRetentionPeriodLength", "dataRetentionPeriodUnitOfMeasure"}},
+		{"flags", base, changed(func(p *DataRetentionProperties) {
+			p.IsDeleteAtEndOfRetentionPeriod = true
+			p.IsRowBasedRetention = false
+			p.IsResetRetentionPeriodOnImport = true
+		}), []string{"isDeleteAtEndOfRetentionPeriod", "isRowBasedRetention", "isResetRetentionPeriodOnImport"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Differences(tt.other); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Differences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -27,24 +28,26 @@ func (s *Salesforce) getAccessToken(ctx context.Context) (string, error) {
 	// Token expired or not available, call Authenticate() to get a new token
 	// Tokens are valid for 20 minutes, so we need to re-authenticate when expired
 	s.logger.Info("Access token expired or not available, authenticating")
-	authResp, err := s.Authenticate()
+	authResp, err := s.AuthenticateCtx(ctx)
 	if err != nil {
 		s.logger.Error("Failed to authenticate", zap.Error(err))
 		return "", fmt.Errorf("failed to authenticate: %w", err)
 	}
 
-	s.tokenCache.mu.Lock()
-	s.tokenCache.accessToken = authResp.AccessToken
-	s.tokenCache.mu.Unlock()
-
 	s.logger.Info("Successfully authenticated and cached access token",
 		zap.Time("expires_at", s.tokenCache.expiresAt))
 
 	return authResp.AccessToken, nil
 }
 
-// Authenticate retrieves an OAuth access token
+// Authenticate retrieves an OAuth access token and caches it, along with the API
+// instance URL that relative CallAPI requests resolve against
 func (s *Salesforce) Authenticate() (*AuthResponse, error) {
+	return s.AuthenticateCtx(context.Background())
+}
+
+// AuthenticateCtx is Authenticate with a context that bounds the token request
+func (s *Salesforce) AuthenticateCtx(ctx context.Context) (*AuthResponse, error) {
 	url := fmt.Sprintf("%s/services/oauth2/token", s.config.BaseURI)
 	s.logger.Info("Authenticating with Salesforce", zap.String("url", url))
 
@@ -58,7 +61,7 @@ func (s *Salesforce) Authenticate() (*AuthResponse, error) {
 		"Content-Type": "application/x-www-form-urlencoded",
 	}
 
-	resp, err := s.httpClient.Post(context.Background(), url, headers, authReq)
+	resp, err := s.httpClient.Post(ctx, url, headers, authReq)
 	if err != nil {
 		s.logger.Error("Authentication request failed", zap.Error(err), zap.String("url", url))
 		return nil, fmt.Errorf("authentication request failed: %w", err)
@@ -77,8 +80,25 @@ func (s *Salesforce) Authenticate() (*AuthResponse, error) {
 		return nil, fmt.Errorf("failed to parse authentication response: %w", err)
 	}
 
+	s.tokenCache.mu.Lock()
+	s.tokenCache.accessToken = authResp.AccessToken
+	if instanceURL := apiInstanceURL(&authResp); instanceURL != "" {
+		s.tokenCache.instanceURL = instanceURL
+	}
+	s.tokenCache.mu.Unlock()
+
 	s.logger.Info("Successfully authenticated",
 		zap.String("token_type", authResp.TokenType))
 
 	return &authResp, nil
 }
+
+// apiInstanceURL returns the api_instance_url of an auth response as an absolute
+// URL; the API may send it without a scheme
+func apiInstanceURL(authResp *AuthResponse) string {
+	instanceURL := strings.TrimSpace(authResp.APIInstanceURL)
+	if instanceURL != "" && !strings.Contains(instanceURL, "://") {
+		instanceURL = "https://" + instanceURL
+	}
+	return instanceURL
+}
@@ -0,0 +1,102 @@
+package sfmcn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// newAuthServer serves the OAuth token endpoint, returning instanceURL as the
+// api_instance_url
+func newAuthServer(t *testing.T, instanceURL string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/services/oauth2/token" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(AuthResponse{
+			AccessToken:    "token-1",
+			TokenType:      "Bearer",
+			APIInstanceURL: instanceURL,
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAuthenticateCachesInstanceURL(t *testing.T) {
+	auth := newAuthServer(t, "tenant.example.com")
+	client := NewSalesforceWithLogger(&Config{BaseURI: auth.URL, ClientID: "id", ClientSecret: "secret"}, zap.NewNop())
+
+	if _, err := client.Authenticate(); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if got, want := client.apiBaseURI(), "https://tenant.example.com"; got != want {
+		t.Errorf("apiBaseURI() = %q, want %q", got, want)
+	}
+	if got := client.tokenCache.accessToken; got != "token-1" {
+		t.Errorf("cached access token = %q, want %q", got, "token-1")
+	}
+}
+
+func TestCallAPIResolvesAgainstInstanceURL(t *testing.T) {
+	var gotPath, gotAuth string
+	instance := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer instance.Close()
+	auth := newAuthServer(t, instance.URL)
+	client := NewSalesforceWithLogger(&Config{BaseURI: auth.URL, ClientID: "id", ClientSecret: "secret"}, zap.NewNop())
+
+	req, err := client.PrepareRequest(context.Background(), http.MethodGet, "/api/v1/query", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("PrepareRequest() error = %v", err)
+	}
+	resp, err := client.CallAPI(req)
+	if err != nil {
+		t.Fatalf("CallAPI() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if gotPath != "/api/v1/query" {
+		t.Errorf("instance server got path %q, want /api/v1/query", gotPath)
+	}
+	if gotAuth != "Bearer token-1" {
+		t.Errorf("Authorization = %q, want %q", gotAuth, "Bearer token-1")
+	}
+}
+
+func TestAuthenticateCtxReturnsOnCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	client := NewSalesforceWithLogger(&Config{BaseURI: server.URL, ClientID: "id", ClientSecret: "secret"}, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := client.AuthenticateCtx(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("AuthenticateCtx() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("AuthenticateCtx() returned after %s, want prompt return", elapsed)
+	}
+}
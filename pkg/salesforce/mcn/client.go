@@ -29,6 +29,8 @@ type tokenCache struct {
 	mu          sync.RWMutex
 	accessToken string
 	expiresAt   time.Time
+	// instanceURL is the api_instance_url of the last authentication, if any
+	instanceURL string
 }
 
 // NewSalesforce creates a new Salesforce client with default production logger
@@ -173,21 +175,24 @@ func (s *Salesforce) CallAPI(request *http.Request) (*http.Response, error) {
 		return nil, http.ErrMissingFile
 	}
 
-	// If caller provided a relative URL, resolve it against BaseURI.
-	if request.URL != nil && !request.URL.IsAbs() && s.config != nil && s.config.BaseURI != "" {
-		base, err := url.Parse(s.config.BaseURI)
-		if err != nil {
-			return nil, err
-		}
-		request.URL = base.ResolveReference(request.URL)
-	}
-
-	token, err := s.getAccessToken(context.Background())
+	token, err := s.getAccessToken(request.Context())
 	if err != nil {
 		s.logger.Error("Failed to get access token", zap.Error(err))
 		return nil, err
 	}
 
+	// If caller provided a relative URL, resolve it against the API instance URL
+	// from authentication, or BaseURI when there is none.
+	if request.URL != nil && !request.URL.IsAbs() {
+		if baseURI := s.apiBaseURI(); baseURI != "" {
+			base, err := url.Parse(baseURI)
+			if err != nil {
+				return nil, err
+			}
+			request.URL = base.ResolveReference(request.URL)
+		}
+	}
+
 	// Add Authorization header unless caller already set it.
 	if request.Header == nil {
 		request.Header = make(http.Header)
@@ -207,3 +212,19 @@ func (s *Salesforce) CallAPI(request *http.Request) (*http.Response, error) {
 
 	return resp, nil
 }
+
+// apiBaseURI returns the base that relative CallAPI requests resolve against: the
+// api_instance_url returned by authentication, which Data Cloud calls must go to,
+// or Config.BaseURI when authentication didn't return one
+func (s *Salesforce) apiBaseURI() string {
+	s.tokenCache.mu.RLock()
+	instanceURL := s.tokenCache.instanceURL
+	s.tokenCache.mu.RUnlock()
+	if instanceURL != "" {
+		return instanceURL
+	}
+	if s.config != nil {
+		return s.config.BaseURI
+	}
+	return ""
+}
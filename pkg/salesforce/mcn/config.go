@@ -2,6 +2,7 @@ package sfmcn
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 
 	"github.com/joho/godotenv"
@@ -32,7 +33,10 @@ func LoadConfig() (*Config, error) {
 
 func (c *Config) Validate() error {
 	if c.BaseURI == "" {
-		return fmt.Errorf("MCN_BaseURI is required")
+		return fmt.Errorf("MCN_BASE_URI is required")
+	}
+	if err := validateBaseURI(c.BaseURI); err != nil {
+		return fmt.Errorf("MCN_BASE_URI is invalid: %w", err)
 	}
 	if c.ClientID == "" {
 		return fmt.Errorf("MCN_CLIENT_ID is required")
@@ -43,3 +47,19 @@ func (c *Config) Validate() error {
 	// AccountID is optional, so we don't validate it
 	return nil
 }
+
+// validateBaseURI checks that uri is an absolute http(s) URL with a host, since
+// request paths are appended to it
+func validateBaseURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%q must be an absolute http or https URL", uri)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q has no host", uri)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package sfmcn
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{BaseURI: "https://example.my.salesforce.com", ClientID: "client", ClientSecret: "secret"}
+
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"http", func(c *Config) { c.BaseURI = "http://localhost:8080" }, ""},
+		{"missing base URI", func(c *Config) { c.BaseURI = "" }, "MCN_BASE_URI is required"},
+		{"relative base URI", func(c *Config) { c.BaseURI = "example.my.salesforce.com" }, "MCN_BASE_URI is invalid"},
+		{"other scheme", func(c *Config) { c.BaseURI = "ftp://example.com" }, "MCN_BASE_URI is invalid"},
+		{"no host", func(c *Config) { c.BaseURI = "https://" }, "MCN_BASE_URI is invalid"},
+		{"unparsable base URI", func(c *Config) { c.BaseURI = "https://exa mple.com/%zz" }, "MCN_BASE_URI is invalid"},
+		{"missing client ID", func(c *Config) { c.ClientID = "" }, "MCN_CLIENT_ID is required"},
+		{"missing client secret", func(c *Config) { c.ClientSecret = "" }, "MCN_CLIENT_SECRET is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("MCN_BASE_URI", "https://example.my.salesforce.com")
+	t.Setenv("MCN_CLIENT_ID", "env-client")
+	t.Setenv("MCN_CLIENT_SECRET", "env-secret")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.BaseURI != "https://example.my.salesforce.com" || cfg.ClientID != "env-client" || cfg.ClientSecret != "env-secret" {
+		t.Errorf("LoadConfig() = %+v", cfg)
+	}
+
+	t.Setenv("MCN_BASE_URI", "not a url")
+	if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "MCN_BASE_URI") {
+		t.Errorf("LoadConfig() error = %v, want an MCN_BASE_URI error", err)
+	}
+}
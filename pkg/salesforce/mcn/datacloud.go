@@ -0,0 +1,120 @@
+package sfmcn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	httpclient "github.com/natserract/sf/pkg/http"
+	"go.uber.org/zap"
+)
+
+const (
+	// querySQLPath is the Data Cloud endpoint for running SQL queries
+	querySQLPath = "/services/data/v65.0/ssot/query-sql"
+	// queryBatchPath is the Data Cloud endpoint for reading the next batch of a query, by nextBatchId
+	queryBatchPath = "/services/data/v65.0/ssot/queryv2/"
+)
+
+// QuerySQL runs a SQL query against Data Cloud and returns the parsed result.
+// Only the first batch of rows is returned; use QuerySQLAll for large results.
+func (s *Salesforce) QuerySQL(ctx context.Context, sql string) (*QueryResult, error) {
+	s.logger.Info("Running Data Cloud query")
+	return s.doQuery(ctx, http.MethodPost, querySQLPath, map[string]string{
+		"sql": sql,
+	})
+}
+
+// QuerySQLWithParams binds args into the :name placeholders of template as quoted
+// SQL literals (see BindSQL) and runs the resulting query, reading every batch
+func (s *Salesforce) QuerySQLWithParams(ctx context.Context, template string, args map[string]interface{}) (*QueryResult, error) {
+	sql, err := BindSQL(template, args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bind query parameters: %w", err)
+	}
+	return s.QuerySQLAll(ctx, sql)
+}
+
+// QuerySQLAll runs a SQL query against Data Cloud and follows nextBatchId until
+// every batch has been read, returning all rows in a single result
+func (s *Salesforce) QuerySQLAll(ctx context.Context, sql string) (*QueryResult, error) {
+	result, err := s.QuerySQL(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := 1
+	for result.NextBatchID != "" {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("query-sql cancelled after %d batches: %w", batches, err)
+		}
+
+		s.logger.Debug("Fetching next Data Cloud query batch",
+			zap.String("next_batch_id", result.NextBatchID),
+			zap.Int("rows_so_far", len(result.Data)))
+
+		batch, err := s.doQuery(ctx, http.MethodGet, queryBatchPath+result.NextBatchID, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch query batch %d: %w", batches+1, err)
+		}
+		batches++
+
+		result.Data = append(result.Data, batch.Data...)
+		result.ReturnedRows += batch.ReturnedRows
+		result.NextBatchID = batch.NextBatchID
+		if len(result.Metadata) == 0 {
+			result.Metadata = batch.Metadata
+		}
+	}
+
+	s.logger.Info("Read all Data Cloud query batches",
+		zap.Int("batches", batches),
+		zap.Int("rows", len(result.Data)))
+
+	return result, nil
+}
+
+// doQuery calls a Data Cloud query endpoint and parses the result
+func (s *Salesforce) doQuery(ctx context.Context, method, path string, body interface{}) (*QueryResult, error) {
+	headers := map[string]string{}
+	if body != nil {
+		headers["Content-Type"] = "application/json"
+	}
+
+	req, err := s.PrepareRequest(ctx, method, path, headers, nil, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query-sql request: %w", err)
+	}
+
+	resp, err := s.CallAPI(req)
+	if err != nil {
+		return nil, fmt.Errorf("query-sql request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query-sql response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		s.logger.Error("Query-sql failed",
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("response", string(respBody)))
+		return nil, fmt.Errorf("query-sql failed: %w", &httpclient.StatusError{StatusCode: resp.StatusCode, Body: respBody})
+	}
+
+	var result QueryResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		s.logger.Error("Failed to parse query-sql response", zap.Error(err))
+		return nil, fmt.Errorf("failed to parse query-sql response: %w", err)
+	}
+
+	s.logger.Info("Successfully ran Data Cloud query",
+		zap.Int("columns", len(result.Metadata)),
+		zap.Int("rows", len(result.Data)))
+
+	return &result, nil
+}
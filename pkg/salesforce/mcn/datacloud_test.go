@@ -0,0 +1,200 @@
+package sfmcn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	httpclient "github.com/natserract/sf/pkg/http"
+	"github.com/natserract/sf/pkg/sferrors"
+)
+
+// sampleQueryResponse is a query-sql response captured from a sandbox org, trimmed to two rows
+const sampleQueryResponse = `{
+  "data": [
+    ["0031x00000AbCdE", "Ada", 36, true, "2024-03-05T14:30:15.000Z"],
+    ["0031x00000FgHiJ", null, 41.5, false, null]
+  ],
+  "startTime": "2024-03-05T14:30:16.102Z",
+  "endTime": "2024-03-05T14:30:16.587Z",
+  "rowCount": 2,
+  "queryId": "20240305_143016_00042_abcde",
+  "done": true,
+  "metadata": {
+    "ssot__Id__c": {"type": "VARCHAR", "placeInOrder": 0, "typeCode": 12},
+    "ssot__FirstName__c": {"type": "VARCHAR", "placeInOrder": 1, "typeCode": 12},
+    "age__c": {"type": "DECIMAL", "placeInOrder": 2, "typeCode": 3},
+    "is_active__c": {"type": "BOOLEAN", "placeInOrder": 3, "typeCode": 16},
+    "ssot__CreatedDate__c": {"type": "TIMESTAMP WITH TIME ZONE", "placeInOrder": 4, "typeCode": 2014}
+  },
+  "returnedRows": 2
+}`
+
+func TestQuerySQL(t *testing.T) {
+	var gotSQL string
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != querySQLPath {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		gotSQL = body["sql"]
+		writeJSON(w, http.StatusOK, sampleQueryResponse)
+	})
+
+	result, err := client.QuerySQL(context.Background(), "SELECT * FROM ssot__Individual__dlm")
+	if err != nil {
+		t.Fatalf("QuerySQL() error = %v", err)
+	}
+	if gotSQL != "SELECT * FROM ssot__Individual__dlm" {
+		t.Errorf("sent sql = %q", gotSQL)
+	}
+
+	var names, types []string
+	for _, column := range result.Metadata {
+		names = append(names, column.Name)
+		types = append(types, column.Type)
+	}
+	wantNames := []string{"ssot__Id__c", "ssot__FirstName__c", "age__c", "is_active__c", "ssot__CreatedDate__c"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("column names = %v, want %v in placeInOrder order", names, wantNames)
+	}
+	if types[2] != "DECIMAL" || types[4] != "TIMESTAMP WITH TIME ZONE" {
+		t.Errorf("column types = %v", types)
+	}
+	if result.ReturnedRows != 2 || len(result.Data) != 2 || result.NextBatchID != "" {
+		t.Errorf("returnedRows = %d, rows = %d, nextBatchId = %q", result.ReturnedRows, len(result.Data), result.NextBatchID)
+	}
+
+	rows := result.Rows()
+	if rows[0]["ssot__FirstName__c"] != "Ada" || rows[0]["age__c"] != 36.0 || rows[0]["is_active__c"] != true {
+		t.Errorf("first row = %v", rows[0])
+	}
+	if rows[1]["ssot__FirstName__c"] != nil || rows[1]["age__c"] != 41.5 {
+		t.Errorf("second row = %v", rows[1])
+	}
+}
+
+func TestQuerySQLColumnList(t *testing.T) {
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, `{
+			"metadata": [{"name": "id", "type": "VARCHAR"}, {"name": "n", "type": "INTEGER"}],
+			"data": [["a", 1]],
+			"returnedRows": 1
+		}`)
+	})
+
+	result, err := client.QuerySQL(context.Background(), "SELECT id, n FROM t")
+	if err != nil {
+		t.Fatalf("QuerySQL() error = %v", err)
+	}
+	if len(result.Metadata) != 2 || result.Metadata[1].Name != "n" {
+		t.Errorf("metadata = %+v", result.Metadata)
+	}
+	if row := result.Rows()[0]; row["id"] != "a" || row["n"] != 1.0 {
+		t.Errorf("row = %v", row)
+	}
+}
+
+func TestQuerySQLErrorBody(t *testing.T) {
+	const body = `[{"errorCode":"INVALID_QUERY","message":"Column 'nope' not found"}]`
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusBadRequest, body)
+	})
+
+	_, err := client.QuerySQL(context.Background(), "SELECT nope FROM t")
+	statusErr, ok := httpclient.AsStatusError(err)
+	if !ok {
+		t.Fatalf("QuerySQL() error = %v, want a status error", err)
+	}
+	if statusErr.StatusCode != http.StatusBadRequest || !strings.Contains(string(statusErr.Body), "Column 'nope' not found") {
+		t.Errorf("status error = %d %s, want the 400 and its body", statusErr.StatusCode, statusErr.Body)
+	}
+}
+
+func TestQuerySQLAllFollowsBatches(t *testing.T) {
+	var batchRequests atomic.Int32
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == querySQLPath:
+			writeJSON(w, http.StatusOK, `{
+				"metadata": {"id": {"type": "VARCHAR", "placeInOrder": 0}},
+				"data": [["a"], ["b"]],
+				"returnedRows": 2,
+				"nextBatchId": "batch-2"
+			}`)
+		case r.Method == http.MethodGet && r.URL.Path == queryBatchPath+"batch-2":
+			batchRequests.Add(1)
+			writeJSON(w, http.StatusOK, `{"data": [["c"]], "returnedRows": 1}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := client.QuerySQLAll(context.Background(), "SELECT id FROM t")
+	if err != nil {
+		t.Fatalf("QuerySQLAll() error = %v", err)
+	}
+	if n := batchRequests.Load(); n != 1 {
+		t.Errorf("fetched the second batch %d times, want 1", n)
+	}
+
+	var ids []interface{}
+	for _, row := range result.Rows() {
+		ids = append(ids, row["id"])
+	}
+	if want := []interface{}{"a", "b", "c"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+	if result.ReturnedRows != 3 || result.NextBatchID != "" {
+		t.Errorf("returnedRows = %d, nextBatchId = %q, want 3 and none", result.ReturnedRows, result.NextBatchID)
+	}
+}
+
+func TestQuerySQLAllCancelledBetweenBatches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("fetched %s after the context was cancelled", r.URL.Path)
+		}
+		// The first batch arrives as the caller gives up
+		cancel()
+		writeJSON(w, http.StatusOK, `{"data": [["a"]], "returnedRows": 1, "nextBatchId": "batch-2"}`)
+	})
+
+	_, err := client.QuerySQLAll(ctx, "SELECT id FROM t")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("QuerySQLAll() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestQuerySQLStatusSentinels(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusUnauthorized, sferrors.ErrUnauthorized},
+		{http.StatusNotFound, sferrors.ErrNotFound},
+		{http.StatusTooManyRequests, sferrors.ErrRateLimited},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			_, err := client.QuerySQL(context.Background(), "SELECT 1")
+			if !errors.Is(err, tt.want) {
+				t.Errorf("QuerySQL() error = %v, want errors.Is %v", err, tt.want)
+			}
+		})
+	}
+}
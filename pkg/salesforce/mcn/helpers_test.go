@@ -0,0 +1,40 @@
+package sfmcn
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// newTestServer starts a fake org that issues tokens naming itself as the API
+// instance and passes every other request to api, and returns a client of it
+func newTestServer(t *testing.T, api http.HandlerFunc) *Salesforce {
+	t.Helper()
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	mux.HandleFunc("/services/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, AuthResponse{
+			AccessToken:    "token-1",
+			TokenType:      "Bearer",
+			APIInstanceURL: server.URL,
+		})
+	})
+	mux.HandleFunc("/", api)
+
+	return NewSalesforceWithLogger(&Config{BaseURI: server.URL, ClientID: "id", ClientSecret: "secret"}, zap.NewNop())
+}
+
+// writeJSON writes body, a JSON document, as the response with the given status
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if raw, ok := body.(string); ok {
+		w.Write([]byte(raw))
+		return
+	}
+	json.NewEncoder(w).Encode(body)
+}
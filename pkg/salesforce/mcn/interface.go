@@ -3,15 +3,35 @@ package sfmcn
 import (
 	"context"
 	"net/http"
+	"time"
 )
 
 // SalesforceClient defines the interface for Salesforce API operations
 type SalesforceClient interface {
 	// Authenticate retrieves an OAuth access token
 	Authenticate() (*AuthResponse, error)
+	AuthenticateCtx(ctx context.Context) (*AuthResponse, error)
 
 	// PrepareRequest builds a request that can be executed via CallAPI.
 	PrepareRequest(ctx context.Context, method string, urlOrPath string, headers map[string]string, queryParams map[string]string, body interface{}) (*http.Request, error)
 
 	CallAPI(request *http.Request) (*http.Response, error)
+
+	// QuerySQL runs a SQL query against Data Cloud and returns the parsed result
+	QuerySQL(ctx context.Context, sql string) (*QueryResult, error)
+
+	// QuerySQLAll runs a SQL query against Data Cloud and reads every result batch
+	QuerySQLAll(ctx context.Context, sql string) (*QueryResult, error)
+
+	// QuerySQLWithParams binds named parameters into a SQL template and runs it
+	QuerySQLWithParams(ctx context.Context, template string, args map[string]interface{}) (*QueryResult, error)
+
+	// SubmitQueryJob starts an asynchronous Data Cloud query and returns its ID
+	SubmitQueryJob(ctx context.Context, sql string) (string, error)
+
+	// PollQueryJob returns the status of an asynchronous query
+	PollQueryJob(ctx context.Context, jobID string) (*QueryJobStatus, error)
+
+	// RunQueryJob submits a query, waits for it to finish and returns all its rows
+	RunQueryJob(ctx context.Context, sql string, pollInterval time.Duration) (*QueryResult, error)
 }
@@ -0,0 +1,162 @@
+package sfmcn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	httpclient "github.com/natserract/sf/pkg/http"
+	"go.uber.org/zap"
+)
+
+// DefaultQueryJobPollInterval is how often RunQueryJob polls when given no interval
+const DefaultQueryJobPollInterval = 2 * time.Second
+
+// ErrQueryJobFailed is returned when an asynchronous query fails or is cancelled
+var ErrQueryJobFailed = errors.New("data cloud query job failed")
+
+// SubmitQueryJob starts a Data Cloud query and returns its ID without waiting for
+// it to finish; poll it with PollQueryJob
+func (s *Salesforce) SubmitQueryJob(ctx context.Context, sql string) (string, error) {
+	result, err := s.submitQueryJob(ctx, sql)
+	if err != nil {
+		return "", err
+	}
+	return result.Status.QueryID, nil
+}
+
+// submitQueryJob starts a query and returns its first response, which carries the
+// column metadata and, for quick queries, the rows
+func (s *Salesforce) submitQueryJob(ctx context.Context, sql string) (*QueryResult, error) {
+	s.logger.Info("Submitting Data Cloud query job")
+	result, err := s.doQuery(ctx, http.MethodPost, querySQLPath, map[string]string{
+		"sql": sql,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == nil || result.Status.QueryID == "" {
+		return nil, fmt.Errorf("query-sql response has no query ID")
+	}
+	return result, nil
+}
+
+// PollQueryJob returns the current status of a query started with SubmitQueryJob
+func (s *Salesforce) PollQueryJob(ctx context.Context, jobID string) (*QueryJobStatus, error) {
+	req, err := s.PrepareRequest(ctx, http.MethodGet, querySQLPath+"/"+url.PathEscape(jobID), nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare query job status request: %w", err)
+	}
+
+	resp, err := s.CallAPI(req)
+	if err != nil {
+		return nil, fmt.Errorf("query job status request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query job status response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		s.logger.Error("Query job status failed",
+			zap.String("query_id", jobID),
+			zap.Int("status_code", resp.StatusCode),
+			zap.String("response", string(respBody)))
+		return nil, fmt.Errorf("query job status failed: %w", &httpclient.StatusError{StatusCode: resp.StatusCode, Body: respBody})
+	}
+
+	var status QueryJobStatus
+	if err := json.Unmarshal(respBody, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse query job status response: %w", err)
+	}
+	if status.QueryID == "" {
+		status.QueryID = jobID
+	}
+
+	s.logger.Debug("Polled Data Cloud query job",
+		zap.String("query_id", jobID),
+		zap.String("completion_status", status.CompletionStatus),
+		zap.Float64("progress", status.Progress))
+
+	return &status, nil
+}
+
+// RunQueryJob submits a query, polls it every pollInterval (DefaultQueryJobPollInterval
+// when zero) until it finishes, and returns all of its rows. A failed or cancelled
+// query yields ErrQueryJobFailed; cancelling ctx stops the polling.
+func (s *Salesforce) RunQueryJob(ctx context.Context, sql string, pollInterval time.Duration) (*QueryResult, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultQueryJobPollInterval
+	}
+
+	result, err := s.submitQueryJob(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	jobID := result.Status.QueryID
+	status := &QueryJobStatus{QueryStatus: *result.Status}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for !status.Finished() {
+		if status.Failed() {
+			return nil, fmt.Errorf("%w: query %s is %s: %s", ErrQueryJobFailed, jobID, status.CompletionStatus, status.ErrorMessage)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("query job %s: %w", jobID, ctx.Err())
+		case <-ticker.C:
+		}
+
+		if status, err = s.PollQueryJob(ctx, jobID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Quick queries return every row with the submission already
+	if len(result.Data) >= status.RowCount {
+		result.Status = &status.QueryStatus
+		return result, nil
+	}
+
+	rows, err := s.queryJobRows(ctx, jobID, len(result.Data), status.RowCount)
+	if err != nil {
+		return nil, err
+	}
+	result.Data = append(result.Data, rows...)
+	result.ReturnedRows = len(result.Data)
+	result.Status = &status.QueryStatus
+
+	s.logger.Info("Read all Data Cloud query job rows",
+		zap.String("query_id", jobID),
+		zap.Int("rows", len(result.Data)))
+
+	return result, nil
+}
+
+// queryJobRows reads the rows of a finished query from offset until rowCount
+func (s *Salesforce) queryJobRows(ctx context.Context, jobID string, offset, rowCount int) ([][]interface{}, error) {
+	var rows [][]interface{}
+	for offset < rowCount {
+		path := querySQLPath + "/" + url.PathEscape(jobID) + "/rows?offset=" + strconv.Itoa(offset)
+		page, err := s.doQuery(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch rows of query job %s at offset %d: %w", jobID, offset, err)
+		}
+		if len(page.Data) == 0 {
+			break
+		}
+		rows = append(rows, page.Data...)
+		offset += len(page.Data)
+	}
+	return rows, nil
+}
@@ -0,0 +1,128 @@
+package sfmcn
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// queryJobServer fakes an asynchronous query q-1: the submission returns the first
+// of its rows, each poll returns the next of statuses, and the remaining rows are
+// served from offset 1. It records the requests it got.
+func queryJobServer(t *testing.T, statuses []QueryJobStatus) (*Salesforce, *[]string) {
+	var mu sync.Mutex
+	var requests []string
+	polls := 0
+	client := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+strings.TrimPrefix(r.URL.Path, querySQLPath)+"?"+r.URL.RawQuery)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == querySQLPath:
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"metadata": []ColumnMeta{{Name: "id", Type: "VARCHAR"}},
+				"data":     [][]interface{}{{"a"}},
+				"status":   QueryStatus{CompletionStatus: QueryStatusRunning, QueryID: "q-1"},
+			})
+		case r.URL.Path == querySQLPath+"/q-1":
+			status := statuses[min(polls, len(statuses)-1)]
+			polls++
+			writeJSON(w, http.StatusOK, status)
+		case r.URL.Path == querySQLPath+"/q-1/rows" && r.URL.Query().Get("offset") == "1":
+			writeJSON(w, http.StatusOK, map[string]interface{}{"data": [][]interface{}{{"b"}, {"c"}}})
+		default:
+			writeJSON(w, http.StatusOK, map[string]interface{}{"data": [][]interface{}{}})
+		}
+	})
+	return client, &requests
+}
+
+func TestRunQueryJob(t *testing.T) {
+	client, requests := queryJobServer(t, []QueryJobStatus{
+		{QueryStatus: QueryStatus{CompletionStatus: QueryStatusRunning}, Progress: 0.5},
+		{QueryStatus: QueryStatus{CompletionStatus: QueryStatusResultsProduced}, Progress: 0.9},
+		{QueryStatus: QueryStatus{CompletionStatus: QueryStatusFinished, RowCount: 3}, Progress: 1},
+	})
+
+	result, err := client.RunQueryJob(context.Background(), "SELECT id FROM t", time.Millisecond)
+	if err != nil {
+		t.Fatalf("RunQueryJob() error = %v", err)
+	}
+	if want := [][]interface{}{{"a"}, {"b"}, {"c"}}; !reflect.DeepEqual(result.Data, want) {
+		t.Errorf("rows = %v, want %v", result.Data, want)
+	}
+	if result.ReturnedRows != 3 || result.Status.CompletionStatus != QueryStatusFinished {
+		t.Errorf("returned %d rows with status %+v", result.ReturnedRows, result.Status)
+	}
+	want := []string{"POST ?", "GET /q-1?", "GET /q-1?", "GET /q-1?", "GET /q-1/rows?offset=1"}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("requests = %v, want %v", *requests, want)
+	}
+}
+
+func TestRunQueryJobFailed(t *testing.T) {
+	for _, status := range []string{QueryStatusFailed, QueryStatusCancelled} {
+		t.Run(status, func(t *testing.T) {
+			client, _ := queryJobServer(t, []QueryJobStatus{
+				{QueryStatus: QueryStatus{CompletionStatus: QueryStatusRunning}},
+				{QueryStatus: QueryStatus{CompletionStatus: status}, ErrorMessage: "table t not found"},
+			})
+
+			_, err := client.RunQueryJob(context.Background(), "SELECT id FROM t", time.Millisecond)
+			if !errors.Is(err, ErrQueryJobFailed) || !strings.Contains(err.Error(), "table t not found") {
+				t.Errorf("RunQueryJob() error = %v, want ErrQueryJobFailed with the message", err)
+			}
+		})
+	}
+}
+
+func TestRunQueryJobCancelled(t *testing.T) {
+	client, _ := queryJobServer(t, []QueryJobStatus{
+		{QueryStatus: QueryStatus{CompletionStatus: QueryStatusRunning}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.RunQueryJob(ctx, "SELECT id FROM t", time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RunQueryJob() error = %v, want the context's", err)
+	}
+}
+
+func TestPollQueryJob(t *testing.T) {
+	client, requests := queryJobServer(t, []QueryJobStatus{
+		{QueryStatus: QueryStatus{CompletionStatus: QueryStatusRunning}, Progress: 0.25},
+	})
+
+	status, err := client.PollQueryJob(context.Background(), "q-1")
+	if err != nil {
+		t.Fatalf("PollQueryJob() error = %v", err)
+	}
+	// The ID is filled in when the status leaves it out
+	if status.QueryID != "q-1" || status.Progress != 0.25 || status.Finished() || status.Failed() {
+		t.Errorf("PollQueryJob() = %+v", status)
+	}
+	if want := []string{"GET /q-1?"}; !reflect.DeepEqual(*requests, want) {
+		t.Errorf("requests = %v, want %v", *requests, want)
+	}
+}
+
+func TestSubmitQueryJob(t *testing.T) {
+	client, _ := queryJobServer(t, nil)
+	id, err := client.SubmitQueryJob(context.Background(), "SELECT id FROM t")
+	if err != nil || id != "q-1" {
+		t.Errorf("SubmitQueryJob() = %q, %v; want q-1", id, err)
+	}
+
+	noID := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": [][]interface{}{}})
+	})
+	if _, err := noID.SubmitQueryJob(context.Background(), "SELECT id FROM t"); err == nil || !strings.Contains(err.Error(), "no query ID") {
+		t.Errorf("SubmitQueryJob() error = %v, want the missing query ID", err)
+	}
+}
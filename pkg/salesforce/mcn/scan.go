@@ -0,0 +1,258 @@
+package sfmcn
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the formats Data Cloud encodes dates and timestamps in
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// ScanRow copies the values of row i into dest, one destination per column in
+// result order. Destinations are pointers to string, bool, integer, float or
+// time.Time values, converted from Data Cloud's encoding, which sends numbers and
+// dates as strings. A NULL needs a pointer-to-pointer destination (e.g. **int64),
+// which it sets to nil. A *interface{} destination gets the value converted by the
+// column type: int64, float64, bool, time.Time, or the raw value for other types.
+func (r *QueryResult) ScanRow(i int, dest ...interface{}) error {
+	if i < 0 || i >= len(r.Data) {
+		return fmt.Errorf("row %d out of range (%d rows)", i, len(r.Data))
+	}
+	if len(dest) != len(r.Metadata) {
+		return fmt.Errorf("expected %d destinations, got %d", len(r.Metadata), len(dest))
+	}
+
+	values := r.Data[i]
+	for j, d := range dest {
+		var value interface{}
+		if j < len(values) {
+			value = values[j]
+		}
+		if err := scanValue(r.Metadata[j], value, d); err != nil {
+			return fmt.Errorf("column %s: %w", r.Metadata[j].Name, err)
+		}
+	}
+	return nil
+}
+
+// scanValue stores value, a value of column, in dest
+func scanValue(column ColumnMeta, value interface{}, dest interface{}) error {
+	if d, ok := dest.(*interface{}); ok {
+		v, err := column.convert(value)
+		if err != nil {
+			return err
+		}
+		*d = v
+		return nil
+	}
+
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("destination must be a non-nil pointer, got %T", dest)
+	}
+	return assign(value, rv.Elem())
+}
+
+// assign converts value to the type of target and sets it
+func assign(value interface{}, target reflect.Value) error {
+	if target.Kind() == reflect.Pointer {
+		if value == nil {
+			target.SetZero()
+			return nil
+		}
+		p := reflect.New(target.Type().Elem())
+		if err := assign(value, p.Elem()); err != nil {
+			return err
+		}
+		target.Set(p)
+		return nil
+	}
+	if value == nil {
+		return fmt.Errorf("cannot scan NULL into %s, use a pointer", target.Type())
+	}
+
+	if target.Type() == timeType {
+		t, err := toTime(value)
+		if err != nil {
+			return err
+		}
+		target.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(toString(value))
+	case reflect.Bool:
+		b, err := toBool(value)
+		if err != nil {
+			return err
+		}
+		target.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		if target.OverflowInt(n) {
+			return fmt.Errorf("value %d overflows %s", n, target.Type())
+		}
+		target.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := toInt(value)
+		if err != nil {
+			return err
+		}
+		if n < 0 || target.OverflowUint(uint64(n)) {
+			return fmt.Errorf("value %d overflows %s", n, target.Type())
+		}
+		target.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat(value)
+		if err != nil {
+			return err
+		}
+		target.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported destination type %s", target.Type())
+	}
+	return nil
+}
+
+// convert returns value as the Go type matching the column type
+func (c ColumnMeta) convert(value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	// Drop any precision or time zone qualifiers, e.g. DECIMAL(18,2)
+	typ := strings.ToUpper(strings.TrimSpace(c.Type))
+	if i := strings.IndexAny(typ, "( "); i >= 0 {
+		typ = typ[:i]
+	}
+
+	switch typ {
+	case "BIGINT", "INTEGER", "INT", "SMALLINT", "TINYINT":
+		return toInt(value)
+	case "DECIMAL", "NUMERIC", "NUMBER", "DOUBLE", "FLOAT", "REAL":
+		return toFloat(value)
+	case "BOOLEAN", "BOOL":
+		return toBool(value)
+	case "TIMESTAMP", "TIMESTAMPTZ", "DATETIME", "DATE":
+		return toTime(value)
+	default:
+		return value, nil
+	}
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, fmt.Errorf("invalid boolean %q", v)
+		}
+		return b, nil
+	default:
+		return false, fmt.Errorf("cannot convert %T to bool", value)
+	}
+}
+
+// toInt parses an integer, accepting decimals without a fractional part (e.g. "12.0")
+func toInt(value interface{}) (int64, error) {
+	var s string
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		return int64(v), nil
+	case string:
+		s = strings.TrimSpace(v)
+	case json.Number:
+		s = v.String()
+	default:
+		return 0, fmt.Errorf("cannot convert %T to an integer", value)
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid integer %q", s)
+	}
+	return toInt(f)
+}
+
+func toFloat(value interface{}) (float64, error) {
+	var s string
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		s = strings.TrimSpace(v)
+	case json.Number:
+		s = v.String()
+	default:
+		return 0, fmt.Errorf("cannot convert %T to a float", value)
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return f, nil
+}
+
+// toTime parses a date or timestamp string; a number is taken as Unix milliseconds
+func toTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case string:
+		s := strings.TrimSpace(v)
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", v)
+	case float64, json.Number:
+		ms, err := toInt(v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.UnixMilli(ms).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("cannot convert %T to a timestamp", value)
+	}
+}
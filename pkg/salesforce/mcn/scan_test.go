@@ -0,0 +1,145 @@
+package sfmcn
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scanResult decodes a query result with one column of each supported type, the
+// numbers, dates and booleans encoded as Data Cloud sends them
+func scanResult(t *testing.T) *QueryResult {
+	t.Helper()
+	body := `{
+		"metadata": {
+			"count__c": {"type": "BIGINT", "placeInOrder": 0},
+			"amount__c": {"type": "DECIMAL(18,2)", "placeInOrder": 1},
+			"active__c": {"type": "BOOLEAN", "placeInOrder": 2},
+			"created__c": {"type": "TIMESTAMP WITH TIME ZONE", "placeInOrder": 3},
+			"name__c": {"type": "VARCHAR", "placeInOrder": 4}
+		},
+		"data": [
+			["420", "19.95", "true", "2025-01-15T12:00:00.000Z", "Alice"],
+			[7, 1.5, false, "2025-01-15", null],
+			[null, null, null, null, null]
+		]
+	}`
+	var result QueryResult
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatalf("failed to decode result: %v", err)
+	}
+	return &result
+}
+
+func TestScanRowTypes(t *testing.T) {
+	result := scanResult(t)
+	tests := []struct {
+		row        int
+		wantCount  int64
+		wantAmount float64
+		wantActive bool
+		wantTime   time.Time
+	}{
+		{0, 420, 19.95, true, time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)},
+		{1, 7, 1.5, false, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		var count int64
+		var amount float64
+		var active bool
+		var created time.Time
+		var name *string
+		if err := result.ScanRow(tt.row, &count, &amount, &active, &created, &name); err != nil {
+			t.Fatalf("ScanRow(%d) error = %v", tt.row, err)
+		}
+		if count != tt.wantCount || amount != tt.wantAmount || active != tt.wantActive || !created.Equal(tt.wantTime) {
+			t.Errorf("row %d = %d, %v, %v, %s; want %d, %v, %v, %s", tt.row,
+				count, amount, active, created, tt.wantCount, tt.wantAmount, tt.wantActive, tt.wantTime)
+		}
+		if tt.row == 0 && (name == nil || *name != "Alice") {
+			t.Errorf("row 0 name = %v, want Alice", name)
+		}
+		if tt.row == 1 && name != nil {
+			t.Errorf("row 1 name = %q, want nil", *name)
+		}
+	}
+}
+
+func TestScanRowNulls(t *testing.T) {
+	result := scanResult(t)
+	count := new(int64)
+	amount := new(float64)
+	active := new(bool)
+	created := new(time.Time)
+	name := new(string)
+	if err := result.ScanRow(2, &count, &amount, &active, &created, &name); err != nil {
+		t.Fatalf("ScanRow() error = %v", err)
+	}
+	if count != nil || amount != nil || active != nil || created != nil || name != nil {
+		t.Errorf("NULLs were not scanned as nil: %v %v %v %v %v", count, amount, active, created, name)
+	}
+
+	var n int64
+	var ignored interface{}
+	err := result.ScanRow(2, &n, &ignored, &ignored, &ignored, &ignored)
+	if err == nil || !strings.Contains(err.Error(), "use a pointer") {
+		t.Errorf("ScanRow() of NULL into int64 error = %v, want a pointer hint", err)
+	}
+}
+
+func TestScanRowInterfaceConvertsByColumnType(t *testing.T) {
+	result := scanResult(t)
+	var count, amount, active, created, name interface{}
+	if err := result.ScanRow(0, &count, &amount, &active, &created, &name); err != nil {
+		t.Fatalf("ScanRow() error = %v", err)
+	}
+	if count != int64(420) {
+		t.Errorf("count = %#v, want int64 420", count)
+	}
+	if amount != 19.95 {
+		t.Errorf("amount = %#v, want float64 19.95", amount)
+	}
+	if active != true {
+		t.Errorf("active = %#v, want true", active)
+	}
+	if ts, ok := created.(time.Time); !ok || !ts.Equal(time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("created = %#v, want the timestamp", created)
+	}
+	if name != "Alice" {
+		t.Errorf("name = %#v, want the raw string", name)
+	}
+}
+
+func TestScanRowErrors(t *testing.T) {
+	result := scanResult(t)
+	var count int64
+	var amount float64
+	var active bool
+	var created time.Time
+	var name string
+	var small int8
+
+	tests := []struct {
+		name    string
+		row     int
+		dest    []interface{}
+		wantErr string
+	}{
+		{"row out of range", 3, []interface{}{&count, &amount, &active, &created, &name}, "out of range"},
+		{"too few destinations", 0, []interface{}{&count}, "expected 5 destinations"},
+		{"not a pointer", 0, []interface{}{count, &amount, &active, &created, &name}, "non-nil pointer"},
+		{"overflow", 0, []interface{}{&small, &amount, &active, &created, &name}, "overflows"},
+		{"not an integer", 0, []interface{}{&count, &count, &active, &created, &name}, "not an integer"},
+		{"not a boolean", 0, []interface{}{&count, &amount, &active, &created, &active}, "invalid boolean"},
+		{"not a timestamp", 0, []interface{}{&count, &amount, &active, &created, &created}, "invalid timestamp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := result.ScanRow(tt.row, tt.dest...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ScanRow() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
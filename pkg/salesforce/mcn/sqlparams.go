@@ -0,0 +1,171 @@
+package sfmcn
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BindSQL substitutes :name placeholders in template with the matching values from
+// args, quoted as SQL literals. Placeholders inside quoted strings or identifiers,
+// -- and /* */ comments, and "::" casts are left untouched. Every placeholder must
+// have a value.
+//
+// Supported values are strings, []byte (bound as a string), integers, floats,
+// booleans, time.Time, nil (NULL) and slices of those (rendered as a parenthesized
+// list for IN clauses).
+func BindSQL(template string, args map[string]interface{}) (string, error) {
+	var b strings.Builder
+	b.Grow(len(template))
+
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		switch {
+		case c == '\'' || c == '"':
+			// Copy quoted literals and identifiers verbatim, including doubled quotes
+			end := i + 1
+			for end < len(template) {
+				if template[end] == c {
+					if end+1 < len(template) && template[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(template) {
+				return "", fmt.Errorf("unterminated quote at position %d", i)
+			}
+			b.WriteString(template[i : end+1])
+			i = end
+		case c == '-' && strings.HasPrefix(template[i:], "--"):
+			// Copy line comments verbatim, up to the end of the line
+			end := strings.IndexByte(template[i:], '\n')
+			if end < 0 {
+				end = len(template) - i
+			}
+			b.WriteString(template[i : i+end])
+			i += end - 1
+		case c == '/' && strings.HasPrefix(template[i:], "/*"):
+			// Copy block comments verbatim
+			end := strings.Index(template[i+2:], "*/")
+			if end < 0 {
+				return "", fmt.Errorf("unterminated comment at position %d", i)
+			}
+			b.WriteString(template[i : i+2+end+2])
+			i += 2 + end + 1
+		case c == ':' && i+1 < len(template) && template[i+1] == ':':
+			b.WriteString("::")
+			i++
+		case c == ':' && i+1 < len(template) && isParamStart(template[i+1]):
+			end := i + 1
+			for end < len(template) && isParamChar(template[end]) {
+				end++
+			}
+			name := template[i+1 : end]
+			value, ok := args[name]
+			if !ok {
+				return "", fmt.Errorf("missing value for parameter :%s", name)
+			}
+			literal, err := sqlLiteral(value)
+			if err != nil {
+				return "", fmt.Errorf("parameter :%s: %w", name, err)
+			}
+			b.WriteString(literal)
+			i = end - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// sqlLiteral renders value as a SQL literal
+func sqlLiteral(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return quoteSQLString(v), nil
+	case []byte:
+		return quoteSQLString(string(v)), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return formatSQLFloat(float64(v))
+	case float64:
+		return formatSQLFloat(v)
+	case time.Time:
+		return "TIMESTAMP " + quoteSQLString(v.UTC().Format("2006-01-02 15:04:05.999999")), nil
+	}
+
+	rv := reflect.ValueOf(value)
+	// Named byte slices such as json.RawMessage are text too, not a list of numbers
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		return quoteSQLString(string(rv.Bytes())), nil
+	}
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		if rv.Len() == 0 {
+			return "", fmt.Errorf("empty list")
+		}
+		items := make([]string, rv.Len())
+		for i := range items {
+			item, err := sqlLiteral(rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			items[i] = item
+		}
+		return "(" + strings.Join(items, ", ") + ")", nil
+	}
+
+	return "", fmt.Errorf("unsupported value type %T", value)
+}
+
+// quoteSQLString wraps s in single quotes, doubling embedded quotes
+func quoteSQLString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func formatSQLFloat(f float64) (string, error) {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if strings.ContainsAny(s, "IN") { // Inf, -Inf, NaN
+		return "", fmt.Errorf("non-finite number %s", s)
+	}
+	return s, nil
+}
+
+func isParamStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isParamChar(c byte) bool {
+	return isParamStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,148 @@
+package sfmcn
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBindSQL(t *testing.T) {
+	modified := time.Date(2024, 3, 5, 14, 30, 15, 250000000, time.FixedZone("CST", -6*3600))
+
+	tests := []struct {
+		name     string
+		template string
+		args     map[string]interface{}
+		want     string
+	}{
+		{
+			name:     "string with quote",
+			template: "SELECT * FROM t WHERE name = :name",
+			args:     map[string]interface{}{"name": "O'Brien"},
+			want:     "SELECT * FROM t WHERE name = 'O''Brien'",
+		},
+		{
+			name:     "injection attempt stays inside the literal",
+			template: "SELECT * FROM t WHERE id = :id",
+			args:     map[string]interface{}{"id": "1' OR '1'='1"},
+			want:     "SELECT * FROM t WHERE id = '1'' OR ''1''=''1'",
+		},
+		{
+			name:     "special characters",
+			template: "SELECT :s",
+			args:     map[string]interface{}{"s": "a\\b;\n--c /* d */ \"e\""},
+			want:     "SELECT 'a\\b;\n--c /* d */ \"e\"'",
+		},
+		{
+			name:     "numbers and booleans",
+			template: "SELECT :i, :u, :f, :neg, :b",
+			args:     map[string]interface{}{"i": 42, "u": uint8(7), "f": 1.5, "neg": int64(-3), "b": true},
+			want:     "SELECT 42, 7, 1.5, -3, TRUE",
+		},
+		{
+			name:     "time in UTC",
+			template: "SELECT * FROM t WHERE modified > :since",
+			args:     map[string]interface{}{"since": modified},
+			want:     "SELECT * FROM t WHERE modified > TIMESTAMP '2024-03-05 20:30:15.25'",
+		},
+		{
+			name:     "nil is NULL",
+			template: "SELECT :v",
+			args:     map[string]interface{}{"v": nil},
+			want:     "SELECT NULL",
+		},
+		{
+			name:     "slice as IN list",
+			template: "SELECT * FROM t WHERE id IN :ids",
+			args:     map[string]interface{}{"ids": []string{"a", "b'c"}},
+			want:     "SELECT * FROM t WHERE id IN ('a', 'b''c')",
+		},
+		{
+			name:     "byte slice is a string",
+			template: "SELECT :raw",
+			args:     map[string]interface{}{"raw": []byte("it's")},
+			want:     "SELECT 'it''s'",
+		},
+		{
+			name:     "named byte slice is a string",
+			template: "SELECT :doc",
+			args:     map[string]interface{}{"doc": json.RawMessage(`{"a":1}`)},
+			want:     `SELECT '{"a":1}'`,
+		},
+		{
+			name:     "placeholders in literals and identifiers are kept",
+			template: `SELECT ':name', "a:name" FROM t WHERE x = :name`,
+			args:     map[string]interface{}{"name": "v"},
+			want:     `SELECT ':name', "a:name" FROM t WHERE x = 'v'`,
+		},
+		{
+			name:     "casts are kept",
+			template: "SELECT :n::int",
+			args:     map[string]interface{}{"n": "5"},
+			want:     "SELECT '5'::int",
+		},
+		{
+			name:     "apostrophe in a line comment",
+			template: "SELECT :a -- don't bind :a here\nFROM t",
+			args:     map[string]interface{}{"a": 1},
+			want:     "SELECT 1 -- don't bind :a here\nFROM t",
+		},
+		{
+			name:     "line comment at the end",
+			template: "SELECT :a -- it's :a",
+			args:     map[string]interface{}{"a": 1},
+			want:     "SELECT 1 -- it's :a",
+		},
+		{
+			name:     "block comment",
+			template: "SELECT /* it's :a */ :a FROM t",
+			args:     map[string]interface{}{"a": 2},
+			want:     "SELECT /* it's :a */ 2 FROM t",
+		},
+		{
+			name:     "minus and division are not comments",
+			template: "SELECT :a - 1, :a / 2",
+			args:     map[string]interface{}{"a": 4},
+			want:     "SELECT 4 - 1, 4 / 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BindSQL(tt.template, tt.args)
+			if err != nil {
+				t.Fatalf("BindSQL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BindSQL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindSQLErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		args     map[string]interface{}
+		wantErr  string
+	}{
+		{"missing value", "SELECT :a", nil, "missing value for parameter :a"},
+		{"unterminated quote", "SELECT 'abc", nil, "unterminated quote"},
+		{"unterminated comment", "SELECT 1 /* it's", nil, "unterminated comment"},
+		{"empty list", "SELECT :ids", map[string]interface{}{"ids": []int{}}, "empty list"},
+		{"non-finite number", "SELECT :f", map[string]interface{}{"f": math.Inf(1)}, "non-finite"},
+		{"unsupported type", "SELECT :m", map[string]interface{}{"m": map[string]int{}}, "unsupported value type"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BindSQL(tt.template, tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("BindSQL() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package sfmcn
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -90,3 +91,103 @@ type AuthRequest struct {
 	ClientID     string `json:"client_id"`
 	ClientSecret string `json:"client_secret"`
 }
+
+// ColumnMeta describes a single column of a Data Cloud query result
+type ColumnMeta struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Nullable     bool   `json:"nullable"`
+	PlaceInOrder int    `json:"placeInOrder"`
+}
+
+// QueryStatus holds the execution status returned with a Data Cloud query result
+type QueryStatus struct {
+	CompletionStatus string `json:"completionStatus"`
+	QueryID          string `json:"queryId"`
+	RowCount         int    `json:"rowCount"`
+}
+
+// Completion statuses of an asynchronous Data Cloud query
+const (
+	QueryStatusRunning         = "Running"
+	QueryStatusResultsProduced = "ResultsProduced"
+	QueryStatusFinished        = "Finished"
+	QueryStatusFailed          = "Failed"
+	QueryStatusCancelled       = "Cancelled"
+)
+
+// QueryJobStatus is the state of an asynchronous Data Cloud query, as returned
+// when it is polled
+type QueryJobStatus struct {
+	QueryStatus
+	// Progress is the fraction of the query done, between 0 and 1
+	Progress float64 `json:"progress"`
+	// ErrorMessage explains why a failed query failed, when the API says
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// Finished reports whether the query completed and all its rows are available
+func (s *QueryJobStatus) Finished() bool {
+	return strings.EqualFold(s.CompletionStatus, QueryStatusFinished)
+}
+
+// Failed reports whether the query failed or was cancelled
+func (s *QueryJobStatus) Failed() bool {
+	return strings.EqualFold(s.CompletionStatus, QueryStatusFailed) ||
+		strings.EqualFold(s.CompletionStatus, QueryStatusCancelled)
+}
+
+// QueryResult represents the response from the Data Cloud query-sql endpoint
+type QueryResult struct {
+	Metadata     QueryMetadata   `json:"metadata"`
+	Data         [][]interface{} `json:"data"`
+	ReturnedRows int             `json:"returnedRows"`
+	Status       *QueryStatus    `json:"status,omitempty"`
+	// NextBatchID is set when more rows are available in a following batch
+	NextBatchID string `json:"nextBatchId,omitempty"`
+}
+
+// Rows returns the result rows keyed by column name
+func (r *QueryResult) Rows() []map[string]interface{} {
+	rows := make([]map[string]interface{}, 0, len(r.Data))
+	for _, values := range r.Data {
+		row := make(map[string]interface{}, len(r.Metadata))
+		for i, column := range r.Metadata {
+			if i < len(values) {
+				row[column.Name] = values[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// QueryMetadata lists the columns of a query result in result order
+type QueryMetadata []ColumnMeta
+
+// UnmarshalJSON implements json.Unmarshaler for QueryMetadata
+// The API returns either a list of columns or an object keyed by column name
+// with a placeInOrder index, depending on the endpoint version
+func (m *QueryMetadata) UnmarshalJSON(data []byte) error {
+	var columns []ColumnMeta
+	if err := json.Unmarshal(data, &columns); err == nil {
+		*m = columns
+		return nil
+	}
+
+	var byName map[string]ColumnMeta
+	if err := json.Unmarshal(data, &byName); err != nil {
+		return fmt.Errorf("unable to parse query metadata: %w", err)
+	}
+
+	columns = make([]ColumnMeta, 0, len(byName))
+	for name, column := range byName {
+		column.Name = name
+		columns = append(columns, column)
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		return columns[i].PlaceInOrder < columns[j].PlaceInOrder
+	})
+	*m = columns
+	return nil
+}
@@ -0,0 +1,38 @@
+// Package sferrors defines the sentinel errors the Salesforce clients report for
+// conditions callers commonly branch on. The clients wrap them, so check for them
+// with errors.Is rather than by comparing errors or matching messages:
+//
+//	if errors.Is(err, sferrors.ErrNotFound) { ... }
+package sferrors
+
+import (
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrUnauthorized means the API rejected the credentials or token (401)
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden means the credentials lack permission for the request (403)
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound means the requested resource doesn't exist (404)
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited means the API throttled the request (429)
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// ForStatus returns the sentinel error matching an HTTP status code, or nil when
+// there is none
+func ForStatus(statusCode int) error {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return nil
+}